@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"maps"
 	"net"
 	"net/http"
 	"os"
@@ -11,26 +12,35 @@ import (
 	"time"
 
 	"github.com/Dhoini/Payment-microservice/internal/app"
+	"github.com/Dhoini/Payment-microservice/internal/authz"
 	"github.com/Dhoini/Payment-microservice/internal/config"
 	"github.com/Dhoini/Payment-microservice/internal/db"
 	paymentgrpc "github.com/Dhoini/Payment-microservice/internal/grpc"
 	"github.com/Dhoini/Payment-microservice/internal/http/routes"
 	"github.com/Dhoini/Payment-microservice/internal/interceptors" // <-- Импорт пакета интерцепторов
 	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
 	"github.com/Dhoini/Payment-microservice/internal/middleware" // <-- Импорт для валидатора и ключа
+	"github.com/Dhoini/Payment-microservice/internal/outbox"
 	"github.com/Dhoini/Payment-microservice/internal/repository"
 	"github.com/Dhoini/Payment-microservice/internal/services"
 	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	"github.com/Dhoini/Payment-microservice/internal/webhookqueue"
 	"github.com/Dhoini/Payment-microservice/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection" // Для дебаггинга gRPC через grpcurl/Evans
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
+// defaultMetricsAddr внутренний адрес эндпоинта /metrics, если metrics.addr не задан
+const defaultMetricsAddr = ":9090"
+
 func main() {
 	// Инициализируем контекст с возможностью отмены для graceful shutdown
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Инициализируем логгер
@@ -43,8 +53,8 @@ func main() {
 	if err != nil {
 		log.Fatalw("Failed to load configuration", "error", err)
 	}
-	// Проверка наличия секрета JWT
-	if cfg.Auth.JWTSecret == "" || cfg.Auth.JWTSecret == "YourVerySecretKeyHere" {
+	// Проверка наличия секрета JWT (не нужен, если токены проверяются по JWKS)
+	if cfg.Auth.JWKSURL == "" && (cfg.Auth.JWTSecret == "" || cfg.Auth.JWTSecret == "YourVerySecretKeyHere") {
 		log.Warnw("JWT Secret is not set or is using the default placeholder!")
 	}
 	// Проверка наличия ключей Stripe
@@ -57,6 +67,9 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Метрики сервиса (HTTP, gRPC, Stripe, Kafka, кеш, пул БД, вебхуки), отдаются на GET /metrics внутреннего адреса metrics.addr
+	metricsRegistry := metrics.NewRegistry()
+
 	// Подключаемся к базе данных
 	dbClient, err := db.NewDBClient(cfg.Database.DSN, log)
 	if err != nil {
@@ -68,6 +81,7 @@ func main() {
 		}
 	}()
 	log.Infow("Database connection established")
+	dbClient.RegisterMetrics(metricsRegistry)
 
 	// Инициализируем Redis кеш
 	redisCache, err := repository.NewRedisCacheRepository(
@@ -94,15 +108,18 @@ func main() {
 	// Создаем репозиторий с кешированием, если Redis доступен
 	var subscriptionRepo repository.SubscriptionRepository
 	if redisCache != nil {
-		subscriptionRepo = repository.NewCachedSubscriptionRepository(baseRepo, redisCache, log)
+		subscriptionRepo = repository.NewCachedSubscriptionRepository(baseRepo, redisCache, metricsRegistry, log)
 		log.Infow("Using cached subscription repository")
 	} else {
 		subscriptionRepo = baseRepo
 		log.Infow("Using non-cached subscription repository")
 	}
 
-	// Инициализируем клиент Stripe
-	stripeClient := stripe.NewStripeClient(cfg.Stripe.APIKey, log)
+	// Инициализируем клиент Stripe (stripe.apiBaseUrl позволяет работать с поддельным Stripe без сети)
+	if cfg.Stripe.APIBaseURL != "" {
+		log.Warnw("Using custom Stripe API URL: %s", cfg.Stripe.APIBaseURL)
+	}
+	stripeClient := stripe.NewStripeClient(cfg.Stripe.APIKey, cfg.Stripe.APIBaseURL, metricsRegistry, log)
 
 	if len(cfg.Kafka.Brokers) > 0 {
 		// Используем функцию из пакета kafka
@@ -117,7 +134,7 @@ func main() {
 	}
 
 	// Инициализируем Kafka Producer
-	kafkaProducer, err := kafka.NewKafkaProducer(cfg.Kafka.Brokers, log)
+	kafkaProducer, err := kafka.NewKafkaProducer(cfg.Kafka.Brokers, metricsRegistry, log)
 	if err != nil {
 		// Можно сделать не фатальным, если отправка событий не критична для основного флоу
 		log.Errorw("Failed to initialize Kafka producer, continuing without event publishing", "error", err)
@@ -131,21 +148,84 @@ func main() {
 		}()
 	}
 
+	// Репозиторий outbox: события пишутся в БД вместе с подписками и публикуются релеем
+	outboxRepo := repository.NewPostgresOutboxRepository(dbClient.DB(), log)
+
+	// Запускаем релей outbox, если Kafka доступна. Иначе события копятся в outbox до следующего старта.
+	relayDone := make(chan struct{})
+	if kafkaProducer != nil {
+		relay := outbox.NewRelay(outboxRepo, kafkaProducer, cfg.Outbox.PollInterval, cfg.Outbox.BatchSize, cfg.Outbox.MaxAttempts, cfg.Outbox.Retention, log)
+		go func() {
+			defer close(relayDone)
+			relay.Run(ctx)
+		}()
+	} else {
+		close(relayDone)
+		log.Warnw("Kafka producer is nil, outbox relay is not started; events will stay in outbox")
+	}
+
+	// Лента изменений подписок из outbox для gRPC WatchSubscriptions (не зависит от Kafka)
+	feed := outbox.NewFeed(outboxRepo, cfg.Outbox.PollInterval, log)
+	feedDone := make(chan struct{})
+	go func() {
+		defer close(feedDone)
+		feed.Run(ctx)
+	}()
+
+	// Журнал и очередь вебхуков Stripe (дедупликация по ID события)
+	webhookRepo := repository.NewPostgresWebhookEventRepository(dbClient.DB(), log)
+
+	// Связь пользователей с клиентами Stripe (источник истины для userID -> Stripe customer)
+	customerRepo := repository.NewCustomerRepository(dbClient.DB(), log)
+
+	// Локальная копия каталога планов Stripe
+	planRepo := repository.NewPostgresPlanRepository(dbClient.DB(), log)
+
+	// История счетов, заполняемая из вебхуков invoice.*
+	invoiceRepo := repository.NewPostgresInvoiceRepository(dbClient.DB(), log)
+
+	// Возвраты и кредит-ноты (через админский API и из вебхуков charge.* и credit_note.*)
+	refundRepo := repository.NewPostgresRefundRepository(dbClient.DB(), log)
+
+	// Разовые платежи (PaymentIntents), статус обновляется из вебхуков payment_intent.*
+	paymentRepo := repository.NewPostgresPaymentRepository(dbClient.DB(), log)
+
 	// Инициализируем service layer
-	paymentService := services.NewPaymentService(cfg, subscriptionRepo, stripeClient, kafkaProducer, log)
+	paymentService := services.NewPaymentService(cfg, subscriptionRepo, customerRepo, planRepo, invoiceRepo, refundRepo, paymentRepo, outboxRepo, webhookRepo, stripeClient, metricsRegistry, log)
+
+	// Запускаем воркеры очереди вебхуков: HTTP-обработчик только сохраняет событие, обработка идет здесь
+	webhookQueueDone := make(chan struct{})
+	webhookPool := webhookqueue.NewPool(webhookRepo, paymentService,
+		cfg.WebhookQueue.Workers, cfg.WebhookQueue.PollInterval, cfg.WebhookQueue.BatchSize, cfg.WebhookQueue.MaxAttempts, metricsRegistry, log)
+	go func() {
+		defer close(webhookQueueDone)
+		webhookPool.Run(ctx)
+	}()
+
+	// Синхронизируем каталог планов со Stripe. Дальше каталог обновляется вебхуками price.*/product.*.
+	// Если Stripe недоступен при старте, повторяем в фоне: до успеха проверка планов опирается на прежнюю копию в БД.
+	go syncPlansOnStartup(ctx, paymentService, log)
 
 	// Инициализируем application (для HTTP)
-	// Создаем валидатор токенов
-	validator := &middleware.DefaultTokenValidator{
-		Secret: []byte(cfg.Auth.JWTSecret),
+	// Создаем валидатор токенов (HS256 с общим секретом или JWKS провайдера - по конфигурации)
+	validator, err := middleware.NewTokenValidator(cfg, log)
+	if err != nil {
+		log.Fatalw("Failed to create token validator. Error: %v", err)
+	}
+	if jwksValidator, ok := validator.(*middleware.JWKSValidator); ok {
+		// Недоступность JWKS при старте не фатальна: ключи загрузятся при первой проверке токена
+		if err := jwksValidator.Refresh(ctx); err != nil {
+			log.Warnw("Failed to load JWKS on startup. Error: %v", err)
+		}
 	}
 	application := app.NewApp(cfg, paymentService, log, validator) // Передаем валидатор
 
 	// Инициализируем HTTP сервер с роутами
 	router := gin.New() // Используем gin.New() для большего контроля над middleware
 	// Добавляем middleware логирования и восстановления Gin
-	router.Use(application.LoggerMiddleware) // Логгер запросов
-	router.Use(gin.Recovery())               // Восстановление после паник
+	router.Use(application.LoggerMiddleware)               // Логгер запросов
+	router.Use(middleware.RequestMetrics(metricsRegistry)) // Счетчики и длительность запросов по маршрутам
+	router.Use(gin.Recovery())                             // Восстановление после паник
 	// Настраиваем маршруты
 	routes.SetupRoutes(router, application, log) // Передаем application
 
@@ -164,32 +244,66 @@ func main() {
 		}
 	}()
 
+	// Метрики в формате Prometheus отдаются без аутентификации, поэтому не на публичном HTTP сервере,
+	// а на отдельном адресе: его порт не публикуется наружу и доступен только сборщику внутри сети
+	metricsAddr := cfg.Metrics.Addr
+	if metricsAddr == "" {
+		metricsAddr = defaultMetricsAddr
+	}
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", metricsRegistry.Handler())
+	metricsServer := &http.Server{
+		Addr:         metricsAddr,
+		Handler:      metricsMux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Infow("Starting metrics server. Addr: %s", metricsAddr)
+		if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalw("Failed to start metrics server. Error: %v", err)
+		}
+	}()
+
 	// --- Настройка gRPC сервера ---
 	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
 	if err != nil {
 		log.Fatalw("Failed to listen for gRPC", "error", err)
 	}
 
-	// Создаем интерцептор аутентификации
-	authInterceptor := interceptors.NewAuthInterceptor(log, validator)
-
-	// Настраиваем логирование для gRPC (пример)
-	// loggerOpts := []grpcMw.Option{
-	// 	grpcMw.WithLogOnEvents(grpcMw.StartCall, grpcMw.FinishCall),
-	// }
-
-	// Создаем gRPC сервер с интерцепторами
+	// Создаем интерцептор аутентификации и авторизации (политики методов - paymentgrpc.Policies)
+	// Reflection доступна любому аутентифицированному вызывающему
+	rpcPolicies := maps.Clone(paymentgrpc.Policies)
+	rpcPolicies[grpc_reflection_v1.ServerReflection_ServerReflectionInfo_FullMethodName] = authz.AnyRead
+	rpcPolicies[grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfo_FullMethodName] = authz.AnyRead
+	authInterceptor := interceptors.NewAuthInterceptor(log, validator, rpcPolicies, cfg.Auth.ServiceAccounts)
+
+	// Создаем gRPC сервер с интерцепторами. Порядок важен: ID запроса нужен логам и паникам,
+	// логирование и метрики видят итоговый код ответа (в том числе Internal после паники
+	// и Unauthenticated/PermissionDenied от аутентификации), восстановление защищает и аутентификацию.
+	requestIDInterceptor := interceptors.NewRequestIDInterceptor()
+	loggingInterceptor := interceptors.NewLoggingInterceptor(log)
+	metricsInterceptor := interceptors.NewMetricsInterceptor(metricsRegistry)
+	recoveryInterceptor := interceptors.NewRecoveryInterceptor(log)
 	grpcServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
-			// grpcMw.UnaryServerInterceptor(interceptorLogger(log), loggerOpts...), // Пример интерцептора логирования
-			authInterceptor.Unary(), // <-- Наш интерцептор аутентификации
-			// Добавьте другие интерцепторы здесь, если нужно
+			requestIDInterceptor.Unary(),
+			loggingInterceptor.Unary(),
+			metricsInterceptor.Unary(),
+			recoveryInterceptor.Unary(),
+			authInterceptor.Unary(),
+		),
+		grpc.ChainStreamInterceptor(
+			requestIDInterceptor.Stream(),
+			loggingInterceptor.Stream(),
+			metricsInterceptor.Stream(),
+			recoveryInterceptor.Stream(),
+			authInterceptor.Stream(), // Аутентификация потоковых методов (WatchSubscriptions)
 		),
-		// grpc.StreamInterceptor(...) // Для потоковых интерцепторов
 	)
 
 	// Регистрируем сервис
-	paymentServer := paymentgrpc.NewPaymentServer(paymentService, log)
+	paymentServer := paymentgrpc.NewPaymentServer(paymentService, feed, log)
 	paymentgrpc.RegisterPaymentServiceServer(grpcServer, paymentServer)
 
 	// Включаем gRPC Reflection для дебаггинга (удобно с grpcurl/Evans)
@@ -222,15 +336,43 @@ func main() {
 	} else {
 		log.Infow("HTTP server gracefully stopped")
 	}
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Errorw("Metrics server shutdown error. Error: %v", err)
+	}
 
-	// Останавливаем gRPC сервер
+	// Останавливаем gRPC сервер. Сначала закрываем ленту изменений подписок:
+	// открытые стримы WatchSubscriptions завершаются, иначе GracefulStop ждал бы их бесконечно.
 	log.Infow("Shutting down gRPC server")
+	cancel()
+	<-feedDone
 	grpcServer.GracefulStop() // GracefulStop ждет завершения текущих RPC
 	log.Infow("gRPC server gracefully stopped")
 
+	// Дожидаемся остановки релея outbox и воркеров вебхуков до закрытия Kafka producer и БД (они закрываются в defer)
+	<-relayDone
+	<-webhookQueueDone
+
 	log.Infow("Cleanup finished. Goodbye!")
 }
 
+// syncPlansOnStartup выполняет полную синхронизацию каталога планов, повторяя ее с растущей задержкой до успеха.
+func syncPlansOnStartup(ctx context.Context, paymentService *services.PaymentService, log *logger.Logger) {
+	delay := 5 * time.Second
+	for {
+		if _, err := paymentService.SyncPlans(ctx); err == nil {
+			return
+		} else {
+			log.Errorw("Failed to sync plan catalog with Stripe, retrying in %s. Error: %v", delay, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, time.Minute)
+	}
+}
+
 // initLogger инициализирует новый логгер (без изменений)
 func initLogger() *logger.Logger {
 	logLevel := logger.INFO
@@ -239,34 +381,3 @@ func initLogger() *logger.Logger {
 	}
 	return logger.New(logLevel)
 }
-
-/*
-// Пример адаптера для grpc-ecosystem/go-grpc-middleware/logging
-// (Требует установки пакета: go get github.com/grpc-ecosystem/go-grpc-middleware/v2)
-func interceptorLogger(l *logger.Logger) grpcMw.Logger {
-	return grpcMw.LoggerFunc(func(ctx context.Context, level grpcMw.Level, msg string, fields ...any) {
-		logFields := make([]interface{}, 0, len(fields))
-		for i := 0; i < len(fields); i += 2 {
-			// Преобразуем в формат ключ-значение, как ожидает наш логгер
-			if i+1 < len(fields) {
-				logFields = append(logFields, fields[i], fields[i+1])
-			} else {
-				logFields = append(logFields, fields[i], "INVALID_FIELD") // Обработка нечетного числа полей
-			}
-		}
-
-		switch level {
-		case grpcMw.LevelDebug:
-			l.Debugw(msg, logFields...)
-		case grpcMw.LevelInfo:
-			l.Infow(msg, logFields...)
-		case grpcMw.LevelWarn:
-			l.Warnw(msg, logFields...)
-		case grpcMw.LevelError:
-			l.Errorw(msg, logFields...)
-		default:
-			l.Errorw("Unknown log level", "level", level, "message", msg)
-		}
-	})
-}
-*/
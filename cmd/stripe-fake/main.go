@@ -0,0 +1,86 @@
+// stripe-fake запускает поддельный Stripe API (internal/stripe/stripetest) для локального запуска сервиса без сети.
+//
+// Пример:
+//
+//	go run ./cmd/stripe-fake -addr :12111 -webhook-url http://localhost:8080/api/v1/webhooks/stripe \
+//		-price price_basic:999:usd:month -price price_pro:2999
+//
+// В config.yml сервиса: stripe.apiBaseUrl: http://localhost:12111, stripe.webhookSecret: значение -webhook-secret.
+package main
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Dhoini/Payment-microservice/internal/stripe/stripetest"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+// priceFlags собирает повторяющийся флаг -price в формате id:amount[:currency[:interval]].
+type priceFlags []stripetest.Price
+
+func (p *priceFlags) String() string {
+	ids := make([]string, len(*p))
+	for i, price := range *p {
+		ids[i] = price.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func (p *priceFlags) Set(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || parts[0] == "" {
+		return errors.New("expected id:amount[:currency[:interval]]")
+	}
+	amount, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || amount < 0 {
+		return errors.New("amount must be a non-negative integer in minor units")
+	}
+	price := stripetest.Price{ID: parts[0], UnitAmount: amount}
+	if len(parts) > 2 {
+		price.Currency = parts[2]
+	}
+	if len(parts) > 3 {
+		price.Interval = parts[3]
+	}
+	*p = append(*p, price)
+	return nil
+}
+
+func main() {
+	var prices priceFlags
+	addr := flag.String("addr", ":12111", "Адрес HTTP сервера")
+	webhookURL := flag.String("webhook-url", "", "Эндпоинт вебхуков сервиса (пусто - вебхуки не отправляются)")
+	webhookSecret := flag.String("webhook-secret", stripetest.DefaultWebhookSecret, "Секрет подписи вебхуков")
+	flag.Var(&prices, "price", "Цена в каталоге: id:amount[:currency[:interval]] (можно указать несколько раз)")
+	flag.Parse()
+
+	log := logger.New(logger.INFO)
+
+	server := stripetest.New(stripetest.Options{WebhookURL: *webhookURL, WebhookSecret: *webhookSecret})
+	for _, price := range prices {
+		server.AddPrice(price)
+	}
+
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+	go func() {
+		log.Infow("Fake Stripe API listening. Addr: %s, WebhookURL: %s, Prices: %s", *addr, *webhookURL, prices.String())
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalw("Fake Stripe API failed. Error: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	_ = httpServer.Close()
+	server.Close()
+	log.Infow("Fake Stripe API stopped")
+}
@@ -14,6 +14,13 @@ type App struct {
 	PaymentService   *services.PaymentService
 	PaymentHandler   *handlers.PaymentHandler
 	WebhookHandler   *handlers.WebhookHandler
+	WebhookAdmin     *handlers.WebhookAdminHandler
+	RefundAdmin      *handlers.RefundAdminHandler
+	PlanHandler      *handlers.PlanHandler
+	InvoiceHandler   *handlers.InvoiceHandler
+	PaymentMethods   *handlers.PaymentMethodHandler
+	CheckoutHandler  *handlers.CheckoutHandler
+	OneTimePayments  *handlers.OneTimePaymentHandler
 	AuthMiddleware   *middleware.JWTMiddleware
 	LoggerMiddleware gin.HandlerFunc
 	Logger           *logger.Logger
@@ -27,6 +34,20 @@ func NewApp(cfg *config.Config, paymentService *services.PaymentService, log *lo
 		log.Fatalw("Failed to initialize webhook handler", "error", err)
 	}
 
+	webhookAdminHandler := handlers.NewWebhookAdminHandler(paymentService, log)
+
+	refundAdminHandler := handlers.NewRefundAdminHandler(paymentService, log)
+
+	planHandler := handlers.NewPlanHandler(paymentService, log)
+
+	invoiceHandler := handlers.NewInvoiceHandler(paymentService, log)
+
+	paymentMethodHandler := handlers.NewPaymentMethodHandler(paymentService, log)
+
+	checkoutHandler := handlers.NewCheckoutHandler(paymentService, log)
+
+	oneTimePaymentHandler := handlers.NewOneTimePaymentHandler(paymentService, log)
+
 	authMiddleware := middleware.NewJWTMiddleware(cfg, log, validator)
 
 	loggerMiddleware := middleware.RequestLogger(log)
@@ -36,6 +57,13 @@ func NewApp(cfg *config.Config, paymentService *services.PaymentService, log *lo
 		PaymentService:   paymentService,
 		PaymentHandler:   paymentHandler,
 		WebhookHandler:   webhookHandler,
+		WebhookAdmin:     webhookAdminHandler,
+		RefundAdmin:      refundAdminHandler,
+		PlanHandler:      planHandler,
+		InvoiceHandler:   invoiceHandler,
+		PaymentMethods:   paymentMethodHandler,
+		CheckoutHandler:  checkoutHandler,
+		OneTimePayments:  oneTimePaymentHandler,
 		AuthMiddleware:   authMiddleware,
 		LoggerMiddleware: loggerMiddleware,
 		Logger:           log,
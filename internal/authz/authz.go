@@ -0,0 +1,183 @@
+// Package authz содержит модель авторизации сервиса: вызывающего (Principal) с ролями и scopes
+// из токена и политики доступа, которые HTTP middleware и gRPC interceptor применяют к каждому
+// маршруту и RPC.
+//
+// Роли:
+//   - user - работает только со своими данными;
+//   - support - читает данные любого пользователя, но ничего не меняет;
+//   - admin - административные операции и любые операции от имени пользователя;
+//   - service - внутренний сервис (service account), действует от имени любого пользователя.
+package authz
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Role роль вызывающего.
+type Role string
+
+const (
+	RoleUser    Role = "user"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+	RoleService Role = "service"
+)
+
+// Access характер операции.
+type Access int
+
+const (
+	AccessRead  Access = iota // Операция только читает данные
+	AccessWrite               // Операция меняет данные (в том числе в Stripe)
+)
+
+// String возвращает название доступа для логов.
+func (a Access) String() string {
+	if a == AccessWrite {
+		return "write"
+	}
+	return "read"
+}
+
+var (
+	// ErrForbidden возвращается, если политика не разрешает вызов.
+	ErrForbidden = errors.New("forbidden")
+	// ErrNoPolicy возвращается для маршрута или RPC без политики (доступ по умолчанию запрещен).
+	ErrNoPolicy = errors.New("no authorization policy")
+)
+
+// Наборы ролей для таблиц политик
+var (
+	// Readers могут читать данные пользователя.
+	Readers = []Role{RoleUser, RoleSupport, RoleAdmin, RoleService}
+	// Writers могут менять данные пользователя (support - нет).
+	Writers = []Role{RoleUser, RoleAdmin, RoleService}
+	// Staff может читать административные данные.
+	Staff = []Role{RoleSupport, RoleAdmin}
+	// Admins могут выполнять административные операции.
+	Admins = []Role{RoleAdmin}
+)
+
+// Типовые политики для таблиц маршрутов и RPC
+var (
+	// UserRead чтение данных пользователя (своих; support, admin и service - любых).
+	UserRead = Policy{Access: AccessRead, Roles: Readers, ActsOnUser: true}
+	// UserWrite изменение данных пользователя (своих; admin и service - любых).
+	UserWrite = Policy{Access: AccessWrite, Roles: Writers, ActsOnUser: true}
+	// AnyRead чтение общих данных (например, каталога планов).
+	AnyRead = Policy{Access: AccessRead, Roles: Readers}
+	// StaffRead чтение административных данных.
+	StaffRead = Policy{Access: AccessRead, Roles: Staff}
+	// AdminWrite административная операция.
+	AdminWrite = Policy{Access: AccessWrite, Roles: Admins}
+)
+
+// Scopes множество scopes токена.
+type Scopes map[string]struct{}
+
+// ParseScopes разбирает scope из токена (значения через пробел, RFC 6749).
+func ParseScopes(scope string) Scopes {
+	fields := strings.Fields(scope)
+	set := make(Scopes, len(fields))
+	for _, s := range fields {
+		set[s] = struct{}{}
+	}
+	return set
+}
+
+// Has сообщает, содержит ли множество все перечисленные scopes.
+func (s Scopes) Has(scopes ...string) bool {
+	for _, scope := range scopes {
+		if _, ok := s[scope]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Principal аутентифицированный вызывающий.
+type Principal struct {
+	Subject string // sub из токена
+	Email   string
+	Roles   []Role
+	Scopes  Scopes
+	// UserID пользователь, над данными которого выполняется операция: Subject или пользователь,
+	// от имени которого действует привилегированный вызывающий (см. Policy.Resolve).
+	UserID string
+}
+
+// HasRole сообщает, есть ли у вызывающего хотя бы одна из ролей.
+func (p *Principal) HasRole(roles ...Role) bool {
+	for _, have := range p.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsServiceAccount сообщает, что вызывает внутренний сервис.
+func (p *Principal) IsServiceAccount() bool {
+	return p.HasRole(RoleService)
+}
+
+// CanActFor сообщает, может ли вызывающий выполнять операцию над данными другого пользователя.
+func (p *Principal) CanActFor(access Access) bool {
+	if p.HasRole(RoleAdmin, RoleService) {
+		return true
+	}
+	return access == AccessRead && p.HasRole(RoleSupport)
+}
+
+// OnBehalf сообщает, что операция выполняется над данными другого пользователя.
+func (p *Principal) OnBehalf() bool {
+	return p.UserID != p.Subject
+}
+
+// Policy политика доступа к маршруту или RPC.
+type Policy struct {
+	Access Access
+	Roles  []Role   // Роли, которым разрешен вызов (достаточно одной)
+	Scopes []string // Scopes, обязательные в токене (все), помимо роли
+	// ActsOnUser - операция над данными пользователя: привилегированный вызывающий может
+	// указать пользователя, от имени которого действует. Для административных операций false.
+	ActsOnUser bool
+}
+
+// Resolve проверяет, что политика разрешает вызов, и заполняет p.UserID: целевой пользователь
+// targetUserID (из пути или запроса), если он указан и вызывающему разрешено действовать от его имени.
+func (pol Policy) Resolve(p *Principal, targetUserID string) error {
+	if !p.HasRole(pol.Roles...) {
+		return ErrForbidden
+	}
+	if !p.Scopes.Has(pol.Scopes...) {
+		return ErrForbidden
+	}
+
+	p.UserID = p.Subject
+	if !pol.ActsOnUser || targetUserID == "" || targetUserID == p.Subject {
+		return nil
+	}
+	if !p.CanActFor(pol.Access) {
+		return ErrForbidden
+	}
+	p.UserID = targetUserID
+	return nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с вызывающим.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает вызывающего из контекста.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
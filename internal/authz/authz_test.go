@@ -0,0 +1,103 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPolicyResolve(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     Policy
+		principal  Principal
+		target     string
+		wantErr    error
+		wantUserID string
+	}{
+		{"user reads own data", UserRead, Principal{Subject: "u1", Roles: []Role{RoleUser}}, "", nil, "u1"},
+		{"user passes own ID as target", UserWrite, Principal{Subject: "u1", Roles: []Role{RoleUser}}, "u1", nil, "u1"},
+		{"user cannot read another user", UserRead, Principal{Subject: "u1", Roles: []Role{RoleUser}}, "u2", ErrForbidden, ""},
+		{"support reads another user", UserRead, Principal{Subject: "s1", Roles: []Role{RoleSupport}}, "u2", nil, "u2"},
+		{"support cannot write for another user", UserWrite, Principal{Subject: "s1", Roles: []Role{RoleSupport}}, "u2", ErrForbidden, ""},
+		{"support has no write role at all", UserWrite, Principal{Subject: "s1", Roles: []Role{RoleSupport}}, "", ErrForbidden, ""},
+		{"admin writes for another user", UserWrite, Principal{Subject: "a1", Roles: []Role{RoleAdmin}}, "u2", nil, "u2"},
+		{"service acts for a user", UserWrite, Principal{Subject: "billing", Roles: []Role{RoleService}}, "u2", nil, "u2"},
+		{"admin operation ignores target", AdminWrite, Principal{Subject: "a1", Roles: []Role{RoleAdmin}}, "u2", nil, "a1"},
+		{"admin operation denied to support", AdminWrite, Principal{Subject: "s1", Roles: []Role{RoleSupport}}, "", ErrForbidden, ""},
+		{"no roles", AnyRead, Principal{Subject: "u1"}, "", ErrForbidden, ""},
+		{
+			"missing required scope",
+			Policy{Access: AccessWrite, Roles: Writers, Scopes: []string{"payments:write"}},
+			Principal{Subject: "u1", Roles: []Role{RoleUser}, Scopes: ParseScopes("payments:read")},
+			"", ErrForbidden, "",
+		},
+		{
+			"required scopes present",
+			Policy{Access: AccessWrite, Roles: Writers, Scopes: []string{"payments:read", "payments:write"}},
+			Principal{Subject: "u1", Roles: []Role{RoleUser}, Scopes: ParseScopes("payments:write  payments:read")},
+			"", nil, "u1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.principal
+			err := tt.policy.Resolve(&p, tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && p.UserID != tt.wantUserID {
+				t.Fatalf("UserID = %q, want %q", p.UserID, tt.wantUserID)
+			}
+			if err == nil && p.OnBehalf() != (tt.wantUserID != p.Subject) {
+				t.Fatalf("OnBehalf = %v for UserID %q and Subject %q", p.OnBehalf(), p.UserID, p.Subject)
+			}
+		})
+	}
+}
+
+func TestCanActFor(t *testing.T) {
+	tests := []struct {
+		roles     []Role
+		access    Access
+		wantAllow bool
+	}{
+		{[]Role{RoleUser}, AccessRead, false},
+		{[]Role{RoleUser}, AccessWrite, false},
+		{[]Role{RoleSupport}, AccessRead, true},
+		{[]Role{RoleSupport}, AccessWrite, false},
+		{[]Role{RoleAdmin}, AccessWrite, true},
+		{[]Role{RoleService}, AccessWrite, true},
+		{[]Role{RoleUser, RoleSupport}, AccessRead, true},
+	}
+	for _, tt := range tests {
+		p := &Principal{Subject: "x", Roles: tt.roles}
+		if got := p.CanActFor(tt.access); got != tt.wantAllow {
+			t.Errorf("CanActFor(%s) with roles %v = %v, want %v", tt.access, tt.roles, got, tt.wantAllow)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes := ParseScopes(" payments:read\tpayments:write ")
+	if !scopes.Has("payments:read", "payments:write") {
+		t.Fatalf("ParseScopes lost a scope: %v", scopes)
+	}
+	if scopes.Has("payments:admin") {
+		t.Fatal("Has reported a scope that is not in the token")
+	}
+	if !ParseScopes("").Has() {
+		t.Fatal("Has with no required scopes must be true")
+	}
+}
+
+func TestPrincipalContext(t *testing.T) {
+	if _, ok := PrincipalFromContext(context.Background()); ok {
+		t.Fatal("PrincipalFromContext found a principal in an empty context")
+	}
+	want := &Principal{Subject: "u1"}
+	got, ok := PrincipalFromContext(WithPrincipal(context.Background(), want))
+	if !ok || got != want {
+		t.Fatalf("PrincipalFromContext = %v, %v; want the stored principal", got, ok)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 	"os"
+	"time"
 )
 
 // Config представляет структуру конфигурации для приложения.
@@ -28,12 +29,41 @@ type Config struct {
 	Stripe struct {
 		APIKey        string `mapstructure:"apiKey"`
 		WebhookSecret string `mapstructure:"webhookSecret"` // Добавим позже
+		APIBaseURL    string `mapstructure:"apiBaseUrl"`    // Адрес Stripe API (пусто - api.stripe.com; для локального запуска - cmd/stripe-fake)
+
+		CheckoutSuccessURL string   `mapstructure:"checkoutSuccessUrl"` // Куда Stripe Checkout вернет пользователя после оплаты
+		CheckoutCancelURL  string   `mapstructure:"checkoutCancelUrl"`  // Куда Stripe Checkout вернет пользователя при отказе от оплаты
+		PortalReturnURL    string   `mapstructure:"portalReturnUrl"`    // Куда вернуться из Billing Portal
+		RedirectHosts      []string `mapstructure:"redirectHosts"`      // Дополнительные хосты, на которые клиент может передать свои URL возврата
 	} `mapstructure:"stripe"`
+	Outbox struct {
+		PollInterval time.Duration `mapstructure:"pollInterval"` // Период опроса таблицы outbox (по умолчанию 1s)
+		BatchSize    int           `mapstructure:"batchSize"`    // Сколько событий публиковать за один проход (по умолчанию 100)
+		MaxAttempts  int           `mapstructure:"maxAttempts"`  // Попыток публикации до перевода события в dead-letter (по умолчанию 20)
+		Retention    time.Duration `mapstructure:"retention"`    // Сколько хранить опубликованные события (по умолчанию 168h)
+	} `mapstructure:"outbox"`
+	WebhookQueue struct {
+		Workers      int           `mapstructure:"workers"`      // Количество воркеров обработки вебхуков (по умолчанию 4)
+		PollInterval time.Duration `mapstructure:"pollInterval"` // Период опроса очереди (по умолчанию 1s)
+		BatchSize    int           `mapstructure:"batchSize"`    // Сколько событий захватывать за раз (по умолчанию 50)
+		MaxAttempts  int           `mapstructure:"maxAttempts"`  // Попыток до перевода события в dead-letter (по умолчанию 10)
+	} `mapstructure:"webhookQueue"`
 	GRPC struct {
 		Port string `mapstructure:"port"`
 	} `mapstructure:"grpc"`
+	Metrics struct {
+		Addr string `mapstructure:"addr"` // Внутренний адрес эндпоинта /metrics, отдельный от публичного API (по умолчанию :9090)
+	} `mapstructure:"metrics"`
 	Auth struct {
-		JWTSecret string `mapstructure:"jwtSecret"`
+		JWTSecret string `mapstructure:"jwtSecret"` // Общий секрет HS256 (используется, если jwksUrl не задан)
+
+		JWKSURL             string        `mapstructure:"jwksUrl"`             // JWKS провайдера: токены RS*/PS*/ES* проверяются его ключами вместо jwtSecret
+		Issuer              string        `mapstructure:"issuer"`              // Ожидаемый iss (обязателен вместе с jwksUrl)
+		Audience            string        `mapstructure:"audience"`            // Ожидаемый aud (обязателен вместе с jwksUrl)
+		ClockSkew           time.Duration `mapstructure:"clockSkew"`           // Допустимое расхождение часов (по умолчанию 30s)
+		JWKSRefreshInterval time.Duration `mapstructure:"jwksRefreshInterval"` // Период перезагрузки ключей (по умолчанию 1h; при неизвестном kid - сразу)
+
+		ServiceAccounts []string `mapstructure:"serviceAccounts"` // Субъекты (sub) внутренних сервисов: действуют от имени любого пользователя
 	} `mapstructure:"auth"`
 }
 
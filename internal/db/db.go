@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
 	"github.com/Dhoini/Payment-microservice/pkg/logger"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
@@ -38,6 +39,31 @@ func NewDBClient(dsn string, log *logger.Logger) (*DBClient, error) {
 	return &DBClient{db: db, log: log}, nil
 }
 
+// RegisterMetrics регистрирует в registry статистику пула соединений (читается из sqlx.DB при каждом сборе).
+func (dc *DBClient) RegisterMetrics(registry *metrics.Registry) {
+	stats := func(value func(s sql.DBStats) float64) func() float64 {
+		return func() float64 { return value(dc.db.Stats()) }
+	}
+	registry.NewGaugeFunc("db_pool_max_open_connections", "Maximum number of open connections to the database.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }))
+	registry.NewGaugeFunc("db_pool_open_connections", "The number of established connections both in use and idle.",
+		stats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	registry.NewGaugeFunc("db_pool_in_use_connections", "The number of connections currently in use.",
+		stats(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	registry.NewGaugeFunc("db_pool_idle_connections", "The number of idle connections.",
+		stats(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	registry.NewCounterFunc("db_pool_wait_count_total", "The total number of connections waited for.",
+		stats(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	registry.NewCounterFunc("db_pool_wait_duration_seconds_total", "The total time blocked waiting for a new connection.",
+		stats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+	registry.NewCounterFunc("db_pool_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }))
+	registry.NewCounterFunc("db_pool_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }))
+	registry.NewCounterFunc("db_pool_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.",
+		stats(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }))
+}
+
 // Close закрывает соединение с базой данных.
 func (dc *DBClient) Close() error {
 	err := dc.db.Close()
@@ -22,12 +22,119 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Режим отмены подписки
+type CancelMode int32
+
+const (
+	CancelMode_CANCEL_MODE_UNSPECIFIED   CancelMode = 0 // Как CANCEL_MODE_IMMEDIATELY
+	CancelMode_CANCEL_MODE_IMMEDIATELY   CancelMode = 1 // Отменить сразу
+	CancelMode_CANCEL_MODE_AT_PERIOD_END CancelMode = 2 // Отменить в конце оплаченного периода (можно снять через ResumeSubscription)
+)
+
+// Enum value maps for CancelMode.
+var (
+	CancelMode_name = map[int32]string{
+		0: "CANCEL_MODE_UNSPECIFIED",
+		1: "CANCEL_MODE_IMMEDIATELY",
+		2: "CANCEL_MODE_AT_PERIOD_END",
+	}
+	CancelMode_value = map[string]int32{
+		"CANCEL_MODE_UNSPECIFIED":   0,
+		"CANCEL_MODE_IMMEDIATELY":   1,
+		"CANCEL_MODE_AT_PERIOD_END": 2,
+	}
+)
+
+func (x CancelMode) Enum() *CancelMode {
+	p := new(CancelMode)
+	*p = x
+	return p
+}
+
+func (x CancelMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CancelMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_payment_proto_enumTypes[0].Descriptor()
+}
+
+func (CancelMode) Type() protoreflect.EnumType {
+	return &file_payment_proto_enumTypes[0]
+}
+
+func (x CancelMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CancelMode.Descriptor instead.
+func (CancelMode) EnumDescriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{0}
+}
+
+// Что делать со счетами, пока списания приостановлены
+type PauseBehavior int32
+
+const (
+	PauseBehavior_PAUSE_BEHAVIOR_UNSPECIFIED        PauseBehavior = 0
+	PauseBehavior_PAUSE_BEHAVIOR_VOID               PauseBehavior = 1 // Счета аннулируются
+	PauseBehavior_PAUSE_BEHAVIOR_KEEP_AS_DRAFT      PauseBehavior = 2 // Счета остаются черновиками
+	PauseBehavior_PAUSE_BEHAVIOR_MARK_UNCOLLECTIBLE PauseBehavior = 3 // Счета помечаются безнадежными
+)
+
+// Enum value maps for PauseBehavior.
+var (
+	PauseBehavior_name = map[int32]string{
+		0: "PAUSE_BEHAVIOR_UNSPECIFIED",
+		1: "PAUSE_BEHAVIOR_VOID",
+		2: "PAUSE_BEHAVIOR_KEEP_AS_DRAFT",
+		3: "PAUSE_BEHAVIOR_MARK_UNCOLLECTIBLE",
+	}
+	PauseBehavior_value = map[string]int32{
+		"PAUSE_BEHAVIOR_UNSPECIFIED":        0,
+		"PAUSE_BEHAVIOR_VOID":               1,
+		"PAUSE_BEHAVIOR_KEEP_AS_DRAFT":      2,
+		"PAUSE_BEHAVIOR_MARK_UNCOLLECTIBLE": 3,
+	}
+)
+
+func (x PauseBehavior) Enum() *PauseBehavior {
+	p := new(PauseBehavior)
+	*p = x
+	return p
+}
+
+func (x PauseBehavior) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PauseBehavior) Descriptor() protoreflect.EnumDescriptor {
+	return file_payment_proto_enumTypes[1].Descriptor()
+}
+
+func (PauseBehavior) Type() protoreflect.EnumType {
+	return &file_payment_proto_enumTypes[1]
+}
+
+func (x PauseBehavior) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PauseBehavior.Descriptor instead.
+func (PauseBehavior) EnumDescriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{1}
+}
+
 type CreateSubscriptionRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                         // ID пользователя из вашей системы
 	PlanId         string                 `protobuf:"bytes,2,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`                         // ID тарифного плана (Price ID из Stripe)
 	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // Ключ идемпотентности (опционально, но рекомендуется)
 	UserEmail      string                 `protobuf:"bytes,4,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`                // Email пользователя (нужен для создания Stripe Customer)
+	TrialDays      *int32                 `protobuf:"varint,5,opt,name=trial_days,json=trialDays,proto3,oneof" json:"trial_days,omitempty"`         // Пробный период в днях (не задан - по умолчанию для плана, 0 - без пробного периода)
+	TrialEnd       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=trial_end,json=trialEnd,proto3" json:"trial_end,omitempty"`                   // Точный конец пробного периода (вместо trial_days)
+	Coupon         string                 `protobuf:"bytes,7,opt,name=coupon,proto3" json:"coupon,omitempty"`                                       // ID купона Stripe (опционально)
+	PromotionCode  string                 `protobuf:"bytes,8,opt,name=promotion_code,json=promotionCode,proto3" json:"promotion_code,omitempty"`    // Промокод (вместо coupon)
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -90,12 +197,42 @@ func (x *CreateSubscriptionRequest) GetUserEmail() string {
 	return ""
 }
 
+func (x *CreateSubscriptionRequest) GetTrialDays() int32 {
+	if x != nil && x.TrialDays != nil {
+		return *x.TrialDays
+	}
+	return 0
+}
+
+func (x *CreateSubscriptionRequest) GetTrialEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TrialEnd
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionRequest) GetCoupon() string {
+	if x != nil {
+		return x.Coupon
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetPromotionCode() string {
+	if x != nil {
+		return x.PromotionCode
+	}
+	return ""
+}
+
 type CreateSubscriptionResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	SubscriptionId string                 `protobuf:"bytes,1,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"` // ID созданной подписки (Stripe sub_...)
 	ClientSecret   string                 `protobuf:"bytes,2,opt,name=client_secret,json=clientSecret,proto3" json:"client_secret,omitempty"`       // Секрет для подтверждения платежа на клиенте (если нужен)
 	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                // Время создания подписки (примерное)
-	Status         string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                                       // Статус подписки из Stripe (например, "active", "incomplete")
+	Status         string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                                       // Статус подписки из Stripe (например, "active", "incomplete", "trialing")
+	TrialEnd       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=trial_end,json=trialEnd,proto3" json:"trial_end,omitempty"`                   // Конец пробного периода (client_secret тогда для сохранения карты)
+	Discount       *Discount              `protobuf:"bytes,6,opt,name=discount,proto3" json:"discount,omitempty"`                                   // Примененная скидка (не задана - без скидки)
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -158,11 +295,26 @@ func (x *CreateSubscriptionResponse) GetStatus() string {
 	return ""
 }
 
+func (x *CreateSubscriptionResponse) GetTrialEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TrialEnd
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionResponse) GetDiscount() *Discount {
+	if x != nil {
+		return x.Discount
+	}
+	return nil
+}
+
 type CancelSubscriptionRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                         // ID пользователя (для проверки прав)
 	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"` // ID подписки для отмены
 	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // Ключ идемпотентности (опционально)
+	Mode           CancelMode             `protobuf:"varint,4,opt,name=mode,proto3,enum=payment.CancelMode" json:"mode,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -218,12 +370,21 @@ func (x *CancelSubscriptionRequest) GetIdempotencyKey() string {
 	return ""
 }
 
+func (x *CancelSubscriptionRequest) GetMode() CancelMode {
+	if x != nil {
+		return x.Mode
+	}
+	return CancelMode_CANCEL_MODE_UNSPECIFIED
+}
+
 type CancelSubscriptionResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`                        // Признак успеха операции
-	CanceledAt    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=canceled_at,json=canceledAt,proto3" json:"canceled_at,omitempty"` // Время отмены (или когда отмена вступит в силу)
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Success           bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`                                                  // Признак успеха операции
+	CanceledAt        *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=canceled_at,json=canceledAt,proto3" json:"canceled_at,omitempty"`                           // Время отмены (или когда отмена вступит в силу)
+	CancelAtPeriodEnd bool                   `protobuf:"varint,3,opt,name=cancel_at_period_end,json=cancelAtPeriodEnd,proto3" json:"cancel_at_period_end,omitempty"` // true - подписка действует до canceled_at
+	Subscription      *Subscription          `protobuf:"bytes,4,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *CancelSubscriptionResponse) Reset() {
@@ -270,28 +431,43 @@ func (x *CancelSubscriptionResponse) GetCanceledAt() *timestamppb.Timestamp {
 	return nil
 }
 
-type GetSubscriptionRequest struct {
+func (x *CancelSubscriptionResponse) GetCancelAtPeriodEnd() bool {
+	if x != nil {
+		return x.CancelAtPeriodEnd
+	}
+	return false
+}
+
+func (x *CancelSubscriptionResponse) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type ResumeSubscriptionRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
-	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                         // ID пользователя (для проверки прав)
-	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"` // ID запрашиваемой подписки
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
-func (x *GetSubscriptionRequest) Reset() {
-	*x = GetSubscriptionRequest{}
+func (x *ResumeSubscriptionRequest) Reset() {
+	*x = ResumeSubscriptionRequest{}
 	mi := &file_payment_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetSubscriptionRequest) String() string {
+func (x *ResumeSubscriptionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetSubscriptionRequest) ProtoMessage() {}
+func (*ResumeSubscriptionRequest) ProtoMessage() {}
 
-func (x *GetSubscriptionRequest) ProtoReflect() protoreflect.Message {
+func (x *ResumeSubscriptionRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_payment_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -303,55 +479,53 @@ func (x *GetSubscriptionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetSubscriptionRequest.ProtoReflect.Descriptor instead.
-func (*GetSubscriptionRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResumeSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*ResumeSubscriptionRequest) Descriptor() ([]byte, []int) {
 	return file_payment_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *GetSubscriptionRequest) GetUserId() string {
+func (x *ResumeSubscriptionRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *GetSubscriptionRequest) GetSubscriptionId() string {
+func (x *ResumeSubscriptionRequest) GetSubscriptionId() string {
 	if x != nil {
 		return x.SubscriptionId
 	}
 	return ""
 }
 
-// Представление подписки (можно расширить)
-type Subscription struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	SubscriptionId   string                 `protobuf:"bytes,1,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
-	UserId           string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	PlanId           string                 `protobuf:"bytes,3,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
-	Status           string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
-	StripeCustomerId string                 `protobuf:"bytes,5,opt,name=stripe_customer_id,json=stripeCustomerId,proto3" json:"stripe_customer_id,omitempty"`
-	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	ExpiresAt        *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
-	CanceledAt       *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=canceled_at,json=canceledAt,proto3" json:"canceled_at,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *ResumeSubscriptionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
 }
 
-func (x *Subscription) Reset() {
-	*x = Subscription{}
+type ResumeSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeSubscriptionResponse) Reset() {
+	*x = ResumeSubscriptionResponse{}
 	mi := &file_payment_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Subscription) String() string {
+func (x *ResumeSubscriptionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Subscription) ProtoMessage() {}
+func (*ResumeSubscriptionResponse) ProtoMessage() {}
 
-func (x *Subscription) ProtoReflect() protoreflect.Message {
+func (x *ResumeSubscriptionResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_payment_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -363,96 +537,220 @@ func (x *Subscription) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
-func (*Subscription) Descriptor() ([]byte, []int) {
+// Deprecated: Use ResumeSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*ResumeSubscriptionResponse) Descriptor() ([]byte, []int) {
 	return file_payment_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *Subscription) GetSubscriptionId() string {
+func (x *ResumeSubscriptionResponse) GetSubscription() *Subscription {
 	if x != nil {
-		return x.SubscriptionId
+		return x.Subscription
 	}
-	return ""
+	return nil
 }
 
-func (x *Subscription) GetUserId() string {
+type PauseSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	Behavior       PauseBehavior          `protobuf:"varint,3,opt,name=behavior,proto3,enum=payment.PauseBehavior" json:"behavior,omitempty"` // Обязательно
+	ResumesAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=resumes_at,json=resumesAt,proto3" json:"resumes_at,omitempty"`          // Автоматическое возобновление (опционально)
+	IdempotencyKey string                 `protobuf:"bytes,5,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PauseSubscriptionRequest) Reset() {
+	*x = PauseSubscriptionRequest{}
+	mi := &file_payment_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseSubscriptionRequest) ProtoMessage() {}
+
+func (x *PauseSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*PauseSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PauseSubscriptionRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *Subscription) GetPlanId() string {
+func (x *PauseSubscriptionRequest) GetSubscriptionId() string {
 	if x != nil {
-		return x.PlanId
+		return x.SubscriptionId
 	}
 	return ""
 }
 
-func (x *Subscription) GetStatus() string {
+func (x *PauseSubscriptionRequest) GetBehavior() PauseBehavior {
 	if x != nil {
-		return x.Status
+		return x.Behavior
 	}
-	return ""
+	return PauseBehavior_PAUSE_BEHAVIOR_UNSPECIFIED
 }
 
-func (x *Subscription) GetStripeCustomerId() string {
+func (x *PauseSubscriptionRequest) GetResumesAt() *timestamppb.Timestamp {
 	if x != nil {
-		return x.StripeCustomerId
+		return x.ResumesAt
+	}
+	return nil
+}
+
+func (x *PauseSubscriptionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
 	}
 	return ""
 }
 
-func (x *Subscription) GetCreatedAt() *timestamppb.Timestamp {
+type PauseSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseSubscriptionResponse) Reset() {
+	*x = PauseSubscriptionResponse{}
+	mi := &file_payment_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseSubscriptionResponse) ProtoMessage() {}
+
+func (x *PauseSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[7]
 	if x != nil {
-		return x.CreatedAt
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *Subscription) GetUpdatedAt() *timestamppb.Timestamp {
+// Deprecated: Use PauseSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*PauseSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PauseSubscriptionResponse) GetSubscription() *Subscription {
 	if x != nil {
-		return x.UpdatedAt
+		return x.Subscription
 	}
 	return nil
 }
 
-func (x *Subscription) GetExpiresAt() *timestamppb.Timestamp {
+type UnpauseSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UnpauseSubscriptionRequest) Reset() {
+	*x = UnpauseSubscriptionRequest{}
+	mi := &file_payment_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnpauseSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnpauseSubscriptionRequest) ProtoMessage() {}
+
+func (x *UnpauseSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[8]
 	if x != nil {
-		return x.ExpiresAt
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *Subscription) GetCanceledAt() *timestamppb.Timestamp {
+// Deprecated: Use UnpauseSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*UnpauseSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UnpauseSubscriptionRequest) GetUserId() string {
 	if x != nil {
-		return x.CanceledAt
+		return x.UserId
 	}
-	return nil
+	return ""
 }
 
-type GetSubscriptionResponse struct {
+func (x *UnpauseSubscriptionRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *UnpauseSubscriptionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type UnpauseSubscriptionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"` // Возвращаем полную информацию о подписке
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetSubscriptionResponse) Reset() {
-	*x = GetSubscriptionResponse{}
-	mi := &file_payment_proto_msgTypes[6]
+func (x *UnpauseSubscriptionResponse) Reset() {
+	*x = UnpauseSubscriptionResponse{}
+	mi := &file_payment_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetSubscriptionResponse) String() string {
+func (x *UnpauseSubscriptionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetSubscriptionResponse) ProtoMessage() {}
+func (*UnpauseSubscriptionResponse) ProtoMessage() {}
 
-func (x *GetSubscriptionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_payment_proto_msgTypes[6]
+func (x *UnpauseSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -463,46 +761,3198 @@ func (x *GetSubscriptionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetSubscriptionResponse.ProtoReflect.Descriptor instead.
-func (*GetSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return file_payment_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use UnpauseSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*UnpauseSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *GetSubscriptionResponse) GetSubscription() *Subscription {
+func (x *UnpauseSubscriptionResponse) GetSubscription() *Subscription {
 	if x != nil {
 		return x.Subscription
 	}
 	return nil
 }
 
-var File_payment_proto protoreflect.FileDescriptor
-
-const file_payment_proto_rawDesc = "" +
-	"\n" +
-	"\rpayment.proto\x12\apayment\x1a\x1fgoogle/protobuf/timestamp.proto\"\x95\x01\n" +
+type GetSubscriptionRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                         // ID пользователя (для проверки прав)
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"` // ID запрашиваемой подписки
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetSubscriptionRequest) Reset() {
+	*x = GetSubscriptionRequest{}
+	mi := &file_payment_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSubscriptionRequest) ProtoMessage() {}
+
+func (x *GetSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*GetSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetSubscriptionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetSubscriptionRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+// Представление подписки (можно расширить)
+type Subscription struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	SubscriptionId    string                 `protobuf:"bytes,1,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	UserId            string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	PlanId            string                 `protobuf:"bytes,3,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	Status            string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	StripeCustomerId  string                 `protobuf:"bytes,5,opt,name=stripe_customer_id,json=stripeCustomerId,proto3" json:"stripe_customer_id,omitempty"`
+	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt         *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	ExpiresAt         *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CanceledAt        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=canceled_at,json=canceledAt,proto3" json:"canceled_at,omitempty"`
+	CancelAtPeriodEnd bool                   `protobuf:"varint,10,opt,name=cancel_at_period_end,json=cancelAtPeriodEnd,proto3" json:"cancel_at_period_end,omitempty"` // Подписка будет отменена в конце текущего периода
+	CancelAt          *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=cancel_at,json=cancelAt,proto3" json:"cancel_at,omitempty"`                                 // Когда вступит в силу запланированная отмена
+	ResumesAt         *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=resumes_at,json=resumesAt,proto3" json:"resumes_at,omitempty"`                              // Когда автоматически возобновятся приостановленные списания (status = paused)
+	TrialStart        *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=trial_start,json=trialStart,proto3" json:"trial_start,omitempty"`
+	TrialEnd          *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=trial_end,json=trialEnd,proto3" json:"trial_end,omitempty"` // Конец пробного периода (status = trialing до этого момента)
+	Discount          *Discount              `protobuf:"bytes,15,opt,name=discount,proto3" json:"discount,omitempty"`                 // Примененная скидка (не задана - без скидки)
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_payment_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Subscription) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *Subscription) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Subscription) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *Subscription) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Subscription) GetStripeCustomerId() string {
+	if x != nil {
+		return x.StripeCustomerId
+	}
+	return ""
+}
+
+func (x *Subscription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetCanceledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CanceledAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetCancelAtPeriodEnd() bool {
+	if x != nil {
+		return x.CancelAtPeriodEnd
+	}
+	return false
+}
+
+func (x *Subscription) GetCancelAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CancelAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetResumesAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ResumesAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetTrialStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TrialStart
+	}
+	return nil
+}
+
+func (x *Subscription) GetTrialEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TrialEnd
+	}
+	return nil
+}
+
+func (x *Subscription) GetDiscount() *Discount {
+	if x != nil {
+		return x.Discount
+	}
+	return nil
+}
+
+// Скидка, примененная к подписке
+type Discount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CouponId      string                 `protobuf:"bytes,1,opt,name=coupon_id,json=couponId,proto3" json:"coupon_id,omitempty"`
+	PromotionCode string                 `protobuf:"bytes,2,opt,name=promotion_code,json=promotionCode,proto3" json:"promotion_code,omitempty"` // Промокод, через который применен купон (пусто - купон указан напрямую)
+	PercentOff    float64                `protobuf:"fixed64,3,opt,name=percent_off,json=percentOff,proto3" json:"percent_off,omitempty"`
+	AmountOff     int64                  `protobuf:"varint,4,opt,name=amount_off,json=amountOff,proto3" json:"amount_off,omitempty"` // В минимальных единицах currency
+	Currency      string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	Duration      string                 `protobuf:"bytes,6,opt,name=duration,proto3" json:"duration,omitempty"`           // once, repeating или forever
+	EndsAt        *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=ends_at,json=endsAt,proto3" json:"ends_at,omitempty"` // Конец скидки repeating-купона
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Discount) Reset() {
+	*x = Discount{}
+	mi := &file_payment_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Discount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Discount) ProtoMessage() {}
+
+func (x *Discount) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Discount.ProtoReflect.Descriptor instead.
+func (*Discount) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Discount) GetCouponId() string {
+	if x != nil {
+		return x.CouponId
+	}
+	return ""
+}
+
+func (x *Discount) GetPromotionCode() string {
+	if x != nil {
+		return x.PromotionCode
+	}
+	return ""
+}
+
+func (x *Discount) GetPercentOff() float64 {
+	if x != nil {
+		return x.PercentOff
+	}
+	return 0
+}
+
+func (x *Discount) GetAmountOff() int64 {
+	if x != nil {
+		return x.AmountOff
+	}
+	return 0
+}
+
+func (x *Discount) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Discount) GetDuration() string {
+	if x != nil {
+		return x.Duration
+	}
+	return ""
+}
+
+func (x *Discount) GetEndsAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndsAt
+	}
+	return nil
+}
+
+type GetSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"` // Возвращаем полную информацию о подписке
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSubscriptionResponse) Reset() {
+	*x = GetSubscriptionResponse{}
+	mi := &file_payment_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSubscriptionResponse) ProtoMessage() {}
+
+func (x *GetSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*GetSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetSubscriptionResponse) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+type ListUserSubscriptionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`          // ID пользователя (должен совпадать с пользователем из токена)
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // Размер страницы (по умолчанию 50, максимум 100)
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // Токен страницы из предыдущего ответа (пустой - первая страница)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserSubscriptionsRequest) Reset() {
+	*x = ListUserSubscriptionsRequest{}
+	mi := &file_payment_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserSubscriptionsRequest) ProtoMessage() {}
+
+func (x *ListUserSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListUserSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListUserSubscriptionsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListUserSubscriptionsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListUserSubscriptionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListUserSubscriptionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscriptions []*Subscription        `protobuf:"bytes,1,rep,name=subscriptions,proto3" json:"subscriptions,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Токен следующей страницы (пустой, если страниц больше нет)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserSubscriptionsResponse) Reset() {
+	*x = ListUserSubscriptionsResponse{}
+	mi := &file_payment_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserSubscriptionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserSubscriptionsResponse) ProtoMessage() {}
+
+func (x *ListUserSubscriptionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserSubscriptionsResponse.ProtoReflect.Descriptor instead.
+func (*ListUserSubscriptionsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListUserSubscriptionsResponse) GetSubscriptions() []*Subscription {
+	if x != nil {
+		return x.Subscriptions
+	}
+	return nil
+}
+
+func (x *ListUserSubscriptionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type ChangePlanRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	PlanId         string                 `protobuf:"bytes,3,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`                       // Новый план (Price ID из Stripe)
+	AtPeriodEnd    bool                   `protobuf:"varint,4,opt,name=at_period_end,json=atPeriodEnd,proto3" json:"at_period_end,omitempty"`     // true - новый план с начала следующего периода, без перерасчета
+	ProrationDate  int64                  `protobuf:"varint,5,opt,name=proration_date,json=prorationDate,proto3" json:"proration_date,omitempty"` // Момент перерасчета из PreviewPlanChangeResponse (Unix, опционально)
+	IdempotencyKey string                 `protobuf:"bytes,6,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ChangePlanRequest) Reset() {
+	*x = ChangePlanRequest{}
+	mi := &file_payment_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePlanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePlanRequest) ProtoMessage() {}
+
+func (x *ChangePlanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePlanRequest.ProtoReflect.Descriptor instead.
+func (*ChangePlanRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ChangePlanRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ChangePlanRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *ChangePlanRequest) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *ChangePlanRequest) GetAtPeriodEnd() bool {
+	if x != nil {
+		return x.AtPeriodEnd
+	}
+	return false
+}
+
+func (x *ChangePlanRequest) GetProrationDate() int64 {
+	if x != nil {
+		return x.ProrationDate
+	}
+	return 0
+}
+
+func (x *ChangePlanRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type ChangePlanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subscription  *Subscription          `protobuf:"bytes,1,opt,name=subscription,proto3" json:"subscription,omitempty"` // plan_id меняется после подтверждения от Stripe (вебхук)
+	NewPlanId     string                 `protobuf:"bytes,2,opt,name=new_plan_id,json=newPlanId,proto3" json:"new_plan_id,omitempty"`
+	AtPeriodEnd   bool                   `protobuf:"varint,3,opt,name=at_period_end,json=atPeriodEnd,proto3" json:"at_period_end,omitempty"`
+	EffectiveAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=effective_at,json=effectiveAt,proto3" json:"effective_at,omitempty"` // Когда новый план начинает действовать
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangePlanResponse) Reset() {
+	*x = ChangePlanResponse{}
+	mi := &file_payment_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangePlanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangePlanResponse) ProtoMessage() {}
+
+func (x *ChangePlanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangePlanResponse.ProtoReflect.Descriptor instead.
+func (*ChangePlanResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ChangePlanResponse) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+func (x *ChangePlanResponse) GetNewPlanId() string {
+	if x != nil {
+		return x.NewPlanId
+	}
+	return ""
+}
+
+func (x *ChangePlanResponse) GetAtPeriodEnd() bool {
+	if x != nil {
+		return x.AtPeriodEnd
+	}
+	return false
+}
+
+func (x *ChangePlanResponse) GetEffectiveAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EffectiveAt
+	}
+	return nil
+}
+
+type PreviewPlanChangeRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	PlanId         string                 `protobuf:"bytes,3,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	AtPeriodEnd    bool                   `protobuf:"varint,4,opt,name=at_period_end,json=atPeriodEnd,proto3" json:"at_period_end,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PreviewPlanChangeRequest) Reset() {
+	*x = PreviewPlanChangeRequest{}
+	mi := &file_payment_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewPlanChangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewPlanChangeRequest) ProtoMessage() {}
+
+func (x *PreviewPlanChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewPlanChangeRequest.ProtoReflect.Descriptor instead.
+func (*PreviewPlanChangeRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PreviewPlanChangeRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PreviewPlanChangeRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *PreviewPlanChangeRequest) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *PreviewPlanChangeRequest) GetAtPeriodEnd() bool {
+	if x != nil {
+		return x.AtPeriodEnd
+	}
+	return false
+}
+
+type PreviewPlanChangeResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	SubscriptionId  string                 `protobuf:"bytes,1,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"`
+	PlanId          string                 `protobuf:"bytes,2,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	AtPeriodEnd     bool                   `protobuf:"varint,3,opt,name=at_period_end,json=atPeriodEnd,proto3" json:"at_period_end,omitempty"`
+	AmountDue       int64                  `protobuf:"varint,4,opt,name=amount_due,json=amountDue,proto3" json:"amount_due,omitempty"` // К оплате по ближайшему счету, в минимальных единицах валюты
+	Total           int64                  `protobuf:"varint,5,opt,name=total,proto3" json:"total,omitempty"`
+	ProrationAmount int64                  `protobuf:"varint,6,opt,name=proration_amount,json=prorationAmount,proto3" json:"proration_amount,omitempty"` // Часть суммы, приходящаяся на перерасчет (отрицательная при понижении плана)
+	Currency        string                 `protobuf:"bytes,7,opt,name=currency,proto3" json:"currency,omitempty"`
+	NextPaymentAt   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=next_payment_at,json=nextPaymentAt,proto3" json:"next_payment_at,omitempty"`
+	ProrationDate   int64                  `protobuf:"varint,9,opt,name=proration_date,json=prorationDate,proto3" json:"proration_date,omitempty"` // Передается в ChangePlanRequest, чтобы сумма совпала с показанной
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PreviewPlanChangeResponse) Reset() {
+	*x = PreviewPlanChangeResponse{}
+	mi := &file_payment_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewPlanChangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewPlanChangeResponse) ProtoMessage() {}
+
+func (x *PreviewPlanChangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewPlanChangeResponse.ProtoReflect.Descriptor instead.
+func (*PreviewPlanChangeResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *PreviewPlanChangeResponse) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *PreviewPlanChangeResponse) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *PreviewPlanChangeResponse) GetAtPeriodEnd() bool {
+	if x != nil {
+		return x.AtPeriodEnd
+	}
+	return false
+}
+
+func (x *PreviewPlanChangeResponse) GetAmountDue() int64 {
+	if x != nil {
+		return x.AmountDue
+	}
+	return 0
+}
+
+func (x *PreviewPlanChangeResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *PreviewPlanChangeResponse) GetProrationAmount() int64 {
+	if x != nil {
+		return x.ProrationAmount
+	}
+	return 0
+}
+
+func (x *PreviewPlanChangeResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *PreviewPlanChangeResponse) GetNextPaymentAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextPaymentAt
+	}
+	return nil
+}
+
+func (x *PreviewPlanChangeResponse) GetProrationDate() int64 {
+	if x != nil {
+		return x.ProrationDate
+	}
+	return 0
+}
+
+type WatchSubscriptionsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                         // ID пользователя (должен совпадать с пользователем из токена)
+	SubscriptionId string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"` // Следить только за одной подпиской (опционально)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WatchSubscriptionsRequest) Reset() {
+	*x = WatchSubscriptionsRequest{}
+	mi := &file_payment_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSubscriptionsRequest) ProtoMessage() {}
+
+func (x *WatchSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*WatchSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchSubscriptionsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *WatchSubscriptionsRequest) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+// Изменение статуса подписки
+type SubscriptionStatusChange struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	EventId        string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`       // ID события (совпадает с event_id события в Kafka)
+	EventType      string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"` // Тип перехода (subscription.activated, subscription.canceled, ...)
+	PreviousStatus string                 `protobuf:"bytes,3,opt,name=previous_status,json=previousStatus,proto3" json:"previous_status,omitempty"`
+	NewStatus      string                 `protobuf:"bytes,4,opt,name=new_status,json=newStatus,proto3" json:"new_status,omitempty"`
+	Subscription   *Subscription          `protobuf:"bytes,5,opt,name=subscription,proto3" json:"subscription,omitempty"` // Снимок подписки после перехода
+	OccurredAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SubscriptionStatusChange) Reset() {
+	*x = SubscriptionStatusChange{}
+	mi := &file_payment_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionStatusChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionStatusChange) ProtoMessage() {}
+
+func (x *SubscriptionStatusChange) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionStatusChange.ProtoReflect.Descriptor instead.
+func (*SubscriptionStatusChange) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SubscriptionStatusChange) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *SubscriptionStatusChange) GetEventType() string {
+	if x != nil {
+		return x.EventType
+	}
+	return ""
+}
+
+func (x *SubscriptionStatusChange) GetPreviousStatus() string {
+	if x != nil {
+		return x.PreviousStatus
+	}
+	return ""
+}
+
+func (x *SubscriptionStatusChange) GetNewStatus() string {
+	if x != nil {
+		return x.NewStatus
+	}
+	return ""
+}
+
+func (x *SubscriptionStatusChange) GetSubscription() *Subscription {
+	if x != nil {
+		return x.Subscription
+	}
+	return nil
+}
+
+func (x *SubscriptionStatusChange) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+// Представление клиента Stripe
+type Customer struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	UserId           string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	StripeCustomerId string                 `protobuf:"bytes,2,opt,name=stripe_customer_id,json=stripeCustomerId,proto3" json:"stripe_customer_id,omitempty"`
+	Email            string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Customer) Reset() {
+	*x = Customer{}
+	mi := &file_payment_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Customer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Customer) ProtoMessage() {}
+
+func (x *Customer) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Customer.ProtoReflect.Descriptor instead.
+func (*Customer) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Customer) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Customer) GetStripeCustomerId() string {
+	if x != nil {
+		return x.StripeCustomerId
+	}
+	return ""
+}
+
+func (x *Customer) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Customer) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Customer) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreateCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCustomerRequest) Reset() {
+	*x = CreateCustomerRequest{}
+	mi := &file_payment_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCustomerRequest) ProtoMessage() {}
+
+func (x *CreateCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCustomerRequest.ProtoReflect.Descriptor instead.
+func (*CreateCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CreateCustomerRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type GetOrCreateCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`                 // Email для создания клиента, если его еще нет
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrCreateCustomerRequest) Reset() {
+	*x = GetOrCreateCustomerRequest{}
+	mi := &file_payment_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrCreateCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrCreateCustomerRequest) ProtoMessage() {}
+
+func (x *GetOrCreateCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrCreateCustomerRequest.ProtoReflect.Descriptor instead.
+func (*GetOrCreateCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetOrCreateCustomerRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetOrCreateCustomerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type CustomerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Customer      *Customer              `protobuf:"bytes,1,opt,name=customer,proto3" json:"customer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CustomerResponse) Reset() {
+	*x = CustomerResponse{}
+	mi := &file_payment_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CustomerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CustomerResponse) ProtoMessage() {}
+
+func (x *CustomerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomerResponse.ProtoReflect.Descriptor instead.
+func (*CustomerResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CustomerResponse) GetCustomer() *Customer {
+	if x != nil {
+		return x.Customer
+	}
+	return nil
+}
+
+type UpdateCustomerEmailRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`                 // Новый email
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCustomerEmailRequest) Reset() {
+	*x = UpdateCustomerEmailRequest{}
+	mi := &file_payment_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCustomerEmailRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCustomerEmailRequest) ProtoMessage() {}
+
+func (x *UpdateCustomerEmailRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCustomerEmailRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCustomerEmailRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *UpdateCustomerEmailRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateCustomerEmailRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type UpdateCustomerEmailResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCustomerEmailResponse) Reset() {
+	*x = UpdateCustomerEmailResponse{}
+	mi := &file_payment_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCustomerEmailResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCustomerEmailResponse) ProtoMessage() {}
+
+func (x *UpdateCustomerEmailResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCustomerEmailResponse.ProtoReflect.Descriptor instead.
+func (*UpdateCustomerEmailResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *UpdateCustomerEmailResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+// Представление плана (периодической цены Stripe)
+type Plan struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PlanId          string                 `protobuf:"bytes,1,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"` // ID цены Stripe, передается в CreateSubscriptionRequest.plan_id
+	ProductId       string                 `protobuf:"bytes,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Name            string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description     string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Nickname        string                 `protobuf:"bytes,5,opt,name=nickname,proto3" json:"nickname,omitempty"`
+	Amount          int64                  `protobuf:"varint,6,opt,name=amount,proto3" json:"amount,omitempty"` // В минимальных единицах валюты
+	Currency        string                 `protobuf:"bytes,7,opt,name=currency,proto3" json:"currency,omitempty"`
+	Interval        string                 `protobuf:"bytes,8,opt,name=interval,proto3" json:"interval,omitempty"` // day, week, month, year
+	IntervalCount   int64                  `protobuf:"varint,9,opt,name=interval_count,json=intervalCount,proto3" json:"interval_count,omitempty"`
+	TrialPeriodDays int64                  `protobuf:"varint,10,opt,name=trial_period_days,json=trialPeriodDays,proto3" json:"trial_period_days,omitempty"` // Пробный период по умолчанию для новых подписок (0 - нет)
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Plan) Reset() {
+	*x = Plan{}
+	mi := &file_payment_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plan) ProtoMessage() {}
+
+func (x *Plan) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plan.ProtoReflect.Descriptor instead.
+func (*Plan) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Plan) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *Plan) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Plan) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Plan) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Plan) GetNickname() string {
+	if x != nil {
+		return x.Nickname
+	}
+	return ""
+}
+
+func (x *Plan) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Plan) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Plan) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *Plan) GetIntervalCount() int64 {
+	if x != nil {
+		return x.IntervalCount
+	}
+	return 0
+}
+
+func (x *Plan) GetTrialPeriodDays() int64 {
+	if x != nil {
+		return x.TrialPeriodDays
+	}
+	return 0
+}
+
+type ListPlansRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPlansRequest) Reset() {
+	*x = ListPlansRequest{}
+	mi := &file_payment_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPlansRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPlansRequest) ProtoMessage() {}
+
+func (x *ListPlansRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPlansRequest.ProtoReflect.Descriptor instead.
+func (*ListPlansRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{29}
+}
+
+type ListPlansResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plans         []*Plan                `protobuf:"bytes,1,rep,name=plans,proto3" json:"plans,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPlansResponse) Reset() {
+	*x = ListPlansResponse{}
+	mi := &file_payment_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPlansResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPlansResponse) ProtoMessage() {}
+
+func (x *ListPlansResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPlansResponse.ProtoReflect.Descriptor instead.
+func (*ListPlansResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListPlansResponse) GetPlans() []*Plan {
+	if x != nil {
+		return x.Plans
+	}
+	return nil
+}
+
+// Счет Stripe
+type Invoice struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	InvoiceId        string                 `protobuf:"bytes,1,opt,name=invoice_id,json=invoiceId,proto3" json:"invoice_id,omitempty"`
+	SubscriptionId   string                 `protobuf:"bytes,2,opt,name=subscription_id,json=subscriptionId,proto3" json:"subscription_id,omitempty"` // Пусто, если счет не относится к известной подписке
+	Number           string                 `protobuf:"bytes,3,opt,name=number,proto3" json:"number,omitempty"`
+	Status           string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"` // draft, open, paid, void, uncollectible
+	BillingReason    string                 `protobuf:"bytes,5,opt,name=billing_reason,json=billingReason,proto3" json:"billing_reason,omitempty"`
+	Currency         string                 `protobuf:"bytes,6,opt,name=currency,proto3" json:"currency,omitempty"`
+	AmountDue        int64                  `protobuf:"varint,7,opt,name=amount_due,json=amountDue,proto3" json:"amount_due,omitempty"` // Суммы в минимальных единицах currency
+	AmountPaid       int64                  `protobuf:"varint,8,opt,name=amount_paid,json=amountPaid,proto3" json:"amount_paid,omitempty"`
+	AmountRemaining  int64                  `protobuf:"varint,9,opt,name=amount_remaining,json=amountRemaining,proto3" json:"amount_remaining,omitempty"`
+	Subtotal         int64                  `protobuf:"varint,10,opt,name=subtotal,proto3" json:"subtotal,omitempty"` // До скидок
+	Total            int64                  `protobuf:"varint,11,opt,name=total,proto3" json:"total,omitempty"`       // После скидок
+	PeriodStart      *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=period_start,json=periodStart,proto3" json:"period_start,omitempty"`
+	PeriodEnd        *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=period_end,json=periodEnd,proto3" json:"period_end,omitempty"`
+	HostedInvoiceUrl string                 `protobuf:"bytes,14,opt,name=hosted_invoice_url,json=hostedInvoiceUrl,proto3" json:"hosted_invoice_url,omitempty"` // Страница счета Stripe
+	InvoicePdf       string                 `protobuf:"bytes,15,opt,name=invoice_pdf,json=invoicePdf,proto3" json:"invoice_pdf,omitempty"`
+	PaidAt           *timestamppb.Timestamp `protobuf:"bytes,16,opt,name=paid_at,json=paidAt,proto3" json:"paid_at,omitempty"`
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Когда счет создан в Stripe
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Invoice) Reset() {
+	*x = Invoice{}
+	mi := &file_payment_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Invoice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Invoice) ProtoMessage() {}
+
+func (x *Invoice) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Invoice.ProtoReflect.Descriptor instead.
+func (*Invoice) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Invoice) GetInvoiceId() string {
+	if x != nil {
+		return x.InvoiceId
+	}
+	return ""
+}
+
+func (x *Invoice) GetSubscriptionId() string {
+	if x != nil {
+		return x.SubscriptionId
+	}
+	return ""
+}
+
+func (x *Invoice) GetNumber() string {
+	if x != nil {
+		return x.Number
+	}
+	return ""
+}
+
+func (x *Invoice) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Invoice) GetBillingReason() string {
+	if x != nil {
+		return x.BillingReason
+	}
+	return ""
+}
+
+func (x *Invoice) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Invoice) GetAmountDue() int64 {
+	if x != nil {
+		return x.AmountDue
+	}
+	return 0
+}
+
+func (x *Invoice) GetAmountPaid() int64 {
+	if x != nil {
+		return x.AmountPaid
+	}
+	return 0
+}
+
+func (x *Invoice) GetAmountRemaining() int64 {
+	if x != nil {
+		return x.AmountRemaining
+	}
+	return 0
+}
+
+func (x *Invoice) GetSubtotal() int64 {
+	if x != nil {
+		return x.Subtotal
+	}
+	return 0
+}
+
+func (x *Invoice) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *Invoice) GetPeriodStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PeriodStart
+	}
+	return nil
+}
+
+func (x *Invoice) GetPeriodEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PeriodEnd
+	}
+	return nil
+}
+
+func (x *Invoice) GetHostedInvoiceUrl() string {
+	if x != nil {
+		return x.HostedInvoiceUrl
+	}
+	return ""
+}
+
+func (x *Invoice) GetInvoicePdf() string {
+	if x != nil {
+		return x.InvoicePdf
+	}
+	return ""
+}
+
+func (x *Invoice) GetPaidAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PaidAt
+	}
+	return nil
+}
+
+func (x *Invoice) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type GetInvoiceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	InvoiceId     string                 `protobuf:"bytes,2,opt,name=invoice_id,json=invoiceId,proto3" json:"invoice_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInvoiceRequest) Reset() {
+	*x = GetInvoiceRequest{}
+	mi := &file_payment_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInvoiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInvoiceRequest) ProtoMessage() {}
+
+func (x *GetInvoiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInvoiceRequest.ProtoReflect.Descriptor instead.
+func (*GetInvoiceRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetInvoiceRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetInvoiceRequest) GetInvoiceId() string {
+	if x != nil {
+		return x.InvoiceId
+	}
+	return ""
+}
+
+type GetInvoiceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invoice       *Invoice               `protobuf:"bytes,1,opt,name=invoice,proto3" json:"invoice,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInvoiceResponse) Reset() {
+	*x = GetInvoiceResponse{}
+	mi := &file_payment_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInvoiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInvoiceResponse) ProtoMessage() {}
+
+func (x *GetInvoiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInvoiceResponse.ProtoReflect.Descriptor instead.
+func (*GetInvoiceResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *GetInvoiceResponse) GetInvoice() *Invoice {
+	if x != nil {
+		return x.Invoice
+	}
+	return nil
+}
+
+type ListUserInvoicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`          // ID пользователя (должен совпадать с пользователем из токена)
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // Размер страницы (по умолчанию 50, максимум 100)
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // Токен страницы из предыдущего ответа (пустой - первая страница)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserInvoicesRequest) Reset() {
+	*x = ListUserInvoicesRequest{}
+	mi := &file_payment_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserInvoicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserInvoicesRequest) ProtoMessage() {}
+
+func (x *ListUserInvoicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserInvoicesRequest.ProtoReflect.Descriptor instead.
+func (*ListUserInvoicesRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListUserInvoicesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListUserInvoicesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListUserInvoicesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListUserInvoicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invoices      []*Invoice             `protobuf:"bytes,1,rep,name=invoices,proto3" json:"invoices,omitempty"`                                  // Новые первыми
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Токен следующей страницы (пустой, если страниц больше нет)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserInvoicesResponse) Reset() {
+	*x = ListUserInvoicesResponse{}
+	mi := &file_payment_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserInvoicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserInvoicesResponse) ProtoMessage() {}
+
+func (x *ListUserInvoicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserInvoicesResponse.ProtoReflect.Descriptor instead.
+func (*ListUserInvoicesResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ListUserInvoicesResponse) GetInvoices() []*Invoice {
+	if x != nil {
+		return x.Invoices
+	}
+	return nil
+}
+
+func (x *ListUserInvoicesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// Разовый платеж
+type Payment struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PaymentId        string                 `protobuf:"bytes,1,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"` // ID PaymentIntent Stripe (pi_...)
+	Amount           int64                  `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`                       // В минимальных единицах currency
+	AmountReceived   int64                  `protobuf:"varint,3,opt,name=amount_received,json=amountReceived,proto3" json:"amount_received,omitempty"`
+	Currency         string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`
+	Status           string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"` // requires_payment_method, requires_action, processing, succeeded, canceled, ...
+	Description      string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	Metadata         map[string]string      `protobuf:"bytes,7,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	LastPaymentError string                 `protobuf:"bytes,8,opt,name=last_payment_error,json=lastPaymentError,proto3" json:"last_payment_error,omitempty"` // Сообщение последней неудачной попытки оплаты
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`                        // Когда PaymentIntent создан в Stripe
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Payment) Reset() {
+	*x = Payment{}
+	mi := &file_payment_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payment) ProtoMessage() {}
+
+func (x *Payment) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payment.ProtoReflect.Descriptor instead.
+func (*Payment) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *Payment) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+func (x *Payment) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Payment) GetAmountReceived() int64 {
+	if x != nil {
+		return x.AmountReceived
+	}
+	return 0
+}
+
+func (x *Payment) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Payment) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Payment) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Payment) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Payment) GetLastPaymentError() string {
+	if x != nil {
+		return x.LastPaymentError
+	}
+	return ""
+}
+
+func (x *Payment) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Payment) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CreatePaymentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`          // ID пользователя (должен совпадать с пользователем из токена)
+	UserEmail      string                 `protobuf:"bytes,2,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"` // Нужен для создания Stripe Customer
+	Amount         int64                  `protobuf:"varint,3,opt,name=amount,proto3" json:"amount,omitempty"`                       // В минимальных единицах currency
+	Currency       string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`                    // Код ISO 4217 (usd, eur, ...)
+	Description    string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Metadata       map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Ключ user_id зарезервирован
+	IdempotencyKey string                 `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreatePaymentRequest) Reset() {
+	*x = CreatePaymentRequest{}
+	mi := &file_payment_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePaymentRequest) ProtoMessage() {}
+
+func (x *CreatePaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePaymentRequest.ProtoReflect.Descriptor instead.
+func (*CreatePaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *CreatePaymentRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetUserEmail() string {
+	if x != nil {
+		return x.UserEmail
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetAmount() int64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *CreatePaymentRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreatePaymentRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *CreatePaymentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type CreatePaymentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payment       *Payment               `protobuf:"bytes,1,opt,name=payment,proto3" json:"payment,omitempty"`
+	ClientSecret  string                 `protobuf:"bytes,2,opt,name=client_secret,json=clientSecret,proto3" json:"client_secret,omitempty"` // Передается в stripe.confirmPayment на фронтенде
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePaymentResponse) Reset() {
+	*x = CreatePaymentResponse{}
+	mi := &file_payment_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePaymentResponse) ProtoMessage() {}
+
+func (x *CreatePaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePaymentResponse.ProtoReflect.Descriptor instead.
+func (*CreatePaymentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CreatePaymentResponse) GetPayment() *Payment {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+func (x *CreatePaymentResponse) GetClientSecret() string {
+	if x != nil {
+		return x.ClientSecret
+	}
+	return ""
+}
+
+type GetPaymentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	PaymentId     string                 `protobuf:"bytes,2,opt,name=payment_id,json=paymentId,proto3" json:"payment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPaymentRequest) Reset() {
+	*x = GetPaymentRequest{}
+	mi := &file_payment_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPaymentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaymentRequest) ProtoMessage() {}
+
+func (x *GetPaymentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaymentRequest.ProtoReflect.Descriptor instead.
+func (*GetPaymentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *GetPaymentRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetPaymentRequest) GetPaymentId() string {
+	if x != nil {
+		return x.PaymentId
+	}
+	return ""
+}
+
+type GetPaymentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payment       *Payment               `protobuf:"bytes,1,opt,name=payment,proto3" json:"payment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPaymentResponse) Reset() {
+	*x = GetPaymentResponse{}
+	mi := &file_payment_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPaymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPaymentResponse) ProtoMessage() {}
+
+func (x *GetPaymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPaymentResponse.ProtoReflect.Descriptor instead.
+func (*GetPaymentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *GetPaymentResponse) GetPayment() *Payment {
+	if x != nil {
+		return x.Payment
+	}
+	return nil
+}
+
+type ListUserPaymentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`          // ID пользователя (должен совпадать с пользователем из токена)
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`   // Размер страницы (по умолчанию 50, максимум 100)
+	PageToken     string                 `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"` // Токен страницы из предыдущего ответа (пустой - первая страница)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserPaymentsRequest) Reset() {
+	*x = ListUserPaymentsRequest{}
+	mi := &file_payment_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserPaymentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserPaymentsRequest) ProtoMessage() {}
+
+func (x *ListUserPaymentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserPaymentsRequest.ProtoReflect.Descriptor instead.
+func (*ListUserPaymentsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListUserPaymentsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListUserPaymentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListUserPaymentsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListUserPaymentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payments      []*Payment             `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`                                  // Новые первыми
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Токен следующей страницы (пустой, если страниц больше нет)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserPaymentsResponse) Reset() {
+	*x = ListUserPaymentsResponse{}
+	mi := &file_payment_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserPaymentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserPaymentsResponse) ProtoMessage() {}
+
+func (x *ListUserPaymentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserPaymentsResponse.ProtoReflect.Descriptor instead.
+func (*ListUserPaymentsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ListUserPaymentsResponse) GetPayments() []*Payment {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+func (x *ListUserPaymentsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// Сохраненная карта клиента
+type PaymentMethod struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	PaymentMethodId string                 `protobuf:"bytes,1,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"`
+	Brand           string                 `protobuf:"bytes,2,opt,name=brand,proto3" json:"brand,omitempty"` // visa, mastercard, ...
+	Last4           string                 `protobuf:"bytes,3,opt,name=last4,proto3" json:"last4,omitempty"`
+	ExpMonth        int64                  `protobuf:"varint,4,opt,name=exp_month,json=expMonth,proto3" json:"exp_month,omitempty"`
+	ExpYear         int64                  `protobuf:"varint,5,opt,name=exp_year,json=expYear,proto3" json:"exp_year,omitempty"`
+	IsDefault       bool                   `protobuf:"varint,6,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"` // Карта по умолчанию для следующих списаний
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PaymentMethod) Reset() {
+	*x = PaymentMethod{}
+	mi := &file_payment_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PaymentMethod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PaymentMethod) ProtoMessage() {}
+
+func (x *PaymentMethod) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PaymentMethod.ProtoReflect.Descriptor instead.
+func (*PaymentMethod) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *PaymentMethod) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+func (x *PaymentMethod) GetBrand() string {
+	if x != nil {
+		return x.Brand
+	}
+	return ""
+}
+
+func (x *PaymentMethod) GetLast4() string {
+	if x != nil {
+		return x.Last4
+	}
+	return ""
+}
+
+func (x *PaymentMethod) GetExpMonth() int64 {
+	if x != nil {
+		return x.ExpMonth
+	}
+	return 0
+}
+
+func (x *PaymentMethod) GetExpYear() int64 {
+	if x != nil {
+		return x.ExpYear
+	}
+	return 0
+}
+
+func (x *PaymentMethod) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
+	}
+	return false
+}
+
+func (x *PaymentMethod) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type CreateSetupIntentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	IdempotencyKey string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateSetupIntentRequest) Reset() {
+	*x = CreateSetupIntentRequest{}
+	mi := &file_payment_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSetupIntentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSetupIntentRequest) ProtoMessage() {}
+
+func (x *CreateSetupIntentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSetupIntentRequest.ProtoReflect.Descriptor instead.
+func (*CreateSetupIntentRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *CreateSetupIntentRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateSetupIntentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type CreateSetupIntentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SetupIntentId string                 `protobuf:"bytes,1,opt,name=setup_intent_id,json=setupIntentId,proto3" json:"setup_intent_id,omitempty"`
+	ClientSecret  string                 `protobuf:"bytes,2,opt,name=client_secret,json=clientSecret,proto3" json:"client_secret,omitempty"` // Передается в stripe.confirmCardSetup на фронтенде
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSetupIntentResponse) Reset() {
+	*x = CreateSetupIntentResponse{}
+	mi := &file_payment_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSetupIntentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSetupIntentResponse) ProtoMessage() {}
+
+func (x *CreateSetupIntentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSetupIntentResponse.ProtoReflect.Descriptor instead.
+func (*CreateSetupIntentResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *CreateSetupIntentResponse) GetSetupIntentId() string {
+	if x != nil {
+		return x.SetupIntentId
+	}
+	return ""
+}
+
+func (x *CreateSetupIntentResponse) GetClientSecret() string {
+	if x != nil {
+		return x.ClientSecret
+	}
+	return ""
+}
+
+func (x *CreateSetupIntentResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ListPaymentMethodsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentMethodsRequest) Reset() {
+	*x = ListPaymentMethodsRequest{}
+	mi := &file_payment_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentMethodsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentMethodsRequest) ProtoMessage() {}
+
+func (x *ListPaymentMethodsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentMethodsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentMethodsRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ListPaymentMethodsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ListPaymentMethodsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PaymentMethods []*PaymentMethod       `protobuf:"bytes,1,rep,name=payment_methods,json=paymentMethods,proto3" json:"payment_methods,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListPaymentMethodsResponse) Reset() {
+	*x = ListPaymentMethodsResponse{}
+	mi := &file_payment_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentMethodsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentMethodsResponse) ProtoMessage() {}
+
+func (x *ListPaymentMethodsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentMethodsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentMethodsResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ListPaymentMethodsResponse) GetPaymentMethods() []*PaymentMethod {
+	if x != nil {
+		return x.PaymentMethods
+	}
+	return nil
+}
+
+type AttachPaymentMethodRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                              // ID пользователя (должен совпадать с пользователем из токена)
+	PaymentMethodId string                 `protobuf:"bytes,2,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"` // Карта, созданная через Stripe.js (pm_...)
+	SetDefault      bool                   `protobuf:"varint,3,opt,name=set_default,json=setDefault,proto3" json:"set_default,omitempty"`                 // Сделать картой по умолчанию
+	IdempotencyKey  string                 `protobuf:"bytes,4,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *AttachPaymentMethodRequest) Reset() {
+	*x = AttachPaymentMethodRequest{}
+	mi := &file_payment_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachPaymentMethodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachPaymentMethodRequest) ProtoMessage() {}
+
+func (x *AttachPaymentMethodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachPaymentMethodRequest.ProtoReflect.Descriptor instead.
+func (*AttachPaymentMethodRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *AttachPaymentMethodRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *AttachPaymentMethodRequest) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+func (x *AttachPaymentMethodRequest) GetSetDefault() bool {
+	if x != nil {
+		return x.SetDefault
+	}
+	return false
+}
+
+func (x *AttachPaymentMethodRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type AttachPaymentMethodResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentMethod *PaymentMethod         `protobuf:"bytes,1,opt,name=payment_method,json=paymentMethod,proto3" json:"payment_method,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachPaymentMethodResponse) Reset() {
+	*x = AttachPaymentMethodResponse{}
+	mi := &file_payment_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachPaymentMethodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachPaymentMethodResponse) ProtoMessage() {}
+
+func (x *AttachPaymentMethodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachPaymentMethodResponse.ProtoReflect.Descriptor instead.
+func (*AttachPaymentMethodResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *AttachPaymentMethodResponse) GetPaymentMethod() *PaymentMethod {
+	if x != nil {
+		return x.PaymentMethod
+	}
+	return nil
+}
+
+type DetachPaymentMethodRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	PaymentMethodId string                 `protobuf:"bytes,2,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DetachPaymentMethodRequest) Reset() {
+	*x = DetachPaymentMethodRequest{}
+	mi := &file_payment_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetachPaymentMethodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetachPaymentMethodRequest) ProtoMessage() {}
+
+func (x *DetachPaymentMethodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetachPaymentMethodRequest.ProtoReflect.Descriptor instead.
+func (*DetachPaymentMethodRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *DetachPaymentMethodRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DetachPaymentMethodRequest) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+type DetachPaymentMethodResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DetachPaymentMethodResponse) Reset() {
+	*x = DetachPaymentMethodResponse{}
+	mi := &file_payment_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DetachPaymentMethodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DetachPaymentMethodResponse) ProtoMessage() {}
+
+func (x *DetachPaymentMethodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DetachPaymentMethodResponse.ProtoReflect.Descriptor instead.
+func (*DetachPaymentMethodResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{51}
+}
+
+type SetDefaultPaymentMethodRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	UserId          string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	PaymentMethodId string                 `protobuf:"bytes,2,opt,name=payment_method_id,json=paymentMethodId,proto3" json:"payment_method_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SetDefaultPaymentMethodRequest) Reset() {
+	*x = SetDefaultPaymentMethodRequest{}
+	mi := &file_payment_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDefaultPaymentMethodRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDefaultPaymentMethodRequest) ProtoMessage() {}
+
+func (x *SetDefaultPaymentMethodRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDefaultPaymentMethodRequest.ProtoReflect.Descriptor instead.
+func (*SetDefaultPaymentMethodRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *SetDefaultPaymentMethodRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SetDefaultPaymentMethodRequest) GetPaymentMethodId() string {
+	if x != nil {
+		return x.PaymentMethodId
+	}
+	return ""
+}
+
+type SetDefaultPaymentMethodResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDefaultPaymentMethodResponse) Reset() {
+	*x = SetDefaultPaymentMethodResponse{}
+	mi := &file_payment_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDefaultPaymentMethodResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDefaultPaymentMethodResponse) ProtoMessage() {}
+
+func (x *SetDefaultPaymentMethodResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDefaultPaymentMethodResponse.ProtoReflect.Descriptor instead.
+func (*SetDefaultPaymentMethodResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{53}
+}
+
+type CreateCheckoutSessionRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	UserId              string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // ID пользователя (должен совпадать с пользователем из токена)
+	PlanId              string                 `protobuf:"bytes,2,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	UserEmail           string                 `protobuf:"bytes,3,opt,name=user_email,json=userEmail,proto3" json:"user_email,omitempty"`
+	SuccessUrl          string                 `protobuf:"bytes,4,opt,name=success_url,json=successUrl,proto3" json:"success_url,omitempty"`                               // Пусто - из конфигурации сервиса
+	CancelUrl           string                 `protobuf:"bytes,5,opt,name=cancel_url,json=cancelUrl,proto3" json:"cancel_url,omitempty"`                                  // Пусто - из конфигурации сервиса
+	AllowPromotionCodes bool                   `protobuf:"varint,6,opt,name=allow_promotion_codes,json=allowPromotionCodes,proto3" json:"allow_promotion_codes,omitempty"` // Поле для промокода на странице оплаты
+	IdempotencyKey      string                 `protobuf:"bytes,7,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *CreateCheckoutSessionRequest) Reset() {
+	*x = CreateCheckoutSessionRequest{}
+	mi := &file_payment_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCheckoutSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCheckoutSessionRequest) ProtoMessage() {}
+
+func (x *CreateCheckoutSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCheckoutSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateCheckoutSessionRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *CreateCheckoutSessionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateCheckoutSessionRequest) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *CreateCheckoutSessionRequest) GetUserEmail() string {
+	if x != nil {
+		return x.UserEmail
+	}
+	return ""
+}
+
+func (x *CreateCheckoutSessionRequest) GetSuccessUrl() string {
+	if x != nil {
+		return x.SuccessUrl
+	}
+	return ""
+}
+
+func (x *CreateCheckoutSessionRequest) GetCancelUrl() string {
+	if x != nil {
+		return x.CancelUrl
+	}
+	return ""
+}
+
+func (x *CreateCheckoutSessionRequest) GetAllowPromotionCodes() bool {
+	if x != nil {
+		return x.AllowPromotionCodes
+	}
+	return false
+}
+
+func (x *CreateCheckoutSessionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type CreateCheckoutSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"` // Страница оплаты Stripe; подписка появится после оплаты
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCheckoutSessionResponse) Reset() {
+	*x = CreateCheckoutSessionResponse{}
+	mi := &file_payment_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCheckoutSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCheckoutSessionResponse) ProtoMessage() {}
+
+func (x *CreateCheckoutSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCheckoutSessionResponse.ProtoReflect.Descriptor instead.
+func (*CreateCheckoutSessionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *CreateCheckoutSessionResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *CreateCheckoutSessionResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *CreateCheckoutSessionResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type CreateBillingPortalSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`          // ID пользователя (должен совпадать с пользователем из токена)
+	ReturnUrl     string                 `protobuf:"bytes,2,opt,name=return_url,json=returnUrl,proto3" json:"return_url,omitempty"` // Пусто - из конфигурации сервиса
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBillingPortalSessionRequest) Reset() {
+	*x = CreateBillingPortalSessionRequest{}
+	mi := &file_payment_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBillingPortalSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBillingPortalSessionRequest) ProtoMessage() {}
+
+func (x *CreateBillingPortalSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBillingPortalSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateBillingPortalSessionRequest) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *CreateBillingPortalSessionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateBillingPortalSessionRequest) GetReturnUrl() string {
+	if x != nil {
+		return x.ReturnUrl
+	}
+	return ""
+}
+
+type CreateBillingPortalSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBillingPortalSessionResponse) Reset() {
+	*x = CreateBillingPortalSessionResponse{}
+	mi := &file_payment_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBillingPortalSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBillingPortalSessionResponse) ProtoMessage() {}
+
+func (x *CreateBillingPortalSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_payment_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBillingPortalSessionResponse.ProtoReflect.Descriptor instead.
+func (*CreateBillingPortalSessionResponse) Descriptor() ([]byte, []int) {
+	return file_payment_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *CreateBillingPortalSessionResponse) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+var File_payment_proto protoreflect.FileDescriptor
+
+const file_payment_proto_rawDesc = "" +
+	"\n" +
+	"\rpayment.proto\x12\apayment\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc0\x02\n" +
 	"\x19CreateSubscriptionRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x17\n" +
 	"\aplan_id\x18\x02 \x01(\tR\x06planId\x12'\n" +
 	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\x12\x1d\n" +
 	"\n" +
-	"user_email\x18\x04 \x01(\tR\tuserEmail\"\xbd\x01\n" +
+	"user_email\x18\x04 \x01(\tR\tuserEmail\x12\"\n" +
+	"\n" +
+	"trial_days\x18\x05 \x01(\x05H\x00R\ttrialDays\x88\x01\x01\x127\n" +
+	"\ttrial_end\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\btrialEnd\x12\x16\n" +
+	"\x06coupon\x18\a \x01(\tR\x06coupon\x12%\n" +
+	"\x0epromotion_code\x18\b \x01(\tR\rpromotionCodeB\r\n" +
+	"\v_trial_days\"\xa5\x02\n" +
 	"\x1aCreateSubscriptionResponse\x12'\n" +
 	"\x0fsubscription_id\x18\x01 \x01(\tR\x0esubscriptionId\x12#\n" +
 	"\rclient_secret\x18\x02 \x01(\tR\fclientSecret\x129\n" +
 	"\n" +
 	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x16\n" +
-	"\x06status\x18\x04 \x01(\tR\x06status\"\x86\x01\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x127\n" +
+	"\ttrial_end\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\btrialEnd\x12-\n" +
+	"\bdiscount\x18\x06 \x01(\v2\x11.payment.DiscountR\bdiscount\"\xaf\x01\n" +
 	"\x19CancelSubscriptionRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
 	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x12'\n" +
-	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\"s\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\x12'\n" +
+	"\x04mode\x18\x04 \x01(\x0e2\x13.payment.CancelModeR\x04mode\"\xdf\x01\n" +
 	"\x1aCancelSubscriptionResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12;\n" +
 	"\vcanceled_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
-	"canceledAt\"Z\n" +
+	"canceledAt\x12/\n" +
+	"\x14cancel_at_period_end\x18\x03 \x01(\bR\x11cancelAtPeriodEnd\x129\n" +
+	"\fsubscription\x18\x04 \x01(\v2\x15.payment.SubscriptionR\fsubscription\"\x86\x01\n" +
+	"\x19ResumeSubscriptionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\"W\n" +
+	"\x1aResumeSubscriptionResponse\x129\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x15.payment.SubscriptionR\fsubscription\"\xf4\x01\n" +
+	"\x18PauseSubscriptionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x122\n" +
+	"\bbehavior\x18\x03 \x01(\x0e2\x16.payment.PauseBehaviorR\bbehavior\x129\n" +
+	"\n" +
+	"resumes_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tresumesAt\x12'\n" +
+	"\x0fidempotency_key\x18\x05 \x01(\tR\x0eidempotencyKey\"V\n" +
+	"\x19PauseSubscriptionResponse\x129\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x15.payment.SubscriptionR\fsubscription\"\x87\x01\n" +
+	"\x1aUnpauseSubscriptionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\"X\n" +
+	"\x1bUnpauseSubscriptionResponse\x129\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x15.payment.SubscriptionR\fsubscription\"Z\n" +
 	"\x16GetSubscriptionRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
-	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\"\x9d\x03\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\"\xe7\x05\n" +
 	"\fSubscription\x12'\n" +
 	"\x0fsubscription_id\x18\x01 \x01(\tR\x0esubscriptionId\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x17\n" +
@@ -516,13 +3966,300 @@ const file_payment_proto_rawDesc = "" +
 	"\n" +
 	"expires_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12;\n" +
 	"\vcanceled_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\n" +
-	"canceledAt\"T\n" +
+	"canceledAt\x12/\n" +
+	"\x14cancel_at_period_end\x18\n" +
+	" \x01(\bR\x11cancelAtPeriodEnd\x127\n" +
+	"\tcancel_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\bcancelAt\x129\n" +
+	"\n" +
+	"resumes_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tresumesAt\x12;\n" +
+	"\vtrial_start\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"trialStart\x127\n" +
+	"\ttrial_end\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\btrialEnd\x12-\n" +
+	"\bdiscount\x18\x0f \x01(\v2\x11.payment.DiscountR\bdiscount\"\xfb\x01\n" +
+	"\bDiscount\x12\x1b\n" +
+	"\tcoupon_id\x18\x01 \x01(\tR\bcouponId\x12%\n" +
+	"\x0epromotion_code\x18\x02 \x01(\tR\rpromotionCode\x12\x1f\n" +
+	"\vpercent_off\x18\x03 \x01(\x01R\n" +
+	"percentOff\x12\x1d\n" +
+	"\n" +
+	"amount_off\x18\x04 \x01(\x03R\tamountOff\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\x12\x1a\n" +
+	"\bduration\x18\x06 \x01(\tR\bduration\x123\n" +
+	"\aends_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x06endsAt\"T\n" +
 	"\x17GetSubscriptionResponse\x129\n" +
-	"\fsubscription\x18\x01 \x01(\v2\x15.payment.SubscriptionR\fsubscription2\xaa\x02\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x15.payment.SubscriptionR\fsubscription\"s\n" +
+	"\x1cListUserSubscriptionsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"\x84\x01\n" +
+	"\x1dListUserSubscriptionsResponse\x12;\n" +
+	"\rsubscriptions\x18\x01 \x03(\v2\x15.payment.SubscriptionR\rsubscriptions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xe2\x01\n" +
+	"\x11ChangePlanRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x12\x17\n" +
+	"\aplan_id\x18\x03 \x01(\tR\x06planId\x12\"\n" +
+	"\rat_period_end\x18\x04 \x01(\bR\vatPeriodEnd\x12%\n" +
+	"\x0eproration_date\x18\x05 \x01(\x03R\rprorationDate\x12'\n" +
+	"\x0fidempotency_key\x18\x06 \x01(\tR\x0eidempotencyKey\"\xd2\x01\n" +
+	"\x12ChangePlanResponse\x129\n" +
+	"\fsubscription\x18\x01 \x01(\v2\x15.payment.SubscriptionR\fsubscription\x12\x1e\n" +
+	"\vnew_plan_id\x18\x02 \x01(\tR\tnewPlanId\x12\"\n" +
+	"\rat_period_end\x18\x03 \x01(\bR\vatPeriodEnd\x12=\n" +
+	"\feffective_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\veffectiveAt\"\x99\x01\n" +
+	"\x18PreviewPlanChangeRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x12\x17\n" +
+	"\aplan_id\x18\x03 \x01(\tR\x06planId\x12\"\n" +
+	"\rat_period_end\x18\x04 \x01(\bR\vatPeriodEnd\"\xe8\x02\n" +
+	"\x19PreviewPlanChangeResponse\x12'\n" +
+	"\x0fsubscription_id\x18\x01 \x01(\tR\x0esubscriptionId\x12\x17\n" +
+	"\aplan_id\x18\x02 \x01(\tR\x06planId\x12\"\n" +
+	"\rat_period_end\x18\x03 \x01(\bR\vatPeriodEnd\x12\x1d\n" +
+	"\n" +
+	"amount_due\x18\x04 \x01(\x03R\tamountDue\x12\x14\n" +
+	"\x05total\x18\x05 \x01(\x03R\x05total\x12)\n" +
+	"\x10proration_amount\x18\x06 \x01(\x03R\x0fprorationAmount\x12\x1a\n" +
+	"\bcurrency\x18\a \x01(\tR\bcurrency\x12B\n" +
+	"\x0fnext_payment_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\rnextPaymentAt\x12%\n" +
+	"\x0eproration_date\x18\t \x01(\x03R\rprorationDate\"]\n" +
+	"\x19WatchSubscriptionsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\"\x94\x02\n" +
+	"\x18SubscriptionStatusChange\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x02 \x01(\tR\teventType\x12'\n" +
+	"\x0fprevious_status\x18\x03 \x01(\tR\x0epreviousStatus\x12\x1d\n" +
+	"\n" +
+	"new_status\x18\x04 \x01(\tR\tnewStatus\x129\n" +
+	"\fsubscription\x18\x05 \x01(\v2\x15.payment.SubscriptionR\fsubscription\x12;\n" +
+	"\voccurred_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"occurredAt\"\xdd\x01\n" +
+	"\bCustomer\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12,\n" +
+	"\x12stripe_customer_id\x18\x02 \x01(\tR\x10stripeCustomerId\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"F\n" +
+	"\x15CreateCustomerRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\"K\n" +
+	"\x1aGetOrCreateCustomerRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\"A\n" +
+	"\x10CustomerResponse\x12-\n" +
+	"\bcustomer\x18\x01 \x01(\v2\x11.payment.CustomerR\bcustomer\"K\n" +
+	"\x1aUpdateCustomerEmailRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\"7\n" +
+	"\x1bUpdateCustomerEmailResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\xb3\x02\n" +
+	"\x04Plan\x12\x17\n" +
+	"\aplan_id\x18\x01 \x01(\tR\x06planId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\tR\tproductId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x1a\n" +
+	"\bnickname\x18\x05 \x01(\tR\bnickname\x12\x16\n" +
+	"\x06amount\x18\x06 \x01(\x03R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\a \x01(\tR\bcurrency\x12\x1a\n" +
+	"\binterval\x18\b \x01(\tR\binterval\x12%\n" +
+	"\x0einterval_count\x18\t \x01(\x03R\rintervalCount\x12*\n" +
+	"\x11trial_period_days\x18\n" +
+	" \x01(\x03R\x0ftrialPeriodDays\"\x12\n" +
+	"\x10ListPlansRequest\"8\n" +
+	"\x11ListPlansResponse\x12#\n" +
+	"\x05plans\x18\x01 \x03(\v2\r.payment.PlanR\x05plans\"\x9a\x05\n" +
+	"\aInvoice\x12\x1d\n" +
+	"\n" +
+	"invoice_id\x18\x01 \x01(\tR\tinvoiceId\x12'\n" +
+	"\x0fsubscription_id\x18\x02 \x01(\tR\x0esubscriptionId\x12\x16\n" +
+	"\x06number\x18\x03 \x01(\tR\x06number\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12%\n" +
+	"\x0ebilling_reason\x18\x05 \x01(\tR\rbillingReason\x12\x1a\n" +
+	"\bcurrency\x18\x06 \x01(\tR\bcurrency\x12\x1d\n" +
+	"\n" +
+	"amount_due\x18\a \x01(\x03R\tamountDue\x12\x1f\n" +
+	"\vamount_paid\x18\b \x01(\x03R\n" +
+	"amountPaid\x12)\n" +
+	"\x10amount_remaining\x18\t \x01(\x03R\x0famountRemaining\x12\x1a\n" +
+	"\bsubtotal\x18\n" +
+	" \x01(\x03R\bsubtotal\x12\x14\n" +
+	"\x05total\x18\v \x01(\x03R\x05total\x12=\n" +
+	"\fperiod_start\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\vperiodStart\x129\n" +
+	"\n" +
+	"period_end\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tperiodEnd\x12,\n" +
+	"\x12hosted_invoice_url\x18\x0e \x01(\tR\x10hostedInvoiceUrl\x12\x1f\n" +
+	"\vinvoice_pdf\x18\x0f \x01(\tR\n" +
+	"invoicePdf\x123\n" +
+	"\apaid_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\x06paidAt\x129\n" +
+	"\n" +
+	"created_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"K\n" +
+	"\x11GetInvoiceRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"invoice_id\x18\x02 \x01(\tR\tinvoiceId\"@\n" +
+	"\x12GetInvoiceResponse\x12*\n" +
+	"\ainvoice\x18\x01 \x01(\v2\x10.payment.InvoiceR\ainvoice\"n\n" +
+	"\x17ListUserInvoicesRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"p\n" +
+	"\x18ListUserInvoicesResponse\x12,\n" +
+	"\binvoices\x18\x01 \x03(\v2\x10.payment.InvoiceR\binvoices\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xdc\x03\n" +
+	"\aPayment\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x01 \x01(\tR\tpaymentId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x03R\x06amount\x12'\n" +
+	"\x0famount_received\x18\x03 \x01(\x03R\x0eamountReceived\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\x12:\n" +
+	"\bmetadata\x18\a \x03(\v2\x1e.payment.Payment.MetadataEntryR\bmetadata\x12,\n" +
+	"\x12last_payment_error\x18\b \x01(\tR\x10lastPaymentError\x129\n" +
+	"\n" +
+	"created_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd3\x02\n" +
+	"\x14CreatePaymentRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"user_email\x18\x02 \x01(\tR\tuserEmail\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x03R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12G\n" +
+	"\bmetadata\x18\x06 \x03(\v2+.payment.CreatePaymentRequest.MetadataEntryR\bmetadata\x12'\n" +
+	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"h\n" +
+	"\x15CreatePaymentResponse\x12*\n" +
+	"\apayment\x18\x01 \x01(\v2\x10.payment.PaymentR\apayment\x12#\n" +
+	"\rclient_secret\x18\x02 \x01(\tR\fclientSecret\"K\n" +
+	"\x11GetPaymentRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"payment_id\x18\x02 \x01(\tR\tpaymentId\"@\n" +
+	"\x12GetPaymentResponse\x12*\n" +
+	"\apayment\x18\x01 \x01(\v2\x10.payment.PaymentR\apayment\"n\n" +
+	"\x17ListUserPaymentsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"p\n" +
+	"\x18ListUserPaymentsResponse\x12,\n" +
+	"\bpayments\x18\x01 \x03(\v2\x10.payment.PaymentR\bpayments\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xf9\x01\n" +
+	"\rPaymentMethod\x12*\n" +
+	"\x11payment_method_id\x18\x01 \x01(\tR\x0fpaymentMethodId\x12\x14\n" +
+	"\x05brand\x18\x02 \x01(\tR\x05brand\x12\x14\n" +
+	"\x05last4\x18\x03 \x01(\tR\x05last4\x12\x1b\n" +
+	"\texp_month\x18\x04 \x01(\x03R\bexpMonth\x12\x19\n" +
+	"\bexp_year\x18\x05 \x01(\x03R\aexpYear\x12\x1d\n" +
+	"\n" +
+	"is_default\x18\x06 \x01(\bR\tisDefault\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\\\n" +
+	"\x18CreateSetupIntentRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\"\x80\x01\n" +
+	"\x19CreateSetupIntentResponse\x12&\n" +
+	"\x0fsetup_intent_id\x18\x01 \x01(\tR\rsetupIntentId\x12#\n" +
+	"\rclient_secret\x18\x02 \x01(\tR\fclientSecret\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\"4\n" +
+	"\x19ListPaymentMethodsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"]\n" +
+	"\x1aListPaymentMethodsResponse\x12?\n" +
+	"\x0fpayment_methods\x18\x01 \x03(\v2\x16.payment.PaymentMethodR\x0epaymentMethods\"\xab\x01\n" +
+	"\x1aAttachPaymentMethodRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12*\n" +
+	"\x11payment_method_id\x18\x02 \x01(\tR\x0fpaymentMethodId\x12\x1f\n" +
+	"\vset_default\x18\x03 \x01(\bR\n" +
+	"setDefault\x12'\n" +
+	"\x0fidempotency_key\x18\x04 \x01(\tR\x0eidempotencyKey\"\\\n" +
+	"\x1bAttachPaymentMethodResponse\x12=\n" +
+	"\x0epayment_method\x18\x01 \x01(\v2\x16.payment.PaymentMethodR\rpaymentMethod\"a\n" +
+	"\x1aDetachPaymentMethodRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12*\n" +
+	"\x11payment_method_id\x18\x02 \x01(\tR\x0fpaymentMethodId\"\x1d\n" +
+	"\x1bDetachPaymentMethodResponse\"e\n" +
+	"\x1eSetDefaultPaymentMethodRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12*\n" +
+	"\x11payment_method_id\x18\x02 \x01(\tR\x0fpaymentMethodId\"!\n" +
+	"\x1fSetDefaultPaymentMethodResponse\"\x8c\x02\n" +
+	"\x1cCreateCheckoutSessionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x17\n" +
+	"\aplan_id\x18\x02 \x01(\tR\x06planId\x12\x1d\n" +
+	"\n" +
+	"user_email\x18\x03 \x01(\tR\tuserEmail\x12\x1f\n" +
+	"\vsuccess_url\x18\x04 \x01(\tR\n" +
+	"successUrl\x12\x1d\n" +
+	"\n" +
+	"cancel_url\x18\x05 \x01(\tR\tcancelUrl\x122\n" +
+	"\x15allow_promotion_codes\x18\x06 \x01(\bR\x13allowPromotionCodes\x12'\n" +
+	"\x0fidempotency_key\x18\a \x01(\tR\x0eidempotencyKey\"\x8b\x01\n" +
+	"\x1dCreateCheckoutSessionResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"[\n" +
+	"!CreateBillingPortalSessionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"return_url\x18\x02 \x01(\tR\treturnUrl\"6\n" +
+	"\"CreateBillingPortalSessionResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url*e\n" +
+	"\n" +
+	"CancelMode\x12\x1b\n" +
+	"\x17CANCEL_MODE_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17CANCEL_MODE_IMMEDIATELY\x10\x01\x12\x1d\n" +
+	"\x19CANCEL_MODE_AT_PERIOD_END\x10\x02*\x91\x01\n" +
+	"\rPauseBehavior\x12\x1e\n" +
+	"\x1aPAUSE_BEHAVIOR_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13PAUSE_BEHAVIOR_VOID\x10\x01\x12 \n" +
+	"\x1cPAUSE_BEHAVIOR_KEEP_AS_DRAFT\x10\x02\x12%\n" +
+	"!PAUSE_BEHAVIOR_MARK_UNCOLLECTIBLE\x10\x032\x85\x13\n" +
 	"\x0ePaymentService\x12_\n" +
 	"\x12CreateSubscription\x12\".payment.CreateSubscriptionRequest\x1a#.payment.CreateSubscriptionResponse\"\x00\x12_\n" +
-	"\x12CancelSubscription\x12\".payment.CancelSubscriptionRequest\x1a#.payment.CancelSubscriptionResponse\"\x00\x12V\n" +
-	"\x0fGetSubscription\x12\x1f.payment.GetSubscriptionRequest\x1a .payment.GetSubscriptionResponse\"\x00B\fZ\n" +
+	"\x12CancelSubscription\x12\".payment.CancelSubscriptionRequest\x1a#.payment.CancelSubscriptionResponse\"\x00\x12_\n" +
+	"\x12ResumeSubscription\x12\".payment.ResumeSubscriptionRequest\x1a#.payment.ResumeSubscriptionResponse\"\x00\x12\\\n" +
+	"\x11PauseSubscription\x12!.payment.PauseSubscriptionRequest\x1a\".payment.PauseSubscriptionResponse\"\x00\x12b\n" +
+	"\x13UnpauseSubscription\x12#.payment.UnpauseSubscriptionRequest\x1a$.payment.UnpauseSubscriptionResponse\"\x00\x12V\n" +
+	"\x0fGetSubscription\x12\x1f.payment.GetSubscriptionRequest\x1a .payment.GetSubscriptionResponse\"\x00\x12h\n" +
+	"\x15ListUserSubscriptions\x12%.payment.ListUserSubscriptionsRequest\x1a&.payment.ListUserSubscriptionsResponse\"\x00\x12G\n" +
+	"\n" +
+	"ChangePlan\x12\x1a.payment.ChangePlanRequest\x1a\x1b.payment.ChangePlanResponse\"\x00\x12\\\n" +
+	"\x11PreviewPlanChange\x12!.payment.PreviewPlanChangeRequest\x1a\".payment.PreviewPlanChangeResponse\"\x00\x12_\n" +
+	"\x12WatchSubscriptions\x12\".payment.WatchSubscriptionsRequest\x1a!.payment.SubscriptionStatusChange\"\x000\x01\x12M\n" +
+	"\x0eCreateCustomer\x12\x1e.payment.CreateCustomerRequest\x1a\x19.payment.CustomerResponse\"\x00\x12W\n" +
+	"\x13GetOrCreateCustomer\x12#.payment.GetOrCreateCustomerRequest\x1a\x19.payment.CustomerResponse\"\x00\x12b\n" +
+	"\x13UpdateCustomerEmail\x12#.payment.UpdateCustomerEmailRequest\x1a$.payment.UpdateCustomerEmailResponse\"\x00\x12D\n" +
+	"\tListPlans\x12\x19.payment.ListPlansRequest\x1a\x1a.payment.ListPlansResponse\"\x00\x12G\n" +
+	"\n" +
+	"GetInvoice\x12\x1a.payment.GetInvoiceRequest\x1a\x1b.payment.GetInvoiceResponse\"\x00\x12Y\n" +
+	"\x10ListUserInvoices\x12 .payment.ListUserInvoicesRequest\x1a!.payment.ListUserInvoicesResponse\"\x00\x12P\n" +
+	"\rCreatePayment\x12\x1d.payment.CreatePaymentRequest\x1a\x1e.payment.CreatePaymentResponse\"\x00\x12G\n" +
+	"\n" +
+	"GetPayment\x12\x1a.payment.GetPaymentRequest\x1a\x1b.payment.GetPaymentResponse\"\x00\x12Y\n" +
+	"\x10ListUserPayments\x12 .payment.ListUserPaymentsRequest\x1a!.payment.ListUserPaymentsResponse\"\x00\x12\\\n" +
+	"\x11CreateSetupIntent\x12!.payment.CreateSetupIntentRequest\x1a\".payment.CreateSetupIntentResponse\"\x00\x12_\n" +
+	"\x12ListPaymentMethods\x12\".payment.ListPaymentMethodsRequest\x1a#.payment.ListPaymentMethodsResponse\"\x00\x12b\n" +
+	"\x13AttachPaymentMethod\x12#.payment.AttachPaymentMethodRequest\x1a$.payment.AttachPaymentMethodResponse\"\x00\x12b\n" +
+	"\x13DetachPaymentMethod\x12#.payment.DetachPaymentMethodRequest\x1a$.payment.DetachPaymentMethodResponse\"\x00\x12n\n" +
+	"\x17SetDefaultPaymentMethod\x12'.payment.SetDefaultPaymentMethodRequest\x1a(.payment.SetDefaultPaymentMethodResponse\"\x00\x12h\n" +
+	"\x15CreateCheckoutSession\x12%.payment.CreateCheckoutSessionRequest\x1a&.payment.CreateCheckoutSessionResponse\"\x00\x12w\n" +
+	"\x1aCreateBillingPortalSession\x12*.payment.CreateBillingPortalSessionRequest\x1a+.payment.CreateBillingPortalSessionResponse\"\x00B\fZ\n" +
 	"./;paymentb\x06proto3"
 
 var (
@@ -537,36 +4274,181 @@ func file_payment_proto_rawDescGZIP() []byte {
 	return file_payment_proto_rawDescData
 }
 
-var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_payment_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_payment_proto_msgTypes = make([]protoimpl.MessageInfo, 60)
 var file_payment_proto_goTypes = []any{
-	(*CreateSubscriptionRequest)(nil),  // 0: payment.CreateSubscriptionRequest
-	(*CreateSubscriptionResponse)(nil), // 1: payment.CreateSubscriptionResponse
-	(*CancelSubscriptionRequest)(nil),  // 2: payment.CancelSubscriptionRequest
-	(*CancelSubscriptionResponse)(nil), // 3: payment.CancelSubscriptionResponse
-	(*GetSubscriptionRequest)(nil),     // 4: payment.GetSubscriptionRequest
-	(*Subscription)(nil),               // 5: payment.Subscription
-	(*GetSubscriptionResponse)(nil),    // 6: payment.GetSubscriptionResponse
-	(*timestamppb.Timestamp)(nil),      // 7: google.protobuf.Timestamp
+	(CancelMode)(0),                            // 0: payment.CancelMode
+	(PauseBehavior)(0),                         // 1: payment.PauseBehavior
+	(*CreateSubscriptionRequest)(nil),          // 2: payment.CreateSubscriptionRequest
+	(*CreateSubscriptionResponse)(nil),         // 3: payment.CreateSubscriptionResponse
+	(*CancelSubscriptionRequest)(nil),          // 4: payment.CancelSubscriptionRequest
+	(*CancelSubscriptionResponse)(nil),         // 5: payment.CancelSubscriptionResponse
+	(*ResumeSubscriptionRequest)(nil),          // 6: payment.ResumeSubscriptionRequest
+	(*ResumeSubscriptionResponse)(nil),         // 7: payment.ResumeSubscriptionResponse
+	(*PauseSubscriptionRequest)(nil),           // 8: payment.PauseSubscriptionRequest
+	(*PauseSubscriptionResponse)(nil),          // 9: payment.PauseSubscriptionResponse
+	(*UnpauseSubscriptionRequest)(nil),         // 10: payment.UnpauseSubscriptionRequest
+	(*UnpauseSubscriptionResponse)(nil),        // 11: payment.UnpauseSubscriptionResponse
+	(*GetSubscriptionRequest)(nil),             // 12: payment.GetSubscriptionRequest
+	(*Subscription)(nil),                       // 13: payment.Subscription
+	(*Discount)(nil),                           // 14: payment.Discount
+	(*GetSubscriptionResponse)(nil),            // 15: payment.GetSubscriptionResponse
+	(*ListUserSubscriptionsRequest)(nil),       // 16: payment.ListUserSubscriptionsRequest
+	(*ListUserSubscriptionsResponse)(nil),      // 17: payment.ListUserSubscriptionsResponse
+	(*ChangePlanRequest)(nil),                  // 18: payment.ChangePlanRequest
+	(*ChangePlanResponse)(nil),                 // 19: payment.ChangePlanResponse
+	(*PreviewPlanChangeRequest)(nil),           // 20: payment.PreviewPlanChangeRequest
+	(*PreviewPlanChangeResponse)(nil),          // 21: payment.PreviewPlanChangeResponse
+	(*WatchSubscriptionsRequest)(nil),          // 22: payment.WatchSubscriptionsRequest
+	(*SubscriptionStatusChange)(nil),           // 23: payment.SubscriptionStatusChange
+	(*Customer)(nil),                           // 24: payment.Customer
+	(*CreateCustomerRequest)(nil),              // 25: payment.CreateCustomerRequest
+	(*GetOrCreateCustomerRequest)(nil),         // 26: payment.GetOrCreateCustomerRequest
+	(*CustomerResponse)(nil),                   // 27: payment.CustomerResponse
+	(*UpdateCustomerEmailRequest)(nil),         // 28: payment.UpdateCustomerEmailRequest
+	(*UpdateCustomerEmailResponse)(nil),        // 29: payment.UpdateCustomerEmailResponse
+	(*Plan)(nil),                               // 30: payment.Plan
+	(*ListPlansRequest)(nil),                   // 31: payment.ListPlansRequest
+	(*ListPlansResponse)(nil),                  // 32: payment.ListPlansResponse
+	(*Invoice)(nil),                            // 33: payment.Invoice
+	(*GetInvoiceRequest)(nil),                  // 34: payment.GetInvoiceRequest
+	(*GetInvoiceResponse)(nil),                 // 35: payment.GetInvoiceResponse
+	(*ListUserInvoicesRequest)(nil),            // 36: payment.ListUserInvoicesRequest
+	(*ListUserInvoicesResponse)(nil),           // 37: payment.ListUserInvoicesResponse
+	(*Payment)(nil),                            // 38: payment.Payment
+	(*CreatePaymentRequest)(nil),               // 39: payment.CreatePaymentRequest
+	(*CreatePaymentResponse)(nil),              // 40: payment.CreatePaymentResponse
+	(*GetPaymentRequest)(nil),                  // 41: payment.GetPaymentRequest
+	(*GetPaymentResponse)(nil),                 // 42: payment.GetPaymentResponse
+	(*ListUserPaymentsRequest)(nil),            // 43: payment.ListUserPaymentsRequest
+	(*ListUserPaymentsResponse)(nil),           // 44: payment.ListUserPaymentsResponse
+	(*PaymentMethod)(nil),                      // 45: payment.PaymentMethod
+	(*CreateSetupIntentRequest)(nil),           // 46: payment.CreateSetupIntentRequest
+	(*CreateSetupIntentResponse)(nil),          // 47: payment.CreateSetupIntentResponse
+	(*ListPaymentMethodsRequest)(nil),          // 48: payment.ListPaymentMethodsRequest
+	(*ListPaymentMethodsResponse)(nil),         // 49: payment.ListPaymentMethodsResponse
+	(*AttachPaymentMethodRequest)(nil),         // 50: payment.AttachPaymentMethodRequest
+	(*AttachPaymentMethodResponse)(nil),        // 51: payment.AttachPaymentMethodResponse
+	(*DetachPaymentMethodRequest)(nil),         // 52: payment.DetachPaymentMethodRequest
+	(*DetachPaymentMethodResponse)(nil),        // 53: payment.DetachPaymentMethodResponse
+	(*SetDefaultPaymentMethodRequest)(nil),     // 54: payment.SetDefaultPaymentMethodRequest
+	(*SetDefaultPaymentMethodResponse)(nil),    // 55: payment.SetDefaultPaymentMethodResponse
+	(*CreateCheckoutSessionRequest)(nil),       // 56: payment.CreateCheckoutSessionRequest
+	(*CreateCheckoutSessionResponse)(nil),      // 57: payment.CreateCheckoutSessionResponse
+	(*CreateBillingPortalSessionRequest)(nil),  // 58: payment.CreateBillingPortalSessionRequest
+	(*CreateBillingPortalSessionResponse)(nil), // 59: payment.CreateBillingPortalSessionResponse
+	nil,                           // 60: payment.Payment.MetadataEntry
+	nil,                           // 61: payment.CreatePaymentRequest.MetadataEntry
+	(*timestamppb.Timestamp)(nil), // 62: google.protobuf.Timestamp
 }
 var file_payment_proto_depIdxs = []int32{
-	7,  // 0: payment.CreateSubscriptionResponse.created_at:type_name -> google.protobuf.Timestamp
-	7,  // 1: payment.CancelSubscriptionResponse.canceled_at:type_name -> google.protobuf.Timestamp
-	7,  // 2: payment.Subscription.created_at:type_name -> google.protobuf.Timestamp
-	7,  // 3: payment.Subscription.updated_at:type_name -> google.protobuf.Timestamp
-	7,  // 4: payment.Subscription.expires_at:type_name -> google.protobuf.Timestamp
-	7,  // 5: payment.Subscription.canceled_at:type_name -> google.protobuf.Timestamp
-	5,  // 6: payment.GetSubscriptionResponse.subscription:type_name -> payment.Subscription
-	0,  // 7: payment.PaymentService.CreateSubscription:input_type -> payment.CreateSubscriptionRequest
-	2,  // 8: payment.PaymentService.CancelSubscription:input_type -> payment.CancelSubscriptionRequest
-	4,  // 9: payment.PaymentService.GetSubscription:input_type -> payment.GetSubscriptionRequest
-	1,  // 10: payment.PaymentService.CreateSubscription:output_type -> payment.CreateSubscriptionResponse
-	3,  // 11: payment.PaymentService.CancelSubscription:output_type -> payment.CancelSubscriptionResponse
-	6,  // 12: payment.PaymentService.GetSubscription:output_type -> payment.GetSubscriptionResponse
-	10, // [10:13] is the sub-list for method output_type
-	7,  // [7:10] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	62, // 0: payment.CreateSubscriptionRequest.trial_end:type_name -> google.protobuf.Timestamp
+	62, // 1: payment.CreateSubscriptionResponse.created_at:type_name -> google.protobuf.Timestamp
+	62, // 2: payment.CreateSubscriptionResponse.trial_end:type_name -> google.protobuf.Timestamp
+	14, // 3: payment.CreateSubscriptionResponse.discount:type_name -> payment.Discount
+	0,  // 4: payment.CancelSubscriptionRequest.mode:type_name -> payment.CancelMode
+	62, // 5: payment.CancelSubscriptionResponse.canceled_at:type_name -> google.protobuf.Timestamp
+	13, // 6: payment.CancelSubscriptionResponse.subscription:type_name -> payment.Subscription
+	13, // 7: payment.ResumeSubscriptionResponse.subscription:type_name -> payment.Subscription
+	1,  // 8: payment.PauseSubscriptionRequest.behavior:type_name -> payment.PauseBehavior
+	62, // 9: payment.PauseSubscriptionRequest.resumes_at:type_name -> google.protobuf.Timestamp
+	13, // 10: payment.PauseSubscriptionResponse.subscription:type_name -> payment.Subscription
+	13, // 11: payment.UnpauseSubscriptionResponse.subscription:type_name -> payment.Subscription
+	62, // 12: payment.Subscription.created_at:type_name -> google.protobuf.Timestamp
+	62, // 13: payment.Subscription.updated_at:type_name -> google.protobuf.Timestamp
+	62, // 14: payment.Subscription.expires_at:type_name -> google.protobuf.Timestamp
+	62, // 15: payment.Subscription.canceled_at:type_name -> google.protobuf.Timestamp
+	62, // 16: payment.Subscription.cancel_at:type_name -> google.protobuf.Timestamp
+	62, // 17: payment.Subscription.resumes_at:type_name -> google.protobuf.Timestamp
+	62, // 18: payment.Subscription.trial_start:type_name -> google.protobuf.Timestamp
+	62, // 19: payment.Subscription.trial_end:type_name -> google.protobuf.Timestamp
+	14, // 20: payment.Subscription.discount:type_name -> payment.Discount
+	62, // 21: payment.Discount.ends_at:type_name -> google.protobuf.Timestamp
+	13, // 22: payment.GetSubscriptionResponse.subscription:type_name -> payment.Subscription
+	13, // 23: payment.ListUserSubscriptionsResponse.subscriptions:type_name -> payment.Subscription
+	13, // 24: payment.ChangePlanResponse.subscription:type_name -> payment.Subscription
+	62, // 25: payment.ChangePlanResponse.effective_at:type_name -> google.protobuf.Timestamp
+	62, // 26: payment.PreviewPlanChangeResponse.next_payment_at:type_name -> google.protobuf.Timestamp
+	13, // 27: payment.SubscriptionStatusChange.subscription:type_name -> payment.Subscription
+	62, // 28: payment.SubscriptionStatusChange.occurred_at:type_name -> google.protobuf.Timestamp
+	62, // 29: payment.Customer.created_at:type_name -> google.protobuf.Timestamp
+	62, // 30: payment.Customer.updated_at:type_name -> google.protobuf.Timestamp
+	24, // 31: payment.CustomerResponse.customer:type_name -> payment.Customer
+	30, // 32: payment.ListPlansResponse.plans:type_name -> payment.Plan
+	62, // 33: payment.Invoice.period_start:type_name -> google.protobuf.Timestamp
+	62, // 34: payment.Invoice.period_end:type_name -> google.protobuf.Timestamp
+	62, // 35: payment.Invoice.paid_at:type_name -> google.protobuf.Timestamp
+	62, // 36: payment.Invoice.created_at:type_name -> google.protobuf.Timestamp
+	33, // 37: payment.GetInvoiceResponse.invoice:type_name -> payment.Invoice
+	33, // 38: payment.ListUserInvoicesResponse.invoices:type_name -> payment.Invoice
+	60, // 39: payment.Payment.metadata:type_name -> payment.Payment.MetadataEntry
+	62, // 40: payment.Payment.created_at:type_name -> google.protobuf.Timestamp
+	62, // 41: payment.Payment.updated_at:type_name -> google.protobuf.Timestamp
+	61, // 42: payment.CreatePaymentRequest.metadata:type_name -> payment.CreatePaymentRequest.MetadataEntry
+	38, // 43: payment.CreatePaymentResponse.payment:type_name -> payment.Payment
+	38, // 44: payment.GetPaymentResponse.payment:type_name -> payment.Payment
+	38, // 45: payment.ListUserPaymentsResponse.payments:type_name -> payment.Payment
+	62, // 46: payment.PaymentMethod.created_at:type_name -> google.protobuf.Timestamp
+	45, // 47: payment.ListPaymentMethodsResponse.payment_methods:type_name -> payment.PaymentMethod
+	45, // 48: payment.AttachPaymentMethodResponse.payment_method:type_name -> payment.PaymentMethod
+	62, // 49: payment.CreateCheckoutSessionResponse.expires_at:type_name -> google.protobuf.Timestamp
+	2,  // 50: payment.PaymentService.CreateSubscription:input_type -> payment.CreateSubscriptionRequest
+	4,  // 51: payment.PaymentService.CancelSubscription:input_type -> payment.CancelSubscriptionRequest
+	6,  // 52: payment.PaymentService.ResumeSubscription:input_type -> payment.ResumeSubscriptionRequest
+	8,  // 53: payment.PaymentService.PauseSubscription:input_type -> payment.PauseSubscriptionRequest
+	10, // 54: payment.PaymentService.UnpauseSubscription:input_type -> payment.UnpauseSubscriptionRequest
+	12, // 55: payment.PaymentService.GetSubscription:input_type -> payment.GetSubscriptionRequest
+	16, // 56: payment.PaymentService.ListUserSubscriptions:input_type -> payment.ListUserSubscriptionsRequest
+	18, // 57: payment.PaymentService.ChangePlan:input_type -> payment.ChangePlanRequest
+	20, // 58: payment.PaymentService.PreviewPlanChange:input_type -> payment.PreviewPlanChangeRequest
+	22, // 59: payment.PaymentService.WatchSubscriptions:input_type -> payment.WatchSubscriptionsRequest
+	25, // 60: payment.PaymentService.CreateCustomer:input_type -> payment.CreateCustomerRequest
+	26, // 61: payment.PaymentService.GetOrCreateCustomer:input_type -> payment.GetOrCreateCustomerRequest
+	28, // 62: payment.PaymentService.UpdateCustomerEmail:input_type -> payment.UpdateCustomerEmailRequest
+	31, // 63: payment.PaymentService.ListPlans:input_type -> payment.ListPlansRequest
+	34, // 64: payment.PaymentService.GetInvoice:input_type -> payment.GetInvoiceRequest
+	36, // 65: payment.PaymentService.ListUserInvoices:input_type -> payment.ListUserInvoicesRequest
+	39, // 66: payment.PaymentService.CreatePayment:input_type -> payment.CreatePaymentRequest
+	41, // 67: payment.PaymentService.GetPayment:input_type -> payment.GetPaymentRequest
+	43, // 68: payment.PaymentService.ListUserPayments:input_type -> payment.ListUserPaymentsRequest
+	46, // 69: payment.PaymentService.CreateSetupIntent:input_type -> payment.CreateSetupIntentRequest
+	48, // 70: payment.PaymentService.ListPaymentMethods:input_type -> payment.ListPaymentMethodsRequest
+	50, // 71: payment.PaymentService.AttachPaymentMethod:input_type -> payment.AttachPaymentMethodRequest
+	52, // 72: payment.PaymentService.DetachPaymentMethod:input_type -> payment.DetachPaymentMethodRequest
+	54, // 73: payment.PaymentService.SetDefaultPaymentMethod:input_type -> payment.SetDefaultPaymentMethodRequest
+	56, // 74: payment.PaymentService.CreateCheckoutSession:input_type -> payment.CreateCheckoutSessionRequest
+	58, // 75: payment.PaymentService.CreateBillingPortalSession:input_type -> payment.CreateBillingPortalSessionRequest
+	3,  // 76: payment.PaymentService.CreateSubscription:output_type -> payment.CreateSubscriptionResponse
+	5,  // 77: payment.PaymentService.CancelSubscription:output_type -> payment.CancelSubscriptionResponse
+	7,  // 78: payment.PaymentService.ResumeSubscription:output_type -> payment.ResumeSubscriptionResponse
+	9,  // 79: payment.PaymentService.PauseSubscription:output_type -> payment.PauseSubscriptionResponse
+	11, // 80: payment.PaymentService.UnpauseSubscription:output_type -> payment.UnpauseSubscriptionResponse
+	15, // 81: payment.PaymentService.GetSubscription:output_type -> payment.GetSubscriptionResponse
+	17, // 82: payment.PaymentService.ListUserSubscriptions:output_type -> payment.ListUserSubscriptionsResponse
+	19, // 83: payment.PaymentService.ChangePlan:output_type -> payment.ChangePlanResponse
+	21, // 84: payment.PaymentService.PreviewPlanChange:output_type -> payment.PreviewPlanChangeResponse
+	23, // 85: payment.PaymentService.WatchSubscriptions:output_type -> payment.SubscriptionStatusChange
+	27, // 86: payment.PaymentService.CreateCustomer:output_type -> payment.CustomerResponse
+	27, // 87: payment.PaymentService.GetOrCreateCustomer:output_type -> payment.CustomerResponse
+	29, // 88: payment.PaymentService.UpdateCustomerEmail:output_type -> payment.UpdateCustomerEmailResponse
+	32, // 89: payment.PaymentService.ListPlans:output_type -> payment.ListPlansResponse
+	35, // 90: payment.PaymentService.GetInvoice:output_type -> payment.GetInvoiceResponse
+	37, // 91: payment.PaymentService.ListUserInvoices:output_type -> payment.ListUserInvoicesResponse
+	40, // 92: payment.PaymentService.CreatePayment:output_type -> payment.CreatePaymentResponse
+	42, // 93: payment.PaymentService.GetPayment:output_type -> payment.GetPaymentResponse
+	44, // 94: payment.PaymentService.ListUserPayments:output_type -> payment.ListUserPaymentsResponse
+	47, // 95: payment.PaymentService.CreateSetupIntent:output_type -> payment.CreateSetupIntentResponse
+	49, // 96: payment.PaymentService.ListPaymentMethods:output_type -> payment.ListPaymentMethodsResponse
+	51, // 97: payment.PaymentService.AttachPaymentMethod:output_type -> payment.AttachPaymentMethodResponse
+	53, // 98: payment.PaymentService.DetachPaymentMethod:output_type -> payment.DetachPaymentMethodResponse
+	55, // 99: payment.PaymentService.SetDefaultPaymentMethod:output_type -> payment.SetDefaultPaymentMethodResponse
+	57, // 100: payment.PaymentService.CreateCheckoutSession:output_type -> payment.CreateCheckoutSessionResponse
+	59, // 101: payment.PaymentService.CreateBillingPortalSession:output_type -> payment.CreateBillingPortalSessionResponse
+	76, // [76:102] is the sub-list for method output_type
+	50, // [50:76] is the sub-list for method input_type
+	50, // [50:50] is the sub-list for extension type_name
+	50, // [50:50] is the sub-list for extension extendee
+	0,  // [0:50] is the sub-list for field type_name
 }
 
 func init() { file_payment_proto_init() }
@@ -574,18 +4456,20 @@ func file_payment_proto_init() {
 	if File_payment_proto != nil {
 		return
 	}
+	file_payment_proto_msgTypes[0].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_payment_proto_rawDesc), len(file_payment_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      2,
+			NumMessages:   60,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_payment_proto_goTypes,
 		DependencyIndexes: file_payment_proto_depIdxs,
+		EnumInfos:         file_payment_proto_enumTypes,
 		MessageInfos:      file_payment_proto_msgTypes,
 	}.Build()
 	File_payment_proto = out.File
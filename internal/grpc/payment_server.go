@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
 	"github.com/Dhoini/Payment-microservice/internal/middleware" // Для ключа контекста
 	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/outbox"
 	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
 	"github.com/Dhoini/Payment-microservice/pkg/logger" // Ваш логгер
 
 	"google.golang.org/grpc/codes"
@@ -17,13 +22,15 @@ import (
 
 type PaymentServer struct {
 	paymentService *services.PaymentService
+	feed           *outbox.Feed // Лента изменений подписок для WatchSubscriptions
 	log            *logger.Logger
 	UnimplementedPaymentServiceServer
 }
 
-func NewPaymentServer(paymentService *services.PaymentService, log *logger.Logger) *PaymentServer {
+func NewPaymentServer(paymentService *services.PaymentService, feed *outbox.Feed, log *logger.Logger) *PaymentServer {
 	return &PaymentServer{
 		paymentService: paymentService,
+		feed:           feed,
 		log:            log, // Используем переданный логгер
 	}
 }
@@ -31,10 +38,9 @@ func NewPaymentServer(paymentService *services.PaymentService, log *logger.Logge
 // CreateSubscription обрабатывает gRPC запрос на создание подписки.
 func (s *PaymentServer) CreateSubscription(ctx context.Context, req *CreateSubscriptionRequest) (*CreateSubscriptionResponse, error) {
 	// Получаем UserID из контекста
-	userIDValue, ok := ctx.Value(middleware.ContextUserIDKey).(string)
-	if !ok {
-		s.log.Errorw("UserID not found in gRPC context. Method: CreateSubscription")
-		return nil, status.Errorf(codes.Unauthenticated, "UserID not found in context")
+	userIDValue, err := s.authorizedUserID(ctx, req.UserId, "CreateSubscription")
+	if err != nil {
+		return nil, err
 	}
 
 	s.log.Infow("gRPC CreateSubscription request received. UserID: %s, PlanID: %s, Email: %s, IdempotencyKey: %s",
@@ -55,10 +61,21 @@ func (s *PaymentServer) CreateSubscription(ctx context.Context, req *CreateSubsc
 		UserID:         userIDValue,
 		PlanID:         req.PlanId,
 		UserEmail:      req.UserEmail,
+		CouponID:       req.Coupon,
+		PromotionCode:  req.PromotionCode,
 		IdempotencyKey: req.IdempotencyKey,
 	}
+	if req.TrialDays != nil {
+		days := int64(*req.TrialDays)
+		input.TrialDays = &days
+	}
+	if req.TrialEnd != nil {
+		trialEnd := req.TrialEnd.AsTime()
+		input.TrialEnd = &trialEnd
+	}
 
 	// Вызов сервисного слоя
+	ctx = kafka.WithCorrelationID(ctx, req.IdempotencyKey)
 	output, err := s.paymentService.CreateSubscription(ctx, input)
 	if err != nil {
 		s.log.Errorw("Service failed to create subscription. UserID: %s, Error: %v", userIDValue, err)
@@ -69,24 +86,28 @@ func (s *PaymentServer) CreateSubscription(ctx context.Context, req *CreateSubsc
 		userIDValue, output.Subscription.SubscriptionID, output.Subscription.Status)
 
 	// Формирование успешного gRPC ответа
-	return &CreateSubscriptionResponse{
+	resp := &CreateSubscriptionResponse{
 		SubscriptionId: output.Subscription.SubscriptionID,
 		ClientSecret:   output.ClientSecret,
 		CreatedAt:      timestamppb.New(output.Subscription.CreatedAt),
 		Status:         output.Subscription.Status,
-	}, nil
+		Discount:       mapModelToProtoDiscount(output.Subscription),
+	}
+	if output.Subscription.TrialEnd != nil {
+		resp.TrialEnd = timestamppb.New(*output.Subscription.TrialEnd)
+	}
+	return resp, nil
 }
 
 // CancelSubscription обрабатывает gRPC запрос на отмену подписки.
 func (s *PaymentServer) CancelSubscription(ctx context.Context, req *CancelSubscriptionRequest) (*CancelSubscriptionResponse, error) {
-	userIDValue, ok := ctx.Value(middleware.ContextUserIDKey).(string)
-	if !ok {
-		s.log.Errorw("UserID not found in gRPC context. Method: CancelSubscription")
-		return nil, status.Errorf(codes.Unauthenticated, "UserID not found in context")
+	userIDValue, err := s.authorizedUserID(ctx, req.UserId, "CancelSubscription")
+	if err != nil {
+		return nil, err
 	}
 
-	s.log.Infow("gRPC CancelSubscription request received. UserID: %s, SubscriptionID: %s, IdempotencyKey: %s",
-		userIDValue, req.SubscriptionId, req.IdempotencyKey)
+	s.log.Infow("gRPC CancelSubscription request received. UserID: %s, SubscriptionID: %s, Mode: %s, IdempotencyKey: %s",
+		userIDValue, req.SubscriptionId, req.Mode, req.IdempotencyKey)
 
 	// Валидация
 	if req.SubscriptionId == "" {
@@ -94,8 +115,18 @@ func (s *PaymentServer) CancelSubscription(ctx context.Context, req *CancelSubsc
 		return nil, status.Errorf(codes.InvalidArgument, "subscription_id is required")
 	}
 
+	mode := services.CancelImmediately
+	switch req.Mode {
+	case CancelMode_CANCEL_MODE_UNSPECIFIED, CancelMode_CANCEL_MODE_IMMEDIATELY:
+	case CancelMode_CANCEL_MODE_AT_PERIOD_END:
+		mode = services.CancelAtPeriodEnd
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown cancel mode %d", req.Mode)
+	}
+
 	// Вызов сервиса
-	err := s.paymentService.CancelSubscription(ctx, userIDValue, req.SubscriptionId, req.IdempotencyKey)
+	ctx = kafka.WithCorrelationID(ctx, req.IdempotencyKey)
+	sub, err := s.paymentService.CancelSubscription(ctx, userIDValue, req.SubscriptionId, mode, req.IdempotencyKey)
 	if err != nil {
 		s.log.Errorw("Service failed to cancel subscription. UserID: %s, SubscriptionID: %s, Error: %v",
 			userIDValue, req.SubscriptionId, err)
@@ -105,18 +136,114 @@ func (s *PaymentServer) CancelSubscription(ctx context.Context, req *CancelSubsc
 	s.log.Infow("Subscription cancellation initiated successfully via gRPC. UserID: %s, SubscriptionID: %s",
 		userIDValue, req.SubscriptionId)
 
-	return &CancelSubscriptionResponse{
-		Success:    true,
-		CanceledAt: timestamppb.Now(),
-	}, nil
+	resp := &CancelSubscriptionResponse{
+		Success:           true,
+		CanceledAt:        timestamppb.Now(),
+		CancelAtPeriodEnd: sub.CancelAtPeriodEnd && sub.Status != "canceled",
+		Subscription:      mapModelToProtoSubscription(sub),
+	}
+	switch {
+	case sub.CanceledAt != nil:
+		resp.CanceledAt = timestamppb.New(*sub.CanceledAt)
+	case sub.CancelAt != nil:
+		resp.CanceledAt = timestamppb.New(*sub.CancelAt)
+	}
+	return resp, nil
+}
+
+// ResumeSubscription обрабатывает gRPC запрос на снятие отмены, запланированной на конец периода.
+func (s *PaymentServer) ResumeSubscription(ctx context.Context, req *ResumeSubscriptionRequest) (*ResumeSubscriptionResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "ResumeSubscription")
+	if err != nil {
+		return nil, err
+	}
+	if req.SubscriptionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "subscription_id is required")
+	}
+
+	s.log.Infow("gRPC ResumeSubscription request received. UserID: %s, SubscriptionID: %s", userID, req.SubscriptionId)
+
+	ctx = kafka.WithCorrelationID(ctx, req.IdempotencyKey)
+	sub, err := s.paymentService.ResumeSubscription(ctx, userID, req.SubscriptionId, req.IdempotencyKey)
+	if err != nil {
+		s.log.Errorw("Service failed to resume subscription. UserID: %s, SubscriptionID: %s, Error: %v", userID, req.SubscriptionId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	return &ResumeSubscriptionResponse{Subscription: mapModelToProtoSubscription(sub)}, nil
+}
+
+// pauseBehaviors сопоставляет поведение из gRPC запроса со значением pause_collection.behavior.
+var pauseBehaviors = map[PauseBehavior]string{
+	PauseBehavior_PAUSE_BEHAVIOR_VOID:               stripe.PauseBehaviorVoid,
+	PauseBehavior_PAUSE_BEHAVIOR_KEEP_AS_DRAFT:      stripe.PauseBehaviorKeepAsDraft,
+	PauseBehavior_PAUSE_BEHAVIOR_MARK_UNCOLLECTIBLE: stripe.PauseBehaviorMarkUncollectible,
+}
+
+// PauseSubscription обрабатывает gRPC запрос на приостановку списаний по подписке.
+func (s *PaymentServer) PauseSubscription(ctx context.Context, req *PauseSubscriptionRequest) (*PauseSubscriptionResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "PauseSubscription")
+	if err != nil {
+		return nil, err
+	}
+	if req.SubscriptionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "subscription_id is required")
+	}
+	behavior, ok := pauseBehaviors[req.Behavior]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "behavior is required")
+	}
+	var resumesAt *time.Time
+	if req.ResumesAt != nil {
+		t := req.ResumesAt.AsTime()
+		resumesAt = &t
+	}
+
+	s.log.Infow("gRPC PauseSubscription request received. UserID: %s, SubscriptionID: %s, Behavior: %s", userID, req.SubscriptionId, behavior)
+
+	ctx = kafka.WithCorrelationID(ctx, req.IdempotencyKey)
+	sub, err := s.paymentService.PauseSubscription(ctx, services.PauseSubscriptionInput{
+		UserID:         userID,
+		SubscriptionID: req.SubscriptionId,
+		Behavior:       behavior,
+		ResumesAt:      resumesAt,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		s.log.Errorw("Service failed to pause subscription. UserID: %s, SubscriptionID: %s, Error: %v", userID, req.SubscriptionId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	return &PauseSubscriptionResponse{Subscription: mapModelToProtoSubscription(sub)}, nil
+}
+
+// UnpauseSubscription обрабатывает gRPC запрос на возобновление списаний по подписке.
+func (s *PaymentServer) UnpauseSubscription(ctx context.Context, req *UnpauseSubscriptionRequest) (*UnpauseSubscriptionResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "UnpauseSubscription")
+	if err != nil {
+		return nil, err
+	}
+	if req.SubscriptionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "subscription_id is required")
+	}
+
+	s.log.Infow("gRPC UnpauseSubscription request received. UserID: %s, SubscriptionID: %s", userID, req.SubscriptionId)
+
+	ctx = kafka.WithCorrelationID(ctx, req.IdempotencyKey)
+	sub, err := s.paymentService.UnpauseSubscription(ctx, userID, req.SubscriptionId, req.IdempotencyKey)
+	if err != nil {
+		s.log.Errorw("Service failed to resume subscription collection. UserID: %s, SubscriptionID: %s, Error: %v", userID, req.SubscriptionId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	return &UnpauseSubscriptionResponse{Subscription: mapModelToProtoSubscription(sub)}, nil
 }
 
 // GetSubscription обрабатывает gRPC запрос на получение информации о подписке.
 func (s *PaymentServer) GetSubscription(ctx context.Context, req *GetSubscriptionRequest) (*GetSubscriptionResponse, error) {
-	userIDValue, ok := ctx.Value(middleware.ContextUserIDKey).(string)
-	if !ok {
-		s.log.Errorw("UserID not found in gRPC context. Method: GetSubscription")
-		return nil, status.Errorf(codes.Unauthenticated, "UserID not found in context")
+	userIDValue, err := s.authorizedUserID(ctx, req.UserId, "GetSubscription")
+	if err != nil {
+		return nil, err
 	}
 
 	s.log.Infow("gRPC GetSubscription request received. UserID: %s, SubscriptionID: %s",
@@ -146,6 +273,524 @@ func (s *PaymentServer) GetSubscription(ctx context.Context, req *GetSubscriptio
 	}, nil
 }
 
+// ListUserSubscriptions обрабатывает gRPC запрос на постраничное получение подписок пользователя.
+func (s *PaymentServer) ListUserSubscriptions(ctx context.Context, req *ListUserSubscriptionsRequest) (*ListUserSubscriptionsResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "ListUserSubscriptions")
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 0
+	if req.PageToken != "" {
+		offset, err = strconv.Atoi(req.PageToken)
+		if err != nil || offset < 0 {
+			s.log.Warnw("Invalid page_token in ListUserSubscriptions request. UserID: %s, PageToken: %s", userID, req.PageToken)
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	s.log.Infow("gRPC ListUserSubscriptions request received. UserID: %s, PageSize: %d, Offset: %d", userID, req.PageSize, offset)
+
+	subs, hasMore, err := s.paymentService.ListSubscriptionsByUserID(ctx, userID, int(req.PageSize), offset)
+	if err != nil {
+		s.log.Errorw("Service failed to list subscriptions. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	response := &ListUserSubscriptionsResponse{
+		Subscriptions: make([]*Subscription, len(subs)),
+	}
+	for i := range subs {
+		response.Subscriptions[i] = mapModelToProtoSubscription(&subs[i])
+	}
+	if hasMore {
+		// Токен страницы - смещение следующей страницы; клиенты должны считать его непрозрачным
+		response.NextPageToken = strconv.Itoa(offset + len(subs))
+	}
+	return response, nil
+}
+
+// ChangePlan обрабатывает gRPC запрос на смену плана подписки.
+func (s *PaymentServer) ChangePlan(ctx context.Context, req *ChangePlanRequest) (*ChangePlanResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "ChangePlan")
+	if err != nil {
+		return nil, err
+	}
+	if req.SubscriptionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "subscription_id is required")
+	}
+	if req.PlanId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "plan_id is required")
+	}
+
+	s.log.Infow("gRPC ChangePlan request received. UserID: %s, SubscriptionID: %s, PlanID: %s, AtPeriodEnd: %t",
+		userID, req.SubscriptionId, req.PlanId, req.AtPeriodEnd)
+
+	ctx = kafka.WithCorrelationID(ctx, req.IdempotencyKey)
+	output, err := s.paymentService.ChangePlan(ctx, services.ChangePlanInput{
+		UserID:         userID,
+		SubscriptionID: req.SubscriptionId,
+		PlanID:         req.PlanId,
+		AtPeriodEnd:    req.AtPeriodEnd,
+		ProrationDate:  req.ProrationDate,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		s.log.Errorw("Service failed to change plan. UserID: %s, SubscriptionID: %s, Error: %v", userID, req.SubscriptionId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	return &ChangePlanResponse{
+		Subscription: mapModelToProtoSubscription(output.Subscription),
+		NewPlanId:    output.PlanID,
+		AtPeriodEnd:  output.AtPeriodEnd,
+		EffectiveAt:  timestamppb.New(output.EffectiveAt),
+	}, nil
+}
+
+// PreviewPlanChange обрабатывает gRPC запрос на предпросмотр счета при смене плана.
+func (s *PaymentServer) PreviewPlanChange(ctx context.Context, req *PreviewPlanChangeRequest) (*PreviewPlanChangeResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "PreviewPlanChange")
+	if err != nil {
+		return nil, err
+	}
+	if req.SubscriptionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "subscription_id is required")
+	}
+	if req.PlanId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "plan_id is required")
+	}
+
+	preview, err := s.paymentService.PreviewPlanChange(ctx, userID, req.SubscriptionId, req.PlanId, req.AtPeriodEnd)
+	if err != nil {
+		s.log.Warnw("Service failed to preview plan change. UserID: %s, SubscriptionID: %s, Error: %v", userID, req.SubscriptionId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	resp := &PreviewPlanChangeResponse{
+		SubscriptionId:  preview.SubscriptionID,
+		PlanId:          preview.PlanID,
+		AtPeriodEnd:     preview.AtPeriodEnd,
+		AmountDue:       preview.AmountDue,
+		Total:           preview.Total,
+		ProrationAmount: preview.ProrationAmount,
+		Currency:        preview.Currency,
+		ProrationDate:   preview.ProrationDate,
+	}
+	if !preview.NextPaymentAt.IsZero() {
+		resp.NextPaymentAt = timestamppb.New(preview.NextPaymentAt)
+	}
+	return resp, nil
+}
+
+// WatchSubscriptions отправляет клиенту изменения статусов подписок пользователя, пока клиент не отключится.
+func (s *PaymentServer) WatchSubscriptions(req *WatchSubscriptionsRequest, stream PaymentService_WatchSubscriptionsServer) error {
+	ctx := stream.Context()
+	userID, err := s.authorizedUserID(ctx, req.UserId, "WatchSubscriptions")
+	if err != nil {
+		return err
+	}
+
+	// Проверяем, что пользователь владеет подпиской, за которой хочет следить
+	if req.SubscriptionId != "" {
+		if _, err := s.paymentService.GetSubscriptionByID(ctx, userID, req.SubscriptionId); err != nil {
+			s.log.Warnw("WatchSubscriptions rejected. UserID: %s, SubscriptionID: %s, Error: %v", userID, req.SubscriptionId, err)
+			return mapErrorToGRPCStatus(err, s.log)
+		}
+	}
+
+	events, cancel := s.feed.Subscribe(outbox.FeedFilter{UserID: userID, SubscriptionID: req.SubscriptionId})
+	defer cancel()
+	s.log.Infow("gRPC WatchSubscriptions stream opened. UserID: %s, SubscriptionID: %s", userID, req.SubscriptionId)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Infow("gRPC WatchSubscriptions stream closed by client. UserID: %s", userID)
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				// Лента отключила подписчика (медленное чтение или остановка сервиса) - клиент должен переподключиться
+				return status.Error(codes.Unavailable, "subscription feed closed, reconnect to continue watching")
+			}
+			change := &SubscriptionStatusChange{
+				EventId:        event.EventID,
+				EventType:      string(event.EventType),
+				PreviousStatus: event.PreviousStatus,
+				NewStatus:      event.NewStatus,
+				Subscription:   mapModelToProtoSubscription(event.Subscription),
+				OccurredAt:     timestamppb.New(event.OccurredAt),
+			}
+			if err := stream.Send(change); err != nil {
+				s.log.Warnw("Failed to send subscription change. UserID: %s, EventID: %s, Error: %v", userID, event.EventID, err)
+				return err
+			}
+		}
+	}
+}
+
+// CreateCustomer обрабатывает gRPC запрос на создание клиента Stripe для пользователя.
+func (s *PaymentServer) CreateCustomer(ctx context.Context, req *CreateCustomerRequest) (*CustomerResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "CreateCustomer")
+	if err != nil {
+		return nil, err
+	}
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	s.log.Infow("gRPC CreateCustomer request received. UserID: %s", userID)
+	customer, err := s.paymentService.CreateCustomer(ctx, userID, req.Email)
+	if err != nil {
+		s.log.Errorw("Service failed to create customer. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &CustomerResponse{Customer: mapModelToProtoCustomer(customer)}, nil
+}
+
+// GetOrCreateCustomer обрабатывает gRPC запрос на получение (или создание) клиента Stripe для пользователя.
+func (s *PaymentServer) GetOrCreateCustomer(ctx context.Context, req *GetOrCreateCustomerRequest) (*CustomerResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "GetOrCreateCustomer")
+	if err != nil {
+		return nil, err
+	}
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	s.log.Infow("gRPC GetOrCreateCustomer request received. UserID: %s", userID)
+	customer, err := s.paymentService.GetOrCreateCustomer(ctx, userID, req.Email)
+	if err != nil {
+		s.log.Errorw("Service failed to get or create customer. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &CustomerResponse{Customer: mapModelToProtoCustomer(customer)}, nil
+}
+
+// UpdateCustomerEmail обрабатывает gRPC запрос на изменение email клиента.
+func (s *PaymentServer) UpdateCustomerEmail(ctx context.Context, req *UpdateCustomerEmailRequest) (*UpdateCustomerEmailResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "UpdateCustomerEmail")
+	if err != nil {
+		return nil, err
+	}
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	s.log.Infow("gRPC UpdateCustomerEmail request received. UserID: %s", userID)
+	if err := s.paymentService.UpdateCustomerEmail(ctx, userID, req.Email); err != nil {
+		s.log.Errorw("Service failed to update customer email. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &UpdateCustomerEmailResponse{Success: true}, nil
+}
+
+// ListPlans обрабатывает gRPC запрос на получение каталога планов.
+func (s *PaymentServer) ListPlans(ctx context.Context, req *ListPlansRequest) (*ListPlansResponse, error) {
+	plans, err := s.paymentService.ListPlans(ctx)
+	if err != nil {
+		s.log.Errorw("Service failed to list plans. Error: %v", err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	resp := &ListPlansResponse{Plans: make([]*Plan, len(plans))}
+	for i, plan := range plans {
+		resp.Plans[i] = mapModelToProtoPlan(plan)
+	}
+	return resp, nil
+}
+
+// GetInvoice обрабатывает gRPC запрос на получение счета из истории пользователя.
+func (s *PaymentServer) GetInvoice(ctx context.Context, req *GetInvoiceRequest) (*GetInvoiceResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "GetInvoice")
+	if err != nil {
+		return nil, err
+	}
+	if req.InvoiceId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "invoice_id is required")
+	}
+
+	invoice, err := s.paymentService.GetInvoiceByID(ctx, userID, req.InvoiceId)
+	if err != nil {
+		s.log.Warnw("Service failed to get invoice. UserID: %s, InvoiceID: %s, Error: %v", userID, req.InvoiceId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &GetInvoiceResponse{Invoice: mapModelToProtoInvoice(invoice)}, nil
+}
+
+// ListUserInvoices обрабатывает gRPC запрос на получение страницы истории счетов пользователя.
+func (s *PaymentServer) ListUserInvoices(ctx context.Context, req *ListUserInvoicesRequest) (*ListUserInvoicesResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "ListUserInvoices")
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 0
+	if req.PageToken != "" {
+		offset, err = strconv.Atoi(req.PageToken)
+		if err != nil || offset < 0 {
+			s.log.Warnw("Invalid page_token in ListUserInvoices request. UserID: %s, PageToken: %s", userID, req.PageToken)
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	invoices, hasMore, err := s.paymentService.ListInvoicesByUserID(ctx, userID, int(req.PageSize), offset)
+	if err != nil {
+		s.log.Errorw("Service failed to list invoices. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	response := &ListUserInvoicesResponse{
+		Invoices: make([]*Invoice, len(invoices)),
+	}
+	for i, invoice := range invoices {
+		response.Invoices[i] = mapModelToProtoInvoice(invoice)
+	}
+	if hasMore {
+		response.NextPageToken = strconv.Itoa(offset + len(invoices))
+	}
+	return response, nil
+}
+
+// CreatePayment обрабатывает gRPC запрос на создание разового платежа.
+func (s *PaymentServer) CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "CreatePayment")
+	if err != nil {
+		return nil, err
+	}
+	if req.UserEmail == "" || req.Amount <= 0 || req.Currency == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_email, a positive amount and currency are required")
+	}
+
+	payment, clientSecret, err := s.paymentService.CreatePayment(ctx, services.CreatePaymentInput{
+		UserID:         userID,
+		UserEmail:      req.UserEmail,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		Description:    req.Description,
+		Metadata:       req.Metadata,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		s.log.Warnw("Service failed to create payment. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &CreatePaymentResponse{Payment: mapModelToProtoPayment(payment), ClientSecret: clientSecret}, nil
+}
+
+// GetPayment обрабатывает gRPC запрос на получение разового платежа пользователя.
+func (s *PaymentServer) GetPayment(ctx context.Context, req *GetPaymentRequest) (*GetPaymentResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "GetPayment")
+	if err != nil {
+		return nil, err
+	}
+	if req.PaymentId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "payment_id is required")
+	}
+
+	payment, err := s.paymentService.GetPaymentByID(ctx, userID, req.PaymentId)
+	if err != nil {
+		s.log.Warnw("Service failed to get payment. UserID: %s, PaymentID: %s, Error: %v", userID, req.PaymentId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &GetPaymentResponse{Payment: mapModelToProtoPayment(payment)}, nil
+}
+
+// ListUserPayments обрабатывает gRPC запрос на получение страницы разовых платежей пользователя.
+func (s *PaymentServer) ListUserPayments(ctx context.Context, req *ListUserPaymentsRequest) (*ListUserPaymentsResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "ListUserPayments")
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 0
+	if req.PageToken != "" {
+		offset, err = strconv.Atoi(req.PageToken)
+		if err != nil || offset < 0 {
+			s.log.Warnw("Invalid page_token in ListUserPayments request. UserID: %s, PageToken: %s", userID, req.PageToken)
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	payments, hasMore, err := s.paymentService.ListPaymentsByUserID(ctx, userID, int(req.PageSize), offset)
+	if err != nil {
+		s.log.Errorw("Service failed to list payments. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	response := &ListUserPaymentsResponse{
+		Payments: make([]*Payment, len(payments)),
+	}
+	for i, payment := range payments {
+		response.Payments[i] = mapModelToProtoPayment(payment)
+	}
+	if hasMore {
+		response.NextPageToken = strconv.Itoa(offset + len(payments))
+	}
+	return response, nil
+}
+
+// CreateSetupIntent обрабатывает gRPC запрос на создание SetupIntent для сохранения новой карты.
+func (s *PaymentServer) CreateSetupIntent(ctx context.Context, req *CreateSetupIntentRequest) (*CreateSetupIntentResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "CreateSetupIntent")
+	if err != nil {
+		return nil, err
+	}
+
+	si, err := s.paymentService.CreateSetupIntent(ctx, userID, req.IdempotencyKey)
+	if err != nil {
+		s.log.Warnw("Service failed to create setup intent. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &CreateSetupIntentResponse{SetupIntentId: si.ID, ClientSecret: si.ClientSecret, Status: si.Status}, nil
+}
+
+// ListPaymentMethods обрабатывает gRPC запрос на получение карт пользователя.
+func (s *PaymentServer) ListPaymentMethods(ctx context.Context, req *ListPaymentMethodsRequest) (*ListPaymentMethodsResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "ListPaymentMethods")
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := s.paymentService.ListPaymentMethods(ctx, userID)
+	if err != nil {
+		s.log.Warnw("Service failed to list payment methods. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+
+	resp := &ListPaymentMethodsResponse{PaymentMethods: make([]*PaymentMethod, len(methods))}
+	for i, pm := range methods {
+		resp.PaymentMethods[i] = mapToProtoPaymentMethod(pm)
+	}
+	return resp, nil
+}
+
+// AttachPaymentMethod обрабатывает gRPC запрос на привязку карты к клиенту пользователя.
+func (s *PaymentServer) AttachPaymentMethod(ctx context.Context, req *AttachPaymentMethodRequest) (*AttachPaymentMethodResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "AttachPaymentMethod")
+	if err != nil {
+		return nil, err
+	}
+	if req.PaymentMethodId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "payment_method_id is required")
+	}
+
+	pm, err := s.paymentService.AttachPaymentMethod(ctx, userID, req.PaymentMethodId, req.SetDefault, req.IdempotencyKey)
+	if err != nil {
+		s.log.Warnw("Service failed to attach payment method. UserID: %s, PaymentMethodID: %s, Error: %v", userID, req.PaymentMethodId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &AttachPaymentMethodResponse{PaymentMethod: mapToProtoPaymentMethod(pm)}, nil
+}
+
+// DetachPaymentMethod обрабатывает gRPC запрос на отвязку карты пользователя.
+func (s *PaymentServer) DetachPaymentMethod(ctx context.Context, req *DetachPaymentMethodRequest) (*DetachPaymentMethodResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "DetachPaymentMethod")
+	if err != nil {
+		return nil, err
+	}
+	if req.PaymentMethodId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "payment_method_id is required")
+	}
+
+	if err := s.paymentService.DetachPaymentMethod(ctx, userID, req.PaymentMethodId); err != nil {
+		s.log.Warnw("Service failed to detach payment method. UserID: %s, PaymentMethodID: %s, Error: %v", userID, req.PaymentMethodId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &DetachPaymentMethodResponse{}, nil
+}
+
+// SetDefaultPaymentMethod обрабатывает gRPC запрос на смену карты по умолчанию.
+func (s *PaymentServer) SetDefaultPaymentMethod(ctx context.Context, req *SetDefaultPaymentMethodRequest) (*SetDefaultPaymentMethodResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "SetDefaultPaymentMethod")
+	if err != nil {
+		return nil, err
+	}
+	if req.PaymentMethodId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "payment_method_id is required")
+	}
+
+	if err := s.paymentService.SetDefaultPaymentMethod(ctx, userID, req.PaymentMethodId); err != nil {
+		s.log.Warnw("Service failed to set default payment method. UserID: %s, PaymentMethodID: %s, Error: %v", userID, req.PaymentMethodId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &SetDefaultPaymentMethodResponse{}, nil
+}
+
+// CreateCheckoutSession обрабатывает gRPC запрос на создание сессии Stripe Checkout для подписки на план.
+func (s *PaymentServer) CreateCheckoutSession(ctx context.Context, req *CreateCheckoutSessionRequest) (*CreateCheckoutSessionResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "CreateCheckoutSession")
+	if err != nil {
+		return nil, err
+	}
+	if req.PlanId == "" || req.UserEmail == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "plan_id and user_email are required")
+	}
+
+	session, err := s.paymentService.CreateCheckoutSession(ctx, services.CreateCheckoutSessionInput{
+		UserID:              userID,
+		PlanID:              req.PlanId,
+		UserEmail:           req.UserEmail,
+		SuccessURL:          req.SuccessUrl,
+		CancelURL:           req.CancelUrl,
+		AllowPromotionCodes: req.AllowPromotionCodes,
+		IdempotencyKey:      req.IdempotencyKey,
+	})
+	if err != nil {
+		s.log.Warnw("Service failed to create checkout session. UserID: %s, PlanID: %s, Error: %v", userID, req.PlanId, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &CreateCheckoutSessionResponse{
+		SessionId: session.ID,
+		Url:       session.URL,
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}, nil
+}
+
+// CreateBillingPortalSession обрабатывает gRPC запрос на создание сессии Billing Portal пользователя.
+func (s *PaymentServer) CreateBillingPortalSession(ctx context.Context, req *CreateBillingPortalSessionRequest) (*CreateBillingPortalSessionResponse, error) {
+	userID, err := s.authorizedUserID(ctx, req.UserId, "CreateBillingPortalSession")
+	if err != nil {
+		return nil, err
+	}
+
+	portalURL, err := s.paymentService.CreateBillingPortalSession(ctx, userID, req.ReturnUrl)
+	if err != nil {
+		s.log.Warnw("Service failed to create billing portal session. UserID: %s, Error: %v", userID, err)
+		return nil, mapErrorToGRPCStatus(err, s.log)
+	}
+	return &CreateBillingPortalSessionResponse{Url: portalURL}, nil
+}
+
+// authorizedUserID возвращает ID пользователя, над данными которого выполняется вызов. Если в запросе
+// указан user_id, доступ к его данным проверяет PaymentService.AuthorizeUserAccess (чужие данные -
+// только для ролей, которые могут действовать от имени пользователя, с записью в журнал аудита).
+func (s *PaymentServer) authorizedUserID(ctx context.Context, requestedUserID, method string) (string, error) {
+	userID, ok := ctx.Value(middleware.ContextUserIDKey).(string)
+	if !ok {
+		s.log.Errorw("UserID not found in gRPC context. Method: %s", method)
+		return "", status.Errorf(codes.Unauthenticated, "UserID not found in context")
+	}
+	if requestedUserID == "" {
+		return userID, nil
+	}
+	// Характер операции (чтение или изменение) берется из таблицы политик
+	access := Policies["/"+PaymentService_ServiceDesc.ServiceName+"/"+method].Access
+	if err := s.paymentService.AuthorizeUserAccess(ctx, userID, requestedUserID, access, "rpc "+method); err != nil {
+		return "", status.Errorf(codes.PermissionDenied, "access to another user's data is forbidden")
+	}
+	return requestedUserID, nil
+}
+
 // mapErrorToGRPCStatus преобразует ошибки сервисного слоя в статус gRPC.
 func mapErrorToGRPCStatus(err error, log *logger.Logger) error { // Принимает логгер
 	switch {
@@ -155,6 +800,27 @@ func mapErrorToGRPCStatus(err error, log *logger.Logger) error { // Приним
 		return status.Error(codes.FailedPrecondition, err.Error())
 	case errors.Is(err, services.ErrUserNotFound):
 		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrPaymentMethodNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrPaymentNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, services.ErrCustomerNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrPlanNotFound):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrPlanArchived):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, services.ErrInvalidDiscount):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, services.ErrSubscriptionNotChangeable), errors.Is(err, services.ErrSubscriptionCanceled),
+		errors.Is(err, services.ErrSubscriptionNotPausable):
+		return status.Error(codes.FailedPrecondition, err.Error())
 	case errors.Is(err, services.ErrStripeClient):
 		return status.Error(codes.Internal, fmt.Sprintf("Payment provider error: %v", err))
 	case errors.Is(err, services.ErrInternalServer):
@@ -172,13 +838,15 @@ func mapModelToProtoSubscription(sub *models.Subscription) *Subscription {
 		return nil
 	}
 	grpcSub := &Subscription{
-		SubscriptionId:   sub.SubscriptionID,
-		UserId:           sub.UserID,
-		PlanId:           sub.PlanID,
-		Status:           sub.Status,
-		StripeCustomerId: sub.StripeCustomerID,
-		CreatedAt:        timestamppb.New(sub.CreatedAt),
-		UpdatedAt:        timestamppb.New(sub.UpdatedAt),
+		SubscriptionId:    sub.SubscriptionID,
+		UserId:            sub.UserID,
+		PlanId:            sub.PlanID,
+		Status:            sub.Status,
+		StripeCustomerId:  sub.StripeCustomerID,
+		CreatedAt:         timestamppb.New(sub.CreatedAt),
+		UpdatedAt:         timestamppb.New(sub.UpdatedAt),
+		CancelAtPeriodEnd: sub.CancelAtPeriodEnd,
+		Discount:          mapModelToProtoDiscount(sub),
 	}
 	if sub.ExpiresAt != nil {
 		grpcSub.ExpiresAt = timestamppb.New(*sub.ExpiresAt)
@@ -186,5 +854,125 @@ func mapModelToProtoSubscription(sub *models.Subscription) *Subscription {
 	if sub.CanceledAt != nil {
 		grpcSub.CanceledAt = timestamppb.New(*sub.CanceledAt)
 	}
+	if sub.CancelAt != nil {
+		grpcSub.CancelAt = timestamppb.New(*sub.CancelAt)
+	}
+	if sub.ResumesAt != nil {
+		grpcSub.ResumesAt = timestamppb.New(*sub.ResumesAt)
+	}
+	if sub.TrialStart != nil {
+		grpcSub.TrialStart = timestamppb.New(*sub.TrialStart)
+	}
+	if sub.TrialEnd != nil {
+		grpcSub.TrialEnd = timestamppb.New(*sub.TrialEnd)
+	}
 	return grpcSub
 }
+
+// mapModelToProtoDiscount возвращает скидку подписки или nil, если скидки нет.
+func mapModelToProtoDiscount(sub *models.Subscription) *Discount {
+	if sub.DiscountCouponID == "" {
+		return nil
+	}
+	discount := &Discount{
+		CouponId:      sub.DiscountCouponID,
+		PromotionCode: sub.DiscountPromotionCode,
+		PercentOff:    sub.DiscountPercentOff,
+		AmountOff:     sub.DiscountAmountOff,
+		Currency:      sub.DiscountCurrency,
+		Duration:      sub.DiscountDuration,
+	}
+	if sub.DiscountEnd != nil {
+		discount.EndsAt = timestamppb.New(*sub.DiscountEnd)
+	}
+	return discount
+}
+
+// mapModelToProtoCustomer преобразует модель клиента в gRPC сообщение.
+func mapModelToProtoCustomer(customer *models.Customer) *Customer {
+	if customer == nil {
+		return nil
+	}
+	return &Customer{
+		UserId:           customer.UserID,
+		StripeCustomerId: customer.StripeCustomerID,
+		Email:            customer.Email,
+		CreatedAt:        timestamppb.New(customer.CreatedAt),
+		UpdatedAt:        timestamppb.New(customer.UpdatedAt),
+	}
+}
+
+// mapModelToProtoPlan преобразует план каталога в gRPC сообщение.
+func mapModelToProtoPlan(plan *models.Plan) *Plan {
+	return &Plan{
+		PlanId:          plan.PlanID,
+		ProductId:       plan.ProductID,
+		Name:            plan.Name,
+		Description:     plan.Description,
+		Nickname:        plan.Nickname,
+		Amount:          plan.Amount,
+		Currency:        plan.Currency,
+		Interval:        plan.Interval,
+		IntervalCount:   plan.IntervalCount,
+		TrialPeriodDays: plan.TrialPeriodDays,
+	}
+}
+
+// mapModelToProtoInvoice преобразует счет в gRPC сообщение.
+func mapModelToProtoInvoice(invoice *models.Invoice) *Invoice {
+	grpcInvoice := &Invoice{
+		InvoiceId:        invoice.InvoiceID,
+		SubscriptionId:   invoice.SubscriptionID,
+		Number:           invoice.Number,
+		Status:           invoice.Status,
+		BillingReason:    invoice.BillingReason,
+		Currency:         invoice.Currency,
+		AmountDue:        invoice.AmountDue,
+		AmountPaid:       invoice.AmountPaid,
+		AmountRemaining:  invoice.AmountRemaining,
+		Subtotal:         invoice.Subtotal,
+		Total:            invoice.Total,
+		HostedInvoiceUrl: invoice.HostedInvoiceURL,
+		InvoicePdf:       invoice.InvoicePDF,
+		CreatedAt:        timestamppb.New(invoice.StripeCreatedAt),
+	}
+	if invoice.PeriodStart != nil {
+		grpcInvoice.PeriodStart = timestamppb.New(*invoice.PeriodStart)
+	}
+	if invoice.PeriodEnd != nil {
+		grpcInvoice.PeriodEnd = timestamppb.New(*invoice.PeriodEnd)
+	}
+	if invoice.PaidAt != nil {
+		grpcInvoice.PaidAt = timestamppb.New(*invoice.PaidAt)
+	}
+	return grpcInvoice
+}
+
+// mapModelToProtoPayment преобразует разовый платеж в gRPC сообщение.
+func mapModelToProtoPayment(payment *models.Payment) *Payment {
+	return &Payment{
+		PaymentId:        payment.PaymentID,
+		Amount:           payment.Amount,
+		AmountReceived:   payment.AmountReceived,
+		Currency:         payment.Currency,
+		Status:           payment.Status,
+		Description:      payment.Description,
+		Metadata:         payment.Metadata,
+		LastPaymentError: payment.LastPaymentError,
+		CreatedAt:        timestamppb.New(payment.StripeCreatedAt),
+		UpdatedAt:        timestamppb.New(payment.UpdatedAt),
+	}
+}
+
+// mapToProtoPaymentMethod преобразует карту Stripe в gRPC сообщение.
+func mapToProtoPaymentMethod(pm *stripe.PaymentMethod) *PaymentMethod {
+	return &PaymentMethod{
+		PaymentMethodId: pm.ID,
+		Brand:           pm.Brand,
+		Last4:           pm.Last4,
+		ExpMonth:        pm.ExpMonth,
+		ExpYear:         pm.ExpYear,
+		IsDefault:       pm.IsDefault,
+		CreatedAt:       timestamppb.New(pm.Created),
+	}
+}
@@ -0,0 +1,40 @@
+package payment
+
+import "github.com/Dhoini/Payment-microservice/internal/authz"
+
+// Policies политики доступа к методам PaymentService (ключ - полное имя метода).
+// Метод без политики недоступен: новый RPC нужно добавить и сюда.
+var Policies = map[string]authz.Policy{
+	PaymentService_CreateSubscription_FullMethodName:    authz.UserWrite,
+	PaymentService_CancelSubscription_FullMethodName:    authz.UserWrite,
+	PaymentService_ResumeSubscription_FullMethodName:    authz.UserWrite,
+	PaymentService_PauseSubscription_FullMethodName:     authz.UserWrite,
+	PaymentService_UnpauseSubscription_FullMethodName:   authz.UserWrite,
+	PaymentService_GetSubscription_FullMethodName:       authz.UserRead,
+	PaymentService_ListUserSubscriptions_FullMethodName: authz.UserRead,
+	PaymentService_ChangePlan_FullMethodName:            authz.UserWrite,
+	PaymentService_PreviewPlanChange_FullMethodName:     authz.UserRead,
+	PaymentService_WatchSubscriptions_FullMethodName:    authz.UserRead,
+
+	PaymentService_CreateCustomer_FullMethodName:      authz.UserWrite,
+	PaymentService_GetOrCreateCustomer_FullMethodName: authz.UserWrite,
+	PaymentService_UpdateCustomerEmail_FullMethodName: authz.UserWrite,
+
+	PaymentService_ListPlans_FullMethodName: authz.AnyRead,
+
+	PaymentService_GetInvoice_FullMethodName:       authz.UserRead,
+	PaymentService_ListUserInvoices_FullMethodName: authz.UserRead,
+
+	PaymentService_CreatePayment_FullMethodName:    authz.UserWrite,
+	PaymentService_GetPayment_FullMethodName:       authz.UserRead,
+	PaymentService_ListUserPayments_FullMethodName: authz.UserRead,
+
+	PaymentService_CreateSetupIntent_FullMethodName:       authz.UserWrite,
+	PaymentService_ListPaymentMethods_FullMethodName:      authz.UserRead,
+	PaymentService_AttachPaymentMethod_FullMethodName:     authz.UserWrite,
+	PaymentService_DetachPaymentMethod_FullMethodName:     authz.UserWrite,
+	PaymentService_SetDefaultPaymentMethod_FullMethodName: authz.UserWrite,
+
+	PaymentService_CreateCheckoutSession_FullMethodName:      authz.UserWrite,
+	PaymentService_CreateBillingPortalSession_FullMethodName: authz.UserWrite,
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Dhoini/Payment-microservice/internal/middleware"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/Dhoini/Payment-microservice/pkg/req"
+	"github.com/Dhoini/Payment-microservice/pkg/res"
+)
+
+// CheckoutHandler обрабатывает HTTP запросы к страницам Stripe: Checkout и Billing Portal.
+type CheckoutHandler struct {
+	service *services.PaymentService
+	log     *logger.Logger
+}
+
+// NewCheckoutHandler создает новый экземпляр CheckoutHandler.
+func NewCheckoutHandler(service *services.PaymentService, log *logger.Logger) *CheckoutHandler {
+	return &CheckoutHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// --- DTO запроса ---
+type CreateCheckoutSessionRequest struct {
+	PlanID              string `json:"plan_id" validate:"required"`
+	UserEmail           string `json:"user_email" validate:"required,email"`
+	SuccessURL          string `json:"success_url,omitempty" validate:"omitempty,url"` // По умолчанию из конфигурации
+	CancelURL           string `json:"cancel_url,omitempty" validate:"omitempty,url"`  // По умолчанию из конфигурации
+	AllowPromotionCodes bool   `json:"allow_promotion_codes"`                          // Поле для промокода на странице оплаты
+}
+
+type CreateBillingPortalSessionRequest struct {
+	ReturnURL string `json:"return_url,omitempty" validate:"omitempty,url"` // По умолчанию из конфигурации
+}
+
+// --- DTO ответа ---
+type CheckoutSessionResponse struct {
+	SessionID string    `json:"session_id"`
+	URL       string    `json:"url"` // Страница оплаты Stripe, на которую нужно перенаправить пользователя
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type BillingPortalSessionResponse struct {
+	URL string `json:"url"`
+}
+
+// CreateCheckoutSession обрабатывает POST /api/v1/checkout/sessions
+func (h *CheckoutHandler) CreateCheckoutSession(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	requestBody, err := req.HandleBody[CreateCheckoutSessionRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	session, err := h.service.CreateCheckoutSession(c.Request.Context(), services.CreateCheckoutSessionInput{
+		UserID:              userID,
+		PlanID:              requestBody.PlanID,
+		UserEmail:           requestBody.UserEmail,
+		SuccessURL:          requestBody.SuccessURL,
+		CancelURL:           requestBody.CancelURL,
+		AllowPromotionCodes: requestBody.AllowPromotionCodes,
+		IdempotencyKey:      c.GetHeader("Idempotency-Key"),
+	})
+	if err != nil {
+		h.respondError(c, "create checkout session", userID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, CheckoutSessionResponse{
+		SessionID: session.ID,
+		URL:       session.URL,
+		ExpiresAt: session.ExpiresAt,
+	}, http.StatusCreated)
+}
+
+// CreateBillingPortalSession обрабатывает POST /api/v1/billing-portal/sessions
+func (h *CheckoutHandler) CreateBillingPortalSession(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var requestBody CreateBillingPortalSessionRequest
+	if c.Request.ContentLength != 0 {
+		body, err := req.HandleBody[CreateBillingPortalSessionRequest](&c.Writer, c.Request, h.log)
+		if err != nil {
+			c.Abort()
+			return
+		}
+		requestBody = *body
+	}
+
+	portalURL, err := h.service.CreateBillingPortalSession(c.Request.Context(), userID, requestBody.ReturnURL)
+	if err != nil {
+		h.respondError(c, "create billing portal session", userID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, BillingPortalSessionResponse{URL: portalURL}, http.StatusCreated)
+}
+
+// userID возвращает пользователя из токена; при его отсутствии отвечает 401.
+func (h *CheckoutHandler) userID(c *gin.Context) (string, bool) {
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return "", false
+	}
+	return userIDValue.(string), true
+}
+
+// respondError отвечает ошибкой сервиса.
+func (h *CheckoutHandler) respondError(c *gin.Context, operation, userID string, err error) {
+	h.log.Warnw("Service failed to %s. UserID: %s, Error: %v", operation, userID, err)
+	statusCode, errMsg := mapErrorToHTTPStatus(err)
+	res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+	c.Abort()
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/middleware"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/Dhoini/Payment-microservice/pkg/res"
+)
+
+// InvoiceHandler обрабатывает HTTP запросы к истории счетов пользователя.
+type InvoiceHandler struct {
+	service *services.PaymentService
+	log     *logger.Logger
+}
+
+// NewInvoiceHandler создает новый экземпляр InvoiceHandler.
+func NewInvoiceHandler(service *services.PaymentService, log *logger.Logger) *InvoiceHandler {
+	return &InvoiceHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// --- DTO ответа ---
+type InvoiceResponse struct {
+	InvoiceID        string     `json:"invoice_id"`
+	SubscriptionID   string     `json:"subscription_id,omitempty"`
+	Number           string     `json:"number,omitempty"`
+	Status           string     `json:"status"`
+	BillingReason    string     `json:"billing_reason,omitempty"`
+	Currency         string     `json:"currency"`
+	AmountDue        int64      `json:"amount_due"`
+	AmountPaid       int64      `json:"amount_paid"`
+	AmountRemaining  int64      `json:"amount_remaining"`
+	Subtotal         int64      `json:"subtotal"`
+	Total            int64      `json:"total"`
+	PeriodStart      *time.Time `json:"period_start,omitempty"`
+	PeriodEnd        *time.Time `json:"period_end,omitempty"`
+	HostedInvoiceURL string     `json:"hosted_invoice_url,omitempty"`
+	InvoicePDF       string     `json:"invoice_pdf,omitempty"`
+	PaidAt           *time.Time `json:"paid_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"` // Когда счет создан в Stripe
+}
+
+type InvoiceListResponse struct {
+	Invoices []InvoiceResponse `json:"invoices"`
+	HasMore  bool              `json:"has_more"`
+}
+
+// ListUserInvoices обрабатывает GET /api/v1/users/:user_id/invoices?limit=&offset=
+func (h *InvoiceHandler) ListUserInvoices(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	requesterUserIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("Requester UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+	requesterUserID := requesterUserIDValue.(string)
+	targetUserID := c.Param("user_id")
+
+	if targetUserID == "" {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Missing user ID"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+	if err := h.service.AuthorizeUserAccess(c.Request.Context(), requesterUserID, targetUserID, authz.AccessRead, "invoices of user "+targetUserID); err != nil {
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	limit, err := parseOptionalInt(c.Query("limit"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid limit"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+	offset, err := parseOptionalInt(c.Query("offset"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid offset"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+
+	invoices, hasMore, err := h.service.ListInvoicesByUserID(ctx, targetUserID, limit, offset)
+	if err != nil {
+		h.log.Errorw("Service failed to list invoices. UserID: %s, Error: %v", targetUserID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	response := InvoiceListResponse{
+		Invoices: make([]InvoiceResponse, len(invoices)),
+		HasMore:  hasMore,
+	}
+	for i, invoice := range invoices {
+		response.Invoices[i] = mapModelToInvoiceResponse(invoice)
+	}
+	res.JsonResponse(c.Writer, response, http.StatusOK)
+}
+
+// GetInvoice обрабатывает GET /api/v1/invoices/:invoice_id
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+	userID := userIDValue.(string)
+	invoiceID := c.Param("invoice_id")
+
+	if invoiceID == "" {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Missing invoice ID"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+
+	invoice, err := h.service.GetInvoiceByID(ctx, userID, invoiceID)
+	if err != nil {
+		h.log.Warnw("Service failed to get invoice. UserID: %s, InvoiceID: %s, Error: %v", userID, invoiceID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	res.JsonResponse(c.Writer, mapModelToInvoiceResponse(invoice), http.StatusOK)
+}
+
+// mapModelToInvoiceResponse преобразует счет в DTO.
+func mapModelToInvoiceResponse(invoice *models.Invoice) InvoiceResponse {
+	return InvoiceResponse{
+		InvoiceID:        invoice.InvoiceID,
+		SubscriptionID:   invoice.SubscriptionID,
+		Number:           invoice.Number,
+		Status:           invoice.Status,
+		BillingReason:    invoice.BillingReason,
+		Currency:         invoice.Currency,
+		AmountDue:        invoice.AmountDue,
+		AmountPaid:       invoice.AmountPaid,
+		AmountRemaining:  invoice.AmountRemaining,
+		Subtotal:         invoice.Subtotal,
+		Total:            invoice.Total,
+		PeriodStart:      invoice.PeriodStart,
+		PeriodEnd:        invoice.PeriodEnd,
+		HostedInvoiceURL: invoice.HostedInvoiceURL,
+		InvoicePDF:       invoice.InvoicePDF,
+		PaidAt:           invoice.PaidAt,
+		CreatedAt:        invoice.StripeCreatedAt,
+	}
+}
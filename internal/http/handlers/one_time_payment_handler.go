@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/middleware"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/Dhoini/Payment-microservice/pkg/req"
+	"github.com/Dhoini/Payment-microservice/pkg/res"
+)
+
+// OneTimePaymentHandler обрабатывает HTTP запросы к разовым платежам (покупки кредитов, дополнений и т.п.).
+type OneTimePaymentHandler struct {
+	service *services.PaymentService
+	log     *logger.Logger
+}
+
+// NewOneTimePaymentHandler создает новый экземпляр OneTimePaymentHandler.
+func NewOneTimePaymentHandler(service *services.PaymentService, log *logger.Logger) *OneTimePaymentHandler {
+	return &OneTimePaymentHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// --- DTO запроса ---
+type CreatePaymentRequest struct {
+	UserEmail   string            `json:"user_email" validate:"required,email"`
+	Amount      int64             `json:"amount" validate:"required,gt=0"` // В минимальных единицах валюты
+	Currency    string            `json:"currency" validate:"required,len=3"`
+	Description string            `json:"description,omitempty" validate:"max=1000"`
+	Metadata    map[string]string `json:"metadata,omitempty"` // Например, {"sku": "credits-100"}; ключ user_id зарезервирован
+}
+
+// --- DTO ответа ---
+type PaymentResponse struct {
+	PaymentID        string            `json:"payment_id"`
+	Amount           int64             `json:"amount"`
+	AmountReceived   int64             `json:"amount_received"`
+	Currency         string            `json:"currency"`
+	Status           string            `json:"status"`
+	Description      string            `json:"description,omitempty"`
+	Metadata         map[string]string `json:"metadata"`
+	LastPaymentError string            `json:"last_payment_error,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"` // Когда PaymentIntent создан в Stripe
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+type CreatePaymentResponse struct {
+	PaymentResponse
+	ClientSecret string `json:"client_secret"` // Передается в stripe.confirmPayment на фронтенде
+}
+
+type PaymentListResponse struct {
+	Payments []PaymentResponse `json:"payments"`
+	HasMore  bool              `json:"has_more"`
+}
+
+// CreatePayment обрабатывает POST /api/v1/payments
+func (h *OneTimePaymentHandler) CreatePayment(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	requestBody, err := req.HandleBody[CreatePaymentRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	payment, clientSecret, err := h.service.CreatePayment(c.Request.Context(), services.CreatePaymentInput{
+		UserID:         userID,
+		UserEmail:      requestBody.UserEmail,
+		Amount:         requestBody.Amount,
+		Currency:       requestBody.Currency,
+		Description:    requestBody.Description,
+		Metadata:       requestBody.Metadata,
+		IdempotencyKey: c.GetHeader("Idempotency-Key"),
+	})
+	if err != nil {
+		h.respondError(c, "create payment", userID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, CreatePaymentResponse{
+		PaymentResponse: mapModelToPaymentResponse(payment),
+		ClientSecret:    clientSecret,
+	}, http.StatusCreated)
+}
+
+// GetPayment обрабатывает GET /api/v1/payments/:payment_id
+func (h *OneTimePaymentHandler) GetPayment(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	paymentID := c.Param("payment_id")
+	if paymentID == "" {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Missing payment ID"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+
+	payment, err := h.service.GetPaymentByID(c.Request.Context(), userID, paymentID)
+	if err != nil {
+		h.respondError(c, "get payment", userID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, mapModelToPaymentResponse(payment), http.StatusOK)
+}
+
+// ListUserPayments обрабатывает GET /api/v1/users/:user_id/payments?limit=&offset=
+func (h *OneTimePaymentHandler) ListUserPayments(c *gin.Context) {
+	requesterUserID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+	targetUserID := c.Param("user_id")
+
+	if targetUserID == "" {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Missing user ID"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+	if err := h.service.AuthorizeUserAccess(c.Request.Context(), requesterUserID, targetUserID, authz.AccessRead, "payments of user "+targetUserID); err != nil {
+		h.respondError(c, "list payments", requesterUserID, err)
+		return
+	}
+
+	limit, err := parseOptionalInt(c.Query("limit"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid limit"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+	offset, err := parseOptionalInt(c.Query("offset"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid offset"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+
+	payments, hasMore, err := h.service.ListPaymentsByUserID(c.Request.Context(), targetUserID, limit, offset)
+	if err != nil {
+		h.respondError(c, "list payments", targetUserID, err)
+		return
+	}
+
+	response := PaymentListResponse{
+		Payments: make([]PaymentResponse, len(payments)),
+		HasMore:  hasMore,
+	}
+	for i, payment := range payments {
+		response.Payments[i] = mapModelToPaymentResponse(payment)
+	}
+	res.JsonResponse(c.Writer, response, http.StatusOK)
+}
+
+// userID возвращает пользователя из токена; при его отсутствии отвечает 401.
+func (h *OneTimePaymentHandler) userID(c *gin.Context) (string, bool) {
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return "", false
+	}
+	return userIDValue.(string), true
+}
+
+// respondError отвечает ошибкой сервиса.
+func (h *OneTimePaymentHandler) respondError(c *gin.Context, operation, userID string, err error) {
+	h.log.Warnw("Service failed to %s. UserID: %s, Error: %v", operation, userID, err)
+	statusCode, errMsg := mapErrorToHTTPStatus(err)
+	res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+	c.Abort()
+}
+
+// mapModelToPaymentResponse преобразует платеж в DTO.
+func mapModelToPaymentResponse(payment *models.Payment) PaymentResponse {
+	metadata := map[string]string(payment.Metadata)
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return PaymentResponse{
+		PaymentID:        payment.PaymentID,
+		Amount:           payment.Amount,
+		AmountReceived:   payment.AmountReceived,
+		Currency:         payment.Currency,
+		Status:           payment.Status,
+		Description:      payment.Description,
+		Metadata:         metadata,
+		LastPaymentError: payment.LastPaymentError,
+		CreatedAt:        payment.StripeCreatedAt,
+		UpdatedAt:        payment.UpdatedAt,
+	}
+}
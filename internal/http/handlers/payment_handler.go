@@ -3,10 +3,13 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
 	"github.com/Dhoini/Payment-microservice/internal/middleware"
 	"github.com/Dhoini/Payment-microservice/internal/models"
 	"github.com/Dhoini/Payment-microservice/internal/services"
@@ -31,28 +34,93 @@ func NewPaymentHandler(service *services.PaymentService, log *logger.Logger) *Pa
 
 // --- DTO запроса ---
 type CreateSubscriptionRequest struct {
-	PlanID    string `json:"plan_id" validate:"required"`
-	UserEmail string `json:"user_email" validate:"required,email"`
+	PlanID        string     `json:"plan_id" validate:"required"`
+	UserEmail     string     `json:"user_email" validate:"required,email"`
+	TrialDays     *int64     `json:"trial_days" validate:"omitempty,min=0,max=730"`                    // Пробный период в днях (не задан - по умолчанию для плана, 0 - без него)
+	TrialEnd      *time.Time `json:"trial_end"`                                                        // Точный конец пробного периода (вместо trial_days)
+	Coupon        string     `json:"coupon" validate:"omitempty,max=255"`                              // ID купона Stripe
+	PromotionCode string     `json:"promotion_code" validate:"omitempty,max=255,excluded_with=Coupon"` // Промокод (вместо coupon)
+}
+
+type ChangePlanRequest struct {
+	PlanID        string `json:"plan_id" validate:"required"`
+	AtPeriodEnd   bool   `json:"at_period_end"`  // true - новый план с начала следующего периода
+	ProrationDate int64  `json:"proration_date"` // Из ответа предпросмотра, чтобы сумма перерасчета совпала с показанной
+}
+
+// CancelSubscriptionRequest тело DELETE-запроса (необязательно; режим можно передать и в query ?mode=).
+type CancelSubscriptionRequest struct {
+	Mode string `json:"mode" validate:"omitempty,oneof=immediately at_period_end"` // immediately (по умолчанию) или at_period_end
+}
+
+type PauseSubscriptionRequest struct {
+	Behavior  string     `json:"behavior" validate:"required,oneof=void keep_as_draft mark_uncollectible"` // Что делать со счетами на время паузы
+	ResumesAt *time.Time `json:"resumes_at"`                                                               // Автоматическое возобновление (опционально)
 }
 
 // --- DTO ответа ---
 type CreateSubscriptionResponse struct {
-	SubscriptionID string `json:"subscription_id"`
-	Status         string `json:"status"`
-	ClientSecret   string `json:"client_secret,omitempty"`
-	CreatedAt      string `json:"created_at"`
+	SubscriptionID string            `json:"subscription_id"`
+	Status         string            `json:"status"`
+	ClientSecret   string            `json:"client_secret,omitempty"` // Для первого платежа или, в пробном периоде, для сохранения карты
+	CreatedAt      string            `json:"created_at"`
+	TrialEnd       *time.Time        `json:"trial_end,omitempty"` // До этого момента подписка в статусе trialing и не оплачивается
+	Discount       *DiscountResponse `json:"discount,omitempty"`
+}
+
+// DiscountResponse скидка, примененная к подписке.
+type DiscountResponse struct {
+	CouponID      string     `json:"coupon_id"`
+	PromotionCode string     `json:"promotion_code,omitempty"`
+	PercentOff    float64    `json:"percent_off,omitempty"`
+	AmountOff     int64      `json:"amount_off,omitempty"` // В минимальных единицах Currency
+	Currency      string     `json:"currency,omitempty"`
+	Duration      string     `json:"duration"`          // once, repeating или forever
+	EndsAt        *time.Time `json:"ends_at,omitempty"` // Конец скидки repeating-купона
 }
 
 type SubscriptionResponse struct {
-	SubscriptionID   string     `json:"subscription_id"`
-	UserID           string     `json:"user_id"`
-	PlanID           string     `json:"plan_id"`
-	Status           string     `json:"status"`
-	StripeCustomerID string     `json:"stripe_customer_id"`
-	CreatedAt        time.Time  `json:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at"`
-	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
-	CanceledAt       *time.Time `json:"canceled_at,omitempty"`
+	SubscriptionID    string            `json:"subscription_id"`
+	UserID            string            `json:"user_id"`
+	PlanID            string            `json:"plan_id"`
+	Status            string            `json:"status"`
+	StripeCustomerID  string            `json:"stripe_customer_id"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	ExpiresAt         *time.Time        `json:"expires_at,omitempty"`
+	CanceledAt        *time.Time        `json:"canceled_at,omitempty"`
+	CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+	CancelAt          *time.Time        `json:"cancel_at,omitempty"`  // Когда вступит в силу запланированная отмена
+	ResumesAt         *time.Time        `json:"resumes_at,omitempty"` // Когда автоматически возобновятся приостановленные списания
+	TrialStart        *time.Time        `json:"trial_start,omitempty"`
+	TrialEnd          *time.Time        `json:"trial_end,omitempty"` // Конец пробного периода (статус trialing до этого момента)
+	Discount          *DiscountResponse `json:"discount,omitempty"`
+}
+
+type CancelSubscriptionResponse struct {
+	Message      string               `json:"message"`
+	Subscription SubscriptionResponse `json:"subscription"`
+}
+
+type ChangePlanResponse struct {
+	SubscriptionID string    `json:"subscription_id"`
+	Status         string    `json:"status"`
+	CurrentPlanID  string    `json:"current_plan_id"` // Меняется после подтверждения от Stripe (вебхук)
+	NewPlanID      string    `json:"new_plan_id"`
+	AtPeriodEnd    bool      `json:"at_period_end"`
+	EffectiveAt    time.Time `json:"effective_at"`
+}
+
+type PlanChangePreviewResponse struct {
+	SubscriptionID  string     `json:"subscription_id"`
+	PlanID          string     `json:"plan_id"`
+	AtPeriodEnd     bool       `json:"at_period_end"`
+	AmountDue       int64      `json:"amount_due"`
+	Total           int64      `json:"total"`
+	ProrationAmount int64      `json:"proration_amount"`
+	Currency        string     `json:"currency"`
+	NextPaymentAt   *time.Time `json:"next_payment_at,omitempty"`
+	ProrationDate   int64      `json:"proration_date,omitempty"`
 }
 
 // --- Обработчики ---
@@ -75,6 +143,7 @@ func (h *PaymentHandler) CreateSubscription(c *gin.Context) {
 
 	// Шаг 2: Получаем ключ идемпотентности
 	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx = kafka.WithCorrelationID(ctx, idempotencyKey) // Ключ идемпотентности связывает событие Kafka с запросом
 	h.log.Debugw("Received CreateSubscription request. UserID: %s, IdempotencyKey: %s", userID, idempotencyKey)
 
 	// Шаг 3: Декодируем и валидируем тело запроса
@@ -90,6 +159,10 @@ func (h *PaymentHandler) CreateSubscription(c *gin.Context) {
 		UserID:         userID,
 		PlanID:         requestBody.PlanID,
 		UserEmail:      requestBody.UserEmail,
+		TrialDays:      requestBody.TrialDays,
+		TrialEnd:       requestBody.TrialEnd,
+		CouponID:       requestBody.Coupon,
+		PromotionCode:  requestBody.PromotionCode,
 		IdempotencyKey: idempotencyKey,
 	}
 
@@ -109,7 +182,10 @@ func (h *PaymentHandler) CreateSubscription(c *gin.Context) {
 		SubscriptionID: output.Subscription.SubscriptionID,
 		Status:         output.Subscription.Status,
 		ClientSecret:   output.ClientSecret,
-		CreatedAt:      time.Now().Format(time.RFC3339)}
+		CreatedAt:      time.Now().Format(time.RFC3339),
+		TrialEnd:       output.Subscription.TrialEnd,
+		Discount:       mapModelToDiscountResponse(output.Subscription),
+	}
 
 	res.JsonResponse(c.Writer, response, http.StatusCreated)
 	h.log.Infow("Handler CreateSubscription finished successfully. UserID: %s, SubscriptionID: %s", userID, response.SubscriptionID)
@@ -178,9 +254,9 @@ func (h *PaymentHandler) GetUserSubscriptions(c *gin.Context) {
 	}
 
 	// !!! ВАЖНО: Проверка прав доступа !!!
-	if requesterUserID != targetUserID {
-		h.log.Warnw("Forbidden access attempt in GetUserSubscriptions. RequesterID: %s, TargetID: %s", requesterUserID, targetUserID)
-		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Forbidden"}, http.StatusForbidden)
+	if err := h.service.AuthorizeUserAccess(ctx, requesterUserID, targetUserID, authz.AccessRead, "subscriptions of user "+targetUserID); err != nil {
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
 		c.Abort()
 		return
 	}
@@ -205,6 +281,7 @@ func (h *PaymentHandler) GetUserSubscriptions(c *gin.Context) {
 }
 
 // CancelSubscription обрабатывает DELETE /api/v1/subscriptions/:subscription_id
+// Режим отмены берется из тела ({"mode": "at_period_end"}) или из query ?mode=; по умолчанию - немедленная отмена.
 func (h *PaymentHandler) CancelSubscription(c *gin.Context) {
 	ctx := c.Request.Context()
 	h.log.Infow("Handler CancelSubscription started")
@@ -219,8 +296,28 @@ func (h *PaymentHandler) CancelSubscription(c *gin.Context) {
 	userID := userIDValue.(string)
 	subscriptionID := c.Param("subscription_id")
 	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx = kafka.WithCorrelationID(ctx, idempotencyKey)
+
+	modeValue := c.Query("mode")
+	if c.Request.ContentLength != 0 { // Тело у DELETE необязательно
+		requestBody, err := req.HandleBody[CancelSubscriptionRequest](&c.Writer, c.Request, h.log)
+		if err != nil {
+			c.Abort()
+			return
+		}
+		if requestBody.Mode != "" {
+			modeValue = requestBody.Mode
+		}
+	}
+	mode, err := services.ParseCancelMode(modeValue)
+	if err != nil {
+		h.log.Warnw("Invalid cancel mode. UserID: %s, Mode: %s", userID, modeValue)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid cancel mode, expected immediately or at_period_end"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
 
-	h.log.Infow("Processing CancelSubscription. UserID: %s, SubscriptionID: %s, IdempotencyKey: %s", userID, subscriptionID, idempotencyKey)
+	h.log.Infow("Processing CancelSubscription. UserID: %s, SubscriptionID: %s, Mode: %s, IdempotencyKey: %s", userID, subscriptionID, mode, idempotencyKey)
 
 	if subscriptionID == "" {
 		h.log.Warnw("Missing subscription ID in request path. UserID: %s", userID)
@@ -229,7 +326,7 @@ func (h *PaymentHandler) CancelSubscription(c *gin.Context) {
 		return
 	}
 
-	err := h.service.CancelSubscription(ctx, userID, subscriptionID, idempotencyKey)
+	sub, err := h.service.CancelSubscription(ctx, userID, subscriptionID, mode, idempotencyKey)
 	if err != nil {
 		h.log.Warnw("Service failed to cancel subscription. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
 		statusCode, errMsg := mapErrorToHTTPStatus(err)
@@ -238,25 +335,264 @@ func (h *PaymentHandler) CancelSubscription(c *gin.Context) {
 		return
 	}
 
-	res.JsonResponse(c.Writer, map[string]string{"message": "Subscription cancellation initiated successfully"}, http.StatusOK)
+	message := "Subscription cancellation initiated successfully"
+	if mode == services.CancelAtPeriodEnd {
+		message = "Subscription will be canceled at the end of the current period"
+	}
+	res.JsonResponse(c.Writer, CancelSubscriptionResponse{
+		Message:      message,
+		Subscription: mapModelToSubscriptionResponse(sub),
+	}, http.StatusOK)
 	h.log.Infow("Handler CancelSubscription finished successfully. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
 }
 
+// ResumeSubscription обрабатывает POST /api/v1/subscriptions/:subscription_id/resume
+// Снимает отмену, запланированную на конец периода.
+func (h *PaymentHandler) ResumeSubscription(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+	userID := userIDValue.(string)
+	subscriptionID := c.Param("subscription_id")
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx = kafka.WithCorrelationID(ctx, idempotencyKey)
+
+	h.log.Infow("Processing ResumeSubscription. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+
+	sub, err := h.service.ResumeSubscription(ctx, userID, subscriptionID, idempotencyKey)
+	if err != nil {
+		h.log.Warnw("Service failed to resume subscription. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	res.JsonResponse(c.Writer, mapModelToSubscriptionResponse(sub), http.StatusOK)
+}
+
+// PauseSubscription обрабатывает POST /api/v1/subscriptions/:subscription_id/pause
+func (h *PaymentHandler) PauseSubscription(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+	userID := userIDValue.(string)
+	subscriptionID := c.Param("subscription_id")
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx = kafka.WithCorrelationID(ctx, idempotencyKey)
+
+	requestBody, err := req.HandleBody[PauseSubscriptionRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	h.log.Infow("Processing PauseSubscription. UserID: %s, SubscriptionID: %s, Behavior: %s", userID, subscriptionID, requestBody.Behavior)
+
+	sub, err := h.service.PauseSubscription(ctx, services.PauseSubscriptionInput{
+		UserID:         userID,
+		SubscriptionID: subscriptionID,
+		Behavior:       requestBody.Behavior,
+		ResumesAt:      requestBody.ResumesAt,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		h.log.Warnw("Service failed to pause subscription. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	res.JsonResponse(c.Writer, mapModelToSubscriptionResponse(sub), http.StatusOK)
+}
+
+// UnpauseSubscription обрабатывает DELETE /api/v1/subscriptions/:subscription_id/pause
+func (h *PaymentHandler) UnpauseSubscription(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+	userID := userIDValue.(string)
+	subscriptionID := c.Param("subscription_id")
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx = kafka.WithCorrelationID(ctx, idempotencyKey)
+
+	h.log.Infow("Processing UnpauseSubscription. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+
+	sub, err := h.service.UnpauseSubscription(ctx, userID, subscriptionID, idempotencyKey)
+	if err != nil {
+		h.log.Warnw("Service failed to resume subscription collection. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	res.JsonResponse(c.Writer, mapModelToSubscriptionResponse(sub), http.StatusOK)
+}
+
+// ChangePlan обрабатывает PATCH /api/v1/subscriptions/:subscription_id
+func (h *PaymentHandler) ChangePlan(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+	userID := userIDValue.(string)
+	subscriptionID := c.Param("subscription_id")
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx = kafka.WithCorrelationID(ctx, idempotencyKey)
+
+	requestBody, err := req.HandleBody[ChangePlanRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	h.log.Infow("Processing ChangePlan. UserID: %s, SubscriptionID: %s, PlanID: %s, AtPeriodEnd: %t",
+		userID, subscriptionID, requestBody.PlanID, requestBody.AtPeriodEnd)
+
+	output, err := h.service.ChangePlan(ctx, services.ChangePlanInput{
+		UserID:         userID,
+		SubscriptionID: subscriptionID,
+		PlanID:         requestBody.PlanID,
+		AtPeriodEnd:    requestBody.AtPeriodEnd,
+		ProrationDate:  requestBody.ProrationDate,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		h.log.Warnw("Service failed to change plan. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	res.JsonResponse(c.Writer, ChangePlanResponse{
+		SubscriptionID: output.Subscription.SubscriptionID,
+		Status:         output.Subscription.Status,
+		CurrentPlanID:  output.Subscription.PlanID,
+		NewPlanID:      output.PlanID,
+		AtPeriodEnd:    output.AtPeriodEnd,
+		EffectiveAt:    output.EffectiveAt,
+	}, http.StatusOK)
+}
+
+// PreviewPlanChange обрабатывает GET /api/v1/subscriptions/:subscription_id/plan-change-preview?plan_id=...&at_period_end=...
+func (h *PaymentHandler) PreviewPlanChange(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return
+	}
+	userID := userIDValue.(string)
+	subscriptionID := c.Param("subscription_id")
+
+	planID := c.Query("plan_id")
+	if planID == "" {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Missing plan_id"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+	atPeriodEnd := false
+	if raw := c.Query("at_period_end"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid at_period_end"}, http.StatusBadRequest)
+			c.Abort()
+			return
+		}
+		atPeriodEnd = parsed
+	}
+
+	preview, err := h.service.PreviewPlanChange(ctx, userID, subscriptionID, planID, atPeriodEnd)
+	if err != nil {
+		h.log.Warnw("Service failed to preview plan change. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	response := PlanChangePreviewResponse{
+		SubscriptionID:  preview.SubscriptionID,
+		PlanID:          preview.PlanID,
+		AtPeriodEnd:     preview.AtPeriodEnd,
+		AmountDue:       preview.AmountDue,
+		Total:           preview.Total,
+		ProrationAmount: preview.ProrationAmount,
+		Currency:        preview.Currency,
+		ProrationDate:   preview.ProrationDate,
+	}
+	if !preview.NextPaymentAt.IsZero() {
+		response.NextPaymentAt = &preview.NextPaymentAt
+	}
+	res.JsonResponse(c.Writer, response, http.StatusOK)
+}
+
 // mapModelToSubscriptionResponse (без изменений)
 func mapModelToSubscriptionResponse(sub *models.Subscription) SubscriptionResponse {
 	if sub == nil {
 		return SubscriptionResponse{}
 	}
 	return SubscriptionResponse{
-		SubscriptionID:   sub.SubscriptionID,
-		UserID:           sub.UserID,
-		PlanID:           sub.PlanID,
-		Status:           sub.Status,
-		StripeCustomerID: sub.StripeCustomerID,
-		CreatedAt:        sub.CreatedAt,
-		UpdatedAt:        sub.UpdatedAt,
-		ExpiresAt:        sub.ExpiresAt,
-		CanceledAt:       sub.CanceledAt,
+		SubscriptionID:    sub.SubscriptionID,
+		UserID:            sub.UserID,
+		PlanID:            sub.PlanID,
+		Status:            sub.Status,
+		StripeCustomerID:  sub.StripeCustomerID,
+		CreatedAt:         sub.CreatedAt,
+		UpdatedAt:         sub.UpdatedAt,
+		ExpiresAt:         sub.ExpiresAt,
+		CanceledAt:        sub.CanceledAt,
+		CancelAtPeriodEnd: sub.CancelAtPeriodEnd,
+		CancelAt:          sub.CancelAt,
+		ResumesAt:         sub.ResumesAt,
+		TrialStart:        sub.TrialStart,
+		TrialEnd:          sub.TrialEnd,
+		Discount:          mapModelToDiscountResponse(sub),
+	}
+}
+
+// mapModelToDiscountResponse возвращает скидку подписки или nil, если скидки нет.
+func mapModelToDiscountResponse(sub *models.Subscription) *DiscountResponse {
+	if sub.DiscountCouponID == "" {
+		return nil
+	}
+	return &DiscountResponse{
+		CouponID:      sub.DiscountCouponID,
+		PromotionCode: sub.DiscountPromotionCode,
+		PercentOff:    sub.DiscountPercentOff,
+		AmountOff:     sub.DiscountAmountOff,
+		Currency:      sub.DiscountCurrency,
+		Duration:      sub.DiscountDuration,
+		EndsAt:        sub.DiscountEnd,
 	}
 }
 
@@ -267,6 +603,42 @@ func mapErrorToHTTPStatus(err error) (statusCode int, message string) {
 		return http.StatusNotFound, "Subscription not found"
 	case errors.Is(err, services.ErrUserNotFound):
 		return http.StatusNotFound, "User not found"
+	case errors.Is(err, services.ErrCustomerNotFound):
+		return http.StatusNotFound, "Customer not found"
+	case errors.Is(err, services.ErrPaymentMethodNotFound):
+		return http.StatusNotFound, "Payment method not found"
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		return http.StatusNotFound, "Invoice not found"
+	case errors.Is(err, services.ErrPaymentNotFound):
+		return http.StatusNotFound, "Payment not found"
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden, "Forbidden"
+	case errors.Is(err, services.ErrChargeNotFound):
+		return http.StatusNotFound, "Charge not found"
+	case errors.Is(err, services.ErrRefundNotFound):
+		return http.StatusNotFound, "Refund not found"
+	case errors.Is(err, services.ErrCreditNoteNotFound):
+		return http.StatusNotFound, "Credit note not found"
+	case errors.Is(err, services.ErrAlreadyRefunded):
+		return http.StatusConflict, "Charge is already fully refunded"
+	case errors.Is(err, services.ErrWebhookEventNotFound):
+		return http.StatusNotFound, "Webhook event not found"
+	case errors.Is(err, services.ErrWebhookEventBusy):
+		return http.StatusConflict, "Webhook event is currently being processed"
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest, "Invalid input data"
+	case errors.Is(err, services.ErrPlanNotFound):
+		return http.StatusBadRequest, "Plan not found"
+	case errors.Is(err, services.ErrPlanArchived):
+		return http.StatusBadRequest, "Plan is not available"
+	case errors.Is(err, services.ErrInvalidDiscount):
+		return http.StatusUnprocessableEntity, "Coupon or promotion code is invalid or expired"
+	case errors.Is(err, services.ErrSubscriptionNotChangeable):
+		return http.StatusConflict, "Subscription plan cannot be changed in its current status"
+	case errors.Is(err, services.ErrSubscriptionNotPausable):
+		return http.StatusConflict, "Subscription cannot be paused in its current status"
+	case errors.Is(err, services.ErrSubscriptionCanceled):
+		return http.StatusConflict, "Subscription is already canceled"
 	case errors.Is(err, services.ErrPaymentFailed):
 		return http.StatusUnprocessableEntity, "Payment processing failed"
 	case errors.Is(err, services.ErrStripeClient):
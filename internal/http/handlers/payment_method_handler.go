@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Dhoini/Payment-microservice/internal/middleware"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/Dhoini/Payment-microservice/pkg/req"
+	"github.com/Dhoini/Payment-microservice/pkg/res"
+)
+
+// PaymentMethodHandler обрабатывает HTTP запросы к картам клиента Stripe аутентифицированного пользователя.
+type PaymentMethodHandler struct {
+	service *services.PaymentService
+	log     *logger.Logger
+}
+
+// NewPaymentMethodHandler создает новый экземпляр PaymentMethodHandler.
+func NewPaymentMethodHandler(service *services.PaymentService, log *logger.Logger) *PaymentMethodHandler {
+	return &PaymentMethodHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// --- DTO запроса ---
+type AttachPaymentMethodRequest struct {
+	PaymentMethodID string `json:"payment_method_id" validate:"required,max=255"` // Карта, созданная через Stripe.js (pm_...)
+	SetDefault      bool   `json:"set_default"`                                   // Сделать картой по умолчанию
+}
+
+// --- DTO ответа ---
+type SetupIntentResponse struct {
+	SetupIntentID string `json:"setup_intent_id"`
+	ClientSecret  string `json:"client_secret"` // Передается в stripe.confirmCardSetup на фронтенде
+	Status        string `json:"status"`
+}
+
+type PaymentMethodResponse struct {
+	PaymentMethodID string    `json:"payment_method_id"`
+	Brand           string    `json:"brand"`
+	Last4           string    `json:"last4"`
+	ExpMonth        int64     `json:"exp_month"`
+	ExpYear         int64     `json:"exp_year"`
+	IsDefault       bool      `json:"is_default"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CreateSetupIntent обрабатывает POST /api/v1/payment-methods/setup-intent
+func (h *PaymentMethodHandler) CreateSetupIntent(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	si, err := h.service.CreateSetupIntent(c.Request.Context(), userID, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		h.respondError(c, "create setup intent", userID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, SetupIntentResponse{
+		SetupIntentID: si.ID,
+		ClientSecret:  si.ClientSecret,
+		Status:        si.Status,
+	}, http.StatusCreated)
+}
+
+// ListPaymentMethods обрабатывает GET /api/v1/payment-methods
+func (h *PaymentMethodHandler) ListPaymentMethods(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	methods, err := h.service.ListPaymentMethods(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, "list payment methods", userID, err)
+		return
+	}
+
+	response := make([]PaymentMethodResponse, len(methods))
+	for i, pm := range methods {
+		response[i] = mapToPaymentMethodResponse(pm)
+	}
+	res.JsonResponse(c.Writer, response, http.StatusOK)
+}
+
+// AttachPaymentMethod обрабатывает POST /api/v1/payment-methods
+func (h *PaymentMethodHandler) AttachPaymentMethod(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	requestBody, err := req.HandleBody[AttachPaymentMethodRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	pm, err := h.service.AttachPaymentMethod(c.Request.Context(), userID, requestBody.PaymentMethodID, requestBody.SetDefault, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		h.respondError(c, "attach payment method", userID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, mapToPaymentMethodResponse(pm), http.StatusOK)
+}
+
+// DetachPaymentMethod обрабатывает DELETE /api/v1/payment-methods/:payment_method_id
+func (h *PaymentMethodHandler) DetachPaymentMethod(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DetachPaymentMethod(c.Request.Context(), userID, c.Param("payment_method_id")); err != nil {
+		h.respondError(c, "detach payment method", userID, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetDefaultPaymentMethod обрабатывает POST /api/v1/payment-methods/:payment_method_id/default
+func (h *PaymentMethodHandler) SetDefaultPaymentMethod(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetDefaultPaymentMethod(c.Request.Context(), userID, c.Param("payment_method_id")); err != nil {
+		h.respondError(c, "set default payment method", userID, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// userID возвращает пользователя из токена; при его отсутствии отвечает 401.
+func (h *PaymentMethodHandler) userID(c *gin.Context) (string, bool) {
+	userIDValue, exists := c.Get(string(middleware.ContextUserIDKey))
+	if !exists {
+		h.log.Errorw("UserID not found in context")
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Unauthorized"}, http.StatusUnauthorized)
+		c.Abort()
+		return "", false
+	}
+	return userIDValue.(string), true
+}
+
+// respondError отвечает ошибкой сервиса.
+func (h *PaymentMethodHandler) respondError(c *gin.Context, operation, userID string, err error) {
+	h.log.Warnw("Service failed to %s. UserID: %s, Error: %v", operation, userID, err)
+	statusCode, errMsg := mapErrorToHTTPStatus(err)
+	res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+	c.Abort()
+}
+
+// mapToPaymentMethodResponse преобразует карту Stripe в DTO.
+func mapToPaymentMethodResponse(pm *stripe.PaymentMethod) PaymentMethodResponse {
+	return PaymentMethodResponse{
+		PaymentMethodID: pm.ID,
+		Brand:           pm.Brand,
+		Last4:           pm.Last4,
+		ExpMonth:        pm.ExpMonth,
+		ExpYear:         pm.ExpYear,
+		IsDefault:       pm.IsDefault,
+		CreatedAt:       pm.Created,
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/Dhoini/Payment-microservice/pkg/res"
+)
+
+// PlanHandler обрабатывает HTTP запросы к каталогу планов.
+type PlanHandler struct {
+	service *services.PaymentService
+	log     *logger.Logger
+}
+
+// NewPlanHandler создает новый экземпляр PlanHandler.
+func NewPlanHandler(service *services.PaymentService, log *logger.Logger) *PlanHandler {
+	return &PlanHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// --- DTO ответа ---
+type PlanResponse struct {
+	PlanID          string `json:"plan_id"`
+	ProductID       string `json:"product_id"`
+	Name            string `json:"name"`
+	Description     string `json:"description,omitempty"`
+	Nickname        string `json:"nickname,omitempty"`
+	Amount          int64  `json:"amount"`
+	Currency        string `json:"currency"`
+	Interval        string `json:"interval"`
+	IntervalCount   int64  `json:"interval_count"`
+	TrialPeriodDays int64  `json:"trial_period_days,omitempty"` // Пробный период по умолчанию для новых подписок
+}
+
+// ListPlans обрабатывает GET /api/v1/plans (только доступные для оформления планы)
+func (h *PlanHandler) ListPlans(c *gin.Context) {
+	plans, err := h.service.ListPlans(c.Request.Context())
+	if err != nil {
+		h.log.Errorw("Service failed to list plans. Error: %v", err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	response := make([]PlanResponse, len(plans))
+	for i, plan := range plans {
+		response[i] = mapModelToPlanResponse(plan)
+	}
+	res.JsonResponse(c.Writer, response, http.StatusOK)
+}
+
+// mapModelToPlanResponse преобразует план каталога в DTO.
+func mapModelToPlanResponse(plan *models.Plan) PlanResponse {
+	return PlanResponse{
+		PlanID:          plan.PlanID,
+		ProductID:       plan.ProductID,
+		Name:            plan.Name,
+		Description:     plan.Description,
+		Nickname:        plan.Nickname,
+		Amount:          plan.Amount,
+		Currency:        plan.Currency,
+		Interval:        plan.Interval,
+		IntervalCount:   plan.IntervalCount,
+		TrialPeriodDays: plan.TrialPeriodDays,
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/middleware"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/Dhoini/Payment-microservice/pkg/req"
+	"github.com/Dhoini/Payment-microservice/pkg/res"
+)
+
+// RefundAdminHandler обрабатывает административные HTTP запросы к возвратам и кредит-нотам.
+type RefundAdminHandler struct {
+	service *services.PaymentService
+	log     *logger.Logger
+}
+
+// NewRefundAdminHandler создает новый экземпляр RefundAdminHandler.
+func NewRefundAdminHandler(service *services.PaymentService, log *logger.Logger) *RefundAdminHandler {
+	return &RefundAdminHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// --- DTO запроса ---
+type CreateRefundRequest struct {
+	ChargeID  string `json:"charge_id"`               // Платеж (ch_...), либо
+	InvoiceID string `json:"invoice_id"`              // счет, платеж по которому возвращается
+	Amount    int64  `json:"amount" validate:"gte=0"` // 0 - вся невозвращенная сумма
+	Reason    string `json:"reason" validate:"omitempty,oneof=duplicate fraudulent requested_by_customer"`
+}
+
+type CreateCreditNoteRequest struct {
+	InvoiceID string `json:"invoice_id" validate:"required"`
+	Amount    int64  `json:"amount" validate:"required,gt=0"`
+	Refund    bool   `json:"refund"` // Вернуть сумму на карту; иначе - на баланс клиента
+	Reason    string `json:"reason" validate:"omitempty,oneof=duplicate fraudulent order_change product_unsatisfactory"`
+	Memo      string `json:"memo" validate:"max=500"`
+}
+
+// --- DTO ответа ---
+type InvoiceRefundsResponse struct {
+	Refunds     []*models.Refund     `json:"refunds"`
+	CreditNotes []*models.CreditNote `json:"credit_notes"`
+}
+
+// CreateRefund обрабатывает POST /api/v1/admin/refunds
+func (h *RefundAdminHandler) CreateRefund(c *gin.Context) {
+	adminID := c.GetString(string(middleware.ContextUserIDKey))
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx := kafka.WithCorrelationID(c.Request.Context(), idempotencyKey)
+
+	body, err := req.HandleBody[CreateRefundRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	h.log.Infow("Admin requested refund. AdminID: %s, ChargeID: %s, InvoiceID: %s, Amount: %d, Reason: %s",
+		adminID, body.ChargeID, body.InvoiceID, body.Amount, body.Reason)
+
+	refund, err := h.service.CreateRefund(ctx, services.CreateRefundInput{
+		ChargeID:       body.ChargeID,
+		InvoiceID:      body.InvoiceID,
+		Amount:         body.Amount,
+		Reason:         body.Reason,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		h.respondError(c, "create refund", adminID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, refund, http.StatusCreated)
+}
+
+// GetRefund обрабатывает GET /api/v1/admin/refunds/:refund_id
+func (h *RefundAdminHandler) GetRefund(c *gin.Context) {
+	adminID := c.GetString(string(middleware.ContextUserIDKey))
+
+	refund, err := h.service.GetRefund(c.Request.Context(), c.Param("refund_id"))
+	if err != nil {
+		h.respondError(c, "get refund", adminID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, refund, http.StatusOK)
+}
+
+// CreateCreditNote обрабатывает POST /api/v1/admin/credit-notes
+func (h *RefundAdminHandler) CreateCreditNote(c *gin.Context) {
+	adminID := c.GetString(string(middleware.ContextUserIDKey))
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	ctx := kafka.WithCorrelationID(c.Request.Context(), idempotencyKey)
+
+	body, err := req.HandleBody[CreateCreditNoteRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		c.Abort()
+		return
+	}
+
+	h.log.Infow("Admin requested credit note. AdminID: %s, InvoiceID: %s, Amount: %d, Refund: %t, Reason: %s",
+		adminID, body.InvoiceID, body.Amount, body.Refund, body.Reason)
+
+	creditNote, err := h.service.CreateCreditNote(ctx, services.CreateCreditNoteInput{
+		InvoiceID:      body.InvoiceID,
+		Amount:         body.Amount,
+		Refund:         body.Refund,
+		Reason:         body.Reason,
+		Memo:           body.Memo,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		h.respondError(c, "create credit note", adminID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, creditNote, http.StatusCreated)
+}
+
+// GetCreditNote обрабатывает GET /api/v1/admin/credit-notes/:credit_note_id
+func (h *RefundAdminHandler) GetCreditNote(c *gin.Context) {
+	adminID := c.GetString(string(middleware.ContextUserIDKey))
+
+	creditNote, err := h.service.GetCreditNote(c.Request.Context(), c.Param("credit_note_id"))
+	if err != nil {
+		h.respondError(c, "get credit note", adminID, err)
+		return
+	}
+
+	res.JsonResponse(c.Writer, creditNote, http.StatusOK)
+}
+
+// ListInvoiceRefunds обрабатывает GET /api/v1/admin/invoices/:invoice_id/refunds
+func (h *RefundAdminHandler) ListInvoiceRefunds(c *gin.Context) {
+	adminID := c.GetString(string(middleware.ContextUserIDKey))
+
+	refunds, creditNotes, err := h.service.ListInvoiceRefunds(c.Request.Context(), c.Param("invoice_id"))
+	if err != nil {
+		h.respondError(c, "list invoice refunds", adminID, err)
+		return
+	}
+	if refunds == nil {
+		refunds = []*models.Refund{}
+	}
+	if creditNotes == nil {
+		creditNotes = []*models.CreditNote{}
+	}
+
+	res.JsonResponse(c.Writer, InvoiceRefundsResponse{Refunds: refunds, CreditNotes: creditNotes}, http.StatusOK)
+}
+
+// respondError отвечает ошибкой сервиса.
+func (h *RefundAdminHandler) respondError(c *gin.Context, operation, adminID string, err error) {
+	h.log.Warnw("Service failed to %s. AdminID: %s, Error: %v", operation, adminID, err)
+	statusCode, errMsg := mapErrorToHTTPStatus(err)
+	res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+	c.Abort()
+}
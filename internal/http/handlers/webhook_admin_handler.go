@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Dhoini/Payment-microservice/internal/middleware"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/Dhoini/Payment-microservice/pkg/req"
+	"github.com/Dhoini/Payment-microservice/pkg/res"
+)
+
+// WebhookAdminHandler обрабатывает административные HTTP запросы к журналу вебхуков Stripe.
+type WebhookAdminHandler struct {
+	service *services.PaymentService
+	log     *logger.Logger
+}
+
+// NewWebhookAdminHandler создает новый экземпляр WebhookAdminHandler.
+func NewWebhookAdminHandler(service *services.PaymentService, log *logger.Logger) *WebhookAdminHandler {
+	return &WebhookAdminHandler{
+		service: service,
+		log:     log,
+	}
+}
+
+// --- DTO ответа ---
+type WebhookEventResponse struct {
+	EventID         string          `json:"event_id"`
+	EventType       string          `json:"event_type"`
+	Status          string          `json:"status"`
+	Error           *string         `json:"error,omitempty"`
+	Attempts        int             `json:"attempts"`
+	SubscriptionID  *string         `json:"subscription_id,omitempty"`
+	StripeCreatedAt *time.Time      `json:"stripe_created_at,omitempty"`
+	NextAttemptAt   time.Time       `json:"next_attempt_at"`
+	ReceivedAt      time.Time       `json:"received_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	ProcessedAt     *time.Time      `json:"processed_at,omitempty"`
+	Payload         json.RawMessage `json:"payload,omitempty"`
+}
+
+type WebhookEventDetailsResponse struct {
+	WebhookEventResponse
+	History []*models.WebhookEventAttempt `json:"history"`
+}
+
+// --- DTO запроса ---
+type ReplayWebhookEventsRequest struct {
+	From   time.Time `json:"from" validate:"required"`
+	To     time.Time `json:"to" validate:"required"`
+	Status string    `json:"status"`
+	Type   string    `json:"type"`
+}
+
+// --- Обработчики ---
+
+// ListWebhookEvents обрабатывает GET /api/v1/admin/webhooks?status=&type=&from=&to=&limit=&offset=
+// from/to - время получения события в формате RFC 3339.
+func (h *WebhookAdminHandler) ListWebhookEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	from, err := parseOptionalTime(c.Query("from"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid from, expected RFC 3339 time"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+	to, err := parseOptionalTime(c.Query("to"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid to, expected RFC 3339 time"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+
+	limit, err := parseOptionalInt(c.Query("limit"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid limit"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+	offset, err := parseOptionalInt(c.Query("offset"))
+	if err != nil {
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Invalid offset"}, http.StatusBadRequest)
+		c.Abort()
+		return
+	}
+
+	filter := models.WebhookEventFilter{
+		Status:    c.Query("status"),
+		EventType: c.Query("type"),
+		From:      from,
+		To:        to,
+		Limit:     limit,
+		Offset:    offset,
+	}
+	h.log.Infow("Processing ListWebhookEvents. Status: %s, Type: %s, Limit: %d, Offset: %d", filter.Status, filter.EventType, limit, offset)
+
+	events, err := h.service.ListWebhookEvents(ctx, filter)
+	if err != nil {
+		h.log.Warnw("Service failed to list webhook events. Error: %v", err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	response := make([]WebhookEventResponse, len(events))
+	for i, event := range events {
+		response[i] = mapModelToWebhookEventResponse(event, false)
+	}
+	res.JsonResponse(c.Writer, response, http.StatusOK)
+}
+
+// GetWebhookEvent обрабатывает GET /api/v1/admin/webhooks/:event_id
+func (h *WebhookAdminHandler) GetWebhookEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	eventID := c.Param("event_id")
+
+	event, err := h.service.GetWebhookEvent(ctx, eventID)
+	if err != nil {
+		h.log.Warnw("Service failed to get webhook event. EventID: %s, Error: %v", eventID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	history, err := h.service.GetWebhookEventAttempts(ctx, eventID)
+	if err != nil {
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+	if history == nil {
+		history = []*models.WebhookEventAttempt{}
+	}
+
+	response := WebhookEventDetailsResponse{
+		WebhookEventResponse: mapModelToWebhookEventResponse(event, true),
+		History:              history,
+	}
+	res.JsonResponse(c.Writer, response, http.StatusOK)
+}
+
+// ReplayWebhookEvent обрабатывает POST /api/v1/admin/webhooks/:event_id/replay
+func (h *WebhookAdminHandler) ReplayWebhookEvent(c *gin.Context) {
+	ctx := c.Request.Context()
+	adminID := c.GetString(string(middleware.ContextUserIDKey))
+	eventID := c.Param("event_id")
+
+	h.log.Infow("Admin requested webhook event replay. AdminID: %s, EventID: %s", adminID, eventID)
+
+	result, err := h.service.ReplayWebhookEvent(ctx, eventID, adminID)
+	if err != nil {
+		h.log.Warnw("Service failed to replay webhook event. AdminID: %s, EventID: %s, Error: %v", adminID, eventID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	h.log.Infow("Webhook event replay finished. AdminID: %s, EventID: %s, Status: %s", adminID, eventID, result.Status)
+	res.JsonResponse(c.Writer, result, http.StatusOK)
+}
+
+// ReplayWebhookEvents обрабатывает POST /api/v1/admin/webhooks/replay (повтор всех событий, полученных за период)
+func (h *WebhookAdminHandler) ReplayWebhookEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	adminID := c.GetString(string(middleware.ContextUserIDKey))
+
+	body, err := req.HandleBody[ReplayWebhookEventsRequest](&c.Writer, c.Request, h.log)
+	if err != nil {
+		// HandleBody уже отправил ответ и залогировал ошибку
+		c.Abort()
+		return
+	}
+
+	h.log.Infow("Admin requested webhook events replay. AdminID: %s, From: %s, To: %s, Status: %s, Type: %s",
+		adminID, body.From, body.To, body.Status, body.Type)
+
+	filter := models.WebhookEventFilter{
+		Status:    body.Status,
+		EventType: body.Type,
+		From:      &body.From,
+		To:        &body.To,
+	}
+	summary, err := h.service.ReplayWebhookEvents(ctx, filter, adminID)
+	if err != nil {
+		h.log.Warnw("Service failed to replay webhook events. AdminID: %s, Error: %v", adminID, err)
+		statusCode, errMsg := mapErrorToHTTPStatus(err)
+		res.JsonResponse(c.Writer, res.ErrorResponse{Error: errMsg}, statusCode)
+		c.Abort()
+		return
+	}
+
+	h.log.Infow("Webhook events replay finished. AdminID: %s, Total: %d, Processed: %d, Stale: %d, Failed: %d, Skipped: %d",
+		adminID, summary.Total, summary.Processed, summary.Stale, summary.Failed, summary.Skipped)
+	res.JsonResponse(c.Writer, summary, http.StatusOK)
+}
+
+// mapModelToWebhookEventResponse преобразует запись журнала в DTO. Тело события включается только по запросу.
+func mapModelToWebhookEventResponse(event *models.WebhookEvent, withPayload bool) WebhookEventResponse {
+	response := WebhookEventResponse{
+		EventID:         event.EventID,
+		EventType:       event.EventType,
+		Status:          event.Status,
+		Error:           event.Error,
+		Attempts:        event.Attempts,
+		SubscriptionID:  event.SubscriptionID,
+		StripeCreatedAt: event.StripeCreatedAt,
+		NextAttemptAt:   event.NextAttemptAt,
+		ReceivedAt:      event.ReceivedAt,
+		UpdatedAt:       event.UpdatedAt,
+		ProcessedAt:     event.ProcessedAt,
+	}
+	if withPayload {
+		response.Payload = event.Payload
+	}
+	return response
+}
+
+// parseOptionalInt разбирает необязательный числовой параметр запроса (пустая строка - 0).
+func parseOptionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid number")
+	}
+	return n, nil
+}
+
+// parseOptionalTime разбирает необязательный параметр времени в формате RFC 3339.
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/json" // Для разбора данных события
 	"errors"
 	"io"
 	"net/http"
@@ -83,67 +82,24 @@ func (h *WebhookHandler) HandleStripeWebhook(c *gin.Context) {
 	// Логируем успешное получение и верификацию
 	h.log.Infow("Received verified Stripe event", "eventID", event.ID, "eventType", event.Type)
 
-	// 4. Извлечение данных и вызов сервиса
-	// Метод сервиса HandleWebhookEvent ожидает: ctx, eventType, stripeSubscriptionID, data
-	// Попытаемся извлечь ID подписки и передать объект данных.
-
-	var subID string
-	var rawData map[string]interface{} // Передаем сырые данные объекта в сервис
-
-	// Пытаемся разобрать event.Data.Raw, чтобы получить доступ к полям объекта
-	if err := json.Unmarshal(event.Data.Raw, &rawData); err != nil {
-		h.log.Errorw("Failed to unmarshal event.Data.Raw", "error", err, "eventID", event.ID, "eventType", event.Type)
-		// Если не можем разобрать данные, скорее всего, дальнейшая обработка невозможна
-		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Failed to parse event data"}, http.StatusInternalServerError)
-		c.Abort()
-		return
-	}
-
-	// Пытаемся найти ID подписки для частых типов событий
-	// Для событий invoice.* ID подписки обычно в поле "subscription"
-	if subVal, ok := rawData["subscription"].(string); ok && subVal != "" {
-		subID = subVal
-	} else if idVal, ok := rawData["id"].(string); ok {
-		// Для событий customer.subscription.* или самого объекта подписки, ID может быть в "id"
-		// Добавим проверку, что объект действительно подписка (если возможно)
-		if objectType, ok := rawData["object"].(string); ok && objectType == "subscription" {
-			subID = idVal
-		} else if objectType == "invoice" {
-			// Если это invoice, но subscription не нашли выше, может его там нет (разовый платеж?)
-			h.log.Infow("Event is invoice, but subscription ID not found directly", "eventID", event.ID, "invoiceID", idVal)
-		} else {
-			// ID найден, но тип объекта не 'subscription'. Это может быть Customer, PaymentIntent и т.д.
-			// В таких случаях subID остается пустым, если только сервис не ожидает ID другого объекта.
-			h.log.Debugw("Found ID in event data, but object is not a subscription", "eventID", event.ID, "objectType", objectType, "objectID", idVal)
-		}
-	}
-
-	if subID == "" {
-		// Если не смогли извлечь ID подписки автоматически
-		h.log.Warnw("Could not reliably determine Stripe Subscription ID from webhook event data", "eventID", event.ID, "eventType", event.Type)
-		// Сервис должен быть готов обработать событие без subID, если это применимо для данного eventType
-	} else {
-		h.log.Debugw("Determined Stripe Subscription ID for event", "eventID", event.ID, "eventType", event.Type, "subscriptionID", subID)
-	}
-
-	// 5. Вызов метода сервиса для обработки логики события
-	// Передаем тип события, найденный (или пустой) ID подписки и разобранные данные объекта
-	err = h.service.HandleWebhookEvent(ctx, event.Type, subID, rawData)
+	// 4. Сохранение события в очередь обработки (дедупликация по ID события)
+	// Бизнес-логика выполняется асинхронно воркерами очереди, поэтому Stripe получает ответ сразу
+	// и не повторяет доставку из-за медленной обработки.
+	duplicate, err := h.service.EnqueueWebhookEvent(ctx, event, payload)
 	if err != nil {
-		// Логируем ошибку из сервисного слоя
-		h.log.Errorw("Error processing webhook event in service", "error", err, "eventID", event.ID, "eventType", event.Type)
-
-		// Отвечаем Stripe ошибкой сервера. Stripe попытается повторить отправку.
-		// Если ошибка в нашей логике постоянная, это приведет к повторным ошибкам.
-		// В реальном приложении может потребоваться более умная обработка ошибок.
+		// Событие не сохранено - отвечаем ошибкой, чтобы Stripe повторил доставку позже
+		h.log.Errorw("Failed to enqueue webhook event", "error", err, "eventID", event.ID, "eventType", event.Type)
 		res.JsonResponse(c.Writer, res.ErrorResponse{Error: "Internal server error processing webhook"}, http.StatusInternalServerError)
 		c.Abort()
 		return
 	}
 
-	// 6. Отправка успешного ответа Stripe (200 OK)
-	// Важно ответить быстро, чтобы Stripe не считал доставку неуспешной.
-	h.log.Infow("Successfully processed webhook event", "eventID", event.ID, "eventType", event.Type)
+	// 5. Отправка успешного ответа Stripe (200 OK)
+	if duplicate {
+		h.log.Infow("Webhook event already received, acknowledging duplicate", "eventID", event.ID, "eventType", event.Type)
+	} else {
+		h.log.Infow("Webhook event queued for processing", "eventID", event.ID, "eventType", event.Type)
+	}
 	// Не используем res.JsonResponse, так как тело ответа не нужно.
 	c.Status(http.StatusOK)
 }
@@ -0,0 +1,49 @@
+package routes
+
+import "github.com/Dhoini/Payment-microservice/internal/authz"
+
+// Policies политики доступа к защищенным маршрутам (ключ - метод и шаблон пути Gin).
+// Маршрут без политики недоступен: новый маршрут нужно добавить и сюда.
+var Policies = map[string]authz.Policy{
+	// Подписки
+	"POST /api/v1/subscriptions":                                     authz.UserWrite,
+	"GET /api/v1/subscriptions/:subscription_id":                     authz.UserRead,
+	"PATCH /api/v1/subscriptions/:subscription_id":                   authz.UserWrite,
+	"GET /api/v1/subscriptions/:subscription_id/plan-change-preview": authz.UserRead,
+	"DELETE /api/v1/subscriptions/:subscription_id":                  authz.UserWrite,
+	"POST /api/v1/subscriptions/:subscription_id/resume":             authz.UserWrite,
+	"POST /api/v1/subscriptions/:subscription_id/pause":              authz.UserWrite,
+	"DELETE /api/v1/subscriptions/:subscription_id/pause":            authz.UserWrite,
+
+	// Данные пользователя
+	"GET /api/v1/users/:user_id/subscriptions": authz.UserRead,
+	"GET /api/v1/users/:user_id/invoices":      authz.UserRead,
+	"GET /api/v1/users/:user_id/payments":      authz.UserRead,
+
+	// Счета и разовые платежи
+	"GET /api/v1/invoices/:invoice_id": authz.UserRead,
+	"POST /api/v1/payments":            authz.UserWrite,
+	"GET /api/v1/payments/:payment_id": authz.UserRead,
+
+	// Карты
+	"POST /api/v1/payment-methods/setup-intent":               authz.UserWrite,
+	"GET /api/v1/payment-methods":                             authz.UserRead,
+	"POST /api/v1/payment-methods":                            authz.UserWrite,
+	"DELETE /api/v1/payment-methods/:payment_method_id":       authz.UserWrite,
+	"POST /api/v1/payment-methods/:payment_method_id/default": authz.UserWrite,
+
+	// Checkout и Billing Portal
+	"POST /api/v1/checkout/sessions":       authz.UserWrite,
+	"POST /api/v1/billing-portal/sessions": authz.UserWrite,
+
+	// Администрирование: support только читает
+	"GET /api/v1/admin/webhooks":                     authz.StaffRead,
+	"GET /api/v1/admin/webhooks/:event_id":           authz.StaffRead,
+	"POST /api/v1/admin/webhooks/:event_id/replay":   authz.AdminWrite,
+	"POST /api/v1/admin/webhooks/replay":             authz.AdminWrite,
+	"POST /api/v1/admin/refunds":                     authz.AdminWrite,
+	"GET /api/v1/admin/refunds/:refund_id":           authz.StaffRead,
+	"POST /api/v1/admin/credit-notes":                authz.AdminWrite,
+	"GET /api/v1/admin/credit-notes/:credit_note_id": authz.StaffRead,
+	"GET /api/v1/admin/invoices/:invoice_id/refunds": authz.StaffRead,
+}
@@ -19,14 +19,17 @@ func SetupRoutes(router *gin.Engine, app *app.App, log *logger.Logger) {
 		// Обработчик вебхуков Stripe
 		api.POST("/webhooks/stripe", app.WebhookHandler.HandleStripeWebhook)
 
+		// Каталог планов (доступные для оформления подписки цены)
+		api.GET("/plans", app.PlanHandler.ListPlans)
+
 		// Здоровье сервиса
 		api.GET("/health", func(c *gin.Context) {
 			c.JSON(200, gin.H{"status": "ok"})
 		})
 
-		// Защищенные маршруты (требуют аутентификации)
+		// Защищенные маршруты (требуют аутентификации; доступ определяется таблицей Policies)
 		auth := api.Group("")
-		auth.Use(app.AuthMiddleware.RequireAuth())
+		auth.Use(app.AuthMiddleware.RequireAuth(), app.AuthMiddleware.Authorize(Policies))
 
 		// Подписки
 		subscriptions := auth.Group("/subscriptions")
@@ -37,8 +40,21 @@ func SetupRoutes(router *gin.Engine, app *app.App, log *logger.Logger) {
 			// Получить подписку по ID
 			subscriptions.GET("/:subscription_id", app.PaymentHandler.GetSubscription)
 
-			// Отменить подписку
+			// Сменить план (сразу с перерасчетом или с начала следующего периода)
+			subscriptions.PATCH("/:subscription_id", app.PaymentHandler.ChangePlan)
+
+			// Предпросмотр ближайшего счета при смене плана
+			subscriptions.GET("/:subscription_id/plan-change-preview", app.PaymentHandler.PreviewPlanChange)
+
+			// Отменить подписку (сразу или в конце периода: ?mode=at_period_end или тело {"mode": ...})
 			subscriptions.DELETE("/:subscription_id", app.PaymentHandler.CancelSubscription)
+
+			// Снять отмену, запланированную на конец периода
+			subscriptions.POST("/:subscription_id/resume", app.PaymentHandler.ResumeSubscription)
+
+			// Приостановить списания (тело: behavior, resumes_at) и возобновить их
+			subscriptions.POST("/:subscription_id/pause", app.PaymentHandler.PauseSubscription)
+			subscriptions.DELETE("/:subscription_id/pause", app.PaymentHandler.UnpauseSubscription)
 		}
 
 		// Подписки пользователя
@@ -46,7 +62,94 @@ func SetupRoutes(router *gin.Engine, app *app.App, log *logger.Logger) {
 		{
 			// Получить все подписки пользователя
 			users.GET("/:user_id/subscriptions", app.PaymentHandler.GetUserSubscriptions)
+
+			// История счетов пользователя (пагинация: limit, offset)
+			users.GET("/:user_id/invoices", app.InvoiceHandler.ListUserInvoices)
+
+			// Разовые платежи пользователя (пагинация: limit, offset)
+			users.GET("/:user_id/payments", app.OneTimePayments.ListUserPayments)
+		}
+
+		// Счета
+		invoices := auth.Group("/invoices")
+		{
+			// Получить счет по ID счета Stripe
+			invoices.GET("/:invoice_id", app.InvoiceHandler.GetInvoice)
 		}
+
+		// Разовые платежи (PaymentIntents)
+		payments := auth.Group("/payments")
+		{
+			// Создать платеж (тело: user_email, amount, currency, description, metadata);
+			// client_secret из ответа подтверждается на фронтенде через Stripe.js
+			payments.POST("", app.OneTimePayments.CreatePayment)
+
+			// Получить платеж по ID PaymentIntent
+			payments.GET("/:payment_id", app.OneTimePayments.GetPayment)
+		}
+
+		// Карты клиента Stripe текущего пользователя
+		paymentMethods := auth.Group("/payment-methods")
+		{
+			// SetupIntent для сохранения новой карты через Stripe.js
+			paymentMethods.POST("/setup-intent", app.PaymentMethods.CreateSetupIntent)
+
+			// Список карт
+			paymentMethods.GET("", app.PaymentMethods.ListPaymentMethods)
+
+			// Привязать карту (тело: payment_method_id, set_default)
+			paymentMethods.POST("", app.PaymentMethods.AttachPaymentMethod)
+
+			// Отвязать карту
+			paymentMethods.DELETE("/:payment_method_id", app.PaymentMethods.DetachPaymentMethod)
+
+			// Сделать карту картой по умолчанию
+			paymentMethods.POST("/:payment_method_id/default", app.PaymentMethods.SetDefaultPaymentMethod)
+		}
+
+		// Оформление подписки на странице Stripe Checkout (тело: plan_id, user_email, success_url, cancel_url);
+		// локальная подписка создается из вебхука checkout.session.completed
+		auth.POST("/checkout/sessions", app.CheckoutHandler.CreateCheckoutSession)
+
+		// Billing Portal текущего пользователя (тело: return_url, необязательно)
+		auth.POST("/billing-portal/sessions", app.CheckoutHandler.CreateBillingPortalSession)
+
+		// Административные маршруты (роль admin; support - только чтение, см. Policies)
+		admin := api.Group("/admin")
+		admin.Use(app.AuthMiddleware.RequireAuth(), app.AuthMiddleware.Authorize(Policies))
+
+		// Журнал и очередь вебхуков Stripe
+		webhooks := admin.Group("/webhooks")
+		{
+			// Список событий (фильтры: status, type; пагинация: limit, offset)
+			webhooks.GET("", app.WebhookAdmin.ListWebhookEvents)
+
+			// Событие по ID события Stripe (тело и история обработки)
+			webhooks.GET("/:event_id", app.WebhookAdmin.GetWebhookEvent)
+
+			// Повторить одно событие
+			webhooks.POST("/:event_id/replay", app.WebhookAdmin.ReplayWebhookEvent)
+
+			// Повторить все события, полученные за период (тело: from, to, status, type)
+			webhooks.POST("/replay", app.WebhookAdmin.ReplayWebhookEvents)
+		}
+
+		// Возвраты платежей (тело: charge_id или invoice_id, amount, reason)
+		refunds := admin.Group("/refunds")
+		{
+			refunds.POST("", app.RefundAdmin.CreateRefund)
+			refunds.GET("/:refund_id", app.RefundAdmin.GetRefund)
+		}
+
+		// Кредит-ноты по счетам (тело: invoice_id, amount, refund, reason, memo)
+		creditNotes := admin.Group("/credit-notes")
+		{
+			creditNotes.POST("", app.RefundAdmin.CreateCreditNote)
+			creditNotes.GET("/:credit_note_id", app.RefundAdmin.GetCreditNote)
+		}
+
+		// Возвраты и кредит-ноты по счету
+		admin.GET("/invoices/:invoice_id/refunds", app.RefundAdmin.ListInvoiceRefunds)
 	}
 
 	log.Infow("API routes successfully configured")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	"github.com/Dhoini/Payment-microservice/internal/authz"
 	"github.com/Dhoini/Payment-microservice/internal/middleware" // Используем тот же пакет для ключа и валидатора
 	"github.com/Dhoini/Payment-microservice/pkg/logger"
 	"google.golang.org/grpc"
@@ -12,15 +13,29 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// onBehalfOfMetadata ключ метаданных, которым привилегированный вызывающий указывает пользователя,
+// если в запросе нет user_id (например, для потоковых методов).
+const onBehalfOfMetadata = "x-on-behalf-of"
+
+// userScopedRequest запрос с полем user_id (геттер генерирует protoc).
+type userScopedRequest interface {
+	GetUserId() string
+}
+
 type AuthInterceptor struct {
-	log       *logger.Logger
-	validator middleware.TokenValidator
+	log             *logger.Logger
+	validator       middleware.TokenValidator
+	policies        map[string]authz.Policy // Ключ - полное имя метода (/payment.PaymentService/...)
+	serviceAccounts []string
 }
 
-func NewAuthInterceptor(log *logger.Logger, validator middleware.TokenValidator) *AuthInterceptor {
+// NewAuthInterceptor создает интерцептор аутентификации и авторизации. Метод без политики в policies недоступен.
+func NewAuthInterceptor(log *logger.Logger, validator middleware.TokenValidator, policies map[string]authz.Policy, serviceAccounts []string) *AuthInterceptor {
 	return &AuthInterceptor{
-		log:       log,
-		validator: validator,
+		log:             log,
+		validator:       validator,
+		policies:        policies,
+		serviceAccounts: serviceAccounts,
 	}
 }
 
@@ -32,47 +47,100 @@ func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		// Получаем метаданные из контекста
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			// Корректное логирование
-			i.log.Warnw("gRPC Auth: Missing metadata for method: %s", info.FullMethod)
-			return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+		var targetUserID string
+		if r, ok := req.(userScopedRequest); ok {
+			targetUserID = r.GetUserId()
 		}
-
-		// Ищем заголовок авторизации
-		authHeaders := md.Get("authorization")
-		if len(authHeaders) == 0 {
-			// Корректное логирование
-			i.log.Warnw("gRPC Auth: Missing authorization header for method: %s", info.FullMethod)
-			return nil, status.Errorf(codes.Unauthenticated, "missing authorization header")
-		}
-
-		// Извлекаем токен (ожидаем "Bearer <token>")
-		authHeader := authHeaders[0]
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			// Корректное логирование
-			i.log.Warnw("gRPC Auth: Invalid authorization header format for method: %s", info.FullMethod)
-			return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header format")
+		newCtx, err := i.authenticate(ctx, info.FullMethod, targetUserID)
+		if err != nil {
+			return nil, err
 		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		return handler(newCtx, req)
+	}
+}
 
-		// Валидируем токен
-		claims, err := i.validator.Validate(tokenString)
+// Stream возвращает StreamServerInterceptor для проверки JWT в потоковых методах.
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		// Запрос потока еще не прочитан: пользователя можно указать только метаданными x-on-behalf-of
+		newCtx, err := i.authenticate(ss.Context(), info.FullMethod, "")
 		if err != nil {
-			// Корректное логирование
-			i.log.Warnw("gRPC Auth: Invalid token for method %s. Error: %v", info.FullMethod, err)
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+			return err
 		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: newCtx})
+	}
+}
 
-		userID := claims.Subject // Используем Subject (sub)
-		if userID == "" {
-			i.log.Warnw("gRPC Auth: User ID (sub) missing in token for method %s", info.FullMethod)
-			return nil, status.Errorf(codes.Unauthenticated, "User ID (sub) missing in token")
+// authenticate проверяет токен из метаданных, применяет политику метода и возвращает контекст
+// с вызывающим и ID пользователя, над данными которого выполняется операция (targetUserID -
+// user_id из запроса, если он есть).
+func (i *AuthInterceptor) authenticate(ctx context.Context, method, targetUserID string) (context.Context, error) {
+	// Получаем метаданные из контекста
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		// Корректное логирование
+		i.log.Warnw("gRPC Auth: Missing metadata for method: %s", method)
+		return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+
+	// Ищем заголовок авторизации
+	authHeaders := md.Get("authorization")
+	if len(authHeaders) == 0 {
+		// Корректное логирование
+		i.log.Warnw("gRPC Auth: Missing authorization header for method: %s", method)
+		return nil, status.Errorf(codes.Unauthenticated, "missing authorization header")
+	}
+
+	// Извлекаем токен (ожидаем "Bearer <token>")
+	authHeader := authHeaders[0]
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		// Корректное логирование
+		i.log.Warnw("gRPC Auth: Invalid authorization header format for method: %s", method)
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization header format")
+	}
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// Валидируем токен
+	claims, err := i.validator.Validate(tokenString)
+	if err != nil {
+		// Корректное логирование
+		i.log.Warnw("gRPC Auth: Invalid token for method %s. Error: %v", method, err)
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+
+	userID := claims.Subject // Используем Subject (sub)
+	if userID == "" {
+		i.log.Warnw("gRPC Auth: User ID (sub) missing in token for method %s", method)
+		return nil, status.Errorf(codes.Unauthenticated, "User ID (sub) missing in token")
+	}
+	principal := claims.Principal(i.serviceAccounts)
+	policy, ok := i.policies[method]
+	if !ok {
+		i.log.Errorw("gRPC Auth: No authorization policy for method %s", method)
+		return nil, status.Errorf(codes.PermissionDenied, "method is not allowed")
+	}
+	if targetUserID == "" {
+		if values := md.Get(onBehalfOfMetadata); len(values) > 0 {
+			targetUserID = values[0]
 		}
-		// Добавляем userID из 'sub' в контекст
-		newCtx := context.WithValue(ctx, middleware.ContextUserIDKey, userID)
-		i.log.Debugw("User authenticated via gRPC. UserID (from sub): %s, Method: %s", userID, info.FullMethod)
-		return handler(newCtx, req)
 	}
+	if err := policy.Resolve(principal, targetUserID); err != nil {
+		i.log.Warnw("gRPC Auth: Access denied for method %s. Subject: %s, Roles: %v, TargetID: %s, Error: %v",
+			method, userID, principal.Roles, targetUserID, err)
+		return nil, status.Errorf(codes.PermissionDenied, "insufficient permissions")
+	}
+	if principal.OnBehalf() {
+		i.log.Infow("AUDIT: acting on behalf of user via gRPC. Subject: %s, Roles: %v, UserID: %s, Method: %s, Access: %s",
+			userID, principal.Roles, principal.UserID, method, policy.Access)
+	}
+
+	// Добавляем пользователя, над данными которого выполняется операция, и вызывающего в контекст
+	i.log.Debugw("User authenticated via gRPC. UserID (from sub): %s, Method: %s", userID, method)
+	ctx = authz.WithPrincipal(ctx, principal)
+	return context.WithValue(ctx, middleware.ContextUserIDKey, principal.UserID), nil
 }
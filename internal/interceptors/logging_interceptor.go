@@ -0,0 +1,78 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// LoggingInterceptor логирует каждый вызов: метод, код ответа, длительность, ID запроса и адрес клиента.
+// Ошибки клиента (InvalidArgument, NotFound, ...) пишутся как предупреждения, ошибки сервера - как ошибки.
+type LoggingInterceptor struct {
+	log *logger.Logger
+}
+
+func NewLoggingInterceptor(log *logger.Logger) *LoggingInterceptor {
+	return &LoggingInterceptor{log: log}
+}
+
+// Unary возвращает UnaryServerInterceptor с логированием вызова.
+func (i *LoggingInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		i.logCall(ctx, info.FullMethod, "unary", start, err)
+		return resp, err
+	}
+}
+
+// Stream возвращает StreamServerInterceptor с логированием потока (после его завершения).
+func (i *LoggingInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		i.log.Debugw("gRPC stream started. Method: %s, RequestID: %s", info.FullMethod, RequestIDFromContext(ss.Context()))
+		err := handler(srv, ss)
+		i.logCall(ss.Context(), info.FullMethod, streamType(info), start, err)
+		return err
+	}
+}
+
+// logCall пишет запись о завершенном вызове с уровнем по коду ответа.
+func (i *LoggingInterceptor) logCall(ctx context.Context, method, kind string, start time.Time, err error) {
+	code := status.Code(err)
+	clientAddr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		clientAddr = p.Addr.String()
+	}
+
+	format := "gRPC call handled. Method: %s, Type: %s, Code: %s, LatencyMs: %d, RequestID: %s, Client: %s"
+	args := []interface{}{method, kind, code, time.Since(start).Milliseconds(), RequestIDFromContext(ctx), clientAddr}
+	if err != nil {
+		format += ", Error: %v"
+		args = append(args, status.Convert(err).Message())
+	}
+
+	switch code {
+	case codes.OK, codes.Canceled:
+		i.log.Infow(format, args...)
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented, codes.Unavailable, codes.DeadlineExceeded:
+		i.log.Errorw(format, args...)
+	default:
+		i.log.Warnw(format, args...)
+	}
+}
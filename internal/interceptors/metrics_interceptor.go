@@ -0,0 +1,64 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// MetricsInterceptor считает вызовы и их длительность по методу, типу вызова и коду ответа.
+type MetricsInterceptor struct {
+	handled *metrics.CounterVec
+	latency *metrics.HistogramVec
+}
+
+// NewMetricsInterceptor регистрирует метрики gRPC сервера в registry.
+func NewMetricsInterceptor(registry *metrics.Registry) *MetricsInterceptor {
+	return &MetricsInterceptor{
+		handled: registry.NewCounterVec("grpc_server_handled_total",
+			"Total number of RPCs completed on the server, regardless of success or failure.",
+			"grpc_method", "grpc_type", "grpc_code"),
+		latency: registry.NewHistogramVec("grpc_server_handling_seconds",
+			"Response latency (seconds) of gRPC that had been application-level handled by the server.",
+			nil, "grpc_method", "grpc_type", "grpc_code"),
+	}
+}
+
+// Unary возвращает UnaryServerInterceptor, записывающий метрики вызова.
+func (i *MetricsInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		i.observe(info.FullMethod, "unary", start, err)
+		return resp, err
+	}
+}
+
+// Stream возвращает StreamServerInterceptor, записывающий метрики потока.
+func (i *MetricsInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		i.observe(info.FullMethod, streamType(info), start, err)
+		return err
+	}
+}
+
+func (i *MetricsInterceptor) observe(method, kind string, start time.Time, err error) {
+	code := status.Code(err).String()
+	i.handled.Inc(method, kind, code)
+	i.latency.Observe(time.Since(start).Seconds(), method, kind, code)
+}
@@ -0,0 +1,61 @@
+package interceptors
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryInterceptor перехватывает панику в обработчике и возвращает клиенту codes.Internal,
+// не роняя сервер.
+type RecoveryInterceptor struct {
+	log *logger.Logger
+}
+
+func NewRecoveryInterceptor(log *logger.Logger) *RecoveryInterceptor {
+	return &RecoveryInterceptor{log: log}
+}
+
+// Unary возвращает UnaryServerInterceptor с восстановлением после паники.
+func (i *RecoveryInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = i.recovered(ctx, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// Stream возвращает StreamServerInterceptor с восстановлением после паники.
+func (i *RecoveryInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = i.recovered(ss.Context(), info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recovered логирует панику со стеком и возвращает ошибку для клиента (без деталей паники).
+func (i *RecoveryInterceptor) recovered(ctx context.Context, method string, r interface{}) error {
+	i.log.Errorw("gRPC panic recovered. Method: %s, RequestID: %s, Panic: %v\n%s", method, RequestIDFromContext(ctx), r, debug.Stack())
+	return status.Errorf(codes.Internal, "internal server error")
+}
@@ -0,0 +1,79 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDMetadata ключ метаданных с ID запроса (входящий и в заголовках ответа).
+const RequestIDMetadata = "x-request-id"
+
+// maxRequestIDLength ограничивает ID запроса от клиента, чтобы он не раздувал логи и события.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext возвращает ID запроса из контекста или пустую строку.
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// RequestIDInterceptor берет ID запроса из метаданных x-request-id (или создает новый), кладет его
+// в контекст (и как correlation ID событий Kafka) и возвращает клиенту в заголовках ответа.
+type RequestIDInterceptor struct{}
+
+func NewRequestIDInterceptor() *RequestIDInterceptor {
+	return &RequestIDInterceptor{}
+}
+
+// Unary возвращает UnaryServerInterceptor, проставляющий ID запроса.
+func (i *RequestIDInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, requestID := i.withRequestID(ctx)
+		// Ошибка возможна только если заголовки уже отправлены - для unary это исключено
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, requestID))
+		return handler(ctx, req)
+	}
+}
+
+// Stream возвращает StreamServerInterceptor, проставляющий ID запроса.
+func (i *RequestIDInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, requestID := i.withRequestID(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(RequestIDMetadata, requestID))
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// withRequestID возвращает контекст с ID запроса. Ключ идемпотентности, если обработчик его передаст,
+// заменит correlation ID событий Kafka.
+func (i *RequestIDInterceptor) withRequestID(ctx context.Context) (context.Context, string) {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadata); len(values) > 0 && len(values[0]) <= maxRequestIDLength {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return kafka.WithCorrelationID(ctx, requestID), requestID
+}
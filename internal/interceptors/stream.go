@@ -0,0 +1,30 @@
+package interceptors
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// contextStream подменяет контекст потока (например, контекстом с ID пользователя или ID запроса).
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context возвращает подмененный контекст.
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// streamType возвращает тип потокового вызова для логов и метрик.
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionEventSchemaVersion версия формата конверта события.
+// Увеличивается при несовместимых изменениях, чтобы консьюмеры могли различать форматы.
+const SubscriptionEventSchemaVersion = 1
+
+// SubscriptionEventType тип перехода в жизненном цикле подписки.
+type SubscriptionEventType string
+
+const (
+	EventSubscriptionCreated     SubscriptionEventType = "subscription.created"
+	EventSubscriptionActivated   SubscriptionEventType = "subscription.activated"
+	EventSubscriptionPastDue     SubscriptionEventType = "subscription.past_due"
+	EventSubscriptionCanceled    SubscriptionEventType = "subscription.canceled"
+	EventSubscriptionRenewed     SubscriptionEventType = "subscription.renewed"
+	EventSubscriptionTrialEnding SubscriptionEventType = "subscription.trial_ending"
+	EventSubscriptionPlanChanged SubscriptionEventType = "subscription.plan_changed"
+	// Запланирована или снята отмена в конце периода (cancel_at_period_end/cancel_at)
+	EventSubscriptionCancellationChanged SubscriptionEventType = "subscription.cancellation_changed"
+	EventSubscriptionPaused              SubscriptionEventType = "subscription.paused"  // Списания приостановлены (pause_collection)
+	EventSubscriptionResumed             SubscriptionEventType = "subscription.resumed" // Списания возобновлены
+)
+
+// subscriptionEventTopics сопоставляет тип события с топиком Kafka.
+var subscriptionEventTopics = map[SubscriptionEventType]string{
+	EventSubscriptionCreated:             TopicSubscriptionCreated,
+	EventSubscriptionActivated:           TopicSubscriptionActivated,
+	EventSubscriptionPastDue:             TopicSubscriptionPastDue,
+	EventSubscriptionCanceled:            TopicSubscriptionCancelled,
+	EventSubscriptionRenewed:             TopicSubscriptionRenewed,
+	EventSubscriptionTrialEnding:         TopicSubscriptionTrialEnding,
+	EventSubscriptionPlanChanged:         TopicSubscriptionPlanChanged,
+	EventSubscriptionCancellationChanged: TopicSubscriptionCancellationChanged,
+	EventSubscriptionPaused:              TopicSubscriptionPaused,
+	EventSubscriptionResumed:             TopicSubscriptionResumed,
+}
+
+// TopicForEventType возвращает топик Kafka для типа события.
+func TopicForEventType(eventType SubscriptionEventType) (string, error) {
+	topic, ok := subscriptionEventTopics[eventType]
+	if !ok {
+		return "", fmt.Errorf("kafka: unknown subscription event type %q", eventType)
+	}
+	return topic, nil
+}
+
+// SubscriptionEvent версионированный конверт события подписки, который публикуется в Kafka.
+type SubscriptionEvent struct {
+	EventID        string                `json:"event_id"`                  // Уникальный ID события (для дедупликации у консьюмеров)
+	EventType      SubscriptionEventType `json:"event_type"`                // Тип перехода
+	Version        int                   `json:"version"`                   // Версия формата конверта
+	OccurredAt     time.Time             `json:"occurred_at"`               // Время, когда произошло изменение
+	CorrelationID  string                `json:"correlation_id,omitempty"`  // ID запроса/вебхука, породившего событие
+	PreviousStatus string                `json:"previous_status,omitempty"` // Статус до перехода (пустой для created)
+	NewStatus      string                `json:"new_status"`                // Статус после перехода
+	Subscription   *models.Subscription  `json:"subscription"`              // Снимок подписки после перехода
+}
+
+// NewSubscriptionEvent создает конверт события с новым EventID.
+// CorrelationID берется из контекста (см. WithCorrelationID).
+func NewSubscriptionEvent(ctx context.Context, eventType SubscriptionEventType, previousStatus string, subscription *models.Subscription) *SubscriptionEvent {
+	return &SubscriptionEvent{
+		EventID:        uuid.NewString(),
+		EventType:      eventType,
+		Version:        SubscriptionEventSchemaVersion,
+		OccurredAt:     time.Now().UTC(),
+		CorrelationID:  CorrelationIDFromContext(ctx),
+		PreviousStatus: previousStatus,
+		NewStatus:      subscription.Status,
+		Subscription:   subscription,
+	}
+}
+
+// correlationIDKey ключ контекста для correlation ID.
+type correlationIDKey struct{}
+
+// WithCorrelationID сохраняет correlation ID в контексте, чтобы он попал в события, созданные в рамках запроса.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	if correlationID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext возвращает correlation ID из контекста или пустую строку.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
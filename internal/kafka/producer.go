@@ -7,38 +7,63 @@ import (
 	"fmt"
 	"time" // Для таймаутов
 
-	"github.com/Dhoini/Payment-microservice/internal/models" // Ваша модель подписки
-	"github.com/Dhoini/Payment-microservice/pkg/logger"      // Ваш логгер
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/Dhoini/Payment-microservice/pkg/logger" // Ваш логгер
 
 	"github.com/segmentio/kafka-go" // Библиотека Kafka
 )
 
 // Constants for Kafka topics used by the payment service
 // (Интерфейс и константы можно вынести в отдельный файл, например, internal/kafka/kafka.go)
+// Каждый переход жизненного цикла подписки публикуется в свой топик (см. events.go).
 const (
-	TopicSubscriptionCreated   = "subscription_created"
-	TopicSubscriptionCancelled = "subscription_cancelled"
+	TopicSubscriptionCreated             = "subscription_created"
+	TopicSubscriptionActivated           = "subscription_activated"
+	TopicSubscriptionPastDue             = "subscription_past_due"
+	TopicSubscriptionCancelled           = "subscription_cancelled"
+	TopicSubscriptionRenewed             = "subscription_renewed"
+	TopicSubscriptionTrialEnding         = "subscription_trial_ending"
+	TopicSubscriptionPlanChanged         = "subscription_plan_changed"
+	TopicSubscriptionCancellationChanged = "subscription_cancellation_changed"
+	TopicSubscriptionPaused              = "subscription_paused"
+	TopicSubscriptionResumed             = "subscription_resumed"
+
+	// Возвраты и кредит-ноты (см. refund_events.go)
+	TopicRefundCreated     = "refund_created"
+	TopicRefundUpdated     = "refund_updated"
+	TopicCreditNoteCreated = "credit_note_created"
+	TopicCreditNoteVoided  = "credit_note_voided"
 	// Добавьте другие топики при необходимости
 )
 
 // Producer определяет интерфейс для публикации сообщений в Kafka.
 type Producer interface {
-	// PublishSubscriptionEvent отправляет событие, связанное с подпиской.
+	// PublishSubscriptionEvent отправляет конверт события подписки в топик, соответствующий его типу.
 	// Ключ сообщения (Key) используется Kafka для партиционирования.
 	// Часто используют UserID или SubscriptionID как ключ.
-	PublishSubscriptionEvent(ctx context.Context, topic string, subscription *models.Subscription) error
+	PublishSubscriptionEvent(ctx context.Context, event *SubscriptionEvent) error
+	// Publish отправляет уже сериализованное сообщение (используется релеем outbox).
+	Publish(ctx context.Context, topic string, key, value []byte) error
 	// Close закрывает соединение продюсера Kafka.
 	Close() error
 }
 
 // kafkaProducer реализует интерфейс Producer, используя segmentio/kafka-go.
 type kafkaProducer struct {
-	writer *kafka.Writer  // Объект для записи сообщений
-	log    *logger.Logger // Ваш логгер
+	writer    *kafka.Writer         // Объект для записи сообщений
+	published *metrics.CounterVec   // Публикации по топику и результату (success/failure)
+	latency   *metrics.HistogramVec // Длительность публикации по топику
+	log       *logger.Logger        // Ваш логгер
 }
 
-// NewKafkaProducer создает и настраивает новый продюсер Kafka.
-func NewKafkaProducer(brokers []string, log *logger.Logger) (Producer, error) {
+// Результаты публикации для метрики kafka_messages_published_total
+const (
+	publishResultSuccess = "success"
+	publishResultFailure = "failure"
+)
+
+// NewKafkaProducer создает и настраивает новый продюсер Kafka. Публикации считаются в registry по топику.
+func NewKafkaProducer(brokers []string, registry *metrics.Registry, log *logger.Logger) (Producer, error) {
 	// Проверяем, что список брокеров не пуст
 	if len(brokers) == 0 {
 		log.Errorw("Kafka brokers list is empty in config, cannot create producer")
@@ -65,31 +90,52 @@ func NewKafkaProducer(brokers []string, log *logger.Logger) (Producer, error) {
 	// Можно добавить .Named("KafkaProducer") или аналогичный метод вашего логгера
 	return &kafkaProducer{
 		writer: writer,
-		log:    log,
+		published: registry.NewCounterVec("kafka_messages_published_total",
+			"Total number of Kafka publish attempts by topic and result (success or failure).",
+			"topic", "result"),
+		latency: registry.NewHistogramVec("kafka_publish_duration_seconds",
+			"Kafka publish latency (seconds) by topic.",
+			nil, "topic"),
+		log: log,
 	}, nil
 }
 
-// PublishSubscriptionEvent преобразует данные подписки в JSON и отправляет в указанный топик Kafka.
-func (k *kafkaProducer) PublishSubscriptionEvent(ctx context.Context, topic string, subscription *models.Subscription) error {
+// PublishSubscriptionEvent преобразует конверт события в JSON и отправляет в топик его типа.
+func (k *kafkaProducer) PublishSubscriptionEvent(ctx context.Context, event *SubscriptionEvent) error {
+	topic, err := TopicForEventType(event.EventType)
+	if err != nil {
+		return err
+	}
+
 	// Используем SubscriptionID как ключ сообщения. Это гарантирует, что все события
 	// для одной и той же подписки попадут в одну и ту же партицию Kafka,
 	// сохраняя порядок обработки для этой подписки (если консьюмер один на партицию).
 	// Альтернатива: использовать UserID, чтобы сгруппировать события по пользователю.
-	messageKey := []byte(subscription.SubscriptionID)
+	messageKey := []byte(event.Subscription.SubscriptionID)
 
-	// Преобразуем структуру подписки в JSON для тела сообщения.
-	messageValue, err := json.Marshal(subscription)
+	// Преобразуем конверт события в JSON для тела сообщения.
+	messageValue, err := json.Marshal(event)
 	if err != nil {
-		k.log.Errorw("Failed to marshal subscription data to JSON for Kafka", "error", err, "subscriptionID", subscription.SubscriptionID, "topic", topic)
+		k.log.Errorw("Failed to marshal subscription event to JSON for Kafka. EventID: %s, Topic: %s, Error: %v", event.EventID, topic, err)
 		return fmt.Errorf("kafka: failed to marshal message data: %w", err)
 	}
 
+	if err := k.Publish(ctx, topic, messageKey, messageValue); err != nil {
+		return err
+	}
+
+	k.log.Infow("Successfully published subscription event to Kafka. Topic: %s, EventID: %s, SubscriptionID: %s", topic, event.EventID, event.Subscription.SubscriptionID)
+	return nil
+}
+
+// Publish отправляет сообщение с заданными ключом и телом в указанный топик Kafka.
+func (k *kafkaProducer) Publish(ctx context.Context, topic string, key, value []byte) error {
 	// Создаем сообщение Kafka.
 	message := kafka.Message{
-		Topic: topic,        // Указываем топик
-		Key:   messageKey,   // Ключ для партиционирования
-		Value: messageValue, // Тело сообщения (JSON)
-		Time:  time.Now(),   // Время создания сообщения
+		Topic: topic,      // Указываем топик
+		Key:   key,        // Ключ для партиционирования
+		Value: value,      // Тело сообщения (JSON)
+		Time:  time.Now(), // Время создания сообщения
 	}
 
 	// Отправляем сообщение в Kafka.
@@ -97,19 +143,22 @@ func (k *kafkaProducer) PublishSubscriptionEvent(ctx context.Context, topic stri
 	writeCtx, cancel := context.WithTimeout(ctx, 15*time.Second) // Таймаут на запись
 	defer cancel()
 
-	err = k.writer.WriteMessages(writeCtx, message)
+	start := time.Now()
+	err := k.writer.WriteMessages(writeCtx, message)
+	k.latency.Observe(time.Since(start).Seconds(), topic)
 	if err != nil {
+		k.published.Inc(topic, publishResultFailure)
 		// Проверяем ошибку таймаута контекста
 		if errors.Is(err, context.DeadlineExceeded) {
-			k.log.Errorw("Kafka write timeout exceeded", "error", err, "topic", topic, "subscriptionID", subscription.SubscriptionID)
+			k.log.Errorw("Kafka write timeout exceeded. Topic: %s, Key: %s, Error: %v", topic, string(key), err)
 			return fmt.Errorf("kafka: write timeout: %w", err)
 		}
 		// Другие ошибки записи
-		k.log.Errorw("Failed to write message to Kafka", "error", err, "topic", topic, "subscriptionID", subscription.SubscriptionID)
+		k.log.Errorw("Failed to write message to Kafka. Topic: %s, Key: %s, Error: %v", topic, string(key), err)
 		return fmt.Errorf("kafka: failed to write message: %w", err)
 	}
 
-	k.log.Infow("Successfully published message to Kafka", "topic", topic, "subscriptionID", subscription.SubscriptionID, "key", string(messageKey))
+	k.published.Inc(topic, publishResultSuccess)
 	return nil
 }
 
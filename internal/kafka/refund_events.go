@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// RefundEventSchemaVersion версия формата конверта события возврата.
+const RefundEventSchemaVersion = 1
+
+// RefundEventType тип события возврата или кредит-ноты.
+type RefundEventType string
+
+const (
+	EventRefundCreated     RefundEventType = "refund.created"      // Создан возврат (через API сервиса или в панели Stripe)
+	EventRefundUpdated     RefundEventType = "refund.updated"      // Изменился статус возврата (например, succeeded -> failed)
+	EventCreditNoteCreated RefundEventType = "credit_note.created" // Выпущена кредит-нота по счету
+	EventCreditNoteVoided  RefundEventType = "credit_note.voided"  // Кредит-нота аннулирована
+)
+
+// refundEventTopics сопоставляет тип события с топиком Kafka.
+var refundEventTopics = map[RefundEventType]string{
+	EventRefundCreated:     TopicRefundCreated,
+	EventRefundUpdated:     TopicRefundUpdated,
+	EventCreditNoteCreated: TopicCreditNoteCreated,
+	EventCreditNoteVoided:  TopicCreditNoteVoided,
+}
+
+// TopicForRefundEventType возвращает топик Kafka для типа события возврата.
+func TopicForRefundEventType(eventType RefundEventType) (string, error) {
+	topic, ok := refundEventTopics[eventType]
+	if !ok {
+		return "", fmt.Errorf("kafka: unknown refund event type %q", eventType)
+	}
+	return topic, nil
+}
+
+// RefundEvent версионированный конверт события возврата. Заполнено одно из полей Refund или CreditNote.
+type RefundEvent struct {
+	EventID       string             `json:"event_id"`                 // Уникальный ID события (для дедупликации у консьюмеров)
+	EventType     RefundEventType    `json:"event_type"`               // Тип события
+	Version       int                `json:"version"`                  // Версия формата конверта
+	OccurredAt    time.Time          `json:"occurred_at"`              // Время, когда произошло изменение
+	CorrelationID string             `json:"correlation_id,omitempty"` // ID запроса/вебхука, породившего событие
+	Refund        *models.Refund     `json:"refund,omitempty"`         // Снимок возврата после изменения
+	CreditNote    *models.CreditNote `json:"credit_note,omitempty"`    // Снимок кредит-ноты после изменения
+}
+
+// NewRefundEvent создает конверт события возврата с новым EventID.
+func NewRefundEvent(ctx context.Context, eventType RefundEventType, refund *models.Refund) *RefundEvent {
+	return &RefundEvent{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		Version:       RefundEventSchemaVersion,
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: CorrelationIDFromContext(ctx),
+		Refund:        refund,
+	}
+}
+
+// NewCreditNoteEvent создает конверт события кредит-ноты с новым EventID.
+func NewCreditNoteEvent(ctx context.Context, eventType RefundEventType, creditNote *models.CreditNote) *RefundEvent {
+	return &RefundEvent{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		Version:       RefundEventSchemaVersion,
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: CorrelationIDFromContext(ctx),
+		CreditNote:    creditNote,
+	}
+}
@@ -21,11 +21,71 @@ func EnsureKafkaTopics(brokers []string, log *logger.Logger) error {
 			NumPartitions:     3,
 			ReplicationFactor: 1,
 		},
+		TopicSubscriptionActivated: {
+			Topic:             TopicSubscriptionActivated,
+			NumPartitions:     3,
+			ReplicationFactor: 1,
+		},
+		TopicSubscriptionPastDue: {
+			Topic:             TopicSubscriptionPastDue,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
 		TopicSubscriptionCancelled: {
 			Topic:             TopicSubscriptionCancelled,
 			NumPartitions:     2,
 			ReplicationFactor: 1,
 		},
+		TopicSubscriptionRenewed: {
+			Topic:             TopicSubscriptionRenewed,
+			NumPartitions:     3,
+			ReplicationFactor: 1,
+		},
+		TopicSubscriptionTrialEnding: {
+			Topic:             TopicSubscriptionTrialEnding,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicSubscriptionPlanChanged: {
+			Topic:             TopicSubscriptionPlanChanged,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicSubscriptionCancellationChanged: {
+			Topic:             TopicSubscriptionCancellationChanged,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicSubscriptionPaused: {
+			Topic:             TopicSubscriptionPaused,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicSubscriptionResumed: {
+			Topic:             TopicSubscriptionResumed,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicRefundCreated: {
+			Topic:             TopicRefundCreated,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicRefundUpdated: {
+			Topic:             TopicRefundUpdated,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicCreditNoteCreated: {
+			Topic:             TopicCreditNoteCreated,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
+		TopicCreditNoteVoided: {
+			Topic:             TopicCreditNoteVoided,
+			NumPartitions:     2,
+			ReplicationFactor: 1,
+		},
 		// "payment_events": { // Если нужен
 		// 	Topic:             "payment_events",
 		// 	NumPartitions:     1,
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// goldenExposition ожидаемый вывод newGoldenRegistry в текстовом формате Prometheus.
+const goldenExposition = `# HELP db_pool_open_connections Open connections (in use + idle).
+# TYPE db_pool_open_connections gauge
+db_pool_open_connections 7
+# HELP http_request_duration_seconds Request latency.\nSecond line with a backslash \\ in it.
+# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{route="/a",le="0.1"} 2
+http_request_duration_seconds_bucket{route="/a",le="0.5"} 3
+http_request_duration_seconds_bucket{route="/a",le="1"} 3
+http_request_duration_seconds_bucket{route="/a",le="+Inf"} 4
+http_request_duration_seconds_sum{route="/a"} 3.15
+http_request_duration_seconds_count{route="/a"} 4
+http_request_duration_seconds_bucket{route="/b",le="0.1"} 0
+http_request_duration_seconds_bucket{route="/b",le="0.5"} 0
+http_request_duration_seconds_bucket{route="/b",le="1"} 1
+http_request_duration_seconds_bucket{route="/b",le="+Inf"} 1
+http_request_duration_seconds_sum{route="/b"} 1
+http_request_duration_seconds_count{route="/b"} 1
+# HELP kafka_messages_total Messages written.
+# TYPE kafka_messages_total counter
+kafka_messages_total 12
+# HELP stripe_errors_total Stripe errors by type.
+# TYPE stripe_errors_total counter
+stripe_errors_total{operation="CreateCustomer",type="card_error"} 2
+stripe_errors_total{operation="quote\"back\\slash\nnewline",type="api_error"} 1.5
+`
+
+func newGoldenRegistry() *Registry {
+	r := NewRegistry()
+
+	errorsTotal := r.NewCounterVec("stripe_errors_total", "Stripe errors by type.", "operation", "type")
+	errorsTotal.Inc("CreateCustomer", "card_error")
+	errorsTotal.Inc("CreateCustomer", "card_error")
+	errorsTotal.Add(1.5, "quote\"back\\slash\nnewline", "api_error")
+
+	// Границы передаются не по порядку: гистограмма должна их отсортировать
+	latency := r.NewHistogramVec("http_request_duration_seconds",
+		"Request latency.\nSecond line with a backslash \\ in it.", []float64{1, 0.1, 0.5}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(0.1, "/a") // Значение на границе попадает в бакет le="0.1"
+	latency.Observe(2.5, "/a") // Больше последней границы - только в +Inf
+	latency.Observe(1, "/b")
+
+	r.NewGaugeFunc("db_pool_open_connections", "Open connections (in use + idle).", func() float64 { return 7 })
+	r.NewCounterFunc("kafka_messages_total", "Messages written.", func() float64 { return 12 })
+	return r
+}
+
+func TestWriteToGolden(t *testing.T) {
+	var b strings.Builder
+	n, err := newGoldenRegistry().WriteTo(&b)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if got := b.String(); got != goldenExposition {
+		t.Fatalf("exposition mismatch\n--- got ---\n%s\n--- want ---\n%s", got, goldenExposition)
+	}
+	if n != int64(len(goldenExposition)) {
+		t.Fatalf("WriteTo returned %d bytes, want %d", n, len(goldenExposition))
+	}
+}
+
+func TestHandlerServesTextFormat(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newGoldenRegistry().Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != contentType {
+		t.Fatalf("Content-Type = %q, want %q", ct, contentType)
+	}
+	if rec.Body.String() != goldenExposition {
+		t.Fatalf("handler body differs from WriteTo output:\n%s", rec.Body.String())
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{42, "42"},
+		{0.005, "0.005"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	}
+	for _, tt := range tests {
+		if got := formatFloat(tt.in); got != tt.want {
+			t.Errorf("formatFloat(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRegistryPanicsOnMisuse(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"duplicate metric", func() {
+			r := NewRegistry()
+			r.NewCounterVec("requests_total", "Requests.")
+			r.NewGaugeFunc("requests_total", "Requests.", func() float64 { return 0 })
+		}},
+		{"wrong label count", func() {
+			NewRegistry().NewCounterVec("requests_total", "Requests.", "route").Inc("/a", "extra")
+		}},
+		{"decreasing counter", func() {
+			NewRegistry().NewCounterVec("requests_total", "Requests.").Add(-1)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package metrics содержит счетчики, гистограммы и вычисляемые метрики с метками, совместимые по модели
+// с Prometheus (counter, gauge, histogram с кумулятивными бакетами). Метрики регистрируются в Registry
+// и отдаются в текстовом формате Prometheus (см. Registry.Handler).
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefBuckets границы бакетов гистограммы длительности по умолчанию (секунды).
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry набор метрик сервиса.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// metric общий интерфейс метрик реестра.
+type metric interface {
+	describe() (name, help, kind string)
+	// writeSamples пишет строки серий метрики в текстовом формате Prometheus.
+	writeSamples(w *strings.Builder)
+}
+
+// NewRegistry создает пустой реестр.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register добавляет метрику; повторная регистрация имени - ошибка программиста.
+func (r *Registry) register(m metric) {
+	name, _, _ := m.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[name]; exists {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", name))
+	}
+	r.metrics[name] = m
+}
+
+// labelSet значения меток одной серии.
+type labelSet struct {
+	key    string // Значения через \xff (ключ карты серий)
+	values []string
+}
+
+func newLabelSet(names, values []string) labelSet {
+	if len(values) != len(names) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d", len(names), len(values)))
+	}
+	return labelSet{key: strings.Join(values, "\xff"), values: append([]string(nil), values...)}
+}
+
+// CounterVec монотонный счетчик с метками.
+type CounterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labels labelSet
+	value  float64
+}
+
+// NewCounterVec регистрирует счетчик.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, series: make(map[string]*counterSeries)}
+	r.register(c)
+	return c
+}
+
+func (c *CounterVec) describe() (string, string, string) { return c.name, c.help, "counter" }
+
+// Inc увеличивает счетчик серии на 1.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает счетчик серии на delta (delta >= 0).
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	ls := newLabelSet(c.labels, labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[ls.key]
+	if !ok {
+		s = &counterSeries{labels: ls}
+		c.series[ls.key] = s
+	}
+	s.value += delta
+}
+
+// HistogramVec гистограмма наблюдений с метками.
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64 // Верхние границы по возрастанию (без +Inf)
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels labelSet
+	counts []uint64 // Наблюдения по бакетам (не кумулятивно); последний - +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec регистрирует гистограмму; buckets nil - DefBuckets.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: sorted, series: make(map[string]*histogramSeries)}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) describe() (string, string, string) { return h.name, h.help, "histogram" }
+
+// Observe добавляет наблюдение в серию.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	ls := newLabelSet(h.labels, labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[ls.key]
+	if !ok {
+		s = &histogramSeries{labels: ls, counts: make([]uint64, len(h.buckets)+1)}
+		h.series[ls.key] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, value)]++
+	s.sum += value
+	s.count++
+}
+
+// funcMetric метрика без меток, значение которой читается при каждом сборе (например, статистика пула БД).
+type funcMetric struct {
+	name, help, kind string
+	fn               func() float64
+}
+
+// NewGaugeFunc регистрирует gauge, значение которого возвращает fn в момент сбора.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc регистрирует счетчик, значение которого возвращает fn в момент сбора
+// (для монотонных счетчиков, которые ведет другая библиотека).
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+func (f *funcMetric) describe() (string, string, string) { return f.name, f.help, f.kind }
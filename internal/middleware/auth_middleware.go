@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Dhoini/Payment-microservice/internal/authz"
 	"github.com/Dhoini/Payment-microservice/internal/config"
 	"github.com/Dhoini/Payment-microservice/pkg/logger"
 	"github.com/Dhoini/Payment-microservice/pkg/res"
@@ -21,6 +22,10 @@ const (
 	// ContextUserIDKey ключ для хранения ID пользователя в контексте (используется HTTP middleware и gRPC interceptor).
 	ContextUserIDKey ContextKey = "userID"
 	authHeaderPrefix            = "Bearer "
+
+	// OnBehalfOfHeader заголовок, которым привилегированный вызывающий (service, admin, support)
+	// указывает пользователя, если его нет в пути запроса. В gRPC - метаданные x-on-behalf-of.
+	OnBehalfOfHeader = "X-On-Behalf-Of"
 )
 
 type TokenValidator interface {
@@ -28,11 +33,39 @@ type TokenValidator interface {
 }
 
 type TokenClaims struct {
-	UserEmail string `json:"email"`
-	Scope     string `json:"scope"`
+	UserEmail string   `json:"email"`
+	Scope     string   `json:"scope"` // Scopes через пробел
+	Roles     []string `json:"roles"` // user, support, admin, service
 	jwt.RegisteredClaims
 }
 
+// Principal строит вызывающего из утверждений токена. Токен без ролей считается токеном
+// пользователя; scope "admin" (прежняя схема) дает роль admin, а субъекты из serviceAccounts -
+// роль service.
+func (c *TokenClaims) Principal(serviceAccounts []string) *authz.Principal {
+	p := &authz.Principal{
+		Subject: c.Subject,
+		Email:   c.UserEmail,
+		Scopes:  authz.ParseScopes(c.Scope),
+		UserID:  c.Subject,
+	}
+	for _, role := range c.Roles {
+		p.Roles = append(p.Roles, authz.Role(role))
+	}
+	if p.Scopes.Has("admin") && !p.HasRole(authz.RoleAdmin) {
+		p.Roles = append(p.Roles, authz.RoleAdmin)
+	}
+	for _, subject := range serviceAccounts {
+		if subject == c.Subject && !p.IsServiceAccount() {
+			p.Roles = append(p.Roles, authz.RoleService)
+		}
+	}
+	if len(p.Roles) == 0 {
+		p.Roles = []authz.Role{authz.RoleUser}
+	}
+	return p
+}
+
 type JWTMiddleware struct {
 	cfg       *config.Config
 	log       *logger.Logger
@@ -62,7 +95,9 @@ func (m *JWTMiddleware) RequireAuth(requiredScopes ...string) gin.HandlerFunc {
 			return
 		}
 
-		if len(requiredScopes) > 0 && !m.hasRequiredScope(claims.Scope, requiredScopes) {
+		principal := claims.Principal(m.cfg.Auth.ServiceAccounts)
+		// scope в токене - список через пробел; требуются все перечисленные scopes
+		if !principal.Scopes.Has(requiredScopes...) {
 			m.handleAuthError(c, "Insufficient token permissions")
 			return
 		}
@@ -76,22 +111,61 @@ func (m *JWTMiddleware) RequireAuth(requiredScopes ...string) gin.HandlerFunc {
 		// Используем определенный ключ контекста
 		c.Set(string(ContextUserIDKey), userID)
 		c.Set("userEmail", claims.UserEmail) // Можно также добавить email в контекст, если нужно
+		c.Request = c.Request.WithContext(authz.WithPrincipal(c.Request.Context(), principal))
 		// Корректное логирование для вашего логгера
 		m.log.Debugw("User authenticated via HTTP. UserID: %s", userID)
 		c.Next()
 	}
 }
 
-func (m *JWTMiddleware) hasRequiredScope(tokenScope string, requiredScopes []string) bool {
-	if len(requiredScopes) == 0 {
-		return true
-	}
-	for _, scope := range requiredScopes {
-		if tokenScope == scope {
-			return true
+// Authorize применяет политику маршрута из policies (ключ - метод и шаблон пути Gin,
+// например "GET /api/v1/users/:user_id/invoices"). Маршрут без политики недоступен.
+// Для операций над данными пользователя в ContextUserIDKey кладется пользователь из пути
+// (:user_id) или заголовка X-On-Behalf-Of, если вызывающему разрешено действовать от его имени.
+// Вызывается после RequireAuth.
+func (m *JWTMiddleware) Authorize(policies map[string]authz.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := authz.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			m.handleAuthError(c, "Missing authentication")
+			return
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		policy, ok := policies[route]
+		if !ok {
+			m.log.Errorw("No authorization policy for route. Route: %s", route)
+			m.handleForbidden(c, principal, authz.ErrNoPolicy)
+			return
+		}
+
+		targetUserID := c.Param("user_id")
+		if targetUserID == "" {
+			targetUserID = c.GetHeader(OnBehalfOfHeader)
 		}
+		if err := policy.Resolve(principal, targetUserID); err != nil {
+			m.handleForbidden(c, principal, err)
+			return
+		}
+
+		if principal.OnBehalf() {
+			m.log.Infow("AUDIT: acting on behalf of user via HTTP. Subject: %s, Roles: %v, UserID: %s, Route: %s, Access: %s",
+				principal.Subject, principal.Roles, principal.UserID, route, policy.Access)
+		}
+		c.Set(string(ContextUserIDKey), principal.UserID)
+		c.Next()
 	}
-	return false
+}
+
+// handleForbidden отвечает 403, если политика не разрешает вызов.
+func (m *JWTMiddleware) handleForbidden(c *gin.Context, principal *authz.Principal, err error) {
+	m.log.Warnw("HTTP Authorization failed. Path: %s, Subject: %s, Roles: %v, Error: %v",
+		c.Request.URL.Path, principal.Subject, principal.Roles, err)
+	res.JsonResponse(c.Writer, res.ErrorResponse{
+		Error:     "Forbidden",
+		ErrorCode: http.StatusForbidden,
+	}, http.StatusForbidden)
+	c.Abort()
 }
 
 func (m *JWTMiddleware) handleAuthError(c *gin.Context, message string) {
@@ -118,15 +192,7 @@ func (v *DefaultTokenValidator) Validate(tokenString string) (*TokenClaims, erro
 	})
 
 	if err != nil {
-		if errors.Is(err, jwt.ErrTokenMalformed) {
-			return nil, errors.New("malformed token")
-		} else if errors.Is(err, jwt.ErrTokenSignatureInvalid) {
-			return nil, errors.New("invalid token signature")
-		} else if errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenNotValidYet) {
-			return nil, errors.New("token expired")
-		} else {
-			return nil, fmt.Errorf("invalid token: %w", err)
-		}
+		return nil, validationError(err)
 	}
 
 	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid {
@@ -135,3 +201,36 @@ func (v *DefaultTokenValidator) Validate(tokenString string) (*TokenClaims, erro
 
 	return nil, errors.New("invalid token claims")
 }
+
+// NewTokenValidator выбирает валидатор по конфигурации: при заданном Auth.JWKSURL токены
+// проверяются ключами провайдера (JWKSValidator), иначе - общим секретом Auth.JWTSecret.
+func NewTokenValidator(cfg *config.Config, log *logger.Logger) (TokenValidator, error) {
+	if cfg.Auth.JWKSURL == "" {
+		return &DefaultTokenValidator{Secret: []byte(cfg.Auth.JWTSecret)}, nil
+	}
+	return NewJWKSValidator(JWKSOptions{
+		URL:             cfg.Auth.JWKSURL,
+		Issuer:          cfg.Auth.Issuer,
+		Audience:        cfg.Auth.Audience,
+		ClockSkew:       cfg.Auth.ClockSkew,
+		RefreshInterval: cfg.Auth.JWKSRefreshInterval,
+	}, log)
+}
+
+// validationError преобразует ошибку разбора токена в сообщение для клиента.
+func validationError(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return errors.New("malformed token")
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return errors.New("invalid token signature")
+	case errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenNotValidYet):
+		return errors.New("token expired")
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		return errors.New("invalid token issuer")
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		return errors.New("invalid token audience")
+	default:
+		return fmt.Errorf("invalid token: %w", err)
+	}
+}
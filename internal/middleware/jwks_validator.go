@@ -0,0 +1,325 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultJWKSRefreshInterval = 1 * time.Hour
+	defaultJWKSClockSkew       = 30 * time.Second
+	// Не чаще одного внепланового обновления за этот период: токены с выдуманным kid
+	// не должны превращаться в поток запросов к провайдеру
+	minJWKSRefreshInterval = 10 * time.Second
+	jwksFetchTimeout       = 10 * time.Second
+)
+
+// jwksSigningMethods алгоритмы, которые принимает JWKSValidator (только асимметричные:
+// HMAC с открытым ключом из JWKS в качестве секрета недопустим).
+var jwksSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// JWKSOptions настройки JWKSValidator.
+type JWKSOptions struct {
+	URL             string        // Адрес JWKS документа провайдера
+	Issuer          string        // Ожидаемый iss
+	Audience        string        // Ожидаемый aud
+	ClockSkew       time.Duration // Допустимое расхождение часов для exp/nbf/iat (по умолчанию 30s)
+	RefreshInterval time.Duration // Период плановой перезагрузки ключей (по умолчанию 1h)
+	HTTPClient      *http.Client  // По умолчанию клиент с таймаутом 10s
+}
+
+// JWKSValidator проверяет токены, подписанные ключами провайдера (RS*/PS*/ES*), которые
+// загружаются из JWKS документа и кешируются. При неизвестном kid ключи перезагружаются,
+// поэтому ротация ключей у провайдера не требует перезапуска сервиса.
+// Загрузка идет без блокировки кеша: проверка токенов с известным kid ее не ждет.
+type JWKSValidator struct {
+	opts   JWKSOptions
+	parser *jwt.Parser
+	log    *logger.Logger
+
+	mu          sync.RWMutex
+	keys        map[string]interface{} // kid -> *rsa.PublicKey | *ecdsa.PublicKey
+	fetchedAt   time.Time              // Время последней успешной загрузки
+	attemptedAt time.Time              // Время последней попытки загрузки
+	inflight    *jwksFetch             // Текущая загрузка (nil, если не идет)
+}
+
+// jwksFetch одна загрузка JWKS, результат которой ждут все вызывающие, пришедшие во время нее.
+type jwksFetch struct {
+	done chan struct{} // Закрывается по завершении загрузки
+	err  error
+}
+
+// NewJWKSValidator создает валидатор токенов по JWKS. Ключи загружаются при первой проверке токена.
+func NewJWKSValidator(opts JWKSOptions, log *logger.Logger) (*JWKSValidator, error) {
+	if opts.URL == "" {
+		return nil, errors.New("jwks: url is required")
+	}
+	if opts.Issuer == "" || opts.Audience == "" {
+		return nil, errors.New("jwks: issuer and audience are required")
+	}
+	if opts.ClockSkew <= 0 {
+		opts.ClockSkew = defaultJWKSClockSkew
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = defaultJWKSRefreshInterval
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: jwksFetchTimeout}
+	}
+
+	return &JWKSValidator{
+		opts: opts,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(jwksSigningMethods),
+			jwt.WithIssuer(opts.Issuer),
+			jwt.WithAudience(opts.Audience),
+			jwt.WithLeeway(opts.ClockSkew),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+		log:  log,
+		keys: make(map[string]interface{}),
+	}, nil
+}
+
+// Validate проверяет подпись токена ключом из JWKS, а также iss, aud и сроки действия.
+func (v *JWKSValidator) Validate(tokenString string) (*TokenClaims, error) {
+	token, err := v.parser.ParseWithClaims(tokenString, &TokenClaims{}, v.keyFunc)
+	if err != nil {
+		return nil, validationError(err)
+	}
+
+	if claims, ok := token.Claims.(*TokenClaims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token claims")
+}
+
+// Refresh загружает ключи заново. Можно вызвать при старте, чтобы проверить доступность JWKS.
+func (v *JWKSValidator) Refresh(ctx context.Context) error {
+	fetch := v.startRefresh(true)
+	select {
+	case <-fetch.done:
+		return fetch.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// keyFunc находит открытый ключ по kid из заголовка токена.
+func (v *JWKSValidator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > v.opts.RefreshInterval
+	v.mu.RUnlock()
+
+	switch {
+	case !ok:
+		// Неизвестный kid: провайдер мог выпустить новый ключ. Ждем загрузку (одну на все такие токены);
+		// при недоступности провайдера продолжаем работать на закешированных ключах
+		if fetch := v.startRefresh(false); fetch != nil {
+			<-fetch.done
+		}
+		v.mu.RLock()
+		key, ok = v.keys[kid]
+		v.mu.RUnlock()
+	case stale:
+		// Плановое обновление идет в фоне, токен проверяется закешированным ключом
+		v.startRefresh(false)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	// Ключ должен соответствовать семейству алгоритма: RSA-ключ не проверяет ES256 и наоборот
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if _, isRSA := token.Method.(*jwt.SigningMethodRSA); !isRSA {
+			if _, isPSS := token.Method.(*jwt.SigningMethodRSAPSS); !isPSS {
+				return nil, fmt.Errorf("signing method %v does not match key %q", token.Header["alg"], kid)
+			}
+		}
+	case *ecdsa.PublicKey:
+		if _, isECDSA := token.Method.(*jwt.SigningMethodECDSA); !isECDSA {
+			return nil, fmt.Errorf("signing method %v does not match key %q", token.Header["alg"], kid)
+		}
+	}
+	return key, nil
+}
+
+// startRefresh запускает загрузку ключей или возвращает уже идущую. Без force загрузка не запускается
+// (возвращается nil), если предыдущая попытка была меньше minJWKSRefreshInterval назад.
+func (v *JWKSValidator) startRefresh(force bool) *jwksFetch {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.inflight != nil {
+		return v.inflight
+	}
+	if !force && time.Since(v.attemptedAt) < minJWKSRefreshInterval {
+		return nil
+	}
+
+	fetch := &jwksFetch{done: make(chan struct{})}
+	v.inflight = fetch
+	v.attemptedAt = time.Now()
+	go v.runFetch(fetch)
+	return fetch
+}
+
+// runFetch загружает ключи (с собственным таймаутом, не зависящим от запроса, который ее запустил)
+// и заменяет кеш при успехе.
+func (v *JWKSValidator) runFetch(fetch *jwksFetch) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	keys, err := v.fetchKeys(ctx)
+
+	v.mu.Lock()
+	if err == nil {
+		v.keys = keys
+		v.fetchedAt = time.Now()
+	}
+	v.inflight = nil
+	v.mu.Unlock()
+
+	if err != nil {
+		v.log.Errorw("Failed to refresh JWKS. URL: %s, Error: %v", v.opts.URL, err)
+	} else {
+		v.log.Infow("JWKS refreshed. URL: %s, Keys: %d", v.opts.URL, len(keys))
+	}
+	fetch.err = err
+	close(fetch.done)
+}
+
+// fetchKeys загружает JWKS документ и возвращает пригодные для проверки подписи ключи.
+func (v *JWKSValidator) fetchKeys(ctx context.Context) (map[string]interface{}, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, v.opts.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: failed to build request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	resp, err := v.opts.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: failed to fetch keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("jwks: failed to decode document: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Ключ неподдерживаемого типа не мешает остальным
+			v.log.Warnw("Skipping JWKS key. Kid: %s, Error: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: document contains no usable signing keys")
+	}
+
+	return keys, nil
+}
+
+// jsonWebKey открытый ключ в формате JWK (RFC 7517). Поддерживаются RSA и EC (P-256, P-384, P-521).
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey восстанавливает открытый ключ из параметров JWK.
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeJWKInt декодирует целое число в base64url без выравнивания.
+func decodeJWKInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("empty value")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
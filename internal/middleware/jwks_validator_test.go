@@ -0,0 +1,306 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com/"
+	testAudience = "payment-service"
+)
+
+// jwksServer отдает JWKS документ с заменяемым набором ключей и считает запросы.
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int32
+
+	mu   sync.Mutex
+	keys []map[string]string
+}
+
+func newJWKSServer(t *testing.T, keys ...map[string]string) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) setKeys(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kid": kid, "kty": "RSA", "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[string]string{
+		"kid": kid, "kty": "EC", "crv": key.Curve.Params().Name,
+		"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, mutate func(*TokenClaims)) string {
+	t.Helper()
+	now := time.Now()
+	claims := &TokenClaims{
+		UserEmail: "user@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    testIssuer,
+			Audience:  jwt.ClaimStrings{testAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+	if mutate != nil {
+		mutate(claims)
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func newTestJWKSValidator(t *testing.T, url string) *JWKSValidator {
+	t.Helper()
+	v, err := NewJWKSValidator(JWKSOptions{URL: url, Issuer: testIssuer, Audience: testAudience}, logger.New(logger.FATAL))
+	if err != nil {
+		t.Fatalf("NewJWKSValidator: %v", err)
+	}
+	return v
+}
+
+type testKeys struct {
+	rsa1, rsa2 *rsa.PrivateKey
+	ec         *ecdsa.PrivateKey
+}
+
+var (
+	keysOnce sync.Once
+	keys     testKeys
+)
+
+// generateKeys создает ключи один раз на все тесты (генерация RSA заметно медленная).
+func generateKeys(t *testing.T) testKeys {
+	t.Helper()
+	keysOnce.Do(func() {
+		var err error
+		if keys.rsa1, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatalf("generate RSA key: %v", err)
+		}
+		if keys.rsa2, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatalf("generate RSA key: %v", err)
+		}
+		if keys.ec, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatalf("generate EC key: %v", err)
+		}
+	})
+	return keys
+}
+
+func TestJWKSValidatorAcceptsValidTokens(t *testing.T) {
+	k := generateKeys(t)
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &k.rsa1.PublicKey), ecJWK("ec-1", &k.ec.PublicKey))
+	v := newTestJWKSValidator(t, srv.URL)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+		kid    string
+	}{
+		{"RS256", jwt.SigningMethodRS256, k.rsa1, "rsa-1"},
+		{"PS256", jwt.SigningMethodPS256, k.rsa1, "rsa-1"},
+		{"ES256", jwt.SigningMethodES256, k.ec, "ec-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Validate(signToken(t, tt.method, tt.key, tt.kid, nil))
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if claims.Subject != "user-1" {
+				t.Fatalf("Subject = %q, want user-1", claims.Subject)
+			}
+		})
+	}
+	if n := srv.requests.Load(); n != 1 {
+		t.Fatalf("JWKS requests = %d, want 1 (keys are cached)", n)
+	}
+}
+
+func TestJWKSValidatorRejectsInvalidTokens(t *testing.T) {
+	k := generateKeys(t)
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &k.rsa1.PublicKey), ecJWK("ec-1", &k.ec.PublicKey))
+	v := newTestJWKSValidator(t, srv.URL)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong issuer", signToken(t, jwt.SigningMethodRS256, k.rsa1, "rsa-1", func(c *TokenClaims) { c.Issuer = "https://evil.example.com/" })},
+		{"wrong audience", signToken(t, jwt.SigningMethodRS256, k.rsa1, "rsa-1", func(c *TokenClaims) { c.Audience = jwt.ClaimStrings{"other"} })},
+		{"expired", signToken(t, jwt.SigningMethodRS256, k.rsa1, "rsa-1", func(c *TokenClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		})},
+		{"no expiration", signToken(t, jwt.SigningMethodRS256, k.rsa1, "rsa-1", func(c *TokenClaims) { c.ExpiresAt = nil })},
+		{"signed by another key", signToken(t, jwt.SigningMethodRS256, k.rsa2, "rsa-1", nil)},
+		// Ключ должен соответствовать семейству алгоритма из заголовка токена
+		{"EC algorithm with RSA key", signToken(t, jwt.SigningMethodES256, k.ec, "rsa-1", nil)},
+		{"RSA algorithm with EC key", signToken(t, jwt.SigningMethodRS256, k.rsa1, "ec-1", nil)},
+		// HMAC не принимается: иначе открытый ключ из JWKS мог бы служить секретом
+		{"HS256", signToken(t, jwt.SigningMethodHS256, []byte("secret"), "rsa-1", nil)},
+		{"no kid", signToken(t, jwt.SigningMethodRS256, k.rsa1, "", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Validate(tt.token); err == nil {
+				t.Fatal("Validate accepted an invalid token")
+			}
+		})
+	}
+}
+
+func TestJWKSValidatorPicksUpRotatedKey(t *testing.T) {
+	k := generateKeys(t)
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &k.rsa1.PublicKey))
+	v := newTestJWKSValidator(t, srv.URL)
+
+	if _, err := v.Validate(signToken(t, jwt.SigningMethodRS256, k.rsa1, "rsa-1", nil)); err != nil {
+		t.Fatalf("Validate before rotation: %v", err)
+	}
+
+	// Провайдер выпустил новый ключ; предыдущая загрузка была давно, так что неизвестный kid ее запускает
+	srv.setKeys(rsaJWK("rsa-2", &k.rsa2.PublicKey))
+	v.mu.Lock()
+	v.attemptedAt = time.Time{}
+	v.mu.Unlock()
+
+	if _, err := v.Validate(signToken(t, jwt.SigningMethodRS256, k.rsa2, "rsa-2", nil)); err != nil {
+		t.Fatalf("Validate with rotated key: %v", err)
+	}
+	if _, err := v.Validate(signToken(t, jwt.SigningMethodRS256, k.rsa1, "rsa-1", nil)); err == nil {
+		t.Fatal("Validate accepted a token signed by a key removed from JWKS")
+	}
+	if n := srv.requests.Load(); n != 2 {
+		t.Fatalf("JWKS requests = %d, want 2", n)
+	}
+}
+
+func TestJWKSValidatorRateLimitsUnknownKidRefresh(t *testing.T) {
+	k := generateKeys(t)
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &k.rsa1.PublicKey))
+	v := newTestJWKSValidator(t, srv.URL)
+	if err := v.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Токены с выдуманными kid сразу после загрузки не вызывают новых запросов к провайдеру
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.Validate(signToken(t, jwt.SigningMethodRS256, k.rsa1, "unknown", nil)); err == nil {
+				t.Error("Validate accepted a token with an unknown kid")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := srv.requests.Load(); n != 1 {
+		t.Fatalf("JWKS requests = %d, want 1", n)
+	}
+}
+
+func TestJWKSValidatorSharesInflightFetch(t *testing.T) {
+	k := generateKeys(t)
+	release := make(chan struct{})
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &k.rsa1.PublicKey))
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		srv.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+	v := newTestJWKSValidator(t, slow.URL)
+
+	token := signToken(t, jwt.SigningMethodRS256, k.rsa1, "rsa-1", nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := v.Validate(token); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond) // Даем всем проверкам дойти до ожидания загрузки
+	close(release)
+	wg.Wait()
+
+	if n := srv.requests.Load(); n != 1 {
+		t.Fatalf("JWKS requests = %d, want 1 shared fetch", n)
+	}
+}
+
+func TestJWKSKeyParsing(t *testing.T) {
+	k := generateKeys(t)
+	encKey := rsaJWK("rsa-enc", &k.rsa2.PublicKey)
+	encKey["use"] = "enc"
+	badCurve := ecJWK("ec-bad", &k.ec.PublicKey)
+	badCurve["crv"] = "P-192"
+	offCurve := ecJWK("ec-off", &k.ec.PublicKey)
+	offCurve["y"] = offCurve["x"]
+
+	srv := newJWKSServer(t, rsaJWK("rsa-1", &k.rsa1.PublicKey), encKey, badCurve, offCurve,
+		map[string]string{"kid": "oct", "kty": "oct", "k": "c2VjcmV0"})
+	v := newTestJWKSValidator(t, srv.URL)
+
+	fetched, err := v.fetchKeys(context.Background())
+	if err != nil {
+		t.Fatalf("fetchKeys: %v", err)
+	}
+	if len(fetched) != 1 || fetched["rsa-1"] == nil {
+		t.Fatalf("usable keys = %v, want only rsa-1", fetched)
+	}
+
+	// Документ без пригодных ключей - ошибка, закешированные ключи не заменяются
+	srv.setKeys(encKey)
+	if _, err := v.fetchKeys(context.Background()); err == nil {
+		t.Fatal("fetchKeys succeeded for a document without usable signing keys")
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute метка запросов, не попавших ни в один маршрут (путь не пишется, чтобы не плодить серии).
+const unmatchedRoute = "unmatched"
+
+// RequestMetrics - Gin middleware, считающий запросы и их длительность по методу и шаблону маршрута
+// (например, /api/v1/subscriptions/:subscription_id).
+func RequestMetrics(registry *metrics.Registry) gin.HandlerFunc {
+	requests := registry.NewCounterVec("http_requests_total",
+		"Total number of HTTP requests by method, route and status code.",
+		"method", "route", "status")
+	latency := registry.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency (seconds) by method and route.",
+		nil, "method", "route")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		requests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		latency.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Invoice счет Stripe, сохраненный локально из вебхуков invoice.*, для истории платежей пользователя.
+type Invoice struct {
+	InvoiceID            string     `db:"invoice_id" json:"invoice_id"`                         // ID счета Stripe (in_...)
+	UserID               string     `db:"user_id" json:"user_id"`                               // Владелец клиента Stripe
+	StripeCustomerID     string     `db:"stripe_customer_id" json:"stripe_customer_id"`         // ID клиента Stripe (cus_...)
+	SubscriptionID       string     `db:"subscription_id" json:"subscription_id"`               // Локальный ID подписки (пусто, если подписка неизвестна)
+	StripeSubscriptionID string     `db:"stripe_subscription_id" json:"stripe_subscription_id"` // ID подписки Stripe (пусто для разовых счетов)
+	Number               string     `db:"number" json:"number"`                                 // Номер счета (присваивается при финализации)
+	Status               string     `db:"status" json:"status"`                                 // draft, open, paid, void, uncollectible
+	BillingReason        string     `db:"billing_reason" json:"billing_reason"`                 // subscription_create, subscription_cycle, ...
+	Currency             string     `db:"currency" json:"currency"`
+	AmountDue            int64      `db:"amount_due" json:"amount_due"` // Суммы в минимальных единицах валюты
+	AmountPaid           int64      `db:"amount_paid" json:"amount_paid"`
+	AmountRemaining      int64      `db:"amount_remaining" json:"amount_remaining"`
+	Subtotal             int64      `db:"subtotal" json:"subtotal"` // До скидок
+	Total                int64      `db:"total" json:"total"`       // После скидок
+	AttemptCount         int64      `db:"attempt_count" json:"attempt_count"`
+	PeriodStart          *time.Time `db:"period_start" json:"period_start,omitempty"`
+	PeriodEnd            *time.Time `db:"period_end" json:"period_end,omitempty"`
+	HostedInvoiceURL     string     `db:"hosted_invoice_url" json:"hosted_invoice_url"` // Страница счета Stripe
+	InvoicePDF           string     `db:"invoice_pdf" json:"invoice_pdf"`               // Ссылка на PDF
+	PaidAt               *time.Time `db:"paid_at" json:"paid_at,omitempty"`
+	StripeCreatedAt      time.Time  `db:"stripe_created_at" json:"stripe_created_at"` // Когда счет создан в Stripe
+	LastEventAt          time.Time  `db:"last_event_at" json:"last_event_at"`         // Время последнего примененного события Stripe
+	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at" json:"updated_at"`
+}
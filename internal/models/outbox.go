@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// OutboxEvent представляет событие, ожидающее публикации в Kafka (transactional outbox).
+type OutboxEvent struct {
+	ID            int64      `db:"id" json:"id"`                           // Последовательный ID, задает порядок публикации
+	AggregateID   string     `db:"aggregate_id" json:"aggregate_id"`       // ID подписки, к которой относится событие
+	Topic         string     `db:"topic" json:"topic"`                     // Топик Kafka
+	MessageKey    string     `db:"message_key" json:"message_key"`         // Ключ сообщения Kafka (для партиционирования)
+	Payload       []byte     `db:"payload" json:"payload"`                 // Тело сообщения (JSON)
+	Attempts      int        `db:"attempts" json:"attempts"`               // Количество неудачных попыток публикации
+	LastError     *string    `db:"last_error" json:"last_error,omitempty"` // Текст последней ошибки публикации
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`           // Время записи события
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"` // Не раньше этого времени релей повторит попытку
+	PublishedAt   *time.Time `db:"published_at" json:"published_at"`       // Время успешной публикации (nil - еще не опубликовано)
+	DeadAt        *time.Time `db:"dead_at" json:"dead_at,omitempty"`       // Время перевода в dead-letter после исчерпания попыток
+}
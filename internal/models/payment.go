@@ -1,16 +1,80 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
 // Subscription представляет подписку пользователя в системе.
 type Subscription struct {
-	SubscriptionID   string     `db:"subscription_id" json:"subscription_id"`       // ID подписки (может быть из Stripe)
-	UserID           string     `db:"user_id" json:"user_id"`                       // ID пользователя, которому принадлежит подписка
-	PlanID           string     `db:"plan_id" json:"plan_id"`                       // ID тарифного плана
-	Status           string     `db:"status" json:"status"`                         // Статус подписки (e.g., active, canceled, past_due)
-	StripeCustomerID string     `db:"stripe_customer_id" json:"stripe_customer_id"` // ID клиента в Stripe
-	CreatedAt        time.Time  `db:"created_at" json:"created_at"`                 // Время создания записи
-	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`                 // Время последнего обновления записи
-	ExpiresAt        *time.Time `db:"expires_at" json:"expires_at,omitempty"`       // Время окончания подписки (если применимо)
-	CanceledAt       *time.Time `db:"canceled_at" json:"canceled_at,omitempty"`     // Время отмены подписки
+	SubscriptionID        string     `db:"subscription_id" json:"subscription_id"`                           // ID подписки (может быть из Stripe)
+	UserID                string     `db:"user_id" json:"user_id"`                                           // ID пользователя, которому принадлежит подписка
+	PlanID                string     `db:"plan_id" json:"plan_id"`                                           // ID тарифного плана
+	Status                string     `db:"status" json:"status"`                                             // Статус подписки (e.g., active, canceled, past_due, paused)
+	StripeCustomerID      string     `db:"stripe_customer_id" json:"stripe_customer_id"`                     // ID клиента в Stripe
+	CreatedAt             time.Time  `db:"created_at" json:"created_at"`                                     // Время создания записи
+	UpdatedAt             time.Time  `db:"updated_at" json:"updated_at"`                                     // Время последнего обновления записи
+	ExpiresAt             *time.Time `db:"expires_at" json:"expires_at,omitempty"`                           // Время окончания подписки (если применимо)
+	CanceledAt            *time.Time `db:"canceled_at" json:"canceled_at,omitempty"`                         // Время отмены подписки
+	CancelAtPeriodEnd     bool       `db:"cancel_at_period_end" json:"cancel_at_period_end"`                 // Подписка будет отменена в конце текущего периода
+	CancelAt              *time.Time `db:"cancel_at" json:"cancel_at,omitempty"`                             // Когда вступит в силу запланированная отмена
+	ResumesAt             *time.Time `db:"resumes_at" json:"resumes_at,omitempty"`                           // Когда автоматически возобновятся приостановленные списания
+	TrialStart            *time.Time `db:"trial_start" json:"trial_start,omitempty"`                         // Начало пробного периода
+	TrialEnd              *time.Time `db:"trial_end" json:"trial_end,omitempty"`                             // Конец пробного периода (до него статус trialing)
+	DiscountCouponID      string     `db:"discount_coupon_id" json:"discount_coupon_id,omitempty"`           // Купон Stripe, примененный к подписке (пусто - без скидки)
+	DiscountPromotionCode string     `db:"discount_promotion_code" json:"discount_promotion_code,omitempty"` // Промокод, через который применен купон
+	DiscountPercentOff    float64    `db:"discount_percent_off" json:"discount_percent_off,omitempty"`       // Скидка в процентах
+	DiscountAmountOff     int64      `db:"discount_amount_off" json:"discount_amount_off,omitempty"`         // Скидка суммой в минимальных единицах DiscountCurrency
+	DiscountCurrency      string     `db:"discount_currency" json:"discount_currency,omitempty"`
+	DiscountDuration      string     `db:"discount_duration" json:"discount_duration,omitempty"` // once, repeating или forever
+	DiscountEnd           *time.Time `db:"discount_end" json:"discount_end,omitempty"`           // Конец скидки repeating-купона
+	LastEventAt           *time.Time `db:"last_event_at" json:"last_event_at,omitempty"`         // Время (created) события Stripe, последним изменившего подписку
+}
+
+// Payment разовый платеж (PaymentIntent Stripe), созданный через API сервиса; статус обновляется из вебхуков payment_intent.*.
+type Payment struct {
+	PaymentID        string    `db:"payment_id" json:"payment_id"`                 // ID PaymentIntent Stripe (pi_...)
+	UserID           string    `db:"user_id" json:"user_id"`                       // Владелец клиента Stripe
+	StripeCustomerID string    `db:"stripe_customer_id" json:"stripe_customer_id"` // ID клиента Stripe (cus_...)
+	Amount           int64     `db:"amount" json:"amount"`                         // Сумма в минимальных единицах валюты
+	AmountReceived   int64     `db:"amount_received" json:"amount_received"`       // Фактически списано
+	Currency         string    `db:"currency" json:"currency"`
+	Status           string    `db:"status" json:"status"` // requires_payment_method, requires_confirmation, requires_action, processing, succeeded, canceled
+	Description      string    `db:"description" json:"description"`
+	Metadata         Metadata  `db:"metadata" json:"metadata"`                     // Метаданные PaymentIntent (например, что покупается)
+	LastPaymentError string    `db:"last_payment_error" json:"last_payment_error"` // Сообщение последней неудачной попытки оплаты
+	StripeCreatedAt  time.Time `db:"stripe_created_at" json:"stripe_created_at"`
+	LastEventAt      time.Time `db:"last_event_at" json:"last_event_at"` // Время последнего примененного изменения из Stripe
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Metadata метаданные объекта Stripe, хранятся в колонке JSONB.
+type Metadata map[string]string
+
+// Value реализует driver.Valuer.
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan реализует sql.Scanner.
+func (m *Metadata) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("models: cannot scan %T into Metadata", src)
+	}
+	return json.Unmarshal(data, m)
 }
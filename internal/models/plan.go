@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Plan элемент каталога планов - периодическая цена Stripe вместе с данными продукта.
+type Plan struct {
+	PlanID          string    `db:"plan_id" json:"plan_id"`                     // ID цены Stripe (price_...)
+	ProductID       string    `db:"product_id" json:"product_id"`               // ID продукта Stripe (prod_...)
+	Name            string    `db:"name" json:"name"`                           // Название продукта
+	Description     string    `db:"description" json:"description"`             // Описание продукта
+	Nickname        string    `db:"nickname" json:"nickname"`                   // Внутреннее название цены
+	Amount          int64     `db:"amount" json:"amount"`                       // Стоимость в минимальных единицах валюты
+	Currency        string    `db:"currency" json:"currency"`                   // Валюта (usd, eur, ...)
+	Interval        string    `db:"billing_interval" json:"interval"`           // Период списания (day, week, month, year)
+	IntervalCount   int64     `db:"interval_count" json:"interval_count"`       // Количество периодов между списаниями
+	TrialPeriodDays int64     `db:"trial_period_days" json:"trial_period_days"` // Пробный период по умолчанию для новых подписок (0 - нет)
+	PriceActive     bool      `db:"price_active" json:"price_active"`           // Цена не архивирована в Stripe
+	ProductActive   bool      `db:"product_active" json:"product_active"`       // Продукт не архивирован в Stripe
+	SyncedAt        time.Time `db:"synced_at" json:"synced_at"`                 // Когда состояние прочитано из Stripe
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Active сообщает, можно ли оформить подписку на план.
+func (p *Plan) Active() bool {
+	return p.PriceActive && p.ProductActive
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Refund возврат платежа Stripe, сохраненный при создании через API сервиса и из вебхуков charge.*.
+type Refund struct {
+	RefundID         string    `db:"refund_id" json:"refund_id"`                   // ID возврата Stripe (re_...)
+	UserID           string    `db:"user_id" json:"user_id"`                       // Владелец клиента Stripe
+	StripeCustomerID string    `db:"stripe_customer_id" json:"stripe_customer_id"` // ID клиента Stripe (cus_...)
+	ChargeID         string    `db:"charge_id" json:"charge_id"`                   // Возвращаемый платеж (ch_...)
+	PaymentIntentID  string    `db:"payment_intent_id" json:"payment_intent_id"`
+	InvoiceID        string    `db:"invoice_id" json:"invoice_id"` // Счет, который оплачивал платеж (пусто для разовых платежей)
+	Amount           int64     `db:"amount" json:"amount"`         // Сумма в минимальных единицах валюты
+	Currency         string    `db:"currency" json:"currency"`
+	Status           string    `db:"status" json:"status"`                 // pending, requires_action, succeeded, failed, canceled
+	Reason           string    `db:"reason" json:"reason"`                 // duplicate, fraudulent, requested_by_customer
+	FailureReason    string    `db:"failure_reason" json:"failure_reason"` // Причина для статуса failed
+	StripeCreatedAt  time.Time `db:"stripe_created_at" json:"stripe_created_at"`
+	LastEventAt      time.Time `db:"last_event_at" json:"last_event_at"` // Время последнего примененного изменения из Stripe
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// CreditNote кредит-нота Stripe: уменьшение суммы выставленного счета с возвратом или зачислением на баланс клиента.
+type CreditNote struct {
+	CreditNoteID     string     `db:"credit_note_id" json:"credit_note_id"` // ID кредит-ноты Stripe (cn_...)
+	UserID           string     `db:"user_id" json:"user_id"`
+	StripeCustomerID string     `db:"stripe_customer_id" json:"stripe_customer_id"`
+	InvoiceID        string     `db:"invoice_id" json:"invoice_id"`
+	Number           string     `db:"number" json:"number"`
+	Type             string     `db:"type" json:"type"`     // pre_payment (счет не оплачен), post_payment
+	Status           string     `db:"status" json:"status"` // issued, void
+	Reason           string     `db:"reason" json:"reason"` // duplicate, fraudulent, order_change, product_unsatisfactory
+	Memo             string     `db:"memo" json:"memo"`
+	Amount           int64      `db:"amount" json:"amount"` // Итоговая сумма кредит-ноты
+	Currency         string     `db:"currency" json:"currency"`
+	RefundID         string     `db:"refund_id" json:"refund_id"` // Возврат на карту, созданный кредит-нотой (пусто, если нет)
+	VoidedAt         *time.Time `db:"voided_at" json:"voided_at,omitempty"`
+	StripeCreatedAt  time.Time  `db:"stripe_created_at" json:"stripe_created_at"`
+	LastEventAt      time.Time  `db:"last_event_at" json:"last_event_at"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at" json:"updated_at"`
+}
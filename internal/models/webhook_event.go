@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Статусы обработки вебхука
+const (
+	WebhookEventStatusPending    = "pending"    // Событие сохранено и ожидает обработки воркером
+	WebhookEventStatusProcessing = "processing" // Событие обрабатывается
+	WebhookEventStatusProcessed  = "processed"  // Событие успешно обработано, повторные доставки игнорируются
+	WebhookEventStatusFailed     = "failed"     // Последняя попытка обработки завершилась ошибкой, запланирован повтор
+	WebhookEventStatusDead       = "dead"       // Попытки исчерпаны, событие требует ручного разбора
+	WebhookEventStatusStale      = "stale"      // Событие старше последнего примененного к подписке и было проигнорировано
+)
+
+// Источники попытки обработки вебхука
+const (
+	WebhookAttemptTriggerQueue  = "queue"  // Попытка воркера очереди
+	WebhookAttemptTriggerReplay = "replay" // Ручной повтор через административный API
+)
+
+// WebhookEvent представляет запись журнала (и очереди обработки) вебхуков Stripe.
+type WebhookEvent struct {
+	EventID         string     `db:"event_id" json:"event_id"`                             // ID события Stripe (evt_...)
+	EventType       string     `db:"event_type" json:"event_type"`                         // Тип события (например, invoice.payment_failed)
+	Payload         []byte     `db:"payload" json:"payload"`                               // Сырое тело запроса вебхука
+	Status          string     `db:"status" json:"status"`                                 // Статус обработки
+	Error           *string    `db:"error" json:"error,omitempty"`                         // Ошибка последней попытки обработки
+	Attempts        int        `db:"attempts" json:"attempts"`                             // Количество попыток обработки
+	StripeCreatedAt *time.Time `db:"stripe_created_at" json:"stripe_created_at,omitempty"` // Время создания события в Stripe
+	SubscriptionID  *string    `db:"subscription_id" json:"subscription_id,omitempty"`     // ID подписки Stripe, к которой относится событие
+	NextAttemptAt   time.Time  `db:"next_attempt_at" json:"next_attempt_at"`               // Время следующей попытки обработки
+	ReceivedAt      time.Time  `db:"received_at" json:"received_at"`                       // Время первой доставки
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`                         // Время последнего изменения записи
+	ProcessedAt     *time.Time `db:"processed_at" json:"processed_at,omitempty"`           // Время успешной обработки
+}
+
+// OrderTime возвращает время, по которому события упорядочиваются при обработке:
+// время создания в Stripe, а для событий без него - время получения.
+func (e *WebhookEvent) OrderTime() time.Time {
+	if e.StripeCreatedAt != nil {
+		return *e.StripeCreatedAt
+	}
+	return e.ReceivedAt
+}
+
+// WebhookEventFilter параметры выборки событий из журнала вебхуков.
+type WebhookEventFilter struct {
+	Status    string     // Фильтр по статусу (пустой - все)
+	EventType string     // Фильтр по типу события (пустой - все)
+	From      *time.Time // Получены не раньше (включительно)
+	To        *time.Time // Получены раньше (не включительно)
+	Limit     int        // Максимальное количество записей
+	Offset    int        // Смещение для пагинации
+}
+
+// WebhookEventAttempt представляет одну попытку обработки события (история обработки).
+type WebhookEventAttempt struct {
+	ID          int64     `db:"id" json:"id"`
+	EventID     string    `db:"event_id" json:"event_id"`                   // ID события Stripe
+	Attempt     int       `db:"attempt" json:"attempt"`                     // Номер попытки
+	Trigger     string    `db:"trigger" json:"trigger"`                     // Источник попытки (queue, replay)
+	Outcome     string    `db:"outcome" json:"outcome"`                     // Статус события после попытки
+	Error       *string   `db:"error" json:"error,omitempty"`               // Ошибка попытки
+	TriggeredBy *string   `db:"triggered_by" json:"triggered_by,omitempty"` // Кто запросил повтор (для replay)
+	StartedAt   time.Time `db:"started_at" json:"started_at"`               // Начало попытки
+	FinishedAt  time.Time `db:"finished_at" json:"finished_at"`             // Окончание попытки
+}
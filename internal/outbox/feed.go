@@ -0,0 +1,182 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+const (
+	defaultFeedPollInterval = 1 * time.Second
+	feedBatchSize           = 500
+
+	// Событие попадает в ленту только спустя feedSettleDelay после записи (по часам БД): id в outbox
+	// выдаются при вставке, а транзакции фиксируются не строго по порядку id. Задержка дает более ранним
+	// транзакциям зафиксироваться, чтобы лента не перескочила их события. Событие транзакции, которая
+	// фиксируется дольше feedSettleDelay после вставки, лента пропустит (см. Feed).
+	feedSettleDelay = 2 * time.Second
+
+	// Размер буфера подписчика. Подписчик, не успевающий читать, отключается.
+	feedSubscriberBuffer = 64
+)
+
+// FeedFilter определяет, какие события получает подписчик ленты.
+type FeedFilter struct {
+	UserID         string // Владелец подписки (обязателен)
+	SubscriptionID string // Конкретная подписка (пустой - все подписки пользователя)
+}
+
+// feedSubscriber подписчик ленты.
+type feedSubscriber struct {
+	filter FeedFilter
+	ch     chan *kafka.SubscriptionEvent
+}
+
+// Feed читает события подписок из outbox и раздает их подписчикам внутри процесса
+// (например, gRPC стримам WatchSubscriptions). Так как outbox общий для всех реплик,
+// подписчик видит изменения, примененные любой репликой сервиса.
+//
+// Доставка best-effort: лента движется по id outbox и не отслеживает доставку, поэтому событие транзакции,
+// зафиксированной позже feedSettleDelay после вставки, пропускается, а события до подключения подписчика
+// и во время переподключения не повторяются. Подписчик должен перечитывать состояние подписки
+// (GetSubscription) после подключения; гарантированная доставка - только через Kafka (Relay).
+type Feed struct {
+	repo         repository.OutboxRepository
+	pollInterval time.Duration
+	log          *logger.Logger
+
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]*feedSubscriber
+}
+
+// NewFeed создает ленту изменений подписок. Нулевой pollInterval заменяется значением по умолчанию.
+func NewFeed(repo repository.OutboxRepository, pollInterval time.Duration, log *logger.Logger) *Feed {
+	if pollInterval <= 0 {
+		pollInterval = defaultFeedPollInterval
+	}
+	return &Feed{
+		repo:         repo,
+		pollInterval: pollInterval,
+		log:          log,
+		subscribers:  make(map[int]*feedSubscriber),
+	}
+}
+
+// Subscribe регистрирует подписчика. Канал закрывается, если подписчик не успевает читать события;
+// cancel нужно вызвать по окончании работы.
+func (f *Feed) Subscribe(filter FeedFilter) (<-chan *kafka.SubscriptionEvent, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := f.nextID
+	f.nextID++
+	sub := &feedSubscriber{filter: filter, ch: make(chan *kafka.SubscriptionEvent, feedSubscriberBuffer)}
+	f.subscribers[id] = sub
+
+	cancel := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subscribers[id]; ok {
+			delete(f.subscribers, id)
+			close(sub.ch)
+		}
+	}
+	return sub.ch, cancel
+}
+
+// Run запускает цикл чтения outbox и блокируется до отмены ctx.
+// Лента начинается с событий, записанных после запуска.
+func (f *Feed) Run(ctx context.Context) {
+	cursor, err := f.repo.LastID(ctx)
+	for err != nil {
+		f.log.Errorw("Subscription feed failed to read outbox position, retrying. Error: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.pollInterval):
+		}
+		cursor, err = f.repo.LastID(ctx)
+	}
+
+	f.log.Infow("Subscription feed started. PollInterval: %s, StartAfterID: %d", f.pollInterval, cursor)
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			f.closeAll()
+			f.log.Infow("Subscription feed stopped")
+			return
+		case <-ticker.C:
+			cursor = f.poll(ctx, cursor)
+		}
+	}
+}
+
+// poll читает новые события outbox, раздает их подписчикам и возвращает новую позицию.
+func (f *Feed) poll(ctx context.Context, cursor int64) int64 {
+	for ctx.Err() == nil {
+		events, err := f.repo.FetchSince(ctx, cursor, feedSettleDelay, feedBatchSize)
+		if err != nil {
+			f.log.Errorw("Subscription feed failed to fetch outbox events. Error: %v", err)
+			return cursor
+		}
+
+		for _, event := range events {
+			cursor = event.ID
+			var envelope kafka.SubscriptionEvent
+			if err := json.Unmarshal(event.Payload, &envelope); err != nil {
+				f.log.Warnw("Subscription feed skipped malformed outbox event. ID: %d, Error: %v", event.ID, err)
+				continue
+			}
+			if envelope.Subscription == nil {
+				continue
+			}
+			f.dispatch(&envelope)
+		}
+
+		if len(events) < feedBatchSize {
+			return cursor
+		}
+	}
+	return cursor
+}
+
+// dispatch отправляет событие подходящим подписчикам. Переполненные подписчики отключаются.
+func (f *Feed) dispatch(event *kafka.SubscriptionEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for id, sub := range f.subscribers {
+		if sub.filter.UserID != event.Subscription.UserID {
+			continue
+		}
+		if sub.filter.SubscriptionID != "" && sub.filter.SubscriptionID != event.Subscription.SubscriptionID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			f.log.Warnw("Subscription feed subscriber is too slow, disconnecting. UserID: %s", sub.filter.UserID)
+			delete(f.subscribers, id)
+			close(sub.ch)
+		}
+	}
+}
+
+// closeAll отключает всех подписчиков при остановке ленты.
+func (f *Feed) closeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, sub := range f.subscribers {
+		delete(f.subscribers, id)
+		close(sub.ch)
+	}
+}
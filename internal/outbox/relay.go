@@ -0,0 +1,199 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+const (
+	defaultPollInterval = 1 * time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 20 // С учетом задержек это около часа попыток
+
+	// Параметры экспоненциальной задержки между попытками публикации одного события
+	retryBaseDelay = 1 * time.Second
+	retryMaxDelay  = 5 * time.Minute
+
+	// Опубликованные события хранятся defaultRetention (для разбора инцидентов), затем удаляются
+	// пачками не чаще раза в cleanupInterval
+	defaultRetention = 7 * 24 * time.Hour
+	cleanupInterval  = 1 * time.Hour
+	cleanupBatchSize = 1000
+)
+
+// Relay периодически читает неопубликованные события из outbox и отправляет их в Kafka.
+// Гарантирует доставку at-least-once и порядок событий в рамках одной подписки:
+// пока старое событие подписки не опубликовано, более новые события этой подписки не отправляются.
+// После maxAttempts неудачных попыток событие уходит в dead-letter, и подписка публикуется дальше.
+// Опубликованные события старше retention релей удаляет из outbox.
+type Relay struct {
+	repo         repository.OutboxRepository
+	producer     kafka.Producer
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	retention    time.Duration
+	lastCleanup  time.Time // Время последней очистки (в этой реплике)
+	log          *logger.Logger
+}
+
+// NewRelay создает новый релей outbox. Нулевые pollInterval, batchSize, maxAttempts и retention заменяются значениями по умолчанию.
+func NewRelay(repo repository.OutboxRepository, producer kafka.Producer, pollInterval time.Duration, batchSize, maxAttempts int, retention time.Duration, log *logger.Logger) *Relay {
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &Relay{
+		repo:         repo,
+		producer:     producer,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		maxAttempts:  maxAttempts,
+		retention:    retention,
+		log:          log,
+	}
+}
+
+// Run запускает цикл релея и блокируется до отмены ctx.
+func (r *Relay) Run(ctx context.Context) {
+	r.log.Infow("Outbox relay started. PollInterval: %s, BatchSize: %d, MaxAttempts: %d, Retention: %s",
+		r.pollInterval, r.batchSize, r.maxAttempts, r.retention)
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.log.Infow("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			r.tick(ctx)
+		}
+	}
+}
+
+// tick захватывает блокировку релея и разгребает outbox, пока есть что публиковать.
+func (r *Relay) tick(ctx context.Context) {
+	release, acquired, err := r.repo.TryAcquireRelayLock(ctx)
+	if err != nil {
+		r.log.Errorw("Outbox relay failed to acquire lock. Error: %v", err)
+		return
+	}
+	if !acquired {
+		// Outbox обрабатывает другая реплика
+		return
+	}
+	defer release()
+
+	for ctx.Err() == nil {
+		// В пачке по одному событию на подписку, поэтому продолжаем сразу же, пока есть прогресс:
+		// следующие события подписок становятся доступны после публикации текущих, а события,
+		// отложенные до ретрая, освобождают место в пачке для других подписок
+		if r.processBatch(ctx) == 0 {
+			break
+		}
+	}
+
+	if time.Since(r.lastCleanup) >= cleanupInterval {
+		r.cleanup(ctx)
+	}
+}
+
+// cleanup удаляет опубликованные события старше retention (под блокировкой релея, то есть в одной реплике).
+func (r *Relay) cleanup(ctx context.Context) {
+	var total int64
+	for ctx.Err() == nil {
+		deleted, err := r.repo.DeletePublished(ctx, r.retention, cleanupBatchSize)
+		if err != nil {
+			r.log.Errorw("Outbox relay failed to delete published events. Error: %v", err)
+			return
+		}
+		total += deleted
+		if deleted < cleanupBatchSize {
+			break
+		}
+	}
+	r.lastCleanup = time.Now()
+	if total > 0 {
+		r.log.Infow("Outbox relay deleted published events. Count: %d, Retention: %s", total, r.retention)
+	}
+}
+
+// processBatch публикует одну пачку событий и возвращает число событий, результат которых записан
+// (опубликованы, отложены до ретрая или переведены в dead-letter).
+func (r *Relay) processBatch(ctx context.Context) (recorded int) {
+	events, err := r.repo.FetchPending(ctx, r.batchSize)
+	if err != nil {
+		r.log.Errorw("Outbox relay failed to fetch pending events. Error: %v", err)
+		return 0
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if r.publish(ctx, event) {
+			recorded++
+		}
+	}
+	return recorded
+}
+
+// publish отправляет одно событие в Kafka и записывает результат. Возвращает false, если результат
+// записать не удалось (событие будет выбрано снова при следующем проходе).
+func (r *Relay) publish(ctx context.Context, event models.OutboxEvent) bool {
+	attempt := event.Attempts + 1
+	if err := r.producer.Publish(ctx, event.Topic, []byte(event.MessageKey), event.Payload); err != nil {
+		if attempt >= r.maxAttempts {
+			r.log.Errorw("Outbox event moved to dead-letter. ID: %d, AggregateID: %s, Topic: %s, Attempts: %d, Error: %v",
+				event.ID, event.AggregateID, event.Topic, attempt, err)
+			if markErr := r.repo.MarkDead(ctx, event.ID, err.Error()); markErr != nil {
+				r.log.Errorw("Outbox relay failed to move event to dead-letter. ID: %d, Error: %v", event.ID, markErr)
+				return false
+			}
+			return true
+		}
+
+		nextAttemptAt := time.Now().Add(retryDelay(attempt))
+		r.log.Warnw("Outbox relay failed to publish event. ID: %d, AggregateID: %s, Topic: %s, Attempt: %d, NextAttemptAt: %s, Error: %v",
+			event.ID, event.AggregateID, event.Topic, attempt, nextAttemptAt, err)
+		if markErr := r.repo.MarkFailed(ctx, event.ID, err.Error(), nextAttemptAt); markErr != nil {
+			r.log.Errorw("Outbox relay failed to record publish failure. ID: %d, Error: %v", event.ID, markErr)
+			return false
+		}
+		return true
+	}
+
+	if err := r.repo.MarkPublished(ctx, event.ID); err != nil {
+		// Событие уйдет в Kafka повторно при следующем проходе (at-least-once)
+		r.log.Errorw("Outbox relay failed to mark event as published. ID: %d, Error: %v", event.ID, err)
+		return false
+	}
+	r.log.Debugw("Outbox event published. ID: %d, AggregateID: %s, Topic: %s", event.ID, event.AggregateID, event.Topic)
+	return true
+}
+
+// retryDelay вычисляет задержку перед попыткой номер attempt (1, 2, 4, ... секунд, но не больше retryMaxDelay).
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
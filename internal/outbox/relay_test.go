@@ -0,0 +1,211 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+// fakeOutboxRepo хранит outbox в памяти и повторяет семантику выборки postgresOutboxRepo.
+type fakeOutboxRepo struct {
+	repository.OutboxRepository
+
+	mu      sync.Mutex
+	events  []*models.OutboxEvent
+	deleted int
+}
+
+func (r *fakeOutboxRepo) add(aggregateID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := int64(len(r.events) + 1)
+	r.events = append(r.events, &models.OutboxEvent{
+		ID:          id,
+		AggregateID: aggregateID,
+		Topic:       "subscription.updated",
+		MessageKey:  aggregateID,
+		Payload:     []byte(strconv.FormatInt(id, 10)),
+	})
+}
+
+func (r *fakeOutboxRepo) find(id int64) *models.OutboxEvent {
+	return r.events[id-1]
+}
+
+func (r *fakeOutboxRepo) FetchPending(_ context.Context, limit int) ([]models.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := make(map[string]bool)
+	var heads []models.OutboxEvent
+	for _, e := range r.events {
+		if e.PublishedAt != nil || e.DeadAt != nil || seen[e.AggregateID] {
+			continue
+		}
+		seen[e.AggregateID] = true
+		if !e.NextAttemptAt.After(time.Now()) {
+			heads = append(heads, *e)
+		}
+	}
+	sort.Slice(heads, func(i, j int) bool { return heads[i].ID < heads[j].ID })
+	if len(heads) > limit {
+		heads = heads[:limit]
+	}
+	return heads, nil
+}
+
+func (r *fakeOutboxRepo) MarkPublished(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.find(id).PublishedAt = &now
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkFailed(_ context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e := r.find(id)
+	e.Attempts++
+	e.LastError = &lastError
+	e.NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (r *fakeOutboxRepo) MarkDead(_ context.Context, id int64, lastError string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	e := r.find(id)
+	e.Attempts++
+	e.LastError = &lastError
+	e.DeadAt = &now
+	return nil
+}
+
+func (r *fakeOutboxRepo) DeletePublished(context.Context, time.Duration, int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted++
+	return 0, nil
+}
+
+func (r *fakeOutboxRepo) TryAcquireRelayLock(context.Context) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+// fakeProducer запоминает опубликованные события и отклоняет сообщения подписок из reject.
+type fakeProducer struct {
+	kafka.Producer
+
+	reject    map[string]bool
+	published []string // Тела сообщений (ID событий outbox) в порядке публикации
+}
+
+func (p *fakeProducer) Publish(_ context.Context, _ string, key, value []byte) error {
+	if p.reject[string(key)] {
+		return errors.New("kafka: message rejected")
+	}
+	p.published = append(p.published, string(value))
+	return nil
+}
+
+func newTestRelay(repo *fakeOutboxRepo, producer *fakeProducer, batchSize, maxAttempts int) *Relay {
+	return NewRelay(repo, producer, time.Second, batchSize, maxAttempts, time.Hour, logger.New(logger.FATAL))
+}
+
+func TestRelayPublishesEachAggregateInOrder(t *testing.T) {
+	repo := &fakeOutboxRepo{}
+	for _, id := range []string{"sub_a", "sub_b", "sub_a", "sub_a", "sub_b"} {
+		repo.add(id)
+	}
+	producer := &fakeProducer{}
+
+	// Пачка меньше числа событий: релей должен продолжать, пока есть прогресс
+	newTestRelay(repo, producer, 1, 3).tick(context.Background())
+
+	// За проход публикуется только голова каждой подписки, порядок внутри подписки сохраняется
+	want := []string{"1", "2", "3", "4", "5"}
+	if !slices.Equal(producer.published, want) {
+		t.Fatalf("published = %v, want %v", producer.published, want)
+	}
+	if repo.deleted == 0 {
+		t.Fatal("tick did not clean up published events")
+	}
+}
+
+func TestRelayFailureDoesNotBlockOtherAggregates(t *testing.T) {
+	repo := &fakeOutboxRepo{}
+	for _, id := range []string{"sub_bad", "sub_bad", "sub_ok", "sub_ok"} {
+		repo.add(id)
+	}
+	producer := &fakeProducer{reject: map[string]bool{"sub_bad": true}}
+	relay := newTestRelay(repo, producer, 1, 3)
+
+	relay.tick(context.Background())
+
+	first := repo.find(1)
+	if first.Attempts != 1 || first.LastError == nil || !first.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("failed event: attempts=%d next_attempt_at=%s, want one attempt and a future retry", first.Attempts, first.NextAttemptAt)
+	}
+	if repo.find(2).Attempts != 0 || repo.find(2).PublishedAt != nil {
+		t.Fatal("later event of the failing subscription was touched before the earlier one")
+	}
+	// Событие, ожидающее ретрая, не занимает единственное место в пачке
+	if want := []string{"3", "4"}; !slices.Equal(producer.published, want) {
+		t.Fatalf("published = %v, want both sub_ok events %v", producer.published, want)
+	}
+}
+
+func TestRelayMovesEventToDeadLetterAfterMaxAttempts(t *testing.T) {
+	repo := &fakeOutboxRepo{}
+	repo.add("sub_bad")
+	repo.add("sub_bad")
+	producer := &fakeProducer{reject: map[string]bool{"sub_bad": true}}
+	relay := newTestRelay(repo, producer, 10, 3)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		repo.find(1).NextAttemptAt = time.Time{} // Не ждем задержку ретрая
+		relay.processBatch(context.Background())
+	}
+
+	first := repo.find(1)
+	if first.DeadAt == nil || first.Attempts != 3 {
+		t.Fatalf("event after max attempts: dead_at=%v attempts=%d, want dead after 3 attempts", first.DeadAt, first.Attempts)
+	}
+
+	// Следующее событие подписки больше не ждет событие из dead-letter
+	producer.reject = nil
+	relay.processBatch(context.Background())
+	if repo.find(2).PublishedAt == nil {
+		t.Fatal("event after a dead-lettered one was not published")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{9, 256 * time.Second},
+		{10, retryMaxDelay},
+		{50, retryMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
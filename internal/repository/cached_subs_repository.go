@@ -2,27 +2,43 @@ package repository
 
 import (
 	"context"
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
 	"github.com/Dhoini/Payment-microservice/internal/models"
 	"github.com/Dhoini/Payment-microservice/pkg/logger"
 )
 
 // CachedSubscriptionRepository реализует SubscriptionRepository с кешированием
 type CachedSubscriptionRepository struct {
-	repo  SubscriptionRepository
-	cache *RedisCacheRepository
-	log   *logger.Logger
+	repo    SubscriptionRepository
+	cache   *RedisCacheRepository
+	lookups *metrics.CounterVec // Обращения к кешу по виду ключа и результату (hit/miss/error)
+	log     *logger.Logger
 }
 
+// Виды ключей и результаты обращений к кешу для метрики subscription_cache_lookups_total
+const (
+	cacheSubscription      = "subscription"
+	cacheUserSubscriptions = "user_subscriptions"
+
+	cacheHit   = "hit"
+	cacheMiss  = "miss"
+	cacheError = "error"
+)
+
 // NewCachedSubscriptionRepository создает новый репозиторий с кешированием
 func NewCachedSubscriptionRepository(
 	repo SubscriptionRepository,
 	cache *RedisCacheRepository,
+	registry *metrics.Registry,
 	log *logger.Logger,
 ) SubscriptionRepository {
 	return &CachedSubscriptionRepository{
 		repo:  repo,
 		cache: cache,
-		log:   log,
+		lookups: registry.NewCounterVec("subscription_cache_lookups_total",
+			"Total number of Redis subscription cache lookups by cache and result (hit, miss or error).",
+			"cache", "result"),
+		log: log,
 	}
 }
 
@@ -55,6 +71,7 @@ func (r *CachedSubscriptionRepository) GetByID(ctx context.Context, subscription
 		r.log.Warnw("Error getting subscription from cache", "error", err, "subscriptionID", subscriptionID)
 		// Продолжаем выполнение при ошибке кеша
 	}
+	r.lookups.Inc(cacheSubscription, lookupResult(cachedSub != nil, err))
 
 	// Если нашли в кеше, возвращаем
 	if cachedSub != nil {
@@ -86,6 +103,7 @@ func (r *CachedSubscriptionRepository) GetByUserID(ctx context.Context, userID s
 		r.log.Warnw("Error getting user subscriptions from cache", "error", err, "userID", userID)
 		// Продолжаем выполнение при ошибке кеша
 	}
+	r.lookups.Inc(cacheUserSubscriptions, lookupResult(len(cachedSubs) > 0, err))
 
 	// Если нашли в кеше, возвращаем
 	if cachedSubs != nil && len(cachedSubs) > 0 {
@@ -109,6 +127,11 @@ func (r *CachedSubscriptionRepository) GetByUserID(ctx context.Context, userID s
 	return subs, nil
 }
 
+// ListByUserID возвращает страницу подписок пользователя напрямую из БД (страницы не кешируются)
+func (r *CachedSubscriptionRepository) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Subscription, error) {
+	return r.repo.ListByUserID(ctx, userID, limit, offset)
+}
+
 // Update обновляет подписку в БД и кеше
 func (r *CachedSubscriptionRepository) Update(ctx context.Context, sub *models.Subscription) error {
 	// Сначала обновляем в основном хранилище
@@ -134,3 +157,43 @@ func (r *CachedSubscriptionRepository) GetByStripeSubscriptionID(ctx context.Con
 	// В нашей реализации это то же самое, что GetByID
 	return r.GetByID(ctx, stripeSubscriptionID)
 }
+
+// CreateWithEvent сохраняет подписку вместе с событием outbox и кеширует ее
+func (r *CachedSubscriptionRepository) CreateWithEvent(ctx context.Context, sub *models.Subscription, event *models.OutboxEvent) error {
+	if err := r.repo.CreateWithEvent(ctx, sub, event); err != nil {
+		return err
+	}
+	r.refreshCache(ctx, sub)
+	return nil
+}
+
+// UpdateWithEvent обновляет подписку вместе с событием outbox и обновляет кеш
+func (r *CachedSubscriptionRepository) UpdateWithEvent(ctx context.Context, sub *models.Subscription, event *models.OutboxEvent) error {
+	if err := r.repo.UpdateWithEvent(ctx, sub, event); err != nil {
+		return err
+	}
+	r.refreshCache(ctx, sub)
+	return nil
+}
+
+// refreshCache кеширует подписку и инвалидирует кеш списка подписок пользователя
+func (r *CachedSubscriptionRepository) refreshCache(ctx context.Context, sub *models.Subscription) {
+	if err := r.cache.CacheSubscription(ctx, sub); err != nil {
+		r.log.Warnw("Failed to cache subscription. SubscriptionID: %s, Error: %v", sub.SubscriptionID, err)
+	}
+	if err := r.cache.InvalidateUserSubscriptionsCache(ctx, sub.UserID); err != nil {
+		r.log.Warnw("Failed to invalidate user subscriptions cache. UserID: %s, Error: %v", sub.UserID, err)
+	}
+}
+
+// lookupResult возвращает результат обращения к кешу для метрики.
+func lookupResult(found bool, err error) string {
+	switch {
+	case err != nil:
+		return cacheError
+	case found:
+		return cacheHit
+	default:
+		return cacheMiss
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/Dhoini/Payment-microservice/internal/models"
@@ -16,6 +17,9 @@ var (
 
 type CustomerRepository interface {
 	Create(ctx context.Context, customer *models.Customer) error
+	// CreateIfNotExists сохраняет связь userID -> Stripe customer, если ее еще нет.
+	// Возвращает сохраненную в БД запись и признак того, что она создана этим вызовом.
+	CreateIfNotExists(ctx context.Context, customer *models.Customer) (*models.Customer, bool, error)
 	GetByUserID(ctx context.Context, userID string) (*models.Customer, error)
 	GetByStripeID(ctx context.Context, stripeID string) (*models.Customer, error)
 	Update(ctx context.Context, customer *models.Customer) error
@@ -55,6 +59,43 @@ func (r *postgresCustomerRepository) Create(ctx context.Context, customer *model
 	return nil
 }
 
+// CreateIfNotExists вставляет запись с ON CONFLICT DO NOTHING, поэтому параллельные запросы
+// для одного пользователя не падают на уникальном ключе, а получают одну и ту же запись.
+func (r *postgresCustomerRepository) CreateIfNotExists(ctx context.Context, customer *models.Customer) (*models.Customer, bool, error) {
+	query := `
+		INSERT INTO customers (user_id, stripe_customer_id, email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		customer.UserID,
+		customer.StripeCustomerID,
+		customer.Email,
+		customer.CreatedAt,
+		customer.UpdatedAt,
+	)
+	if err != nil {
+		r.log.Errorw("Failed to create customer. UserID: %s, Error: %v", customer.UserID, err)
+		return nil, false, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 1 {
+		return customer, true, nil
+	}
+
+	// Запись уже создана другим запросом - возвращаем ее
+	existing, err := r.GetByUserID(ctx, customer.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
 func (r *postgresCustomerRepository) GetByUserID(ctx context.Context, userID string) (*models.Customer, error) {
 	var customer models.Customer
 
@@ -66,6 +107,9 @@ func (r *postgresCustomerRepository) GetByUserID(ctx context.Context, userID str
 
 	err := r.db.GetContext(ctx, &customer, query, userID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCustomerNotFound
+		}
 		r.log.Errorw("Failed to get customer by userID", "error", err, "userID", userID)
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
@@ -84,7 +128,9 @@ func (r *postgresCustomerRepository) GetByStripeID(ctx context.Context, stripeID
 
 	err := r.db.GetContext(ctx, &customer, query, stripeID)
 	if err != nil {
-
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCustomerNotFound
+		}
 		r.log.Errorw("Failed to get customer by stripeID", "error", err, "stripeID", stripeID)
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
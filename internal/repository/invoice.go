@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// invoiceColumns список колонок счетов для SELECT.
+const invoiceColumns = `invoice_id, user_id, stripe_customer_id, subscription_id, stripe_subscription_id,
+               number, status, billing_reason, currency, amount_due, amount_paid, amount_remaining,
+               subtotal, total, attempt_count, period_start, period_end, hosted_invoice_url, invoice_pdf,
+               paid_at, stripe_created_at, last_event_at, created_at, updated_at`
+
+// InvoiceRepository определяет методы для работы с локальной историей счетов Stripe.
+type InvoiceRepository interface {
+	// Upsert сохраняет состояние счета из события Stripe. Запись не перезаписывается,
+	// если в БД уже лежит состояние из более позднего события (invoice.LastEventAt).
+	Upsert(ctx context.Context, invoice *models.Invoice) error
+
+	// GetByID возвращает счет по ID счета Stripe (ErrNotFound, если счета нет).
+	GetByID(ctx context.Context, invoiceID string) (*models.Invoice, error)
+
+	// ListByUserID возвращает страницу счетов пользователя, новые первыми.
+	ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Invoice, error)
+}
+
+// postgresInvoiceRepo реализует InvoiceRepository для PostgreSQL.
+type postgresInvoiceRepo struct {
+	db  *sqlx.DB
+	log *logger.Logger
+}
+
+// NewPostgresInvoiceRepository создает новый экземпляр репозитория счетов.
+func NewPostgresInvoiceRepository(db *sqlx.DB, log *logger.Logger) InvoiceRepository {
+	return &postgresInvoiceRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Upsert вставляет или обновляет счет. Условие по last_event_at защищает от событий,
+// пришедших не по порядку (например, invoice.finalized после invoice.paid).
+func (r *postgresInvoiceRepo) Upsert(ctx context.Context, invoice *models.Invoice) error {
+	now := time.Now()
+	invoice.CreatedAt = now
+	invoice.UpdatedAt = now
+
+	query := `
+        INSERT INTO invoices (invoice_id, user_id, stripe_customer_id, subscription_id, stripe_subscription_id,
+                              number, status, billing_reason, currency, amount_due, amount_paid, amount_remaining,
+                              subtotal, total, attempt_count, period_start, period_end, hosted_invoice_url, invoice_pdf,
+                              paid_at, stripe_created_at, last_event_at, created_at, updated_at)
+        VALUES (:invoice_id, :user_id, :stripe_customer_id, :subscription_id, :stripe_subscription_id,
+                :number, :status, :billing_reason, :currency, :amount_due, :amount_paid, :amount_remaining,
+                :subtotal, :total, :attempt_count, :period_start, :period_end, :hosted_invoice_url, :invoice_pdf,
+                :paid_at, :stripe_created_at, :last_event_at, :created_at, :updated_at)
+        ON CONFLICT (invoice_id) DO UPDATE SET
+            user_id = EXCLUDED.user_id,
+            stripe_customer_id = EXCLUDED.stripe_customer_id,
+            subscription_id = EXCLUDED.subscription_id,
+            stripe_subscription_id = EXCLUDED.stripe_subscription_id,
+            number = EXCLUDED.number,
+            status = EXCLUDED.status,
+            billing_reason = EXCLUDED.billing_reason,
+            currency = EXCLUDED.currency,
+            amount_due = EXCLUDED.amount_due,
+            amount_paid = EXCLUDED.amount_paid,
+            amount_remaining = EXCLUDED.amount_remaining,
+            subtotal = EXCLUDED.subtotal,
+            total = EXCLUDED.total,
+            attempt_count = EXCLUDED.attempt_count,
+            period_start = EXCLUDED.period_start,
+            period_end = EXCLUDED.period_end,
+            hosted_invoice_url = EXCLUDED.hosted_invoice_url,
+            invoice_pdf = EXCLUDED.invoice_pdf,
+            paid_at = EXCLUDED.paid_at,
+            stripe_created_at = EXCLUDED.stripe_created_at,
+            last_event_at = EXCLUDED.last_event_at,
+            updated_at = EXCLUDED.updated_at
+        WHERE invoices.last_event_at <= EXCLUDED.last_event_at`
+
+	if _, err := r.db.NamedExecContext(ctx, query, invoice); err != nil {
+		r.log.Errorw("Failed to upsert invoice. InvoiceID: %s, Error: %v", invoice.InvoiceID, err)
+		return fmt.Errorf("repository: failed to upsert invoice: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает счет по ID счета Stripe.
+func (r *postgresInvoiceRepo) GetByID(ctx context.Context, invoiceID string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	query := `
+        SELECT ` + invoiceColumns + `
+        FROM invoices
+        WHERE invoice_id = $1`
+
+	if err := r.db.GetContext(ctx, &invoice, query, invoiceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("Failed to get invoice. InvoiceID: %s, Error: %v", invoiceID, err)
+		return nil, fmt.Errorf("repository: failed to get invoice: %w", err)
+	}
+	return &invoice, nil
+}
+
+// ListByUserID возвращает страницу счетов пользователя.
+func (r *postgresInvoiceRepo) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Invoice, error) {
+	query := `
+        SELECT ` + invoiceColumns + `
+        FROM invoices
+        WHERE user_id = $1
+        ORDER BY stripe_created_at DESC, invoice_id DESC
+        LIMIT $2 OFFSET $3`
+
+	var invoices []*models.Invoice
+	if err := r.db.SelectContext(ctx, &invoices, query, userID, limit, offset); err != nil {
+		r.log.Errorw("Failed to list invoices. UserID: %s, Error: %v", userID, err)
+		return nil, fmt.Errorf("repository: failed to list invoices: %w", err)
+	}
+	return invoices, nil
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// outboxRelayLockID ключ advisory lock в Postgres, гарантирующий, что outbox
+// разгребает только один релей, даже если запущено несколько реплик сервиса.
+const outboxRelayLockID int64 = 7_340_001
+
+// OutboxRepository определяет методы для работы с таблицей outbox.
+type OutboxRepository interface {
+	// Enqueue записывает событие в outbox вне транзакции подписки
+	// (когда строка subscriptions не меняется, например при отмене до прихода вебхука).
+	Enqueue(ctx context.Context, event *models.OutboxEvent) error
+
+	// FetchPending возвращает события, готовые к публикации: для каждой подписки только самое раннее
+	// неопубликованное событие (не из dead-letter), и только если для него наступило next_attempt_at.
+	FetchPending(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+
+	// MarkPublished отмечает событие как успешно опубликованное.
+	MarkPublished(ctx context.Context, id int64) error
+
+	// MarkFailed увеличивает счетчик попыток и откладывает следующую попытку.
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+
+	// MarkDead сохраняет ошибку и переводит событие в dead-letter: релей его больше не публикует,
+	// и следующие события подписки перестают его ждать.
+	MarkDead(ctx context.Context, id int64, lastError string) error
+
+	// FetchSince возвращает события с id > afterID, записанные не позже чем settleDelay назад по часам БД,
+	// в порядке id (независимо от статуса публикации). Используется лентой изменений подписок.
+	FetchSince(ctx context.Context, afterID int64, settleDelay time.Duration, limit int) ([]models.OutboxEvent, error)
+
+	// DeletePublished удаляет до limit событий, опубликованных раньше чем retention назад.
+	// Возвращает число удаленных событий.
+	DeletePublished(ctx context.Context, retention time.Duration, limit int) (int64, error)
+
+	// LastID возвращает максимальный id события в outbox (0, если outbox пуст).
+	LastID(ctx context.Context) (int64, error)
+
+	// TryAcquireRelayLock пытается захватить эксклюзивную блокировку релея.
+	// Если блокировка получена, release должен быть вызван по окончании работы.
+	TryAcquireRelayLock(ctx context.Context) (release func(), acquired bool, err error)
+}
+
+// postgresOutboxRepo реализует OutboxRepository для PostgreSQL.
+type postgresOutboxRepo struct {
+	db  *sqlx.DB
+	log *logger.Logger
+}
+
+// NewPostgresOutboxRepository создает новый экземпляр репозитория outbox.
+func NewPostgresOutboxRepository(db *sqlx.DB, log *logger.Logger) OutboxRepository {
+	return &postgresOutboxRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// insertOutboxEvent вставляет событие через переданный исполнитель (БД или транзакцию).
+// Используется репозиторием подписок, чтобы писать событие в той же транзакции, что и строку subscriptions.
+// created_at берется по часам БД (время начала транзакции), чтобы задержка ленты не зависела от часов реплик.
+func insertOutboxEvent(ctx context.Context, execer sqlx.ExtContext, event *models.OutboxEvent) error {
+	query := `
+        INSERT INTO outbox (aggregate_id, topic, message_key, payload, created_at, next_attempt_at)
+        VALUES ($1, $2, $3, $4, NOW(), NOW())
+        RETURNING id, created_at, next_attempt_at`
+
+	if err := execer.QueryRowxContext(ctx, query,
+		event.AggregateID,
+		event.Topic,
+		event.MessageKey,
+		event.Payload,
+	).Scan(&event.ID, &event.CreatedAt, &event.NextAttemptAt); err != nil {
+		return fmt.Errorf("repository: failed to insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// Enqueue записывает событие в outbox.
+func (r *postgresOutboxRepo) Enqueue(ctx context.Context, event *models.OutboxEvent) error {
+	if err := insertOutboxEvent(ctx, r.db, event); err != nil {
+		r.log.Errorw("Failed to enqueue outbox event. AggregateID: %s, Topic: %s, Error: %v", event.AggregateID, event.Topic, err)
+		return err
+	}
+	r.log.Debugw("Outbox event enqueued. ID: %d, AggregateID: %s, Topic: %s", event.ID, event.AggregateID, event.Topic)
+	return nil
+}
+
+// FetchPending возвращает головные неопубликованные события подписок, готовые к публикации, упорядоченные по ID.
+// Более поздние события подписки не возвращаются, пока не опубликовано (или не ушло в dead-letter) предыдущее,
+// а события, ожидающие ретрая, не занимают место в пачке и не задерживают другие подписки.
+func (r *postgresOutboxRepo) FetchPending(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	query := `
+        SELECT id, aggregate_id, topic, message_key, payload, attempts, last_error,
+               created_at, next_attempt_at, published_at, dead_at
+        FROM (
+            SELECT DISTINCT ON (aggregate_id) *
+            FROM outbox
+            WHERE published_at IS NULL AND dead_at IS NULL
+            ORDER BY aggregate_id, id
+        ) head
+        WHERE next_attempt_at <= NOW()
+        ORDER BY id
+        LIMIT $1`
+
+	if err := r.db.SelectContext(ctx, &events, query, limit); err != nil {
+		r.log.Errorw("Failed to fetch pending outbox events. Error: %v", err)
+		return nil, fmt.Errorf("repository: failed to fetch pending outbox events: %w", err)
+	}
+	return events, nil
+}
+
+// MarkPublished отмечает событие как опубликованное.
+func (r *postgresOutboxRepo) MarkPublished(ctx context.Context, id int64) error {
+	query := `UPDATE outbox SET published_at = $1, last_error = NULL WHERE id = $2`
+	if _, err := r.db.ExecContext(ctx, query, time.Now(), id); err != nil {
+		r.log.Errorw("Failed to mark outbox event as published. ID: %d, Error: %v", id, err)
+		return fmt.Errorf("repository: failed to mark outbox event published: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed фиксирует неудачную попытку публикации.
+func (r *postgresOutboxRepo) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `
+        UPDATE outbox SET
+            attempts = attempts + 1,
+            last_error = $1,
+            next_attempt_at = $2
+        WHERE id = $3`
+	if _, err := r.db.ExecContext(ctx, query, lastError, nextAttemptAt, id); err != nil {
+		r.log.Errorw("Failed to mark outbox event as failed. ID: %d, Error: %v", id, err)
+		return fmt.Errorf("repository: failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// MarkDead фиксирует последнюю неудачную попытку и переводит событие в dead-letter.
+func (r *postgresOutboxRepo) MarkDead(ctx context.Context, id int64, lastError string) error {
+	query := `
+        UPDATE outbox SET
+            attempts = attempts + 1,
+            last_error = $1,
+            dead_at = NOW()
+        WHERE id = $2`
+	if _, err := r.db.ExecContext(ctx, query, lastError, id); err != nil {
+		r.log.Errorw("Failed to mark outbox event as dead. ID: %d, Error: %v", id, err)
+		return fmt.Errorf("repository: failed to mark outbox event dead: %w", err)
+	}
+	return nil
+}
+
+// FetchSince возвращает события с id > afterID, записанные раньше чем settleDelay назад (по часам БД), в порядке id.
+func (r *postgresOutboxRepo) FetchSince(ctx context.Context, afterID int64, settleDelay time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	query := `
+        SELECT id, aggregate_id, topic, message_key, payload, attempts, last_error,
+               created_at, next_attempt_at, published_at
+        FROM outbox
+        WHERE id > $1 AND created_at < NOW() - make_interval(secs => $2)
+        ORDER BY id
+        LIMIT $3`
+
+	if err := r.db.SelectContext(ctx, &events, query, afterID, settleDelay.Seconds(), limit); err != nil {
+		r.log.Errorw("Failed to fetch outbox events since ID %d. Error: %v", afterID, err)
+		return nil, fmt.Errorf("repository: failed to fetch outbox events: %w", err)
+	}
+	return events, nil
+}
+
+// DeletePublished удаляет пачку опубликованных событий старше retention (по часам БД), начиная с самых старых.
+func (r *postgresOutboxRepo) DeletePublished(ctx context.Context, retention time.Duration, limit int) (int64, error) {
+	query := `
+        DELETE FROM outbox
+        WHERE id IN (
+            SELECT id FROM outbox
+            WHERE published_at < NOW() - make_interval(secs => $1)
+            ORDER BY id
+            LIMIT $2
+        )`
+
+	result, err := r.db.ExecContext(ctx, query, retention.Seconds(), limit)
+	if err != nil {
+		r.log.Errorw("Failed to delete published outbox events. Error: %v", err)
+		return 0, fmt.Errorf("repository: failed to delete published outbox events: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("repository: failed to get affected rows: %w", err)
+	}
+	return rows, nil
+}
+
+// LastID возвращает максимальный id события в outbox.
+func (r *postgresOutboxRepo) LastID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.db.GetContext(ctx, &id, `SELECT COALESCE(MAX(id), 0) FROM outbox`); err != nil {
+		r.log.Errorw("Failed to get last outbox event ID. Error: %v", err)
+		return 0, fmt.Errorf("repository: failed to get last outbox event id: %w", err)
+	}
+	return id, nil
+}
+
+// TryAcquireRelayLock берет session-level advisory lock на выделенном соединении.
+func (r *postgresOutboxRepo) TryAcquireRelayLock(ctx context.Context) (func(), bool, error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("repository: failed to get connection for relay lock: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, `SELECT pg_try_advisory_lock($1)`, outboxRelayLockID); err != nil {
+		_ = conn.Close()
+		return nil, false, fmt.Errorf("repository: failed to acquire relay lock: %w", err)
+	}
+	if !acquired {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// Используем отдельный контекст: ctx релея может быть уже отменен при shutdown
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock($1)`, outboxRelayLockID); err != nil {
+			r.log.Warnw("Failed to release outbox relay lock. Error: %v", err)
+		}
+		_ = conn.Close()
+	}
+	return release, true, nil
+}
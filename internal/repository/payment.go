@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// paymentColumns список колонок разовых платежей для SELECT.
+const paymentColumns = `payment_id, user_id, stripe_customer_id, amount, amount_received, currency, status,
+               description, metadata, last_payment_error, stripe_created_at, last_event_at, created_at, updated_at`
+
+// PaymentRepository определяет методы для работы с разовыми платежами (PaymentIntents Stripe).
+type PaymentRepository interface {
+	// Upsert сохраняет состояние платежа. Запись не перезаписывается,
+	// если в БД уже лежит состояние из более позднего изменения (payment.LastEventAt).
+	Upsert(ctx context.Context, payment *models.Payment) error
+
+	// GetByID возвращает платеж по ID PaymentIntent (ErrNotFound, если платежа нет).
+	GetByID(ctx context.Context, paymentID string) (*models.Payment, error)
+
+	// ListByUserID возвращает страницу платежей пользователя, новые первыми.
+	ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Payment, error)
+}
+
+// postgresPaymentRepo реализует PaymentRepository для PostgreSQL.
+type postgresPaymentRepo struct {
+	db  *sqlx.DB
+	log *logger.Logger
+}
+
+// NewPostgresPaymentRepository создает новый экземпляр репозитория разовых платежей.
+func NewPostgresPaymentRepository(db *sqlx.DB, log *logger.Logger) PaymentRepository {
+	return &postgresPaymentRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Upsert вставляет или обновляет платеж. Условие по last_event_at защищает от событий,
+// пришедших не по порядку (например, payment_intent.created после payment_intent.succeeded).
+func (r *postgresPaymentRepo) Upsert(ctx context.Context, payment *models.Payment) error {
+	now := time.Now()
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+
+	query := `
+        INSERT INTO payments (payment_id, user_id, stripe_customer_id, amount, amount_received, currency, status,
+                              description, metadata, last_payment_error, stripe_created_at, last_event_at,
+                              created_at, updated_at)
+        VALUES (:payment_id, :user_id, :stripe_customer_id, :amount, :amount_received, :currency, :status,
+                :description, :metadata, :last_payment_error, :stripe_created_at, :last_event_at,
+                :created_at, :updated_at)
+        ON CONFLICT (payment_id) DO UPDATE SET
+            user_id = EXCLUDED.user_id,
+            stripe_customer_id = EXCLUDED.stripe_customer_id,
+            amount = EXCLUDED.amount,
+            amount_received = EXCLUDED.amount_received,
+            currency = EXCLUDED.currency,
+            status = EXCLUDED.status,
+            description = EXCLUDED.description,
+            metadata = EXCLUDED.metadata,
+            last_payment_error = EXCLUDED.last_payment_error,
+            stripe_created_at = EXCLUDED.stripe_created_at,
+            last_event_at = EXCLUDED.last_event_at,
+            updated_at = EXCLUDED.updated_at
+        WHERE payments.last_event_at <= EXCLUDED.last_event_at`
+
+	if _, err := r.db.NamedExecContext(ctx, query, payment); err != nil {
+		r.log.Errorw("Failed to upsert payment. PaymentID: %s, Error: %v", payment.PaymentID, err)
+		return fmt.Errorf("repository: failed to upsert payment: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает платеж по ID PaymentIntent.
+func (r *postgresPaymentRepo) GetByID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	var payment models.Payment
+	query := `
+        SELECT ` + paymentColumns + `
+        FROM payments
+        WHERE payment_id = $1`
+
+	if err := r.db.GetContext(ctx, &payment, query, paymentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("Failed to get payment. PaymentID: %s, Error: %v", paymentID, err)
+		return nil, fmt.Errorf("repository: failed to get payment: %w", err)
+	}
+	return &payment, nil
+}
+
+// ListByUserID возвращает страницу платежей пользователя.
+func (r *postgresPaymentRepo) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]*models.Payment, error) {
+	query := `
+        SELECT ` + paymentColumns + `
+        FROM payments
+        WHERE user_id = $1
+        ORDER BY stripe_created_at DESC, payment_id DESC
+        LIMIT $2 OFFSET $3`
+
+	var payments []*models.Payment
+	if err := r.db.SelectContext(ctx, &payments, query, userID, limit, offset); err != nil {
+		r.log.Errorw("Failed to list payments. UserID: %s, Error: %v", userID, err)
+		return nil, fmt.Errorf("repository: failed to list payments: %w", err)
+	}
+	return payments, nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// planColumns список колонок каталога планов для SELECT.
+const planColumns = `plan_id, product_id, name, description, nickname, amount, currency,
+               billing_interval, interval_count, trial_period_days, price_active, product_active,
+               synced_at, created_at, updated_at`
+
+// PlanRepository определяет методы для работы с локальной копией каталога планов Stripe.
+type PlanRepository interface {
+	// Upsert сохраняет состояние плана, прочитанное из Stripe. Запись не перезаписывается,
+	// если в БД уже лежит состояние, прочитанное позже (plan.SyncedAt).
+	Upsert(ctx context.Context, plan *models.Plan) error
+
+	// GetByID возвращает план по ID цены Stripe (ErrNotFound, если плана нет).
+	GetByID(ctx context.Context, planID string) (*models.Plan, error)
+
+	// List возвращает планы, отсортированные по продукту и стоимости; activeOnly скрывает архивные.
+	List(ctx context.Context, activeOnly bool) ([]*models.Plan, error)
+
+	// Deactivate отмечает план архивным (цена удалена в Stripe).
+	Deactivate(ctx context.Context, planID string, syncedAt time.Time) error
+
+	// DeactivateExcept отмечает архивными все планы, кроме keepIDs, прочитанные раньше syncedAt.
+	// Используется полной синхронизацией для цен, которых больше нет в Stripe.
+	DeactivateExcept(ctx context.Context, keepIDs []string, syncedAt time.Time) (int64, error)
+}
+
+// postgresPlanRepo реализует PlanRepository для PostgreSQL.
+type postgresPlanRepo struct {
+	db  *sqlx.DB
+	log *logger.Logger
+}
+
+// NewPostgresPlanRepository создает новый экземпляр репозитория каталога планов.
+func NewPostgresPlanRepository(db *sqlx.DB, log *logger.Logger) PlanRepository {
+	return &postgresPlanRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Upsert вставляет или обновляет план. Условие по synced_at защищает от ситуации, когда
+// два обработчика событий читают цену из Stripe и пишут результаты в обратном порядке.
+func (r *postgresPlanRepo) Upsert(ctx context.Context, plan *models.Plan) error {
+	now := time.Now()
+	plan.CreatedAt = now
+	plan.UpdatedAt = now
+
+	query := `
+        INSERT INTO plans (plan_id, product_id, name, description, nickname, amount, currency,
+                           billing_interval, interval_count, trial_period_days, price_active, product_active,
+                           synced_at, created_at, updated_at)
+        VALUES (:plan_id, :product_id, :name, :description, :nickname, :amount, :currency,
+                :billing_interval, :interval_count, :trial_period_days, :price_active, :product_active,
+                :synced_at, :created_at, :updated_at)
+        ON CONFLICT (plan_id) DO UPDATE SET
+            product_id = EXCLUDED.product_id,
+            name = EXCLUDED.name,
+            description = EXCLUDED.description,
+            nickname = EXCLUDED.nickname,
+            amount = EXCLUDED.amount,
+            currency = EXCLUDED.currency,
+            billing_interval = EXCLUDED.billing_interval,
+            interval_count = EXCLUDED.interval_count,
+            trial_period_days = EXCLUDED.trial_period_days,
+            price_active = EXCLUDED.price_active,
+            product_active = EXCLUDED.product_active,
+            synced_at = EXCLUDED.synced_at,
+            updated_at = EXCLUDED.updated_at
+        WHERE plans.synced_at <= EXCLUDED.synced_at`
+
+	if _, err := r.db.NamedExecContext(ctx, query, plan); err != nil {
+		r.log.Errorw("Failed to upsert plan. PlanID: %s, Error: %v", plan.PlanID, err)
+		return fmt.Errorf("repository: failed to upsert plan: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает план по ID цены Stripe.
+func (r *postgresPlanRepo) GetByID(ctx context.Context, planID string) (*models.Plan, error) {
+	var plan models.Plan
+	query := `
+        SELECT ` + planColumns + `
+        FROM plans
+        WHERE plan_id = $1`
+
+	if err := r.db.GetContext(ctx, &plan, query, planID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("Failed to get plan. PlanID: %s, Error: %v", planID, err)
+		return nil, fmt.Errorf("repository: failed to get plan: %w", err)
+	}
+	return &plan, nil
+}
+
+// List возвращает планы каталога.
+func (r *postgresPlanRepo) List(ctx context.Context, activeOnly bool) ([]*models.Plan, error) {
+	query := `
+        SELECT ` + planColumns + `
+        FROM plans`
+	if activeOnly {
+		query += `
+        WHERE price_active AND product_active`
+	}
+	query += `
+        ORDER BY name, amount, plan_id`
+
+	var plans []*models.Plan
+	if err := r.db.SelectContext(ctx, &plans, query); err != nil {
+		r.log.Errorw("Failed to list plans. Error: %v", err)
+		return nil, fmt.Errorf("repository: failed to list plans: %w", err)
+	}
+	return plans, nil
+}
+
+// Deactivate отмечает цену архивной.
+func (r *postgresPlanRepo) Deactivate(ctx context.Context, planID string, syncedAt time.Time) error {
+	query := `
+        UPDATE plans SET
+            price_active = FALSE,
+            synced_at = $2,
+            updated_at = $3
+        WHERE plan_id = $1 AND synced_at <= $2`
+
+	if _, err := r.db.ExecContext(ctx, query, planID, syncedAt, time.Now()); err != nil {
+		r.log.Errorw("Failed to deactivate plan. PlanID: %s, Error: %v", planID, err)
+		return fmt.Errorf("repository: failed to deactivate plan: %w", err)
+	}
+	return nil
+}
+
+// DeactivateExcept отмечает архивными планы, отсутствующие в keepIDs.
+func (r *postgresPlanRepo) DeactivateExcept(ctx context.Context, keepIDs []string, syncedAt time.Time) (int64, error) {
+	if keepIDs == nil {
+		keepIDs = []string{}
+	}
+	query := `
+        UPDATE plans SET
+            price_active = FALSE,
+            synced_at = $2,
+            updated_at = $3
+        WHERE plan_id <> ALL($1) AND price_active AND synced_at < $2`
+
+	result, err := r.db.ExecContext(ctx, query, keepIDs, syncedAt, time.Now())
+	if err != nil {
+		r.log.Errorw("Failed to deactivate missing plans. Error: %v", err)
+		return 0, fmt.Errorf("repository: failed to deactivate missing plans: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("repository: failed to get affected rows: %w", err)
+	}
+	return rows, nil
+}
@@ -31,6 +31,11 @@ func NewPostgresSubscriptionRepository(db *sqlx.DB, log *logger.Logger) Subscrip
 
 // Create сохраняет новую подписку в базе данных.
 func (r *postgresSubscriptionRepo) Create(ctx context.Context, sub *models.Subscription) error {
+	return r.create(ctx, r.db, sub)
+}
+
+// create вставляет подписку через переданный исполнитель (БД или транзакцию).
+func (r *postgresSubscriptionRepo) create(ctx context.Context, execer sqlx.ExtContext, sub *models.Subscription) error {
 	// Добавляем время создания и обновления перед вставкой
 	now := time.Now()
 	sub.CreatedAt = now
@@ -39,13 +44,17 @@ func (r *postgresSubscriptionRepo) Create(ctx context.Context, sub *models.Subsc
 	query := `
         INSERT INTO subscriptions (
             subscription_id, user_id, plan_id, status, stripe_customer_id,
-            created_at, updated_at, expires_at, canceled_at
+            created_at, updated_at, expires_at, canceled_at, cancel_at_period_end, cancel_at, resumes_at, trial_start, trial_end,
+            discount_coupon_id, discount_promotion_code, discount_percent_off, discount_amount_off, discount_currency,
+            discount_duration, discount_end, last_event_at
         ) VALUES (
             :subscription_id, :user_id, :plan_id, :status, :stripe_customer_id,
-            :created_at, :updated_at, :expires_at, :canceled_at
+            :created_at, :updated_at, :expires_at, :canceled_at, :cancel_at_period_end, :cancel_at, :resumes_at, :trial_start, :trial_end,
+            :discount_coupon_id, :discount_promotion_code, :discount_percent_off, :discount_amount_off, :discount_currency,
+            :discount_duration, :discount_end, :last_event_at
         )`
 	// Используем NamedExecContext для удобного маппинга полей структуры на параметры запроса
-	_, err := r.db.NamedExecContext(ctx, query, sub)
+	_, err := sqlx.NamedExecContext(ctx, execer, query, sub)
 	if err != nil {
 		r.log.Errorw("Failed to create subscription in DB", "error", err, "subscriptionID", sub.SubscriptionID, "userID", sub.UserID)
 		// TODO: Обработать специфические ошибки БД (например, дубликат ключа), если нужно
@@ -61,7 +70,9 @@ func (r *postgresSubscriptionRepo) GetByID(ctx context.Context, subscriptionID s
 	var sub models.Subscription
 	query := `
         SELECT subscription_id, user_id, plan_id, status, stripe_customer_id,
-               created_at, updated_at, expires_at, canceled_at
+               created_at, updated_at, expires_at, canceled_at, cancel_at_period_end, cancel_at, resumes_at, trial_start, trial_end,
+               discount_coupon_id, discount_promotion_code, discount_percent_off, discount_amount_off, discount_currency,
+               discount_duration, discount_end, last_event_at
         FROM subscriptions
         WHERE subscription_id = $1`
 
@@ -86,7 +97,9 @@ func (r *postgresSubscriptionRepo) GetByUserID(ctx context.Context, userID strin
 	var subs []models.Subscription
 	query := `
         SELECT subscription_id, user_id, plan_id, status, stripe_customer_id,
-               created_at, updated_at, expires_at, canceled_at
+               created_at, updated_at, expires_at, canceled_at, cancel_at_period_end, cancel_at, resumes_at, trial_start, trial_end,
+               discount_coupon_id, discount_promotion_code, discount_percent_off, discount_amount_off, discount_currency,
+               discount_duration, discount_end, last_event_at
         FROM subscriptions
         WHERE user_id = $1
         ORDER BY created_at DESC` // Сортируем по убыванию даты создания
@@ -106,22 +119,62 @@ func (r *postgresSubscriptionRepo) GetByUserID(ctx context.Context, userID strin
 	return subs, nil
 }
 
+// ListByUserID возвращает страницу подписок пользователя, упорядоченных по убыванию даты создания.
+func (r *postgresSubscriptionRepo) ListByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Subscription, error) {
+	subs := []models.Subscription{}
+	query := `
+        SELECT subscription_id, user_id, plan_id, status, stripe_customer_id,
+               created_at, updated_at, expires_at, canceled_at, cancel_at_period_end, cancel_at, resumes_at, trial_start, trial_end,
+               discount_coupon_id, discount_promotion_code, discount_percent_off, discount_amount_off, discount_currency,
+               discount_duration, discount_end, last_event_at
+        FROM subscriptions
+        WHERE user_id = $1
+        ORDER BY created_at DESC, subscription_id
+        LIMIT $2 OFFSET $3`
+
+	if err := r.db.SelectContext(ctx, &subs, query, userID, limit, offset); err != nil {
+		r.log.Errorw("Failed to list subscriptions by user ID from DB. UserID: %s, Error: %v", userID, err)
+		return nil, fmt.Errorf("repository: failed to list subscriptions by user ID: %w", err)
+	}
+	return subs, nil
+}
+
 // Update обновляет данные существующей подписки в базе данных.
-// Обновляет только изменяемые поля: status, updated_at, expires_at, canceled_at.
+// Обновляет только изменяемые поля: status, plan_id, updated_at, expires_at, canceled_at,
+// cancel_at_period_end, cancel_at, resumes_at, trial_start, trial_end, поля скидки (discount_*), last_event_at.
 func (r *postgresSubscriptionRepo) Update(ctx context.Context, sub *models.Subscription) error {
+	return r.update(ctx, r.db, sub)
+}
+
+// update обновляет подписку через переданный исполнитель (БД или транзакцию).
+func (r *postgresSubscriptionRepo) update(ctx context.Context, execer sqlx.ExtContext, sub *models.Subscription) error {
 	// Устанавливаем время обновления
 	sub.UpdatedAt = time.Now()
 
 	query := `
         UPDATE subscriptions SET
             status = :status,
+            plan_id = :plan_id,
             updated_at = :updated_at,
             expires_at = :expires_at,
-            canceled_at = :canceled_at
-            -- Не обновляем: subscription_id, user_id, plan_id, stripe_customer_id, created_at
+            canceled_at = :canceled_at,
+            cancel_at_period_end = :cancel_at_period_end,
+            cancel_at = :cancel_at,
+            resumes_at = :resumes_at,
+            trial_start = :trial_start,
+            trial_end = :trial_end,
+            discount_coupon_id = :discount_coupon_id,
+            discount_promotion_code = :discount_promotion_code,
+            discount_percent_off = :discount_percent_off,
+            discount_amount_off = :discount_amount_off,
+            discount_currency = :discount_currency,
+            discount_duration = :discount_duration,
+            discount_end = :discount_end,
+            last_event_at = :last_event_at
+            -- Не обновляем: subscription_id, user_id, stripe_customer_id, created_at
         WHERE subscription_id = :subscription_id`
 
-	result, err := r.db.NamedExecContext(ctx, query, sub)
+	result, err := sqlx.NamedExecContext(ctx, execer, query, sub)
 	if err != nil {
 		r.log.Errorw("Failed to update subscription in DB", "error", err, "subscriptionID", sub.SubscriptionID)
 		return fmt.Errorf("repository: failed to update subscription: %w", err)
@@ -143,6 +196,49 @@ func (r *postgresSubscriptionRepo) Update(ctx context.Context, sub *models.Subsc
 	return nil
 }
 
+// CreateWithEvent сохраняет подписку и событие outbox в одной транзакции.
+// Если Kafka недоступна, событие не теряется: его опубликует релей outbox позже.
+func (r *postgresSubscriptionRepo) CreateWithEvent(ctx context.Context, sub *models.Subscription, event *models.OutboxEvent) error {
+	return r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if err := r.create(ctx, tx, sub); err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, event)
+	})
+}
+
+// UpdateWithEvent обновляет подписку и записывает событие outbox в одной транзакции.
+func (r *postgresSubscriptionRepo) UpdateWithEvent(ctx context.Context, sub *models.Subscription, event *models.OutboxEvent) error {
+	return r.withTx(ctx, func(tx *sqlx.Tx) error {
+		if err := r.update(ctx, tx, sub); err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, event)
+	})
+}
+
+// withTx выполняет fn в транзакции, откатывая ее при ошибке.
+func (r *postgresSubscriptionRepo) withTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.log.Errorw("Failed to begin transaction. Error: %v", err)
+		return fmt.Errorf("repository: failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			r.log.Errorw("Failed to rollback transaction. Error: %v", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Errorw("Failed to commit transaction. Error: %v", err)
+		return fmt.Errorf("repository: failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // GetByStripeSubscriptionID возвращает подписку по ее Stripe ID.
 // В нашей модели `SubscriptionID` и есть Stripe Subscription ID.
 func (r *postgresSubscriptionRepo) GetByStripeSubscriptionID(ctx context.Context, stripeSubscriptionID string) (*models.Subscription, error) {
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// refundColumns список колонок возвратов для SELECT.
+const refundColumns = `refund_id, user_id, stripe_customer_id, charge_id, payment_intent_id, invoice_id,
+               amount, currency, status, reason, failure_reason, stripe_created_at, last_event_at, created_at, updated_at`
+
+// creditNoteColumns список колонок кредит-нот для SELECT.
+const creditNoteColumns = `credit_note_id, user_id, stripe_customer_id, invoice_id, number, type, status, reason, memo,
+               amount, currency, refund_id, voided_at, stripe_created_at, last_event_at, created_at, updated_at`
+
+// RefundRepository определяет методы для работы с возвратами и кредит-нотами Stripe.
+type RefundRepository interface {
+	// SaveRefund вставляет или обновляет возврат и, если запись изменена, пишет событие outbox
+	// (nil - без события) в той же транзакции. Возвращает false, если в БД уже лежит состояние
+	// из более позднего изменения (refund.LastEventAt) - тогда не пишется и событие.
+	SaveRefund(ctx context.Context, refund *models.Refund, event *models.OutboxEvent) (bool, error)
+
+	// GetRefund возвращает возврат по ID возврата Stripe (ErrNotFound, если его нет).
+	GetRefund(ctx context.Context, refundID string) (*models.Refund, error)
+
+	// ListRefundsByInvoiceID возвращает возвраты платежей по счету, новые первыми.
+	ListRefundsByInvoiceID(ctx context.Context, invoiceID string) ([]*models.Refund, error)
+
+	// SaveCreditNote вставляет или обновляет кредит-ноту; семантика как у SaveRefund.
+	SaveCreditNote(ctx context.Context, creditNote *models.CreditNote, event *models.OutboxEvent) (bool, error)
+
+	// GetCreditNote возвращает кредит-ноту по ID Stripe (ErrNotFound, если ее нет).
+	GetCreditNote(ctx context.Context, creditNoteID string) (*models.CreditNote, error)
+
+	// ListCreditNotesByInvoiceID возвращает кредит-ноты счета, новые первыми.
+	ListCreditNotesByInvoiceID(ctx context.Context, invoiceID string) ([]*models.CreditNote, error)
+}
+
+// postgresRefundRepo реализует RefundRepository для PostgreSQL.
+type postgresRefundRepo struct {
+	db  *sqlx.DB
+	log *logger.Logger
+}
+
+// NewPostgresRefundRepository создает новый экземпляр репозитория возвратов.
+func NewPostgresRefundRepository(db *sqlx.DB, log *logger.Logger) RefundRepository {
+	return &postgresRefundRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// SaveRefund выполняет upsert возврата. Условие по last_event_at защищает от событий,
+// пришедших не по порядку (например, charge.refunded после charge.refund.updated).
+func (r *postgresRefundRepo) SaveRefund(ctx context.Context, refund *models.Refund, event *models.OutboxEvent) (bool, error) {
+	now := time.Now()
+	refund.CreatedAt = now
+	refund.UpdatedAt = now
+
+	query := `
+        INSERT INTO refunds (refund_id, user_id, stripe_customer_id, charge_id, payment_intent_id, invoice_id,
+                             amount, currency, status, reason, failure_reason, stripe_created_at, last_event_at,
+                             created_at, updated_at)
+        VALUES (:refund_id, :user_id, :stripe_customer_id, :charge_id, :payment_intent_id, :invoice_id,
+                :amount, :currency, :status, :reason, :failure_reason, :stripe_created_at, :last_event_at,
+                :created_at, :updated_at)
+        ON CONFLICT (refund_id) DO UPDATE SET
+            user_id = EXCLUDED.user_id,
+            stripe_customer_id = EXCLUDED.stripe_customer_id,
+            charge_id = EXCLUDED.charge_id,
+            payment_intent_id = EXCLUDED.payment_intent_id,
+            invoice_id = EXCLUDED.invoice_id,
+            amount = EXCLUDED.amount,
+            currency = EXCLUDED.currency,
+            status = EXCLUDED.status,
+            reason = EXCLUDED.reason,
+            failure_reason = EXCLUDED.failure_reason,
+            stripe_created_at = EXCLUDED.stripe_created_at,
+            last_event_at = EXCLUDED.last_event_at,
+            updated_at = EXCLUDED.updated_at
+        WHERE refunds.last_event_at <= EXCLUDED.last_event_at`
+
+	return r.saveWithEvent(ctx, query, refund, event, "refund", refund.RefundID)
+}
+
+// GetRefund возвращает возврат по ID возврата Stripe.
+func (r *postgresRefundRepo) GetRefund(ctx context.Context, refundID string) (*models.Refund, error) {
+	var refund models.Refund
+	query := `
+        SELECT ` + refundColumns + `
+        FROM refunds
+        WHERE refund_id = $1`
+
+	if err := r.db.GetContext(ctx, &refund, query, refundID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("Failed to get refund. RefundID: %s, Error: %v", refundID, err)
+		return nil, fmt.Errorf("repository: failed to get refund: %w", err)
+	}
+	return &refund, nil
+}
+
+// ListRefundsByInvoiceID возвращает возвраты по счету.
+func (r *postgresRefundRepo) ListRefundsByInvoiceID(ctx context.Context, invoiceID string) ([]*models.Refund, error) {
+	query := `
+        SELECT ` + refundColumns + `
+        FROM refunds
+        WHERE invoice_id = $1
+        ORDER BY stripe_created_at DESC, refund_id DESC`
+
+	var refunds []*models.Refund
+	if err := r.db.SelectContext(ctx, &refunds, query, invoiceID); err != nil {
+		r.log.Errorw("Failed to list refunds. InvoiceID: %s, Error: %v", invoiceID, err)
+		return nil, fmt.Errorf("repository: failed to list refunds: %w", err)
+	}
+	return refunds, nil
+}
+
+// SaveCreditNote выполняет upsert кредит-ноты с защитой от событий, пришедших не по порядку.
+func (r *postgresRefundRepo) SaveCreditNote(ctx context.Context, creditNote *models.CreditNote, event *models.OutboxEvent) (bool, error) {
+	now := time.Now()
+	creditNote.CreatedAt = now
+	creditNote.UpdatedAt = now
+
+	query := `
+        INSERT INTO credit_notes (credit_note_id, user_id, stripe_customer_id, invoice_id, number, type, status, reason,
+                                  memo, amount, currency, refund_id, voided_at, stripe_created_at, last_event_at,
+                                  created_at, updated_at)
+        VALUES (:credit_note_id, :user_id, :stripe_customer_id, :invoice_id, :number, :type, :status, :reason,
+                :memo, :amount, :currency, :refund_id, :voided_at, :stripe_created_at, :last_event_at,
+                :created_at, :updated_at)
+        ON CONFLICT (credit_note_id) DO UPDATE SET
+            user_id = EXCLUDED.user_id,
+            stripe_customer_id = EXCLUDED.stripe_customer_id,
+            invoice_id = EXCLUDED.invoice_id,
+            number = EXCLUDED.number,
+            type = EXCLUDED.type,
+            status = EXCLUDED.status,
+            reason = EXCLUDED.reason,
+            memo = EXCLUDED.memo,
+            amount = EXCLUDED.amount,
+            currency = EXCLUDED.currency,
+            refund_id = EXCLUDED.refund_id,
+            voided_at = EXCLUDED.voided_at,
+            stripe_created_at = EXCLUDED.stripe_created_at,
+            last_event_at = EXCLUDED.last_event_at,
+            updated_at = EXCLUDED.updated_at
+        WHERE credit_notes.last_event_at <= EXCLUDED.last_event_at`
+
+	return r.saveWithEvent(ctx, query, creditNote, event, "credit note", creditNote.CreditNoteID)
+}
+
+// GetCreditNote возвращает кредит-ноту по ID Stripe.
+func (r *postgresRefundRepo) GetCreditNote(ctx context.Context, creditNoteID string) (*models.CreditNote, error) {
+	var creditNote models.CreditNote
+	query := `
+        SELECT ` + creditNoteColumns + `
+        FROM credit_notes
+        WHERE credit_note_id = $1`
+
+	if err := r.db.GetContext(ctx, &creditNote, query, creditNoteID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("Failed to get credit note. CreditNoteID: %s, Error: %v", creditNoteID, err)
+		return nil, fmt.Errorf("repository: failed to get credit note: %w", err)
+	}
+	return &creditNote, nil
+}
+
+// ListCreditNotesByInvoiceID возвращает кредит-ноты счета.
+func (r *postgresRefundRepo) ListCreditNotesByInvoiceID(ctx context.Context, invoiceID string) ([]*models.CreditNote, error) {
+	query := `
+        SELECT ` + creditNoteColumns + `
+        FROM credit_notes
+        WHERE invoice_id = $1
+        ORDER BY stripe_created_at DESC, credit_note_id DESC`
+
+	var creditNotes []*models.CreditNote
+	if err := r.db.SelectContext(ctx, &creditNotes, query, invoiceID); err != nil {
+		r.log.Errorw("Failed to list credit notes. InvoiceID: %s, Error: %v", invoiceID, err)
+		return nil, fmt.Errorf("repository: failed to list credit notes: %w", err)
+	}
+	return creditNotes, nil
+}
+
+// saveWithEvent выполняет upsert и, если строка изменена, вставляет событие outbox в той же транзакции.
+func (r *postgresRefundRepo) saveWithEvent(ctx context.Context, query string, arg interface{}, event *models.OutboxEvent, kind, id string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.log.Errorw("Failed to begin transaction. Error: %v", err)
+		return false, fmt.Errorf("repository: failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// После Commit откат ничего не делает
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.NamedExecContext(ctx, query, arg)
+	if err != nil {
+		r.log.Errorw("Failed to save %s. ID: %s, Error: %v", kind, id, err)
+		return false, fmt.Errorf("repository: failed to save %s: %w", kind, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository: failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		// В БД более позднее состояние
+		return false, nil
+	}
+
+	if event != nil {
+		if err := insertOutboxEvent(ctx, tx, event); err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		r.log.Errorw("Failed to commit transaction. Error: %v", err)
+		return false, fmt.Errorf("repository: failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
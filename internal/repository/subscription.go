@@ -16,12 +16,21 @@ type SubscriptionRepository interface {
 	// GetByUserID возвращает все активные подписки пользователя.
 	GetByUserID(ctx context.Context, userID string) ([]models.Subscription, error)
 
+	// ListByUserID возвращает страницу подписок пользователя (новые первыми).
+	ListByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Subscription, error)
+
 	// Update обновляет данные существующей подписки (например, статус или время отмены).
 	Update(ctx context.Context, sub *models.Subscription) error
 
 	// GetByStripeSubscriptionID возвращает подписку по её Stripe ID. (понадобится для вебхуков)
 	GetByStripeSubscriptionID(ctx context.Context, stripeSubscriptionID string) (*models.Subscription, error)
 
+	// CreateWithEvent сохраняет подписку и событие outbox в одной транзакции.
+	CreateWithEvent(ctx context.Context, sub *models.Subscription, event *models.OutboxEvent) error
+
+	// UpdateWithEvent обновляет подписку и записывает событие outbox в одной транзакции.
+	UpdateWithEvent(ctx context.Context, sub *models.Subscription, event *models.OutboxEvent) error
+
 	// Возможно, понадобятся другие методы, например:
 	//FindActiveByUserID(ctx context.Context, userID string) (*models.Subscription, error)
 	//Delete(ctx context.Context, subscriptionID string) error
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// webhookProcessingTimeout время, после которого событие в статусе processing
+// считается брошенным (например, процесс упал) и может быть захвачено повторно.
+const webhookProcessingTimeout = 5 * time.Minute
+
+// webhookEventColumns список колонок журнала вебхуков для SELECT/RETURNING.
+const webhookEventColumns = `event_id, event_type, payload, status, error, attempts,
+               stripe_created_at, subscription_id, next_attempt_at,
+               received_at, updated_at, processed_at`
+
+// WebhookEventRepository определяет методы для работы с журналом (очередью) вебхуков.
+type WebhookEventRepository interface {
+	// Enqueue сохраняет проверенное событие в статусе pending.
+	// Возвращает inserted=false, если событие с таким ID уже было сохранено ранее (повторная доставка).
+	Enqueue(ctx context.Context, event *models.WebhookEvent) (inserted bool, err error)
+
+	// ClaimBatch захватывает до limit событий, готовых к обработке, и переводит их в статус processing.
+	// Одно событие не может быть захвачено двумя воркерами (в том числе в разных репликах), а событие подписки
+	// не захватывается, пока не обработаны более ранние события той же подписки.
+	ClaimBatch(ctx context.Context, limit int) ([]*models.WebhookEvent, error)
+
+	// GetByID возвращает событие по ID события Stripe.
+	GetByID(ctx context.Context, eventID string) (*models.WebhookEvent, error)
+
+	// List возвращает события по фильтру, новые первыми.
+	List(ctx context.Context, filter models.WebhookEventFilter) ([]*models.WebhookEvent, error)
+
+	// MarkProcessed отмечает событие как успешно обработанное.
+	MarkProcessed(ctx context.Context, eventID string) error
+
+	// MarkFailed сохраняет ошибку обработки события и планирует следующую попытку.
+	MarkFailed(ctx context.Context, eventID, errMsg string, nextAttemptAt time.Time) error
+
+	// MarkDead сохраняет ошибку и переводит событие в dead-letter: автоматических повторов больше не будет.
+	MarkDead(ctx context.Context, eventID, errMsg string) error
+
+	// MarkStale отмечает событие как проигнорированное из-за нарушения порядка доставки.
+	MarkStale(ctx context.Context, eventID, reason string) error
+
+	// ClaimForReplay переводит событие в processing для ручного повтора, если оно сейчас не обрабатывается.
+	// Возвращает ErrNotFound, если события нет, и claimed=false, если его обрабатывает воркер.
+	ClaimForReplay(ctx context.Context, eventID string) (event *models.WebhookEvent, claimed bool, err error)
+
+	// RecordAttempt сохраняет попытку обработки в историю события.
+	RecordAttempt(ctx context.Context, attempt *models.WebhookEventAttempt) error
+
+	// ListAttempts возвращает историю обработки события в хронологическом порядке.
+	ListAttempts(ctx context.Context, eventID string) ([]*models.WebhookEventAttempt, error)
+}
+
+// postgresWebhookEventRepo реализует WebhookEventRepository для PostgreSQL.
+type postgresWebhookEventRepo struct {
+	db  *sqlx.DB
+	log *logger.Logger
+}
+
+// NewPostgresWebhookEventRepository создает новый экземпляр репозитория журнала вебхуков.
+func NewPostgresWebhookEventRepository(db *sqlx.DB, log *logger.Logger) WebhookEventRepository {
+	return &postgresWebhookEventRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Enqueue вставляет событие в статусе pending. Повторная доставка того же события ничего не меняет.
+func (r *postgresWebhookEventRepo) Enqueue(ctx context.Context, event *models.WebhookEvent) (bool, error) {
+	now := time.Now()
+	event.Status = models.WebhookEventStatusPending
+	event.Attempts = 0
+	event.NextAttemptAt = now
+	event.ReceivedAt = now
+	event.UpdatedAt = now
+
+	query := `
+        INSERT INTO webhook_events (event_id, event_type, payload, status, attempts,
+                                    stripe_created_at, subscription_id, next_attempt_at, received_at, updated_at)
+        VALUES (:event_id, :event_type, :payload, :status, :attempts,
+                :stripe_created_at, :subscription_id, :next_attempt_at, :received_at, :updated_at)
+        ON CONFLICT (event_id) DO NOTHING`
+
+	result, err := r.db.NamedExecContext(ctx, query, event)
+	if err != nil {
+		r.log.Errorw("Failed to enqueue webhook event. EventID: %s, Error: %v", event.EventID, err)
+		return false, fmt.Errorf("repository: failed to enqueue webhook event: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repository: failed to get affected rows: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// ClaimBatch захватывает события в статусах pending/failed, у которых наступило время попытки,
+// а также зависшие в processing дольше webhookProcessingTimeout. Используется FOR UPDATE SKIP LOCKED,
+// поэтому несколько реплик могут разбирать очередь параллельно.
+// Событие подписки не захватывается, пока у той же подписки есть более раннее (по created в Stripe)
+// необработанное событие (pending/failed/processing): иначе новое событие применится раньше повторной
+// попытки старого, и старое будет навсегда отклонено как устаревшее.
+// События возвращаются в порядке их создания в Stripe.
+func (r *postgresWebhookEventRepo) ClaimBatch(ctx context.Context, limit int) ([]*models.WebhookEvent, error) {
+	now := time.Now()
+	query := `
+        UPDATE webhook_events SET
+            status = $1,
+            attempts = attempts + 1,
+            updated_at = $2
+        WHERE event_id IN (
+            SELECT e.event_id FROM webhook_events e
+            WHERE ((e.status IN ($3, $4) AND e.next_attempt_at <= $2)
+                OR (e.status = $1 AND e.updated_at < $5))
+              AND NOT EXISTS (
+                  SELECT 1 FROM webhook_events earlier
+                  WHERE earlier.subscription_id = e.subscription_id
+                    AND earlier.stripe_created_at < e.stripe_created_at
+                    AND earlier.status IN ($1, $3, $4)
+              )
+            ORDER BY e.next_attempt_at
+            LIMIT $6
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + webhookEventColumns
+
+	var events []*models.WebhookEvent
+	err := r.db.SelectContext(ctx, &events, query,
+		models.WebhookEventStatusProcessing,
+		now,
+		models.WebhookEventStatusPending,
+		models.WebhookEventStatusFailed,
+		now.Add(-webhookProcessingTimeout),
+		limit,
+	)
+	if err != nil {
+		r.log.Errorw("Failed to claim webhook events. Error: %v", err)
+		return nil, fmt.Errorf("repository: failed to claim webhook events: %w", err)
+	}
+
+	sortWebhookEvents(events)
+	return events, nil
+}
+
+// GetByID возвращает событие по ID события Stripe.
+func (r *postgresWebhookEventRepo) GetByID(ctx context.Context, eventID string) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	query := `
+        SELECT ` + webhookEventColumns + `
+        FROM webhook_events
+        WHERE event_id = $1`
+
+	if err := r.db.GetContext(ctx, &event, query, eventID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		r.log.Errorw("Failed to get webhook event. EventID: %s, Error: %v", eventID, err)
+		return nil, fmt.Errorf("repository: failed to get webhook event: %w", err)
+	}
+	return &event, nil
+}
+
+// List возвращает события по фильтру, новые первыми.
+func (r *postgresWebhookEventRepo) List(ctx context.Context, filter models.WebhookEventFilter) ([]*models.WebhookEvent, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.EventType != "" {
+		args = append(args, filter.EventType)
+		conditions = append(conditions, fmt.Sprintf("event_type = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("received_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("received_at < $%d", len(args)))
+	}
+
+	query := `
+        SELECT ` + webhookEventColumns + `
+        FROM webhook_events`
+	if len(conditions) > 0 {
+		query += "\n        WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf("\n        ORDER BY received_at DESC\n        LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	var events []*models.WebhookEvent
+	if err := r.db.SelectContext(ctx, &events, query, args...); err != nil {
+		r.log.Errorw("Failed to list webhook events. Status: %s, EventType: %s, Error: %v", filter.Status, filter.EventType, err)
+		return nil, fmt.Errorf("repository: failed to list webhook events: %w", err)
+	}
+	return events, nil
+}
+
+// MarkProcessed отмечает событие как успешно обработанное.
+func (r *postgresWebhookEventRepo) MarkProcessed(ctx context.Context, eventID string) error {
+	now := time.Now()
+	query := `
+        UPDATE webhook_events SET
+            status = $1,
+            error = NULL,
+            updated_at = $2,
+            processed_at = $2
+        WHERE event_id = $3`
+
+	if _, err := r.db.ExecContext(ctx, query, models.WebhookEventStatusProcessed, now, eventID); err != nil {
+		r.log.Errorw("Failed to mark webhook event as processed. EventID: %s, Error: %v", eventID, err)
+		return fmt.Errorf("repository: failed to mark webhook event processed: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed сохраняет ошибку обработки события и планирует следующую попытку.
+func (r *postgresWebhookEventRepo) MarkFailed(ctx context.Context, eventID, errMsg string, nextAttemptAt time.Time) error {
+	query := `
+        UPDATE webhook_events SET
+            status = $1,
+            error = $2,
+            next_attempt_at = $3,
+            updated_at = $4
+        WHERE event_id = $5`
+
+	if _, err := r.db.ExecContext(ctx, query, models.WebhookEventStatusFailed, errMsg, nextAttemptAt, time.Now(), eventID); err != nil {
+		r.log.Errorw("Failed to mark webhook event as failed. EventID: %s, Error: %v", eventID, err)
+		return fmt.Errorf("repository: failed to mark webhook event failed: %w", err)
+	}
+	return nil
+}
+
+// MarkDead сохраняет ошибку и переводит событие в dead-letter.
+func (r *postgresWebhookEventRepo) MarkDead(ctx context.Context, eventID, errMsg string) error {
+	query := `
+        UPDATE webhook_events SET
+            status = $1,
+            error = $2,
+            updated_at = $3
+        WHERE event_id = $4`
+
+	if _, err := r.db.ExecContext(ctx, query, models.WebhookEventStatusDead, errMsg, time.Now(), eventID); err != nil {
+		r.log.Errorw("Failed to mark webhook event as dead. EventID: %s, Error: %v", eventID, err)
+		return fmt.Errorf("repository: failed to mark webhook event dead: %w", err)
+	}
+	return nil
+}
+
+// MarkStale отмечает событие как проигнорированное из-за нарушения порядка доставки.
+func (r *postgresWebhookEventRepo) MarkStale(ctx context.Context, eventID, reason string) error {
+	now := time.Now()
+	query := `
+        UPDATE webhook_events SET
+            status = $1,
+            error = $2,
+            updated_at = $3,
+            processed_at = $3
+        WHERE event_id = $4`
+
+	if _, err := r.db.ExecContext(ctx, query, models.WebhookEventStatusStale, reason, now, eventID); err != nil {
+		r.log.Errorw("Failed to mark webhook event as stale. EventID: %s, Error: %v", eventID, err)
+		return fmt.Errorf("repository: failed to mark webhook event stale: %w", err)
+	}
+	return nil
+}
+
+// ClaimForReplay переводит событие в processing для ручного повтора.
+// Событие, которое сейчас обрабатывает воркер (и не зависло), не захватывается.
+func (r *postgresWebhookEventRepo) ClaimForReplay(ctx context.Context, eventID string) (*models.WebhookEvent, bool, error) {
+	now := time.Now()
+	query := `
+        UPDATE webhook_events SET
+            status = $1,
+            attempts = attempts + 1,
+            updated_at = $2
+        WHERE event_id = $3
+          AND (status <> $1 OR updated_at < $4)
+        RETURNING ` + webhookEventColumns
+
+	var event models.WebhookEvent
+	err := r.db.GetContext(ctx, &event, query, models.WebhookEventStatusProcessing, now, eventID, now.Add(-webhookProcessingTimeout))
+	if err == nil {
+		return &event, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		r.log.Errorw("Failed to claim webhook event for replay. EventID: %s, Error: %v", eventID, err)
+		return nil, false, fmt.Errorf("repository: failed to claim webhook event for replay: %w", err)
+	}
+
+	// Строка не обновлена: события нет или оно сейчас обрабатывается
+	existing, err := r.GetByID(ctx, eventID)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// RecordAttempt сохраняет попытку обработки в историю события.
+func (r *postgresWebhookEventRepo) RecordAttempt(ctx context.Context, attempt *models.WebhookEventAttempt) error {
+	query := `
+        INSERT INTO webhook_event_attempts (event_id, attempt, trigger, outcome, error, triggered_by, started_at, finished_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id`
+
+	err := r.db.QueryRowxContext(ctx, query,
+		attempt.EventID,
+		attempt.Attempt,
+		attempt.Trigger,
+		attempt.Outcome,
+		attempt.Error,
+		attempt.TriggeredBy,
+		attempt.StartedAt,
+		attempt.FinishedAt,
+	).Scan(&attempt.ID)
+	if err != nil {
+		r.log.Errorw("Failed to record webhook event attempt. EventID: %s, Attempt: %d, Error: %v", attempt.EventID, attempt.Attempt, err)
+		return fmt.Errorf("repository: failed to record webhook event attempt: %w", err)
+	}
+	return nil
+}
+
+// ListAttempts возвращает историю обработки события в хронологическом порядке.
+func (r *postgresWebhookEventRepo) ListAttempts(ctx context.Context, eventID string) ([]*models.WebhookEventAttempt, error) {
+	query := `
+        SELECT id, event_id, attempt, trigger, outcome, error, triggered_by, started_at, finished_at
+        FROM webhook_event_attempts
+        WHERE event_id = $1
+        ORDER BY started_at, id`
+
+	var attempts []*models.WebhookEventAttempt
+	if err := r.db.SelectContext(ctx, &attempts, query, eventID); err != nil {
+		r.log.Errorw("Failed to list webhook event attempts. EventID: %s, Error: %v", eventID, err)
+		return nil, fmt.Errorf("repository: failed to list webhook event attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// sortWebhookEvents упорядочивает события по времени создания в Stripe (см. models.WebhookEvent.OrderTime).
+func sortWebhookEvents(events []*models.WebhookEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OrderTime().Before(events[j].OrderTime())
+	})
+}
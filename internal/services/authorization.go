@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+)
+
+// ErrForbidden возвращается, если вызывающий не может работать с данными другого пользователя.
+var ErrForbidden = errors.New("access to another user's data is forbidden")
+
+// AuthorizeUserAccess проверяет, что вызывающий может работать с данными пользователя ownerUserID.
+// Вызывающий берется из контекста (authz.Principal): свои данные доступны всегда, чужие - ролям,
+// которые могут действовать от имени пользователя (admin и service - всегда, support - только для
+// чтения). Каждое обращение к чужим данным записывается в журнал аудита.
+// Для вызовов без аутентифицированного вызывающего (внутренние задачи) владелец сравнивается с requesterUserID.
+// resource описывает данные для журнала, например "subscription sub_123".
+func (s *PaymentService) AuthorizeUserAccess(ctx context.Context, requesterUserID, ownerUserID string, access authz.Access, resource string) error {
+	principal, ok := authz.PrincipalFromContext(ctx)
+	if !ok {
+		if requesterUserID != "" && requesterUserID == ownerUserID {
+			return nil
+		}
+		s.log.Warnw("AUDIT: cross-user access denied. RequesterID: %s, OwnerID: %s, Resource: %s, Access: %s",
+			requesterUserID, ownerUserID, resource, access)
+		return ErrForbidden
+	}
+
+	if principal.Subject == ownerUserID {
+		return nil
+	}
+	if !principal.CanActFor(access) {
+		s.log.Warnw("AUDIT: cross-user access denied. Subject: %s, Roles: %v, OwnerID: %s, Resource: %s, Access: %s",
+			principal.Subject, principal.Roles, ownerUserID, resource, access)
+		return ErrForbidden
+	}
+	s.log.Infow("AUDIT: cross-user access granted. Subject: %s, Roles: %v, OwnerID: %s, Resource: %s, Access: %s",
+		principal.Subject, principal.Roles, ownerUserID, resource, access)
+	return nil
+}
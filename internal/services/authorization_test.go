@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+)
+
+func TestAuthorizeUserAccess(t *testing.T) {
+	svc := newTestService(&fakeSubscriptionRepo{}, &fakeOutboxRepo{})
+
+	withRoles := func(subject string, roles ...authz.Role) context.Context {
+		return authz.WithPrincipal(context.Background(), &authz.Principal{Subject: subject, UserID: subject, Roles: roles})
+	}
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		requester string
+		owner     string
+		access    authz.Access
+		wantErr   error
+	}{
+		{"owner", withRoles("u1", authz.RoleUser), "u1", "u1", authz.AccessWrite, nil},
+		{"other user", withRoles("u1", authz.RoleUser), "u1", "u2", authz.AccessRead, ErrForbidden},
+		{"support reads", withRoles("s1", authz.RoleSupport), "s1", "u2", authz.AccessRead, nil},
+		{"support writes", withRoles("s1", authz.RoleSupport), "s1", "u2", authz.AccessWrite, ErrForbidden},
+		{"admin writes", withRoles("a1", authz.RoleAdmin), "a1", "u2", authz.AccessWrite, nil},
+		{"service writes", withRoles("billing", authz.RoleService), "u2", "u2", authz.AccessWrite, nil},
+		// При аутентифицированном вызове владелец сравнивается с subject токена, а не с переданным requesterUserID
+		{"requester ID does not override subject", withRoles("u1", authz.RoleUser), "u2", "u2", authz.AccessRead, ErrForbidden},
+		{"internal call for owner", context.Background(), "u1", "u1", authz.AccessWrite, nil},
+		{"internal call for other user", context.Background(), "u1", "u2", authz.AccessRead, ErrForbidden},
+		{"internal call without requester", context.Background(), "", "", authz.AccessRead, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.AuthorizeUserAccess(tt.ctx, tt.requester, tt.owner, tt.access, "subscription sub_1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthorizeUserAccess error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+)
+
+// ErrSubscriptionCanceled возвращается, если операция требует неотмененной подписки.
+var ErrSubscriptionCanceled = errors.New("subscription is already canceled")
+
+// CancelMode режим отмены подписки.
+type CancelMode string
+
+const (
+	CancelImmediately CancelMode = "immediately"   // Подписка отменяется сразу
+	CancelAtPeriodEnd CancelMode = "at_period_end" // Подписка действует до конца оплаченного периода
+)
+
+// ParseCancelMode разбирает режим отмены; пустая строка означает немедленную отмену.
+func ParseCancelMode(mode string) (CancelMode, error) {
+	switch CancelMode(mode) {
+	case "", CancelImmediately:
+		return CancelImmediately, nil
+	case CancelAtPeriodEnd:
+		return CancelAtPeriodEnd, nil
+	default:
+		return "", fmt.Errorf("%w: unknown cancel mode %q", ErrInvalidInput, mode)
+	}
+}
+
+// ResumeSubscription снимает отмену подписки, запланированную на конец периода.
+// Если отмена не запланирована, подписка возвращается без изменений.
+func (s *PaymentService) ResumeSubscription(ctx context.Context, userID, subscriptionID, idempotencyKey string) (*models.Subscription, error) {
+	if userID == "" || subscriptionID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	s.log.Infow("Attempting to resume subscription. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+
+	sub, err := s.getOwnedSubscription(ctx, userID, subscriptionID, authz.AccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == "canceled" {
+		s.log.Warnw("Attempted to resume an already canceled subscription. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+		return nil, ErrSubscriptionCanceled
+	}
+	if !sub.CancelAtPeriodEnd && sub.CancelAt == nil {
+		s.log.Infow("Subscription has no scheduled cancellation. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+		return sub, nil
+	}
+
+	return s.setCancelAtPeriodEnd(ctx, sub, false, idempotencyKey)
+}
+
+// setCancelAtPeriodEnd планирует или снимает отмену в Stripe и сразу отражает ее в локальной подписке
+// вместе с событием subscription.cancellation_changed. last_event_at не меняется: вебхук
+// customer.subscription.updated с тем же состоянием затем не найдет изменений.
+func (s *PaymentService) setCancelAtPeriodEnd(ctx context.Context, sub *models.Subscription, cancelAtPeriodEnd bool, idempotencyKey string) (*models.Subscription, error) {
+	cancelAt, err := s.stripeClient.SetCancelAtPeriodEnd(ctx, sub.SubscriptionID, cancelAtPeriodEnd, idempotencyKey)
+	if err != nil {
+		s.trackStripeError(err, CreateSubscriptionInput{UserID: sub.UserID, PlanID: sub.PlanID})
+		s.log.Errorw("Stripe failed to update subscription cancellation. UserID: %s, SubscriptionID: %s, CancelAtPeriodEnd: %t, Error: %v",
+			sub.UserID, sub.SubscriptionID, cancelAtPeriodEnd, err)
+		return nil, fmt.Errorf("%w: failed to update subscription cancellation: %v", ErrStripeClient, err)
+	}
+	s.log.Infow("Subscription cancellation updated in Stripe. UserID: %s, SubscriptionID: %s, CancelAtPeriodEnd: %t",
+		sub.UserID, sub.SubscriptionID, cancelAtPeriodEnd)
+
+	if !setCancellation(sub, cancelAtPeriodEnd, cancelAt) {
+		return sub, nil
+	}
+	sub.UpdatedAt = time.Now()
+	event, err := newSubscriptionOutboxEvent(ctx, kafka.EventSubscriptionCancellationChanged, sub.Status, sub)
+	if err == nil {
+		err = s.subRepo.UpdateWithEvent(ctx, sub, event)
+	}
+	if err != nil {
+		// Изменение в Stripe уже прошло; подписка и событие будут записаны при обработке вебхука customer.subscription.updated
+		s.log.Errorw("Failed to update local subscription cancellation. UserID: %s, SubscriptionID: %s, Error: %v", sub.UserID, sub.SubscriptionID, err)
+	}
+	return sub, nil
+}
+
+// setCancellation записывает в подписку запланированную отмену; возвращает true, если значения изменились.
+func setCancellation(sub *models.Subscription, cancelAtPeriodEnd bool, cancelAt *time.Time) bool {
+	if sub.CancelAtPeriodEnd == cancelAtPeriodEnd && sameTime(sub.CancelAt, cancelAt) {
+		return false
+	}
+	sub.CancelAtPeriodEnd = cancelAtPeriodEnd
+	sub.CancelAt = cancelAt
+	return true
+}
+
+// sameTime сравнивает необязательные моменты времени.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
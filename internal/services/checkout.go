@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+)
+
+// CreateCheckoutSessionInput параметры оформления подписки через Stripe Checkout.
+type CreateCheckoutSessionInput struct {
+	UserID              string
+	PlanID              string
+	UserEmail           string
+	SuccessURL          string // Пусто - stripe.checkoutSuccessUrl из конфигурации
+	CancelURL           string // Пусто - stripe.checkoutCancelUrl из конфигурации
+	AllowPromotionCodes bool
+	IdempotencyKey      string
+}
+
+// CreateCheckoutSession создает сессию Stripe Checkout для подписки пользователя на план.
+// Локальная подписка появится после оплаты, из вебхука checkout.session.completed.
+func (s *PaymentService) CreateCheckoutSession(ctx context.Context, input CreateCheckoutSessionInput) (*stripe.CheckoutSession, error) {
+	if input.UserID == "" || input.PlanID == "" || input.UserEmail == "" {
+		return nil, ErrInvalidInput
+	}
+	successURL, err := s.redirectURL(input.SuccessURL, s.cfg.Stripe.CheckoutSuccessURL, "success_url")
+	if err != nil {
+		return nil, err
+	}
+	cancelURL, err := s.redirectURL(input.CancelURL, s.cfg.Stripe.CheckoutCancelURL, "cancel_url")
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.validatePlan(ctx, input.PlanID)
+	if err != nil {
+		s.log.Warnw("CreateCheckoutSession rejected: plan is unavailable. UserID: %s, PlanID: %s, Error: %v", input.UserID, input.PlanID, err)
+		return nil, err
+	}
+	customer, err := s.GetOrCreateCustomer(ctx, input.UserID, input.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.stripeClient.CreateCheckoutSession(ctx, stripe.NewCheckoutSession{
+		CustomerID:          customer.StripeCustomerID,
+		UserID:              input.UserID,
+		PriceID:             plan.PlanID,
+		TrialPeriodDays:     min(plan.TrialPeriodDays, maxTrialDays),
+		SuccessURL:          successURL,
+		CancelURL:           cancelURL,
+		AllowPromotionCodes: input.AllowPromotionCodes,
+		IdempotencyKey:      input.IdempotencyKey,
+	})
+	if err != nil {
+		s.log.Errorw("Failed to create checkout session. UserID: %s, PlanID: %s, Error: %v", input.UserID, input.PlanID, err)
+		return nil, fmt.Errorf("%w: failed to create checkout session: %v", ErrStripeClient, err)
+	}
+	s.log.Infow("Checkout session created. UserID: %s, PlanID: %s, SessionID: %s", input.UserID, input.PlanID, session.ID)
+	return session, nil
+}
+
+// CreateBillingPortalSession создает сессию Billing Portal для клиента пользователя и возвращает ее адрес.
+func (s *PaymentService) CreateBillingPortalSession(ctx context.Context, userID, returnURL string) (string, error) {
+	if userID == "" {
+		return "", ErrInvalidInput
+	}
+	returnURL, err := s.redirectURL(returnURL, s.cfg.Stripe.PortalReturnURL, "return_url")
+	if err != nil {
+		return "", err
+	}
+	customer, err := s.getLocalCustomer(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	portalURL, err := s.stripeClient.CreateBillingPortalSession(ctx, customer.StripeCustomerID, returnURL)
+	if err != nil {
+		s.log.Errorw("Failed to create billing portal session. UserID: %s, Error: %v", userID, err)
+		return "", fmt.Errorf("%w: failed to create billing portal session: %v", ErrStripeClient, err)
+	}
+	s.log.Infow("Billing portal session created. UserID: %s, StripeCustomerID: %s", userID, customer.StripeCustomerID)
+	return portalURL, nil
+}
+
+// redirectURL выбирает URL возврата из Stripe: переданный клиентом или из конфигурации.
+// URL клиента принимается, только если это абсолютный http(s) адрес на одном из разрешенных хостов
+// (хосты URL из конфигурации и stripe.redirectHosts), иначе сервис стал бы открытым редиректом.
+func (s *PaymentService) redirectURL(requested, configured, param string) (string, error) {
+	if requested == "" {
+		if configured == "" {
+			return "", fmt.Errorf("%w: %s is required", ErrInvalidInput, param)
+		}
+		return configured, nil
+	}
+
+	u, err := url.Parse(requested)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: %s must be an absolute http(s) URL", ErrInvalidInput, param)
+	}
+	allowed := slices.Clone(s.cfg.Stripe.RedirectHosts)
+	for _, raw := range []string{s.cfg.Stripe.CheckoutSuccessURL, s.cfg.Stripe.CheckoutCancelURL, s.cfg.Stripe.PortalReturnURL} {
+		if cu, err := url.Parse(raw); err == nil && cu.Host != "" {
+			allowed = append(allowed, cu.Host)
+		}
+	}
+	if !slices.ContainsFunc(allowed, func(host string) bool { return strings.EqualFold(host, u.Host) }) {
+		return "", fmt.Errorf("%w: %s host %s is not allowed", ErrInvalidInput, param, u.Host)
+	}
+	return requested, nil
+}
+
+// handleCheckoutSessionCompleted создает локальную подписку, оформленную через Stripe Checkout.
+// Состояние подписки берется из Stripe, а не из события: к моменту доставки оно могло измениться.
+func (s *PaymentService) handleCheckoutSessionCompleted(ctx context.Context, eventCreated time.Time, data map[string]interface{}) error {
+	sessionID := getStringValue(data, "id")
+	if mode := getStringValue(data, "mode"); mode != "subscription" {
+		s.log.Debugw("Ignoring checkout session without subscription. SessionID: %s, Mode: %s", sessionID, mode)
+		return nil
+	}
+	stripeSubID := getStringValue(data, "subscription")
+	if stripeSubID == "" {
+		s.log.Errorw("Subscription missing in checkout.session.completed event data. SessionID: %s", sessionID)
+		return nil
+	}
+
+	_, err := s.subRepo.GetByStripeSubscriptionID(ctx, stripeSubID)
+	if err == nil {
+		s.log.Infow("Subscription from checkout session already exists. SessionID: %s, StripeSubID: %s", sessionID, stripeSubID)
+		return nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to get subscription %s: %w", stripeSubID, err)
+	}
+
+	state, err := s.stripeClient.GetSubscription(ctx, stripeSubID)
+	if err != nil {
+		if errors.Is(err, stripe.ErrResourceMissing) {
+			s.log.Errorw("Subscription from checkout session not found in Stripe. SessionID: %s, StripeSubID: %s", sessionID, stripeSubID)
+			return nil
+		}
+		return fmt.Errorf("failed to get subscription %s from stripe: %w", stripeSubID, err)
+	}
+
+	userID, err := s.checkoutSessionUserID(ctx, state.CustomerID, data)
+	if err != nil {
+		return err
+	}
+	if userID == "" {
+		s.log.Errorw("Cannot determine user of checkout session. SessionID: %s, StripeCustomerID: %s", sessionID, state.CustomerID)
+		return nil
+	}
+
+	subscription := &models.Subscription{
+		SubscriptionID:   state.ID,
+		UserID:           userID,
+		PlanID:           state.PriceID,
+		Status:           state.Status,
+		StripeCustomerID: state.CustomerID,
+		TrialStart:       state.TrialStart,
+		TrialEnd:         state.TrialEnd,
+	}
+	if !state.CurrentPeriodEnd.IsZero() {
+		subscription.ExpiresAt = &state.CurrentPeriodEnd
+	}
+	if !eventCreated.IsZero() {
+		subscription.LastEventAt = &eventCreated
+	}
+	// Код промокода, введенный на странице оплаты, в подписке недоступен - сохраняется только купон
+	setDiscount(subscription, state.Discount, "")
+
+	event, err := newSubscriptionOutboxEvent(ctx, kafka.EventSubscriptionCreated, "", subscription)
+	if err != nil {
+		return fmt.Errorf("failed to build subscription created event: %w", err)
+	}
+	if err := s.subRepo.CreateWithEvent(ctx, subscription, event); err != nil {
+		return fmt.Errorf("failed to save subscription %s from checkout session: %w", stripeSubID, err)
+	}
+	s.log.Infow("Subscription created from checkout session. UserID: %s, SessionID: %s, StripeSubID: %s, Status: %s",
+		userID, sessionID, stripeSubID, subscription.Status)
+	return nil
+}
+
+// checkoutSessionUserID определяет пользователя сессии Checkout: client_reference_id, метаданные сессии
+// или локальная связь с клиентом Stripe. Пустая строка - пользователь неизвестен.
+func (s *PaymentService) checkoutSessionUserID(ctx context.Context, stripeCustomerID string, data map[string]interface{}) (string, error) {
+	if userID := getStringValue(data, "client_reference_id"); userID != "" {
+		return userID, nil
+	}
+	if metadata, ok := data["metadata"].(map[string]interface{}); ok {
+		if userID := getStringValue(metadata, "user_id"); userID != "" {
+			return userID, nil
+		}
+	}
+	if stripeCustomerID == "" {
+		return "", nil
+	}
+	customer, err := s.customerRepo.GetByStripeID(ctx, stripeCustomerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get customer %s: %w", stripeCustomerID, err)
+	}
+	return customer.UserID, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+)
+
+var (
+	ErrCustomerNotFound = errors.New("customer not found") // Для пользователя еще не создан клиент Stripe
+)
+
+// CreateCustomer создает клиента Stripe для пользователя. Если связь уже есть в таблице customers,
+// возвращается существующая запись.
+func (s *PaymentService) CreateCustomer(ctx context.Context, userID, email string) (*models.Customer, error) {
+	if userID == "" || email == "" {
+		return nil, ErrInvalidInput
+	}
+
+	if existing, err := s.getLocalCustomer(ctx, userID); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, ErrCustomerNotFound) {
+		return nil, err
+	}
+
+	stripeCustomerID, err := s.stripeClient.CreateCustomer(ctx, userID, email)
+	if err != nil {
+		s.log.Errorw("Failed to create Stripe customer. UserID: %s, Error: %v", userID, err)
+		return nil, fmt.Errorf("%w: failed to create customer: %v", ErrStripeClient, err)
+	}
+
+	return s.saveCustomer(ctx, models.NewCustomer(userID, stripeCustomerID, email))
+}
+
+// GetOrCreateCustomer возвращает клиента Stripe пользователя. Сначала проверяется таблица customers;
+// при промахе клиент ищется в Stripe по метаданным (для клиентов, созданных до появления таблицы)
+// или создается, после чего связь сохраняется локально.
+func (s *PaymentService) GetOrCreateCustomer(ctx context.Context, userID, email string) (*models.Customer, error) {
+	if userID == "" || email == "" {
+		return nil, ErrInvalidInput
+	}
+
+	customer, err := s.getLocalCustomer(ctx, userID)
+	if err == nil {
+		s.log.Debugw("Stripe customer found in local DB. UserID: %s, StripeCustomerID: %s", userID, customer.StripeCustomerID)
+		return customer, nil
+	}
+	if !errors.Is(err, ErrCustomerNotFound) {
+		return nil, err
+	}
+
+	stripeCustomerID, err := s.stripeClient.GetOrCreateCustomer(ctx, userID, email)
+	if err != nil {
+		s.log.Errorw("Failed to get or create Stripe customer. UserID: %s, Error: %v", userID, err)
+		return nil, fmt.Errorf("%w: failed to process customer: %v", ErrStripeClient, err)
+	}
+
+	return s.saveCustomer(ctx, models.NewCustomer(userID, stripeCustomerID, email))
+}
+
+// UpdateCustomerEmail изменяет email клиента в Stripe и в таблице customers.
+func (s *PaymentService) UpdateCustomerEmail(ctx context.Context, userID, newEmail string) error {
+	if userID == "" || newEmail == "" {
+		return ErrInvalidInput
+	}
+
+	customer, err := s.getLocalCustomer(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if customer.Email == newEmail {
+		return nil
+	}
+
+	// Сначала Stripe: если он недоступен, локальная запись не разойдется с ним
+	if err := s.stripeClient.UpdateCustomerEmail(ctx, customer.StripeCustomerID, newEmail); err != nil {
+		s.log.Errorw("Failed to update Stripe customer email. UserID: %s, StripeCustomerID: %s, Error: %v", userID, customer.StripeCustomerID, err)
+		return fmt.Errorf("%w: failed to update customer email: %v", ErrStripeClient, err)
+	}
+
+	customer.Email = newEmail
+	customer.UpdatedAt = time.Now()
+	if err := s.customerRepo.Update(ctx, customer); err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			return ErrCustomerNotFound
+		}
+		s.log.Errorw("Failed to update customer email in local DB. UserID: %s, Error: %v", userID, err)
+		return fmt.Errorf("%w: failed to update customer: %v", ErrInternalServer, err)
+	}
+
+	s.log.Infow("Customer email updated. UserID: %s, StripeCustomerID: %s", userID, customer.StripeCustomerID)
+	return nil
+}
+
+// getLocalCustomer читает связь userID -> Stripe customer из таблицы customers.
+func (s *PaymentService) getLocalCustomer(ctx context.Context, userID string) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("%w: failed to get customer: %v", ErrInternalServer, err)
+	}
+	return customer, nil
+}
+
+// saveCustomer сохраняет связь с клиентом Stripe. Если параллельный запрос успел сохранить свою,
+// возвращается она, чтобы все запросы пользователя использовали одного клиента. Клиент Stripe,
+// созданный проигравшим запросом, остается неиспользуемым.
+func (s *PaymentService) saveCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
+	stored, created, err := s.customerRepo.CreateIfNotExists(ctx, customer)
+	if err != nil {
+		// Клиента в Stripe не удаляем: при следующем запросе он будет найден поиском по метаданным
+		s.log.Errorw("Failed to save customer to local DB. UserID: %s, StripeCustomerID: %s, Error: %v", customer.UserID, customer.StripeCustomerID, err)
+		return nil, fmt.Errorf("%w: failed to save customer: %v", ErrInternalServer, err)
+	}
+
+	if created {
+		s.log.Infow("Customer saved to local DB. UserID: %s, StripeCustomerID: %s", customer.UserID, customer.StripeCustomerID)
+	} else if stored.StripeCustomerID != customer.StripeCustomerID {
+		s.log.Warnw("Concurrent customer creation detected, using existing mapping. UserID: %s, StripeCustomerID: %s, UnusedStripeCustomerID: %s",
+			customer.UserID, stored.StripeCustomerID, customer.StripeCustomerID)
+	}
+	return stored, nil
+}
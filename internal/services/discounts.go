@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	stripego "github.com/stripe/stripe-go/v78"
+)
+
+// ErrInvalidDiscount возвращается, если купон или промокод не существует, истек или неприменим к плану.
+var ErrInvalidDiscount = errors.New("coupon or promotion code is invalid or expired")
+
+// subscriptionDiscount проверенная скидка новой подписки: купон задается либо напрямую, либо через промокод.
+type subscriptionDiscount struct {
+	CouponID        string
+	PromotionCodeID string
+	PromotionCode   string // Код, который ввел пользователь
+}
+
+// resolveDiscount проверяет купон или промокод новой подписки в Stripe до ее создания.
+// Проверяются ограничения, которые видны заранее (срок, лимит, клиент, продукт, валюта и минимальная
+// сумма); остальные (например, first_time_transaction) Stripe проверит при создании подписки.
+func (s *PaymentService) resolveDiscount(ctx context.Context, input CreateSubscriptionInput, plan *models.Plan, stripeCustomerID string) (subscriptionDiscount, error) {
+	couponID := strings.TrimSpace(input.CouponID)
+	code := strings.TrimSpace(input.PromotionCode)
+	switch {
+	case couponID == "" && code == "":
+		return subscriptionDiscount{}, nil
+	case couponID != "" && code != "":
+		return subscriptionDiscount{}, fmt.Errorf("%w: coupon and promotion_code are mutually exclusive", ErrInvalidInput)
+	}
+
+	if code != "" {
+		promo, err := s.stripeClient.FindPromotionCode(ctx, code)
+		if err != nil {
+			return subscriptionDiscount{}, s.discountLookupError(err, "promotion code", code)
+		}
+		if !promo.Active || promo.Coupon == nil {
+			return subscriptionDiscount{}, fmt.Errorf("%w: promotion code %q is no longer active", ErrInvalidDiscount, code)
+		}
+		if promo.ExpiresAt != nil && !promo.ExpiresAt.After(time.Now()) {
+			return subscriptionDiscount{}, fmt.Errorf("%w: promotion code %q expired at %s", ErrInvalidDiscount, code, promo.ExpiresAt)
+		}
+		if promo.CustomerID != "" && promo.CustomerID != stripeCustomerID {
+			return subscriptionDiscount{}, fmt.Errorf("%w: promotion code %q is not available for this customer", ErrInvalidDiscount, code)
+		}
+		if promo.MinimumAmount > 0 && (plan.Amount < promo.MinimumAmount || !strings.EqualFold(plan.Currency, promo.MinimumAmountCurrency)) {
+			return subscriptionDiscount{}, fmt.Errorf("%w: promotion code %q requires a minimum amount of %d %s",
+				ErrInvalidDiscount, code, promo.MinimumAmount, promo.MinimumAmountCurrency)
+		}
+		if err := checkCouponApplies(promo.Coupon, plan); err != nil {
+			return subscriptionDiscount{}, err
+		}
+		return subscriptionDiscount{CouponID: promo.Coupon.ID, PromotionCodeID: promo.ID, PromotionCode: promo.Code}, nil
+	}
+
+	coupon, err := s.stripeClient.GetCoupon(ctx, couponID)
+	if err != nil {
+		return subscriptionDiscount{}, s.discountLookupError(err, "coupon", couponID)
+	}
+	if err := checkCouponApplies(coupon, plan); err != nil {
+		return subscriptionDiscount{}, err
+	}
+	return subscriptionDiscount{CouponID: coupon.ID}, nil
+}
+
+// discountLookupError преобразует ошибку поиска купона или промокода в ошибку сервиса.
+func (s *PaymentService) discountLookupError(err error, kind, value string) error {
+	if errors.Is(err, stripe.ErrResourceMissing) {
+		return fmt.Errorf("%w: %s %q not found", ErrInvalidDiscount, kind, value)
+	}
+	s.log.Errorw("Failed to look up discount in Stripe. Kind: %s, Value: %s, Error: %v", kind, value, err)
+	return fmt.Errorf("%w: failed to look up %s: %v", ErrStripeClient, kind, err)
+}
+
+// checkCouponApplies проверяет, что купон действителен и применим к плану.
+func checkCouponApplies(coupon *stripe.Coupon, plan *models.Plan) error {
+	if !coupon.Valid {
+		return fmt.Errorf("%w: coupon %s has expired", ErrInvalidDiscount, coupon.ID)
+	}
+	if len(coupon.AppliesToProducts) > 0 && !slices.Contains(coupon.AppliesToProducts, plan.ProductID) {
+		return fmt.Errorf("%w: coupon %s does not apply to plan %s", ErrInvalidDiscount, coupon.ID, plan.PlanID)
+	}
+	if coupon.AmountOff > 0 && !strings.EqualFold(coupon.Currency, plan.Currency) {
+		return fmt.Errorf("%w: coupon %s currency %s does not match plan currency %s", ErrInvalidDiscount, coupon.ID, coupon.Currency, plan.Currency)
+	}
+	return nil
+}
+
+// discountRejection возвращает сообщение Stripe, если при создании подписки он отклонил купон или промокод.
+func discountRejection(err error) (string, bool) {
+	var stripeErr *stripego.Error
+	if errors.As(err, &stripeErr) && stripeErr.Type == StripeErrorTypeInvalidRequest &&
+		(stripeErr.Param == "coupon" || stripeErr.Param == "promotion_code") {
+		return stripeErr.Msg, true
+	}
+	return "", false
+}
+
+// setDiscount записывает в подписку скидку Stripe (nil - скидки нет) и код промокода, через который
+// она применена; возвращает true, если скидка изменилась.
+func setDiscount(sub *models.Subscription, discount *stripe.Discount, promotionCode string) bool {
+	updated := *sub
+	updated.DiscountCouponID, updated.DiscountPromotionCode = "", ""
+	updated.DiscountPercentOff, updated.DiscountAmountOff = 0, 0
+	updated.DiscountCurrency, updated.DiscountDuration = "", ""
+	updated.DiscountEnd = nil
+	if discount != nil && discount.Coupon != nil {
+		updated.DiscountCouponID = discount.Coupon.ID
+		updated.DiscountPromotionCode = promotionCode
+		updated.DiscountPercentOff = discount.Coupon.PercentOff
+		updated.DiscountAmountOff = discount.Coupon.AmountOff
+		if discount.Coupon.AmountOff > 0 {
+			updated.DiscountCurrency = discount.Coupon.Currency
+		}
+		updated.DiscountDuration = discount.Coupon.Duration
+		updated.DiscountEnd = discount.End
+	}
+
+	if updated.DiscountCouponID == sub.DiscountCouponID && updated.DiscountPromotionCode == sub.DiscountPromotionCode &&
+		updated.DiscountPercentOff == sub.DiscountPercentOff && updated.DiscountAmountOff == sub.DiscountAmountOff &&
+		updated.DiscountCurrency == sub.DiscountCurrency && updated.DiscountDuration == sub.DiscountDuration &&
+		sameTime(updated.DiscountEnd, sub.DiscountEnd) {
+		return false
+	}
+	*sub = updated
+	return true
+}
+
+// setDiscountFromWebhook обновляет скидку подписки по объекту подписки Stripe; возвращает true, если она изменилась.
+func setDiscountFromWebhook(sub *models.Subscription, data map[string]interface{}) bool {
+	raw, ok := data["discount"].(map[string]interface{})
+	if !ok {
+		return setDiscount(sub, nil, "")
+	}
+	coupon, ok := raw["coupon"].(map[string]interface{})
+	if !ok {
+		return setDiscount(sub, nil, "")
+	}
+	discount := &stripe.Discount{
+		Coupon: &stripe.Coupon{
+			ID:         getStringValue(coupon, "id"),
+			PercentOff: getFloat64Value(coupon, "percent_off"),
+			AmountOff:  getInt64Value(coupon, "amount_off"),
+			Currency:   getStringValue(coupon, "currency"),
+			Duration:   getStringValue(coupon, "duration"),
+		},
+	}
+	if end := getTimeValueFromUnix(raw, "end"); !end.IsZero() {
+		discount.End = &end
+	}
+	// В объекте подписки есть только ID промокода: код сохраняется, пока действует тот же купон
+	promotionCode := ""
+	if discount.Coupon.ID == sub.DiscountCouponID {
+		promotionCode = sub.DiscountPromotionCode
+	}
+	return setDiscount(sub, discount, promotionCode)
+}
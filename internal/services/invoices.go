@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+)
+
+// ErrInvoiceNotFound возвращается, если счета нет в локальной истории или он принадлежит другому пользователю.
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+const (
+	defaultInvoicesPageSize = 50
+	maxInvoicesPageSize     = 100
+)
+
+// GetInvoiceByID возвращает счет пользователя из локальной истории.
+func (s *PaymentService) GetInvoiceByID(ctx context.Context, userID, invoiceID string) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		s.log.Errorw("Failed to get invoice from repository. InvoiceID: %s, Error: %v", invoiceID, err)
+		return nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+
+	if err := s.AuthorizeUserAccess(ctx, userID, invoice.UserID, authz.AccessRead, "invoice "+invoiceID); err != nil {
+		// Не раскрываем существование чужого счета
+		return nil, ErrInvoiceNotFound
+	}
+	return invoice, nil
+}
+
+// ListInvoicesByUserID возвращает страницу истории счетов пользователя (новые первыми).
+// limit ограничивается maxInvoicesPageSize; hasMore сообщает, есть ли счета после этой страницы.
+func (s *PaymentService) ListInvoicesByUserID(ctx context.Context, userID string, limit, offset int) (invoices []*models.Invoice, hasMore bool, err error) {
+	if limit <= 0 {
+		limit = defaultInvoicesPageSize
+	}
+	if limit > maxInvoicesPageSize {
+		limit = maxInvoicesPageSize
+	}
+	if offset < 0 {
+		return nil, false, fmt.Errorf("%w: negative offset", ErrInvalidInput)
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	invoices, err = s.invoiceRepo.ListByUserID(ctx, userID, limit+1, offset)
+	if err != nil {
+		s.log.Errorw("Failed to list invoices from repository. UserID: %s, Error: %v", userID, err)
+		return nil, false, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+	if len(invoices) > limit {
+		return invoices[:limit], true, nil
+	}
+	return invoices, false, nil
+}
+
+// recordInvoiceFromWebhook сохраняет состояние счета из события invoice.* в локальную историю.
+// Владелец определяется по локальной подписке или по клиенту Stripe; счета неизвестных клиентов пропускаются.
+func (s *PaymentService) recordInvoiceFromWebhook(ctx context.Context, eventCreated time.Time, data map[string]interface{}) error {
+	invoice := invoiceFromWebhook(data)
+	if invoice.InvoiceID == "" {
+		s.log.Errorw("Invoice ID missing in invoice event data")
+		return nil
+	}
+	invoice.LastEventAt = eventCreated
+	if invoice.StripeCreatedAt.IsZero() {
+		invoice.StripeCreatedAt = eventCreated
+	}
+
+	if invoice.StripeSubscriptionID != "" {
+		sub, err := s.subRepo.GetByStripeSubscriptionID(ctx, invoice.StripeSubscriptionID)
+		switch {
+		case err == nil:
+			invoice.SubscriptionID = sub.SubscriptionID
+			invoice.UserID = sub.UserID
+		case !errors.Is(err, repository.ErrNotFound):
+			return fmt.Errorf("failed to find subscription of invoice %s: %w", invoice.InvoiceID, err)
+		}
+	}
+	if invoice.UserID == "" && invoice.StripeCustomerID != "" {
+		customer, err := s.customerRepo.GetByStripeID(ctx, invoice.StripeCustomerID)
+		switch {
+		case err == nil:
+			invoice.UserID = customer.UserID
+		case !errors.Is(err, repository.ErrCustomerNotFound):
+			return fmt.Errorf("failed to find customer of invoice %s: %w", invoice.InvoiceID, err)
+		}
+	}
+	if invoice.UserID == "" {
+		s.log.Warnw("Invoice belongs to an unknown customer, skipping. InvoiceID: %s, CustomerID: %s", invoice.InvoiceID, invoice.StripeCustomerID)
+		return nil
+	}
+
+	if err := s.invoiceRepo.Upsert(ctx, invoice); err != nil {
+		return fmt.Errorf("failed to save invoice %s: %w", invoice.InvoiceID, err)
+	}
+	s.log.Infow("Invoice saved. InvoiceID: %s, UserID: %s, Status: %s", invoice.InvoiceID, invoice.UserID, invoice.Status)
+	return nil
+}
+
+// invoiceFromWebhook преобразует объект счета Stripe из события в модель.
+func invoiceFromWebhook(data map[string]interface{}) *models.Invoice {
+	invoice := &models.Invoice{
+		InvoiceID:            getStringValue(data, "id"),
+		StripeCustomerID:     getStringValue(data, "customer"),
+		StripeSubscriptionID: getStringValue(data, "subscription"),
+		Number:               getStringValue(data, "number"),
+		Status:               getStringValue(data, "status"),
+		BillingReason:        getStringValue(data, "billing_reason"),
+		Currency:             getStringValue(data, "currency"),
+		AmountDue:            getInt64Value(data, "amount_due"),
+		AmountPaid:           getInt64Value(data, "amount_paid"),
+		AmountRemaining:      getInt64Value(data, "amount_remaining"),
+		Subtotal:             getInt64Value(data, "subtotal"),
+		Total:                getInt64Value(data, "total"),
+		AttemptCount:         getInt64Value(data, "attempt_count"),
+		HostedInvoiceURL:     getStringValue(data, "hosted_invoice_url"),
+		InvoicePDF:           getStringValue(data, "invoice_pdf"),
+		StripeCreatedAt:      getTimeValueFromUnix(data, "created"),
+	}
+	if periodStart := getTimeValueFromUnix(data, "period_start"); !periodStart.IsZero() {
+		invoice.PeriodStart = &periodStart
+	}
+	if periodEnd := getTimeValueFromUnix(data, "period_end"); !periodEnd.IsZero() {
+		invoice.PeriodEnd = &periodEnd
+	}
+	if transitions, ok := data["status_transitions"].(map[string]interface{}); ok {
+		if paidAt := getTimeValueFromUnix(transitions, "paid_at"); !paidAt.IsZero() {
+			invoice.PaidAt = &paidAt
+		}
+	}
+	return invoice
+}
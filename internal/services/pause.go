@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+)
+
+// ErrSubscriptionNotPausable возвращается, если списания по подписке нельзя приостановить в ее текущем статусе.
+var ErrSubscriptionNotPausable = errors.New("subscription cannot be paused in its current status")
+
+// pausableSubscriptionStatuses статусы, в которых разрешено приостановить списания.
+// Stripe при этом оставляет статус active/trialing; локально подписка получает статус paused.
+var pausableSubscriptionStatuses = map[string]bool{
+	"active":   true,
+	"trialing": true,
+	"paused":   true, // Повторная приостановка меняет поведение и дату возобновления
+}
+
+// pauseBehaviors допустимые значения pause_collection.behavior.
+var pauseBehaviors = map[string]bool{
+	stripe.PauseBehaviorVoid:              true,
+	stripe.PauseBehaviorKeepAsDraft:       true,
+	stripe.PauseBehaviorMarkUncollectible: true,
+}
+
+type PauseSubscriptionInput struct {
+	UserID         string
+	SubscriptionID string
+	Behavior       string     // void, keep_as_draft или mark_uncollectible
+	ResumesAt      *time.Time // Автоматическое возобновление (nil - только через UnpauseSubscription)
+	IdempotencyKey string
+}
+
+// PauseSubscription приостанавливает списания по подписке пользователя.
+func (s *PaymentService) PauseSubscription(ctx context.Context, input PauseSubscriptionInput) (*models.Subscription, error) {
+	if input.UserID == "" || input.SubscriptionID == "" {
+		return nil, ErrInvalidInput
+	}
+	if !pauseBehaviors[input.Behavior] {
+		return nil, fmt.Errorf("%w: unknown pause behavior %q", ErrInvalidInput, input.Behavior)
+	}
+	if input.ResumesAt != nil && !input.ResumesAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: resumes_at must be in the future", ErrInvalidInput)
+	}
+
+	s.log.Infow("Starting PauseSubscription. UserID: %s, SubscriptionID: %s, Behavior: %s", input.UserID, input.SubscriptionID, input.Behavior)
+
+	sub, err := s.getOwnedSubscription(ctx, input.UserID, input.SubscriptionID, authz.AccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	if !pausableSubscriptionStatuses[sub.Status] {
+		s.log.Warnw("Pause rejected: subscription status does not allow it. SubscriptionID: %s, Status: %s", sub.SubscriptionID, sub.Status)
+		return nil, fmt.Errorf("%w: status %s", ErrSubscriptionNotPausable, sub.Status)
+	}
+
+	err = s.stripeClient.PauseSubscription(ctx, sub.SubscriptionID, stripe.PauseCollection{
+		Behavior:  input.Behavior,
+		ResumesAt: input.ResumesAt,
+	}, input.IdempotencyKey)
+	if err != nil {
+		s.trackStripeError(err, CreateSubscriptionInput{UserID: sub.UserID, PlanID: sub.PlanID})
+		s.log.Errorw("Stripe failed to pause subscription. UserID: %s, SubscriptionID: %s, Error: %v", sub.UserID, sub.SubscriptionID, err)
+		return nil, fmt.Errorf("%w: failed to pause subscription: %v", ErrStripeClient, err)
+	}
+
+	previousStatus := sub.Status
+	sub.Status = "paused"
+	sub.ResumesAt = input.ResumesAt
+	s.saveCollectionChange(ctx, sub, previousStatus)
+	return sub, nil
+}
+
+// UnpauseSubscription возобновляет списания по подписке пользователя.
+// Если списания не приостановлены, подписка возвращается без изменений.
+func (s *PaymentService) UnpauseSubscription(ctx context.Context, userID, subscriptionID, idempotencyKey string) (*models.Subscription, error) {
+	if userID == "" || subscriptionID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	s.log.Infow("Starting UnpauseSubscription. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+
+	sub, err := s.getOwnedSubscription(ctx, userID, subscriptionID, authz.AccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == "canceled" {
+		return nil, ErrSubscriptionCanceled
+	}
+	if sub.Status != "paused" {
+		s.log.Infow("Subscription collection is not paused. UserID: %s, SubscriptionID: %s, Status: %s", userID, subscriptionID, sub.Status)
+		return sub, nil
+	}
+
+	status, err := s.stripeClient.UnpauseSubscription(ctx, sub.SubscriptionID, idempotencyKey)
+	if err != nil {
+		s.trackStripeError(err, CreateSubscriptionInput{UserID: sub.UserID, PlanID: sub.PlanID})
+		s.log.Errorw("Stripe failed to resume subscription collection. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+		return nil, fmt.Errorf("%w: failed to resume subscription collection: %v", ErrStripeClient, err)
+	}
+
+	previousStatus := sub.Status
+	sub.Status = status
+	sub.ResumesAt = nil
+	s.saveCollectionChange(ctx, sub, previousStatus)
+	return sub, nil
+}
+
+// saveCollectionChange сохраняет подписку после приостановки или возобновления списаний вместе с событием перехода.
+// last_event_at не меняется, поэтому ошибка сохранения не критична: вебхук customer.subscription.updated
+// запишет то же состояние.
+func (s *PaymentService) saveCollectionChange(ctx context.Context, sub *models.Subscription, previousStatus string) {
+	sub.UpdatedAt = time.Now()
+	var err error
+	if eventType := subscriptionTransitionEvent(previousStatus, sub.Status); eventType != "" {
+		var event *models.OutboxEvent
+		event, err = newSubscriptionOutboxEvent(ctx, eventType, previousStatus, sub)
+		if err == nil {
+			err = s.subRepo.UpdateWithEvent(ctx, sub, event)
+		}
+	} else {
+		err = s.subRepo.Update(ctx, sub)
+	}
+	if err != nil {
+		s.log.Errorw("Failed to update local subscription after collection change. SubscriptionID: %s, Status: %s, Error: %v", sub.SubscriptionID, sub.Status, err)
+	}
+}
+
+// subscriptionStatusFromWebhook возвращает локальный статус подписки по объекту подписки Stripe:
+// при заданном pause_collection Stripe оставляет статус active/trialing, локально это paused.
+func subscriptionStatusFromWebhook(data map[string]interface{}) string {
+	status := getStringValue(data, "status")
+	if _, paused := data["pause_collection"].(map[string]interface{}); paused && (status == "active" || status == "trialing") {
+		return "paused"
+	}
+	return status
+}
+
+// pauseResumesAtFromWebhook возвращает pause_collection.resumes_at объекта подписки или nil.
+func pauseResumesAtFromWebhook(data map[string]interface{}) *time.Time {
+	pause, ok := data["pause_collection"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if resumesAt := getTimeValueFromUnix(pause, "resumes_at"); !resumesAt.IsZero() {
+		return &resumesAt
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	stripego "github.com/stripe/stripe-go/v78"
+)
+
+// ErrPaymentMethodNotFound возвращается, если способа оплаты нет или он привязан к другому клиенту.
+var ErrPaymentMethodNotFound = errors.New("payment method not found")
+
+// CreateSetupIntent создает SetupIntent для сохранения новой карты клиента пользователя.
+// ClientSecret передается фронтенду, который подтверждает его через Stripe.js.
+func (s *PaymentService) CreateSetupIntent(ctx context.Context, userID, idempotencyKey string) (*stripe.SetupIntent, error) {
+	if userID == "" {
+		return nil, ErrInvalidInput
+	}
+	customer, err := s.getLocalCustomer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	si, err := s.stripeClient.CreateSetupIntent(ctx, customer.StripeCustomerID, idempotencyKey)
+	if err != nil {
+		s.log.Errorw("Failed to create setup intent. UserID: %s, Error: %v", userID, err)
+		return nil, fmt.Errorf("%w: failed to create setup intent: %v", ErrStripeClient, err)
+	}
+	s.log.Infow("Setup intent created. UserID: %s, SetupIntentID: %s", userID, si.ID)
+	return si, nil
+}
+
+// ListPaymentMethods возвращает карты клиента пользователя (пустой список, если клиента Stripe еще нет).
+func (s *PaymentService) ListPaymentMethods(ctx context.Context, userID string) ([]*stripe.PaymentMethod, error) {
+	if userID == "" {
+		return nil, ErrInvalidInput
+	}
+	customer, err := s.getLocalCustomer(ctx, userID)
+	if errors.Is(err, ErrCustomerNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := s.stripeClient.ListPaymentMethods(ctx, customer.StripeCustomerID)
+	if err != nil {
+		s.log.Errorw("Failed to list payment methods. UserID: %s, Error: %v", userID, err)
+		return nil, fmt.Errorf("%w: failed to list payment methods: %v", ErrStripeClient, err)
+	}
+	return methods, nil
+}
+
+// AttachPaymentMethod привязывает карту, созданную на фронтенде, к клиенту пользователя;
+// makeDefault делает ее картой по умолчанию для следующих списаний.
+func (s *PaymentService) AttachPaymentMethod(ctx context.Context, userID, paymentMethodID string, makeDefault bool, idempotencyKey string) (*stripe.PaymentMethod, error) {
+	if userID == "" || paymentMethodID == "" {
+		return nil, ErrInvalidInput
+	}
+	customer, err := s.getLocalCustomer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pm, err := s.stripeClient.AttachPaymentMethod(ctx, customer.StripeCustomerID, paymentMethodID, idempotencyKey)
+	if err != nil {
+		return nil, s.paymentMethodError(err, "attach", userID, paymentMethodID)
+	}
+	if makeDefault {
+		if err := s.stripeClient.SetDefaultPaymentMethod(ctx, customer.StripeCustomerID, pm.ID); err != nil {
+			return nil, s.paymentMethodError(err, "set default", userID, paymentMethodID)
+		}
+		pm.IsDefault = true
+	}
+
+	s.log.Infow("Payment method attached. UserID: %s, PaymentMethodID: %s, Default: %t", userID, pm.ID, makeDefault)
+	return pm, nil
+}
+
+// DetachPaymentMethod отвязывает карту от клиента пользователя.
+func (s *PaymentService) DetachPaymentMethod(ctx context.Context, userID, paymentMethodID string) error {
+	customerID, err := s.ownedPaymentMethodCustomer(ctx, userID, paymentMethodID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.stripeClient.DetachPaymentMethod(ctx, paymentMethodID); err != nil {
+		return s.paymentMethodError(err, "detach", userID, paymentMethodID)
+	}
+	s.log.Infow("Payment method detached. UserID: %s, StripeCustomerID: %s, PaymentMethodID: %s", userID, customerID, paymentMethodID)
+	return nil
+}
+
+// SetDefaultPaymentMethod делает привязанную карту пользователя картой по умолчанию.
+// Подписки без собственной карты используют ее для следующих списаний и повторных попыток оплаты.
+func (s *PaymentService) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) error {
+	customerID, err := s.ownedPaymentMethodCustomer(ctx, userID, paymentMethodID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.stripeClient.SetDefaultPaymentMethod(ctx, customerID, paymentMethodID); err != nil {
+		return s.paymentMethodError(err, "set default", userID, paymentMethodID)
+	}
+	s.log.Infow("Default payment method updated. UserID: %s, PaymentMethodID: %s", userID, paymentMethodID)
+	return nil
+}
+
+// ownedPaymentMethodCustomer проверяет, что карта привязана к клиенту пользователя, и возвращает ID клиента Stripe.
+// Чужая карта неотличима от несуществующей.
+func (s *PaymentService) ownedPaymentMethodCustomer(ctx context.Context, userID, paymentMethodID string) (string, error) {
+	if userID == "" || paymentMethodID == "" {
+		return "", ErrInvalidInput
+	}
+	customer, err := s.getLocalCustomer(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+
+	pm, err := s.stripeClient.GetPaymentMethod(ctx, paymentMethodID)
+	if err != nil {
+		return "", s.paymentMethodError(err, "get", userID, paymentMethodID)
+	}
+	if pm.CustomerID != customer.StripeCustomerID {
+		s.log.Warnw("User attempted to access payment method of another customer. UserID: %s, PaymentMethodID: %s", userID, paymentMethodID)
+		return "", ErrPaymentMethodNotFound
+	}
+	return customer.StripeCustomerID, nil
+}
+
+// paymentMethodError преобразует ошибку Stripe при операции со способом оплаты в ошибку сервиса.
+func (s *PaymentService) paymentMethodError(err error, operation, userID, paymentMethodID string) error {
+	if errors.Is(err, stripe.ErrResourceMissing) {
+		return ErrPaymentMethodNotFound
+	}
+	var stripeErr *stripego.Error
+	if errors.As(err, &stripeErr) && (stripeErr.Type == StripeErrorTypeInvalidRequest || stripeErr.Type == StripeErrorTypeCard) {
+		// Например, карта уже привязана к другому клиенту или отклонена банком
+		return fmt.Errorf("%w: %s", ErrInvalidInput, stripeErr.Msg)
+	}
+	s.log.Errorw("Failed to %s payment method. UserID: %s, PaymentMethodID: %s, Error: %v", operation, userID, paymentMethodID, err)
+	return fmt.Errorf("%w: failed to %s payment method: %v", ErrStripeClient, operation, err)
+}
@@ -8,8 +8,10 @@ import (
 	"net/http" // <-- Добавлен импорт для http.StatusTooManyRequests
 	"time"
 
+	"github.com/Dhoini/Payment-microservice/internal/authz"
 	"github.com/Dhoini/Payment-microservice/internal/config"
 	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
 	"github.com/Dhoini/Payment-microservice/internal/models"
 	"github.com/Dhoini/Payment-microservice/internal/repository"
 	"github.com/Dhoini/Payment-microservice/internal/stripe"
@@ -41,12 +43,23 @@ var (
 	ErrStripeClient         = errors.New("stripe client error")       // Ошибка взаимодействия со Stripe
 	ErrInternalServer       = errors.New("internal server error")     // Общая внутренняя ошибка
 	ErrInvalidInput         = errors.New("invalid input data")        // Ошибка валидации входных данных
+	ErrStaleWebhookEvent    = errors.New("stale webhook event")       // Событие Stripe старше последнего примененного к подписке
+)
+
+// Размер страницы при постраничной выдаче подписок
+const (
+	defaultSubscriptionsPageSize = 50
+	maxSubscriptionsPageSize     = 100
 )
 
 type CreateSubscriptionInput struct {
 	UserID         string
 	PlanID         string
 	UserEmail      string
+	TrialDays      *int64     // Пробный период в днях (nil - по умолчанию для плана, 0 - без пробного периода)
+	TrialEnd       *time.Time // Точный конец пробного периода (взаимоисключается с TrialDays)
+	CouponID       string     // Купон Stripe (взаимоисключается с PromotionCode)
+	PromotionCode  string     // Промокод, который ввел пользователь
 	IdempotencyKey string
 }
 
@@ -56,32 +69,51 @@ type CreateSubscriptionOutput struct {
 }
 
 type PaymentService struct {
-	cfg           *config.Config
-	subRepo       repository.SubscriptionRepository
-	customerRepo  repository.CustomerRepository
-	stripeClient  stripe.Client
-	kafkaProducer kafka.Producer // Может быть nil, если Kafka недоступен
-	log           *logger.Logger
+	cfg          *config.Config
+	subRepo      repository.SubscriptionRepository
+	customerRepo repository.CustomerRepository
+	planRepo     repository.PlanRepository
+	invoiceRepo  repository.InvoiceRepository
+	refundRepo   repository.RefundRepository
+	paymentRepo  repository.PaymentRepository
+	outboxRepo   repository.OutboxRepository // События пишутся в outbox и публикуются в Kafka релеем
+	webhookRepo  repository.WebhookEventRepository
+	stripeClient stripe.Client
+	log          *logger.Logger
+
+	staleWebhookEvents *metrics.CounterVec // Отклоненные устаревшие события (из очереди и при ручном повторе) по типу события
 }
 
-// NewPaymentService конструктор сервиса
+// NewPaymentService конструктор сервиса. Отклоненные устаревшие вебхуки считаются в registry.
 func NewPaymentService(
 	cfg *config.Config,
 	subRepo repository.SubscriptionRepository,
+	customerRepo repository.CustomerRepository,
+	planRepo repository.PlanRepository,
+	invoiceRepo repository.InvoiceRepository,
+	refundRepo repository.RefundRepository,
+	paymentRepo repository.PaymentRepository,
+	outboxRepo repository.OutboxRepository,
+	webhookRepo repository.WebhookEventRepository,
 	stripeClient stripe.Client,
-	kafkaProducer kafka.Producer, // Принимаем интерфейс, может быть nil
+	registry *metrics.Registry,
 	log *logger.Logger,
 ) *PaymentService {
-	// Логируем, если Kafka продюсер не инициализирован
-	if kafkaProducer == nil {
-		log.Warnw("Kafka producer is nil, event publishing will be skipped.")
-	}
 	return &PaymentService{
-		cfg:           cfg,
-		subRepo:       subRepo,
-		stripeClient:  stripeClient,
-		kafkaProducer: kafkaProducer,
-		log:           log,
+		cfg:          cfg,
+		subRepo:      subRepo,
+		customerRepo: customerRepo,
+		planRepo:     planRepo,
+		invoiceRepo:  invoiceRepo,
+		refundRepo:   refundRepo,
+		paymentRepo:  paymentRepo,
+		outboxRepo:   outboxRepo,
+		webhookRepo:  webhookRepo,
+		stripeClient: stripeClient,
+		log:          log,
+		staleWebhookEvents: registry.NewCounterVec("webhook_events_stale_rejected_total",
+			"Total number of out-of-order Stripe webhook events rejected as stale by event type.",
+			"event_type"),
 	}
 }
 
@@ -97,21 +129,50 @@ func (s *PaymentService) CreateSubscription(ctx context.Context, input CreateSub
 	s.log.Infow("Starting CreateSubscription process. UserID: %s, PlanID: %s", input.UserID, input.PlanID)
 	startTime := time.Now()
 
-	// Получаем или создаем клиента Stripe
-	// Используем errgroup для параллельного выполнения, если это имеет смысл (здесь нет)
-	stripeCustomerID, err := s.stripeClient.GetOrCreateCustomer(ctx, input.UserID, input.UserEmail)
+	// Проверяем план по локальному каталогу до обращения к Stripe
+	plan, err := s.validatePlan(ctx, input.PlanID)
+	if err != nil {
+		s.log.Warnw("CreateSubscription rejected: plan is unavailable. UserID: %s, PlanID: %s, Error: %v", input.UserID, input.PlanID, err)
+		return nil, err
+	}
+	trial, err := resolveTrial(input, plan, startTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Получаем клиента Stripe из таблицы customers (или создаем при первой подписке)
+	customer, err := s.GetOrCreateCustomer(ctx, input.UserID, input.UserEmail)
 	if err != nil {
-		// Оборачиваем ошибку Stripe для консистентности
 		s.log.Errorw("Failed to get or create Stripe customer. UserID: %s, Error: %v", input.UserID, err)
-		return nil, fmt.Errorf("%w: failed to process customer: %v", ErrStripeClient, err)
+		return nil, err
 	}
+	stripeCustomerID := customer.StripeCustomerID
 	s.log.Debugw("Stripe customer processed. UserID: %s, StripeCustomerID: %s", input.UserID, stripeCustomerID)
 
+	// Проверяем купон или промокод до создания подписки
+	discount, err := s.resolveDiscount(ctx, input, plan, stripeCustomerID)
+	if err != nil {
+		s.log.Warnw("CreateSubscription rejected: discount is not applicable. UserID: %s, PlanID: %s, Error: %v", input.UserID, input.PlanID, err)
+		return nil, err
+	}
+
 	// Создаем подписку в Stripe
-	stripeSubID, clientSecret, err := s.stripeClient.CreateSubscription(ctx, stripeCustomerID, input.PlanID, input.IdempotencyKey)
+	created, err := s.stripeClient.CreateSubscription(ctx, stripe.NewSubscription{
+		CustomerID:      stripeCustomerID,
+		PriceID:         input.PlanID,
+		TrialPeriodDays: trial.Days,
+		TrialEnd:        trial.End,
+		CouponID:        discount.CouponID,
+		PromotionCodeID: discount.PromotionCodeID,
+		IdempotencyKey:  input.IdempotencyKey,
+	})
 	if err != nil {
 		// Логируем детали ошибки Stripe
 		s.trackStripeError(err, input)
+		// Ограничения скидки, которые проверяет только Stripe (например, только для новых клиентов)
+		if msg, rejected := discountRejection(err); rejected {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidDiscount, msg)
+		}
 		// Оборачиваем ошибку
 		// Проверяем специфичные ошибки, которые могут быть важны для клиента
 		var stripeErr *stripego.Error
@@ -125,20 +186,17 @@ func (s *PaymentService) CreateSubscription(ctx context.Context, input CreateSub
 		return nil, fmt.Errorf("%w: failed to create subscription: %v", ErrStripeClient, err)
 	}
 
+	stripeSubID := created.ID
 	duration := time.Since(startTime)
-	s.log.Infow("Stripe subscription created successfully. UserID: %s, PlanID: %s, StripeSubID: %s, DurationMs: %d",
-		input.UserID, input.PlanID, stripeSubID, duration.Milliseconds())
-
-	// Создаем модель подписки для сохранения и отправки события
-	// ВАЖНО: Статус из Stripe может быть не 'active' сразу (например, 'incomplete')
-	// Нужно получить актуальный статус из Stripe или обрабатывать вебхуки
-	// Пока оставляем 'incomplete' как более безопасный дефолт после создания с default_incomplete
-	// или получаем статус из Stripe ответа, если он там есть.
-	// stripe.Client.CreateSubscription должен возвращать статус.
-	// Предположим, что stripe.Client.CreateSubscription возвращает и статус:
-	// stripeSubID, clientSecret, stripeStatus, err := s.stripeClient.CreateSubscription(...)
-	subscriptionStatus := "incomplete" // Безопасное значение по умолчанию
-	// TODO: Получить реальный статус из ответа Stripe, если stripeClient его возвращает
+	s.log.Infow("Stripe subscription created successfully. UserID: %s, PlanID: %s, StripeSubID: %s, Status: %s, DurationMs: %d",
+		input.UserID, input.PlanID, stripeSubID, created.Status, duration.Milliseconds())
+
+	// Статус из Stripe: с default_incomplete это 'incomplete' (ждет первого платежа),
+	// с пробным периодом - 'trialing' (платеж не нужен до конца пробного периода)
+	subscriptionStatus := created.Status
+	if subscriptionStatus == "" {
+		subscriptionStatus = "incomplete" // Безопасное значение по умолчанию
+	}
 
 	subscription := &models.Subscription{
 		SubscriptionID:   stripeSubID, // Используем ID из Stripe
@@ -146,26 +204,29 @@ func (s *PaymentService) CreateSubscription(ctx context.Context, input CreateSub
 		PlanID:           input.PlanID,
 		Status:           subscriptionStatus, // Используем статус из Stripe
 		StripeCustomerID: stripeCustomerID,
+		TrialStart:       created.TrialStart,
+		TrialEnd:         created.TrialEnd,
 		// CreatedAt и UpdatedAt будут установлены репозиторием при сохранении
 	}
+	setDiscount(subscription, created.Discount, discount.PromotionCode)
+
+	// Событие о создании пишется в outbox в той же транзакции, что и строка подписки
+	event, err := newSubscriptionOutboxEvent(ctx, kafka.EventSubscriptionCreated, "", subscription)
+	if err != nil {
+		s.log.Errorw("Failed to build subscription created event. UserID: %s, StripeSubID: %s, Error: %v", input.UserID, stripeSubID, err)
+		return nil, fmt.Errorf("%w: failed to build subscription event: %v", ErrInternalServer, err)
+	}
 
-	// Опционально: Синхронное сохранение в БД (если нужно)
-	err = s.subRepo.Create(ctx, subscription)
+	err = s.subRepo.CreateWithEvent(ctx, subscription, event)
 	if err != nil {
 		s.log.Errorw("Failed to save subscription to local DB synchronously. UserID: %s, StripeSubID: %s, Error: %v", input.UserID, stripeSubID, err)
 		return nil, fmt.Errorf("%w: failed to save subscription locally: %v", ErrInternalServer, err)
 	}
 	s.log.Infow("Subscription saved to local DB synchronously. UserID: %s, StripeSubID: %s", input.UserID, stripeSubID)
 
-	// Асинхронная отправка события в Kafka (если продюсер доступен)
-	if s.kafkaProducer != nil {
-		// Запускаем в горутине, чтобы не блокировать ответ
-		go s.publishSubscriptionEvent(context.WithoutCancel(ctx), subscription) // Используем новый контекст для горутины
-	}
-
 	return &CreateSubscriptionOutput{
 		Subscription: subscription, // Возвращаем модель с ID и статусом
-		ClientSecret: clientSecret,
+		ClientSecret: created.ClientSecret,
 	}, nil
 }
 
@@ -215,27 +276,24 @@ func (s *PaymentService) CreateSubscriptionWithRetry(ctx context.Context, input
 	return output, nil
 }
 
-// publishSubscriptionEvent отправляет событие в Kafka
-func (s *PaymentService) publishSubscriptionEvent(ctx context.Context, subscription *models.Subscription) {
-	// Проверяем, инициализирован ли продюсер
-	if s.kafkaProducer == nil {
-		s.log.Warnw("Kafka producer not available, skipping event publishing for SubscriptionID: %s", subscription.SubscriptionID)
-		return
+// newSubscriptionOutboxEvent готовит событие outbox с конвертом kafka.SubscriptionEvent.
+// Топик выбирается по типу события, ключ сообщения - SubscriptionID,
+// чтобы все события подписки попадали в одну партицию Kafka.
+func newSubscriptionOutboxEvent(ctx context.Context, eventType kafka.SubscriptionEventType, previousStatus string, subscription *models.Subscription) (*models.OutboxEvent, error) {
+	topic, err := kafka.TopicForEventType(eventType)
+	if err != nil {
+		return nil, err
 	}
-
-	// Используем контекст с таймаутом для операции Kafka
-	kafkaCtx, cancel := context.WithTimeout(ctx, 10*time.Second) // Увеличил таймаут
-	defer cancel()
-
-	err := s.kafkaProducer.PublishSubscriptionEvent(kafkaCtx, kafka.TopicSubscriptionCreated, subscription)
+	payload, err := json.Marshal(kafka.NewSubscriptionEvent(ctx, eventType, previousStatus, subscription))
 	if err != nil {
-		// Логируем ошибку, но не прерываем основной поток
-		s.log.Errorw("Failed to publish subscription created event. SubscriptionID: %s, Error: %v",
-			subscription.SubscriptionID, err)
-		// TODO: Рассмотреть механизм retry или отправки в dead-letter queue для Kafka
-	} else {
-		s.log.Infow("Subscription created event published successfully. SubscriptionID: %s", subscription.SubscriptionID)
+		return nil, fmt.Errorf("failed to marshal subscription event: %w", err)
 	}
+	return &models.OutboxEvent{
+		AggregateID: subscription.SubscriptionID,
+		Topic:       topic,
+		MessageKey:  subscription.SubscriptionID,
+		Payload:     payload,
+	}, nil
 }
 
 // trackStripeError логирует детали ошибки Stripe
@@ -299,6 +357,12 @@ func isRetryableStripeError(err error) bool {
 
 // GetSubscriptionByID получает подписку по ID, проверяя принадлежность пользователю
 func (s *PaymentService) GetSubscriptionByID(ctx context.Context, userID, subscriptionID string) (*models.Subscription, error) {
+	return s.getOwnedSubscription(ctx, userID, subscriptionID, authz.AccessRead)
+}
+
+// getOwnedSubscription получает подписку по ID, проверяя доступ вызывающего (см. AuthorizeUserAccess).
+// Недоступная подписка неотличима от несуществующей.
+func (s *PaymentService) getOwnedSubscription(ctx context.Context, userID, subscriptionID string, access authz.Access) (*models.Subscription, error) {
 	s.log.Infow("Fetching subscription by ID. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
 	sub, err := s.subRepo.GetByID(ctx, subscriptionID) // Получаем из репозитория
 	if err != nil {
@@ -311,9 +375,7 @@ func (s *PaymentService) GetSubscriptionByID(ctx context.Context, userID, subscr
 	}
 
 	// Проверка принадлежности подписки пользователю
-	if sub.UserID != userID {
-		s.log.Warnw("User attempted to access subscription belonging to another user. RequesterID: %s, OwnerID: %s, SubscriptionID: %s",
-			userID, sub.UserID, subscriptionID)
+	if err := s.AuthorizeUserAccess(ctx, userID, sub.UserID, access, "subscription "+subscriptionID); err != nil {
 		// Важно не раскрывать информацию о существовании подписки, возвращаем NotFound
 		return nil, ErrSubscriptionNotFound
 	}
@@ -335,78 +397,113 @@ func (s *PaymentService) GetSubscriptionsByUserID(ctx context.Context, userID st
 	return subs, nil
 }
 
-// CancelSubscription отменяет подписку
-func (s *PaymentService) CancelSubscription(ctx context.Context, userID, subscriptionID, idempotencyKey string) error {
-	s.log.Infow("Attempting to cancel subscription. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+// ListSubscriptionsByUserID возвращает страницу подписок пользователя.
+// limit ограничивается maxSubscriptionsPageSize; hasMore сообщает, есть ли подписки после этой страницы.
+func (s *PaymentService) ListSubscriptionsByUserID(ctx context.Context, userID string, limit, offset int) (subs []models.Subscription, hasMore bool, err error) {
+	if limit <= 0 {
+		limit = defaultSubscriptionsPageSize
+	}
+	if limit > maxSubscriptionsPageSize {
+		limit = maxSubscriptionsPageSize
+	}
+	if offset < 0 {
+		return nil, false, fmt.Errorf("%w: negative offset", ErrInvalidInput)
+	}
 
-	// 1. Получить подписку из нашей БД, чтобы проверить владельца
-	sub, err := s.subRepo.GetByID(ctx, subscriptionID)
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	subs, err = s.subRepo.ListByUserID(ctx, userID, limit+1, offset)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			s.log.Warnw("Subscription to cancel not found in repository. SubscriptionID: %s", subscriptionID)
-			return ErrSubscriptionNotFound
-		}
-		s.log.Errorw("Failed to get subscription before cancellation. SubscriptionID: %s, Error: %v", subscriptionID, err)
-		return fmt.Errorf("%w: failed to verify subscription owner: %v", ErrInternalServer, err)
+		s.log.Errorw("Failed to list subscriptions from repository. UserID: %s, Error: %v", userID, err)
+		return nil, false, fmt.Errorf("%w: %v", ErrInternalServer, err)
 	}
+	if len(subs) > limit {
+		return subs[:limit], true, nil
+	}
+	return subs, false, nil
+}
 
-	// 2. Проверить владельца
-	if sub.UserID != userID {
-		s.log.Warnw("User attempted to cancel subscription belonging to another user. RequesterID: %s, OwnerID: %s, SubscriptionID: %s",
-			userID, sub.UserID, subscriptionID)
-		return ErrSubscriptionNotFound // Возвращаем NotFound из соображений безопасности
+// CancelSubscription отменяет подписку сразу (CancelImmediately) или в конце оплаченного периода (CancelAtPeriodEnd).
+// Возвращает подписку после изменения.
+func (s *PaymentService) CancelSubscription(ctx context.Context, userID, subscriptionID string, mode CancelMode, idempotencyKey string) (*models.Subscription, error) {
+	s.log.Infow("Attempting to cancel subscription. UserID: %s, SubscriptionID: %s, Mode: %s", userID, subscriptionID, mode)
+
+	// 1-2. Получить подписку из нашей БД и проверить владельца (чужая подписка - NotFound)
+	sub, err := s.getOwnedSubscription(ctx, userID, subscriptionID, authz.AccessWrite)
+	if err != nil {
+		return nil, err
 	}
 
 	// 3. Проверить статус (можно ли отменить?)
 	if sub.Status == "canceled" {
 		s.log.Warnw("Attempted to cancel an already canceled subscription. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
-		return nil // Считаем операцию успешной, если уже отменена
+		return sub, nil // Считаем операцию успешной, если уже отменена
+	}
+
+	if mode == CancelAtPeriodEnd {
+		if sub.CancelAtPeriodEnd {
+			s.log.Infow("Subscription is already scheduled for cancellation. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
+			return sub, nil
+		}
+		return s.setCancelAtPeriodEnd(ctx, sub, true, idempotencyKey)
 	}
 
 	// 4. Отменить подписку в Stripe
 	// TODO: Добавить передачу idempotencyKey в Stripe клиент, если API Stripe поддерживает это для отмены
-	err = s.stripeClient.CancelSubscription(ctx, subscriptionID)
+	canceledAt, err := s.stripeClient.CancelSubscription(ctx, subscriptionID)
 	if err != nil {
 		// Логируем ошибку Stripe
 		s.trackStripeError(err, CreateSubscriptionInput{UserID: userID, PlanID: sub.PlanID}) // Передаем данные для логирования
 		s.log.Errorw("Stripe failed to cancel subscription. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
-		return fmt.Errorf("%w: failed to cancel stripe subscription: %v", ErrStripeClient, err)
+		return nil, fmt.Errorf("%w: failed to cancel stripe subscription: %v", ErrStripeClient, err)
 	}
 	s.log.Infow("Subscription successfully canceled in Stripe. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
 
-	// 5. Обновить статус в локальной БД (или дождаться вебхука)
-	// Если полагаемся на вебхуки, этот шаг не нужен.
-	// Если обновляем здесь, нужно быть готовым к рассогласованию, если вебхук придет позже/раньше.
-	// Пример синхронного обновления:
-	// now := time.Now()
-	// sub.Status = "canceled"
-	// sub.CanceledAt = &now
-	// sub.UpdatedAt = now
-	// err = s.subRepo.Update(ctx, sub)
-	// if err != nil {
-	//     s.log.Errorw("Failed to update local subscription status after cancellation. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
-	//     // Ошибка некритична для пользователя, но требует мониторинга
-	// } else {
-	//     s.log.Infow("Local subscription status updated to 'canceled'. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
-	// }
-
-	// 6. Отправить событие об отмене в Kafka (если нужно)
-	if s.kafkaProducer != nil {
-		// Создаем модель для события (может отличаться от основной)
-		canceledEventSub := *sub // Копируем
-		now := time.Now()
-		canceledEventSub.Status = "canceled"
-		canceledEventSub.CanceledAt = &now                                           // Устанавливаем время для события
-		go s.publishSubscriptionEvent(context.WithoutCancel(ctx), &canceledEventSub) // Используем копию
+	// 5. Обновить статус в локальной БД и записать событие об отмене в той же транзакции.
+	// Вебхук customer.subscription.deleted затем увидит статус 'canceled' и не создаст дубль события.
+	previousStatus := sub.Status
+	now := time.Now()
+	sub.Status = "canceled"
+	sub.CanceledAt = &now
+	sub.UpdatedAt = now
+	// События Stripe, созданные до отмены, не должны вернуть подписку в прежний статус. Граница берется
+	// по часам Stripe (canceled_at с точностью до секунды): локальное время позже created вебхука
+	// customer.subscription.deleted, и он был бы отклонен как устаревший.
+	if canceledAt != nil {
+		sub.CanceledAt = canceledAt
+		sub.LastEventAt = canceledAt
+	}
+	event, err := newSubscriptionOutboxEvent(ctx, kafka.EventSubscriptionCanceled, previousStatus, sub)
+	if err == nil {
+		err = s.subRepo.UpdateWithEvent(ctx, sub, event)
+	}
+	if err != nil {
+		// Отмена в Stripe уже прошла; статус и событие будут записаны при обработке вебхука customer.subscription.deleted
+		s.log.Errorw("Failed to update local subscription status after cancellation. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+	} else {
+		s.log.Infow("Local subscription status updated to 'canceled'. UserID: %s, SubscriptionID: %s", userID, subscriptionID)
 	}
 
-	return nil
+	return sub, nil
 }
 
-// HandleWebhookEvent обрабатывает события из вебхуков Stripe
-func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType stripego.EventType, eventSubscriptionID string, data map[string]interface{}) error {
-	// eventSubscriptionID из хендлера может быть неточным для invoice.* событий,
-	// лучше извлекать ID из самого объекта `data`.
+// HandleWebhookEvent обрабатывает события из вебхуков Stripe.
+// eventCreated - время создания события в Stripe (поле created); Stripe не гарантирует порядок доставки,
+// поэтому события старше последнего примененного к подписке отклоняются с ErrStaleWebhookEvent,
+// логируются и считаются в webhook_events_stale_rejected_total.
+func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType stripego.EventType, eventCreated time.Time, eventSubscriptionID string, data map[string]interface{}) error {
+	err := s.handleWebhookEvent(ctx, eventType, eventCreated, data)
+	if errors.Is(err, ErrStaleWebhookEvent) {
+		s.staleWebhookEvents.Inc(string(eventType))
+		s.log.Warnw("Stale webhook event rejected. Type: %s, EventCreated: %s, StripeSubID: %s",
+			eventType, eventCreated, eventSubscriptionID)
+	}
+	return err
+}
+
+// handleWebhookEvent выполняет логику обработки конкретного типа события.
+func (s *PaymentService) handleWebhookEvent(ctx context.Context, eventType stripego.EventType, eventCreated time.Time, data map[string]interface{}) error {
+	// ID подписки извлекается из самого объекта `data`:
+	// ID из хендлера может быть неточным для invoice.* событий.
 
 	s.log.Infow("Handling webhook event. Type: %s", eventType)
 
@@ -416,25 +513,25 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 		// Часто это событие приходит ПОСЛЕ вашего CreateSubscription.
 		// Может использоваться для дополнительной синхронизации или если подписки создаются только через Stripe UI.
 		subID := getStringValue(data, "id")
-		status := getStringValue(data, "status")
+		status := subscriptionStatusFromWebhook(data)
 		s.log.Infow("Webhook 'customer.subscription.created' received. StripeSubID: %s, Status: %s", subID, status)
 		// Можно найти подписку по ID и обновить статус, если он отличается от того, что записали при создании.
 		// Либо просто игнорировать, если создание идет через API сервиса.
-		_, err := s.findAndUpdateSubscriptionStatus(ctx, subID, status, data) // Пример вызова хелпера
-		if err != nil && !errors.Is(err, ErrSubscriptionNotFound) {           // Игнорируем NotFound, если подписку еще не успели создать локально
+		_, err := s.findAndUpdateSubscriptionStatus(ctx, subID, status, eventCreated, data) // Пример вызова хелпера
+		if err != nil && !errors.Is(err, ErrSubscriptionNotFound) {                         // Игнорируем NotFound, если подписку еще не успели создать локально
 			return fmt.Errorf("failed processing subscription.created: %w", err)
 		}
 
 	case "customer.subscription.updated":
 		subID := getStringValue(data, "id")
-		status := getStringValue(data, "status")
+		status := subscriptionStatusFromWebhook(data)
 		s.log.Infow("Webhook 'customer.subscription.updated' received. StripeSubID: %s, Status: %s", subID, status)
 		if subID == "" {
 			s.log.Errorw("StripeSubscriptionID missing in customer.subscription.updated event data")
 			return nil // Не можем обработать без ID
 		}
 
-		_, err := s.findAndUpdateSubscriptionStatus(ctx, subID, status, data)
+		_, err := s.findAndUpdateSubscriptionStatus(ctx, subID, status, eventCreated, data)
 		if err != nil {
 			// Если подписка не найдена, это может быть проблемой
 			if errors.Is(err, ErrSubscriptionNotFound) {
@@ -453,7 +550,8 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 			return nil
 		}
 
-		sub, err := s.findAndUpdateSubscriptionStatus(ctx, subID, "canceled", data) // Принудительно ставим 'canceled'
+		// Принудительно ставим 'canceled'; событие об отмене пишется в outbox вместе с обновлением строки
+		_, err := s.findAndUpdateSubscriptionStatus(ctx, subID, "canceled", eventCreated, data)
 		if err != nil {
 			if errors.Is(err, ErrSubscriptionNotFound) {
 				s.log.Errorw("Received deletion for non-existent local subscription. StripeSubID: %s", subID)
@@ -462,27 +560,30 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 			return fmt.Errorf("failed processing subscription.deleted: %w", err)
 		}
 
-		// Отправка события об отмене, если еще не отправляли из CancelSubscription
-		if s.kafkaProducer != nil && sub != nil {
-			// Создаем копию для события
-			eventSub := *sub
-			eventSub.Status = "canceled"    // Убедимся, что статус верный
-			if eventSub.CanceledAt == nil { // Установим время, если его нет
-				now := time.Now()
-				eventSub.CanceledAt = &now
-			}
-			go s.publishSubscriptionEvent(context.WithoutCancel(ctx), &eventSub)
-		}
-
 	case "customer.subscription.trial_will_end":
 		subID := getStringValue(data, "id")
 		userID := "" // Попробуем получить UserID
-		if sub, err := s.subRepo.GetByStripeSubscriptionID(ctx, subID); err == nil {
+		sub, err := s.subRepo.GetByStripeSubscriptionID(ctx, subID)
+		if err == nil {
 			userID = sub.UserID
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			// Временная ошибка БД: возвращаем ее, чтобы очередь повторила событие и TrialEnding не потерялся
+			return fmt.Errorf("failed to get subscription %s for trial_will_end: %w", subID, err)
 		}
 		trialEndDate := getTimeValueFromUnix(data, "trial_end")
 		s.log.Infow("Webhook 'customer.subscription.trial_will_end' received. StripeSubID: %s, UserID: %s, TrialEnd: %s", subID, userID, trialEndDate)
 
+		// Статус не меняется, поэтому событие пишется в outbox отдельно от строки подписки
+		if sub != nil {
+			event, err := newSubscriptionOutboxEvent(ctx, kafka.EventSubscriptionTrialEnding, sub.Status, sub)
+			if err == nil {
+				err = s.outboxRepo.Enqueue(ctx, event)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to enqueue trial ending event for sub %s: %w", subID, err)
+			}
+		}
+
 		// TODO: Отправить уведомление пользователю
 		//if s.notificationSvc != nil && userID != "" {
 		//    go s.notificationSvc.SendTrialEndingNotification(userID, subID, trialEndDate)
@@ -495,13 +596,16 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 		periodEnd := getTimeValueFromUnix(data, "period_end")
 
 		s.log.Infow("Webhook 'invoice.payment_succeeded' received. InvoiceID: %s, StripeSubID: %s, CustomerID: %s", invoiceID, subID, customerID)
+		if err := s.recordInvoiceFromWebhook(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing invoice.payment_succeeded: %w", err)
+		}
 
 		if subID == "" {
 			s.log.Infow("Invoice %s is not related to a subscription, skipping.", invoiceID)
 			return nil // Не ошибка, просто инвойс не для подписки
 		}
 
-		sub, err := s.findAndUpdateSubscriptionStatus(ctx, subID, "active", data) // Оплата прошла -> статус должен быть active
+		sub, err := s.findAndUpdateSubscriptionStatus(ctx, subID, "active", eventCreated, data) // Оплата прошла -> статус должен быть active
 		if err != nil {
 			if errors.Is(err, ErrSubscriptionNotFound) {
 				s.log.Errorw("Received successful payment for non-existent local subscription. StripeSubID: %s", subID)
@@ -533,6 +637,9 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 		attemptCount := getInt64Value(data, "attempt_count")
 
 		s.log.Warnw("Webhook 'invoice.payment_failed' received. InvoiceID: %s, StripeSubID: %s, CustomerID: %s, Attempt: %d", invoiceID, subID, customerID, attemptCount)
+		if err := s.recordInvoiceFromWebhook(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing invoice.payment_failed: %w", err)
+		}
 
 		if subID == "" {
 			s.log.Infow("Failed Invoice %s is not related to a subscription, skipping.", invoiceID)
@@ -545,7 +652,7 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 		// или установить 'past_due' как индикатор проблемы.
 		newStatus := "past_due" // Статус по умолчанию при ошибке оплаты
 
-		_, err := s.findAndUpdateSubscriptionStatus(ctx, subID, newStatus, data) // Обновляем на 'past_due'
+		_, err := s.findAndUpdateSubscriptionStatus(ctx, subID, newStatus, eventCreated, data) // Обновляем на 'past_due'
 		if err != nil {
 			if errors.Is(err, ErrSubscriptionNotFound) {
 				s.log.Errorw("Received failed payment for non-existent local subscription. StripeSubID: %s", subID)
@@ -560,6 +667,49 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 		//    go s.notificationSvc.SendPaymentFailedNotification(sub.UserID, subID, nextAttemptTime)
 		//}
 
+	case "invoice.created", "invoice.finalized", "invoice.updated", "invoice.paid",
+		"invoice.voided", "invoice.marked_uncollectible", "invoice.payment_action_required":
+		// Остальные изменения счета только обновляют историю счетов
+		if err := s.recordInvoiceFromWebhook(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing %s: %w", eventType, err)
+		}
+
+	case "charge.refunded":
+		// Возврат через API сервиса или вручную в панели Stripe
+		if err := s.handleChargeRefunded(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing %s: %w", eventType, err)
+		}
+
+	case "charge.refund.updated":
+		if err := s.handleRefundUpdated(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing %s: %w", eventType, err)
+		}
+
+	case "credit_note.created", "credit_note.updated", "credit_note.voided":
+		if err := s.handleCreditNoteEvent(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing %s: %w", eventType, err)
+		}
+
+	case "payment_intent.created", "payment_intent.processing", "payment_intent.requires_action",
+		"payment_intent.succeeded", "payment_intent.payment_failed", "payment_intent.canceled":
+		// Статус разовых платежей; PaymentIntents счетов подписок отслеживаются через invoice.*
+		if err := s.handlePaymentIntentEvent(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing %s: %w", eventType, err)
+		}
+
+	case "checkout.session.completed":
+		// Подписка, оформленная через Stripe Checkout, появляется локально только после оплаты
+		if err := s.handleCheckoutSessionCompleted(ctx, eventCreated, data); err != nil {
+			return fmt.Errorf("failed processing %s: %w", eventType, err)
+		}
+
+	case "price.created", "price.updated", "price.deleted",
+		"product.created", "product.updated", "product.deleted":
+		// Изменения каталога планов
+		if err := s.handleCatalogWebhookEvent(ctx, eventType, data); err != nil {
+			return fmt.Errorf("failed processing %s: %w", eventType, err)
+		}
+
 	default:
 		s.log.Infow("Unhandled webhook event type received: %s", eventType)
 	}
@@ -574,8 +724,11 @@ func (s *PaymentService) HandleWebhookEvent(ctx context.Context, eventType strip
 
 // findAndUpdateSubscriptionStatus находит подписку по Stripe ID и обновляет ее статус и другие поля.
 // newStatus - желаемый статус, который будет установлен.
+// eventCreated - время создания события в Stripe; если оно раньше last_event_at подписки, возвращается ErrStaleWebhookEvent.
 // data - данные из объекта события Stripe (обычно объект subscription или invoice).
-func (s *PaymentService) findAndUpdateSubscriptionStatus(ctx context.Context, stripeSubscriptionID, newStatus string, data map[string]interface{}) (*models.Subscription, error) {
+// Если обновление меняет статус (или это продление по инвойсу, смена плана или запланированной отмены),
+// в той же транзакции в outbox пишется событие перехода.
+func (s *PaymentService) findAndUpdateSubscriptionStatus(ctx context.Context, stripeSubscriptionID, newStatus string, eventCreated time.Time, data map[string]interface{}) (*models.Subscription, error) {
 	if stripeSubscriptionID == "" {
 		return nil, fmt.Errorf("stripeSubscriptionID is empty")
 	}
@@ -591,9 +744,22 @@ func (s *PaymentService) findAndUpdateSubscriptionStatus(ctx context.Context, st
 		return nil, fmt.Errorf("%w: repository error: %v", ErrInternalServer, err)
 	}
 
-	// 2. Подготовить обновления
+	// 2. Отклонить событие, если к подписке уже применено более новое
+	if !eventCreated.IsZero() && sub.LastEventAt != nil && eventCreated.Before(*sub.LastEventAt) {
+		s.log.Warnw("Ignoring out-of-order webhook event. StripeSubID: %s, EventCreated: %s, LastEventAt: %s, IgnoredStatus: %s",
+			stripeSubscriptionID, eventCreated, *sub.LastEventAt, newStatus)
+		return sub, fmt.Errorf("%w: event created at %s, subscription last changed by event at %s", ErrStaleWebhookEvent, eventCreated, *sub.LastEventAt)
+	}
+
+	// 3. Подготовить обновления
 	needsUpdate := false
 	now := time.Now()
+	previousStatus := sub.Status
+
+	// Приостановку снимает только изменение самой подписки: оплаченный инвойс не возобновляет списания
+	if sub.Status == "paused" && newStatus == "active" && getStringValue(data, "object") == "invoice" {
+		newStatus = "paused"
+	}
 
 	// Обновляем статус, если он отличается или если пришел статус отмены
 	if sub.Status != newStatus || newStatus == "canceled" {
@@ -605,10 +771,12 @@ func (s *PaymentService) findAndUpdateSubscriptionStatus(ctx context.Context, st
 	// Обновляем ID плана, если он изменился (из subscription.updated)
 	// Путь к ID плана: plan -> id или items -> data -> [0] -> price -> id
 	newPlanID := extractPlanIDFromWebhookData(data)
+	planChanged := false
 	if newPlanID != "" && sub.PlanID != newPlanID {
+		s.log.Infow("Updating subscription plan ID. StripeSubID: %s, OldPlanID: %s, NewPlanID: %s", stripeSubscriptionID, sub.PlanID, newPlanID)
 		sub.PlanID = newPlanID
+		planChanged = true
 		needsUpdate = true
-		s.log.Infow("Updating subscription plan ID. StripeSubID: %s, NewPlanID: %s", stripeSubscriptionID, newPlanID)
 	}
 
 	// Обновляем время окончания текущего периода (из subscription.updated или invoice.paid)
@@ -631,10 +799,63 @@ func (s *PaymentService) findAndUpdateSubscriptionStatus(ctx context.Context, st
 		s.log.Infow("Updating subscription canceled_at. StripeSubID: %s, CanceledAt: %s", stripeSubscriptionID, canceledAt)
 	}
 
+	// Запланированная отмена, приостановка, пробный период и скидка есть только в объекте подписки (в инвойсе этих полей нет)
+	cancellationChanged := false
+	if getStringValue(data, "object") == "subscription" {
+		if setTrialFromWebhook(sub, data) {
+			needsUpdate = true
+		}
+		if setDiscountFromWebhook(sub, data) {
+			needsUpdate = true
+			s.log.Infow("Updating subscription discount. StripeSubID: %s, CouponID: %s", stripeSubscriptionID, sub.DiscountCouponID)
+		}
+		if resumesAt := pauseResumesAtFromWebhook(data); !sameTime(sub.ResumesAt, resumesAt) {
+			sub.ResumesAt = resumesAt
+			needsUpdate = true
+		}
+		cancelAtPeriodEnd, _ := data["cancel_at_period_end"].(bool)
+		var cancelAt *time.Time
+		if t := getTimeValueFromUnix(data, "cancel_at"); !t.IsZero() {
+			cancelAt = &t
+		}
+		if setCancellation(sub, cancelAtPeriodEnd, cancelAt) {
+			cancellationChanged = true
+			needsUpdate = true
+			s.log.Infow("Updating subscription cancellation. StripeSubID: %s, CancelAtPeriodEnd: %t", stripeSubscriptionID, cancelAtPeriodEnd)
+		}
+	}
+
+	// Определяем, какой переход жизненного цикла произошел
+	eventType := subscriptionTransitionEvent(previousStatus, sub.Status)
+	if eventType == "" && isRenewalInvoice(data) {
+		eventType = kafka.EventSubscriptionRenewed
+	}
+	// Смена статуса важнее: снимок подписки в событии перехода уже содержит новый plan_id
+	if eventType == "" && planChanged {
+		eventType = kafka.EventSubscriptionPlanChanged
+	}
+	if eventType == "" && cancellationChanged {
+		eventType = kafka.EventSubscriptionCancellationChanged
+	}
+	if eventType != "" {
+		needsUpdate = true
+	}
+
 	// Если были изменения, обновляем запись в БД
 	if needsUpdate {
 		sub.UpdatedAt = now // Устанавливаем время обновления
-		err = s.subRepo.Update(ctx, sub)
+		if !eventCreated.IsZero() && (sub.LastEventAt == nil || eventCreated.After(*sub.LastEventAt)) {
+			sub.LastEventAt = &eventCreated
+		}
+		if eventType != "" {
+			var event *models.OutboxEvent
+			event, err = newSubscriptionOutboxEvent(ctx, eventType, previousStatus, sub)
+			if err == nil {
+				err = s.subRepo.UpdateWithEvent(ctx, sub, event)
+			}
+		} else {
+			err = s.subRepo.Update(ctx, sub)
+		}
 		if err != nil {
 			s.log.Errorw("Failed to update subscription in repository. StripeSubID: %s, Error: %v", stripeSubscriptionID, err)
 			return sub, fmt.Errorf("%w: failed to save subscription update: %v", ErrInternalServer, err)
@@ -647,6 +868,34 @@ func (s *PaymentService) findAndUpdateSubscriptionStatus(ctx context.Context, st
 	return sub, nil
 }
 
+// subscriptionTransitionEvent возвращает тип события для смены статуса или пустую строку,
+// если статус не изменился либо переход не публикуется.
+func subscriptionTransitionEvent(previousStatus, newStatus string) kafka.SubscriptionEventType {
+	if previousStatus == newStatus {
+		return ""
+	}
+	if previousStatus == "paused" && (newStatus == "active" || newStatus == "trialing") {
+		return kafka.EventSubscriptionResumed
+	}
+	switch newStatus {
+	case "paused":
+		return kafka.EventSubscriptionPaused
+	case "active":
+		return kafka.EventSubscriptionActivated
+	case "past_due":
+		return kafka.EventSubscriptionPastDue
+	case "canceled":
+		return kafka.EventSubscriptionCanceled
+	default:
+		return ""
+	}
+}
+
+// isRenewalInvoice проверяет, что данные вебхука - инвойс очередного периода подписки.
+func isRenewalInvoice(data map[string]interface{}) bool {
+	return getStringValue(data, "object") == "invoice" && getStringValue(data, "billing_reason") == "subscription_cycle"
+}
+
 // extractPlanIDFromWebhookData пытается извлечь Price ID из данных вебхука.
 func extractPlanIDFromWebhookData(data map[string]interface{}) string {
 	// Попробовать извлечь из 'plan.id' (старый формат)
@@ -669,65 +918,6 @@ func extractPlanIDFromWebhookData(data map[string]interface{}) string {
 	}
 	return "" // Не найден
 }
-func (s *PaymentService) CreateCustomer(ctx context.Context, userID, email string) (*models.Customer, error) {
-	// Проверяем, существует ли уже customer
-	if existing, err := s.customerRepo.GetByUserID(ctx, userID); err == nil {
-		return existing, nil
-	} else if err != repository.ErrCustomerNotFound {
-		return nil, fmt.Errorf("failed to check existing customer: %w", err)
-	}
-
-	// Создаем customer в Stripe
-	stripeCustomerID, err := s.stripeClient.CreateCustomer(ctx, userID, email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stripe customer: %w", err)
-	}
-
-	// Создаем customer в локальной БД
-	customer := models.NewCustomer(userID, stripeCustomerID, email)
-	if err := s.customerRepo.Create(ctx, customer); err != nil {
-		// Логируем ошибку, но не удаляем customer из Stripe,
-		// так как он может быть использован позже
-		s.log.Errorw("Failed to create customer in local DB",
-			"error", err,
-			"userID", userID,
-			"stripeCustomerID", stripeCustomerID)
-		return nil, fmt.Errorf("failed to create customer in local DB: %w", err)
-	}
-
-	return customer, nil
-}
-
-func (s *PaymentService) GetOrCreateCustomer(ctx context.Context, userID, email string) (*models.Customer, error) {
-	// Пытаемся найти существующего customer
-	customer, err := s.customerRepo.GetByUserID(ctx, userID)
-	if err == nil {
-		return customer, nil
-	}
-	if err != repository.ErrCustomerNotFound {
-		return nil, fmt.Errorf("failed to get customer: %w", err)
-	}
-
-	// Если не нашли, создаем нового
-	return s.CreateCustomer(ctx, userID, email)
-}
-
-func (s *PaymentService) UpdateCustomerEmail(ctx context.Context, userID, newEmail string) error {
-	customer, err := s.customerRepo.GetByUserID(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("failed to get customer: %w", err)
-	}
-
-	// Обновляем email в локальной БД
-	customer.Email = newEmail
-	customer.UpdatedAt = time.Now()
-
-	if err := s.customerRepo.Update(ctx, customer); err != nil {
-		return fmt.Errorf("failed to update customer: %w", err)
-	}
-
-	return nil
-}
 
 // getStringValue безопасно извлекает строковое значение из map[string]interface{}.
 func getStringValue(data map[string]interface{}, key string) string {
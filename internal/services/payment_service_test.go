@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/config"
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+// fakeSubscriptionRepo возвращает заданную подписку или ошибку чтения.
+type fakeSubscriptionRepo struct {
+	repository.SubscriptionRepository
+
+	sub    *models.Subscription
+	getErr error
+}
+
+func (r *fakeSubscriptionRepo) GetByStripeSubscriptionID(_ context.Context, _ string) (*models.Subscription, error) {
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	if r.sub == nil {
+		return nil, repository.ErrNotFound
+	}
+	copied := *r.sub
+	return &copied, nil
+}
+
+// fakeOutboxRepo запоминает события, записанные в outbox вне транзакции подписки.
+type fakeOutboxRepo struct {
+	repository.OutboxRepository
+
+	enqueued []*models.OutboxEvent
+}
+
+func (r *fakeOutboxRepo) Enqueue(_ context.Context, event *models.OutboxEvent) error {
+	r.enqueued = append(r.enqueued, event)
+	return nil
+}
+
+func newTestService(subRepo *fakeSubscriptionRepo, outboxRepo *fakeOutboxRepo) *PaymentService {
+	return NewPaymentService(&config.Config{}, subRepo, nil, nil, nil, nil, nil, outboxRepo, nil, nil, metrics.NewRegistry(), logger.New(logger.FATAL))
+}
+
+func TestTrialWillEndEnqueuesTrialEndingEvent(t *testing.T) {
+	subRepo := &fakeSubscriptionRepo{sub: &models.Subscription{SubscriptionID: "sub_1", UserID: "user-1", Status: "trialing"}}
+	outboxRepo := &fakeOutboxRepo{}
+	svc := newTestService(subRepo, outboxRepo)
+
+	data := map[string]interface{}{"id": "sub_1", "object": "subscription", "trial_end": float64(time.Now().Add(72 * time.Hour).Unix())}
+	if err := svc.HandleWebhookEvent(context.Background(), "customer.subscription.trial_will_end", time.Now(), "sub_1", data); err != nil {
+		t.Fatalf("HandleWebhookEvent: %v", err)
+	}
+
+	if len(outboxRepo.enqueued) != 1 {
+		t.Fatalf("enqueued %d outbox events, want 1", len(outboxRepo.enqueued))
+	}
+	var envelope kafka.SubscriptionEvent
+	if err := json.Unmarshal(outboxRepo.enqueued[0].Payload, &envelope); err != nil {
+		t.Fatalf("unmarshal outbox payload: %v", err)
+	}
+	if envelope.EventType != kafka.EventSubscriptionTrialEnding {
+		t.Fatalf("event type = %s, want %s", envelope.EventType, kafka.EventSubscriptionTrialEnding)
+	}
+}
+
+func TestTrialWillEndForUnknownSubscriptionIsSkipped(t *testing.T) {
+	outboxRepo := &fakeOutboxRepo{}
+	svc := newTestService(&fakeSubscriptionRepo{}, outboxRepo)
+
+	data := map[string]interface{}{"id": "sub_unknown", "object": "subscription"}
+	if err := svc.HandleWebhookEvent(context.Background(), "customer.subscription.trial_will_end", time.Now(), "sub_unknown", data); err != nil {
+		t.Fatalf("HandleWebhookEvent for unknown subscription: %v", err)
+	}
+	if len(outboxRepo.enqueued) != 0 {
+		t.Fatalf("enqueued %d outbox events for unknown subscription, want 0", len(outboxRepo.enqueued))
+	}
+}
+
+func TestTrialWillEndReturnsRepositoryError(t *testing.T) {
+	dbErr := errors.New("connection reset")
+	outboxRepo := &fakeOutboxRepo{}
+	svc := newTestService(&fakeSubscriptionRepo{getErr: dbErr}, outboxRepo)
+
+	data := map[string]interface{}{"id": "sub_1", "object": "subscription"}
+	err := svc.HandleWebhookEvent(context.Background(), "customer.subscription.trial_will_end", time.Now(), "sub_1", data)
+	if !errors.Is(err, dbErr) {
+		t.Fatalf("HandleWebhookEvent error = %v, want the repository error so the queue retries", err)
+	}
+	if len(outboxRepo.enqueued) != 0 {
+		t.Fatalf("enqueued %d outbox events after a repository error, want 0", len(outboxRepo.enqueued))
+	}
+}
+
+func TestHandleWebhookEventRejectsAndCountsStaleEvents(t *testing.T) {
+	lastEventAt := time.Now()
+	subRepo := &fakeSubscriptionRepo{sub: &models.Subscription{SubscriptionID: "sub_1", UserID: "user-1", Status: "canceled", LastEventAt: &lastEventAt}}
+	registry := metrics.NewRegistry()
+	svc := NewPaymentService(&config.Config{}, subRepo, nil, nil, nil, nil, nil, &fakeOutboxRepo{}, nil, nil, registry, logger.New(logger.FATAL))
+
+	// Событие, созданное раньше последнего примененного, не должно вернуть подписку в active
+	data := map[string]interface{}{"id": "sub_1", "object": "subscription", "status": "active"}
+	err := svc.HandleWebhookEvent(context.Background(), "customer.subscription.updated", lastEventAt.Add(-time.Minute), "sub_1", data)
+	if !errors.Is(err, ErrStaleWebhookEvent) {
+		t.Fatalf("HandleWebhookEvent error = %v, want ErrStaleWebhookEvent", err)
+	}
+
+	var out strings.Builder
+	if _, err := registry.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	want := `webhook_events_stale_rejected_total{event_type="customer.subscription.updated"} 1`
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("metrics output does not contain %q:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	stripego "github.com/stripe/stripe-go/v78"
+)
+
+// ErrPaymentNotFound возвращается, если разового платежа нет или он принадлежит другому пользователю.
+var ErrPaymentNotFound = errors.New("payment not found")
+
+const (
+	defaultPaymentsPageSize = 50
+	maxPaymentsPageSize     = 100
+
+	// Ограничения метаданных Stripe (один ключ занимает user_id)
+	maxMetadataKeys        = 50
+	maxMetadataKeyLength   = 40
+	maxMetadataValueLength = 500
+)
+
+// CreatePaymentInput параметры разового платежа.
+type CreatePaymentInput struct {
+	UserID         string
+	UserEmail      string
+	Amount         int64  // В минимальных единицах валюты
+	Currency       string // Код ISO 4217 (usd, eur, ...)
+	Description    string
+	Metadata       map[string]string // Ключ user_id зарезервирован сервисом
+	IdempotencyKey string
+}
+
+// CreatePayment создает PaymentIntent разового платежа и сохраняет платеж локально.
+// Возвращает платеж и ClientSecret, которым фронтенд подтверждает оплату через Stripe.js;
+// дальнейшие изменения статуса приходят вебхуками payment_intent.*.
+func (s *PaymentService) CreatePayment(ctx context.Context, input CreatePaymentInput) (*models.Payment, string, error) {
+	if input.UserID == "" || input.UserEmail == "" {
+		return nil, "", ErrInvalidInput
+	}
+	if input.Amount <= 0 {
+		return nil, "", fmt.Errorf("%w: amount must be positive", ErrInvalidInput)
+	}
+	currency := strings.ToLower(input.Currency)
+	if len(currency) != 3 {
+		return nil, "", fmt.Errorf("%w: currency must be a three-letter ISO code", ErrInvalidInput)
+	}
+	if err := validatePaymentMetadata(input.Metadata); err != nil {
+		return nil, "", err
+	}
+
+	customer, err := s.GetOrCreateCustomer(ctx, input.UserID, input.UserEmail)
+	if err != nil {
+		return nil, "", err
+	}
+
+	intent, err := s.stripeClient.CreatePaymentIntent(ctx, stripe.NewPaymentIntent{
+		CustomerID:     customer.StripeCustomerID,
+		UserID:         input.UserID,
+		Amount:         input.Amount,
+		Currency:       currency,
+		Description:    input.Description,
+		Metadata:       input.Metadata,
+		IdempotencyKey: input.IdempotencyKey,
+	})
+	if err != nil {
+		var stripeErr *stripego.Error
+		if errors.As(err, &stripeErr) && stripeErr.Type == StripeErrorTypeInvalidRequest {
+			// Например, сумма меньше минимальной для валюты или валюта не поддерживается
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidInput, stripeErr.Msg)
+		}
+		s.log.Errorw("Failed to create payment intent. UserID: %s, Error: %v", input.UserID, err)
+		return nil, "", fmt.Errorf("%w: failed to create payment intent: %v", ErrStripeClient, err)
+	}
+
+	// Изменение датируется временем создания в Stripe, чтобы вебхук payment_intent.created не считался устаревшим
+	payment := paymentFromIntent(intent)
+	payment.UserID = input.UserID
+	payment.LastEventAt = intent.Created
+	if err := s.paymentRepo.Upsert(ctx, payment); err != nil {
+		// PaymentIntent уже создан: платеж сохранится из вебхука payment_intent.created
+		s.log.Errorw("Failed to save payment. UserID: %s, PaymentID: %s, Error: %v", input.UserID, payment.PaymentID, err)
+		return nil, "", fmt.Errorf("%w: failed to save payment: %v", ErrInternalServer, err)
+	}
+
+	s.log.Infow("Payment created. UserID: %s, PaymentID: %s, Amount: %d %s", input.UserID, payment.PaymentID, payment.Amount, payment.Currency)
+	return payment, intent.ClientSecret, nil
+}
+
+// GetPaymentByID возвращает разовый платеж пользователя.
+func (s *PaymentService) GetPaymentByID(ctx context.Context, userID, paymentID string) (*models.Payment, error) {
+	payment, err := s.paymentRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		s.log.Errorw("Failed to get payment from repository. PaymentID: %s, Error: %v", paymentID, err)
+		return nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+
+	if err := s.AuthorizeUserAccess(ctx, userID, payment.UserID, authz.AccessRead, "payment "+paymentID); err != nil {
+		// Не раскрываем существование чужого платежа
+		return nil, ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+// ListPaymentsByUserID возвращает страницу разовых платежей пользователя (новые первыми).
+// limit ограничивается maxPaymentsPageSize; hasMore сообщает, есть ли платежи после этой страницы.
+func (s *PaymentService) ListPaymentsByUserID(ctx context.Context, userID string, limit, offset int) (payments []*models.Payment, hasMore bool, err error) {
+	if limit <= 0 {
+		limit = defaultPaymentsPageSize
+	}
+	if limit > maxPaymentsPageSize {
+		limit = maxPaymentsPageSize
+	}
+	if offset < 0 {
+		return nil, false, fmt.Errorf("%w: negative offset", ErrInvalidInput)
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	payments, err = s.paymentRepo.ListByUserID(ctx, userID, limit+1, offset)
+	if err != nil {
+		s.log.Errorw("Failed to list payments from repository. UserID: %s, Error: %v", userID, err)
+		return nil, false, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+	if len(payments) > limit {
+		return payments[:limit], true, nil
+	}
+	return payments, false, nil
+}
+
+// handlePaymentIntentEvent сохраняет состояние разового платежа из событий payment_intent.*.
+// PaymentIntents счетов (подписок) и платежи клиентов, не связанных с пользователями, пропускаются.
+func (s *PaymentService) handlePaymentIntentEvent(ctx context.Context, eventCreated time.Time, data map[string]interface{}) error {
+	intent := &stripe.PaymentIntent{
+		ID:             getStringValue(data, "id"),
+		CustomerID:     getStringValue(data, "customer"),
+		InvoiceID:      getStringValue(data, "invoice"),
+		Amount:         getInt64Value(data, "amount"),
+		AmountReceived: getInt64Value(data, "amount_received"),
+		Currency:       getStringValue(data, "currency"),
+		Status:         getStringValue(data, "status"),
+		Description:    getStringValue(data, "description"),
+		Created:        getTimeValueFromUnix(data, "created"),
+	}
+	if metadata, ok := data["metadata"].(map[string]interface{}); ok {
+		intent.Metadata = make(map[string]string, len(metadata))
+		for k := range metadata {
+			intent.Metadata[k] = getStringValue(metadata, k)
+		}
+	}
+	if lastError, ok := data["last_payment_error"].(map[string]interface{}); ok {
+		intent.LastPaymentError = getStringValue(lastError, "message")
+	}
+	if intent.ID == "" {
+		s.log.Errorw("PaymentIntent ID missing in payment_intent event data")
+		return nil
+	}
+	if intent.InvoiceID != "" {
+		s.log.Debugw("PaymentIntent belongs to an invoice, skipping. PaymentIntentID: %s, InvoiceID: %s", intent.ID, intent.InvoiceID)
+		return nil
+	}
+
+	userID, err := s.stripeCustomerOwner(ctx, intent.CustomerID)
+	if err != nil {
+		return err
+	}
+	if userID == "" {
+		s.log.Warnw("PaymentIntent belongs to an unknown customer, skipping. PaymentIntentID: %s, CustomerID: %s", intent.ID, intent.CustomerID)
+		return nil
+	}
+
+	payment := paymentFromIntent(intent)
+	payment.UserID = userID
+	payment.LastEventAt = eventCreated
+	if payment.StripeCreatedAt.IsZero() {
+		payment.StripeCreatedAt = eventCreated
+	}
+	if err := s.paymentRepo.Upsert(ctx, payment); err != nil {
+		return fmt.Errorf("failed to save payment %s: %w", payment.PaymentID, err)
+	}
+	s.log.Infow("Payment saved. PaymentID: %s, UserID: %s, Status: %s", payment.PaymentID, payment.UserID, payment.Status)
+	return nil
+}
+
+// paymentFromIntent преобразует PaymentIntent в модель платежа (без владельца и времени изменения).
+// Служебный ключ user_id не попадает в метаданные платежа.
+func paymentFromIntent(intent *stripe.PaymentIntent) *models.Payment {
+	metadata := make(models.Metadata, len(intent.Metadata))
+	for k, v := range intent.Metadata {
+		if k != "user_id" {
+			metadata[k] = v
+		}
+	}
+	return &models.Payment{
+		PaymentID:        intent.ID,
+		StripeCustomerID: intent.CustomerID,
+		Amount:           intent.Amount,
+		AmountReceived:   intent.AmountReceived,
+		Currency:         intent.Currency,
+		Status:           intent.Status,
+		Description:      intent.Description,
+		Metadata:         metadata,
+		LastPaymentError: intent.LastPaymentError,
+		StripeCreatedAt:  intent.Created,
+	}
+}
+
+// validatePaymentMetadata проверяет метаданные платежа по ограничениям Stripe.
+func validatePaymentMetadata(metadata map[string]string) error {
+	if len(metadata) > maxMetadataKeys-1 {
+		return fmt.Errorf("%w: metadata supports at most %d keys", ErrInvalidInput, maxMetadataKeys-1)
+	}
+	for k, v := range metadata {
+		switch {
+		case k == "user_id":
+			return fmt.Errorf("%w: metadata key user_id is reserved", ErrInvalidInput)
+		case k == "" || len(k) > maxMetadataKeyLength:
+			return fmt.Errorf("%w: metadata keys must be 1-%d characters long", ErrInvalidInput, maxMetadataKeyLength)
+		case len(v) > maxMetadataValueLength:
+			return fmt.Errorf("%w: metadata value of %q exceeds %d characters", ErrInvalidInput, k, maxMetadataValueLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/authz"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	stripego "github.com/stripe/stripe-go/v78"
+)
+
+// ErrSubscriptionNotChangeable возвращается, если план подписки нельзя сменить в ее текущем статусе.
+var ErrSubscriptionNotChangeable = errors.New("subscription plan cannot be changed in its current status")
+
+// changeableSubscriptionStatuses статусы, в которых разрешена смена плана.
+var changeableSubscriptionStatuses = map[string]bool{
+	"active":   true,
+	"trialing": true,
+}
+
+type ChangePlanInput struct {
+	UserID         string
+	SubscriptionID string
+	PlanID         string // Новый план (Price ID)
+	AtPeriodEnd    bool   // true - с начала следующего периода, false - сразу с перерасчетом
+	ProrationDate  int64  // Момент перерасчета из PreviewPlanChange (Unix, опционально)
+	IdempotencyKey string
+}
+
+type ChangePlanOutput struct {
+	Subscription *models.Subscription // Локальная подписка; plan_id меняется при обработке вебхука Stripe
+	PlanID       string               // Новый план
+	AtPeriodEnd  bool
+	EffectiveAt  time.Time // Когда новый план начинает действовать
+}
+
+// PlanChangePreview сумма ближайшего счета, если сменить план с указанными параметрами.
+type PlanChangePreview struct {
+	SubscriptionID  string
+	PlanID          string
+	AtPeriodEnd     bool
+	AmountDue       int64 // К оплате по ближайшему счету, в минимальных единицах валюты
+	Total           int64
+	ProrationAmount int64 // Часть суммы, приходящаяся на перерасчет (отрицательная при понижении плана)
+	Currency        string
+	NextPaymentAt   time.Time
+	ProrationDate   int64 // Передается в ChangePlan, чтобы сумма совпала с показанной
+}
+
+// ChangePlan меняет план подписки пользователя. Локальная подписка не меняется: plan_id обновляется
+// из вебхука customer.subscription.updated, который Stripe пришлет после смены цены
+// (для смены с конца периода - когда начнется следующий период).
+func (s *PaymentService) ChangePlan(ctx context.Context, input ChangePlanInput) (*ChangePlanOutput, error) {
+	if input.UserID == "" || input.SubscriptionID == "" || input.PlanID == "" {
+		return nil, ErrInvalidInput
+	}
+	if input.AtPeriodEnd && input.ProrationDate != 0 {
+		return nil, fmt.Errorf("%w: proration_date is not used for a change at period end", ErrInvalidInput)
+	}
+
+	s.log.Infow("Starting ChangePlan. UserID: %s, SubscriptionID: %s, PlanID: %s, AtPeriodEnd: %t",
+		input.UserID, input.SubscriptionID, input.PlanID, input.AtPeriodEnd)
+
+	sub, err := s.checkPlanChange(ctx, input.UserID, input.SubscriptionID, input.PlanID, authz.AccessWrite)
+	if err != nil {
+		return nil, err
+	}
+	// Подписка закончится вместе с текущим периодом: новый план с его конца не наступит
+	if input.AtPeriodEnd && sub.CancelAtPeriodEnd {
+		return nil, fmt.Errorf("%w: subscription is scheduled for cancellation, resume it first", ErrSubscriptionNotChangeable)
+	}
+
+	result, err := s.stripeClient.ChangeSubscriptionPlan(ctx, stripe.PlanChange{
+		SubscriptionID: sub.SubscriptionID,
+		PriceID:        input.PlanID,
+		AtPeriodEnd:    input.AtPeriodEnd,
+		ProrationDate:  input.ProrationDate,
+		IdempotencyKey: input.IdempotencyKey,
+	})
+	if err != nil {
+		s.trackStripeError(err, CreateSubscriptionInput{UserID: input.UserID, PlanID: input.PlanID})
+		var stripeErr *stripego.Error
+		if errors.As(err, &stripeErr) && (stripeErr.Type == StripeErrorTypeCard || stripeErr.Type == StripeErrorTypeInvalidRequest) {
+			return nil, fmt.Errorf("%w: %s", ErrPaymentFailed, stripeErr.Msg)
+		}
+		return nil, fmt.Errorf("%w: failed to change subscription plan: %v", ErrStripeClient, err)
+	}
+
+	s.log.Infow("Subscription plan change accepted by Stripe. UserID: %s, SubscriptionID: %s, PlanID: %s, EffectiveAt: %s",
+		input.UserID, sub.SubscriptionID, input.PlanID, result.EffectiveAt)
+
+	return &ChangePlanOutput{
+		Subscription: sub,
+		PlanID:       input.PlanID,
+		AtPeriodEnd:  input.AtPeriodEnd,
+		EffectiveAt:  result.EffectiveAt,
+	}, nil
+}
+
+// PreviewPlanChange показывает ближайший счет подписки при смене плана, ничего не меняя в Stripe.
+func (s *PaymentService) PreviewPlanChange(ctx context.Context, userID, subscriptionID, planID string, atPeriodEnd bool) (*PlanChangePreview, error) {
+	if userID == "" || subscriptionID == "" || planID == "" {
+		return nil, ErrInvalidInput
+	}
+
+	sub, err := s.checkPlanChange(ctx, userID, subscriptionID, planID, authz.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := s.stripeClient.PreviewPlanChange(ctx, stripe.PlanChange{
+		SubscriptionID: sub.SubscriptionID,
+		PriceID:        planID,
+		AtPeriodEnd:    atPeriodEnd,
+	})
+	if err != nil {
+		s.log.Errorw("Stripe failed to preview plan change. UserID: %s, SubscriptionID: %s, Error: %v", userID, subscriptionID, err)
+		return nil, fmt.Errorf("%w: failed to preview plan change: %v", ErrStripeClient, err)
+	}
+
+	return &PlanChangePreview{
+		SubscriptionID:  sub.SubscriptionID,
+		PlanID:          planID,
+		AtPeriodEnd:     atPeriodEnd,
+		AmountDue:       preview.AmountDue,
+		Total:           preview.Total,
+		ProrationAmount: preview.ProrationAmount,
+		Currency:        preview.Currency,
+		NextPaymentAt:   preview.NextPaymentAt,
+		ProrationDate:   preview.ProrationDate,
+	}, nil
+}
+
+// checkPlanChange проверяет доступ к подписке, ее статус и доступность нового плана.
+func (s *PaymentService) checkPlanChange(ctx context.Context, userID, subscriptionID, planID string, access authz.Access) (*models.Subscription, error) {
+	sub, err := s.getOwnedSubscription(ctx, userID, subscriptionID, access)
+	if err != nil {
+		return nil, err
+	}
+	if !changeableSubscriptionStatuses[sub.Status] {
+		s.log.Warnw("Plan change rejected: subscription status does not allow it. SubscriptionID: %s, Status: %s", subscriptionID, sub.Status)
+		return nil, fmt.Errorf("%w: status %s", ErrSubscriptionNotChangeable, sub.Status)
+	}
+	if sub.PlanID == planID {
+		return nil, fmt.Errorf("%w: subscription is already on plan %s", ErrInvalidInput, planID)
+	}
+	if _, err := s.validatePlan(ctx, planID); err != nil {
+		s.log.Warnw("Plan change rejected: plan is unavailable. SubscriptionID: %s, PlanID: %s, Error: %v", subscriptionID, planID, err)
+		return nil, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	stripego "github.com/stripe/stripe-go/v78"
+)
+
+var (
+	ErrPlanNotFound = errors.New("plan not found")        // Плана нет в каталоге
+	ErrPlanArchived = errors.New("plan is not available") // Цена или продукт архивированы в Stripe
+)
+
+// ListPlans возвращает планы, на которые можно оформить подписку.
+func (s *PaymentService) ListPlans(ctx context.Context) ([]*models.Plan, error) {
+	plans, err := s.planRepo.List(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list plans: %v", ErrInternalServer, err)
+	}
+	return plans, nil
+}
+
+// SyncPlans полностью синхронизирует каталог с периодическими ценами Stripe.
+// Цены, которых больше нет в Stripe, отмечаются архивными. Возвращает количество прочитанных цен.
+func (s *PaymentService) SyncPlans(ctx context.Context) (int, error) {
+	syncedAt := time.Now()
+	prices, err := s.stripeClient.ListPrices(ctx, "")
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to list prices: %v", ErrStripeClient, err)
+	}
+
+	keepIDs := make([]string, 0, len(prices))
+	for _, price := range prices {
+		if err := s.planRepo.Upsert(ctx, mapPriceToPlan(price, syncedAt)); err != nil {
+			return 0, fmt.Errorf("%w: failed to save plan %s: %v", ErrInternalServer, price.ID, err)
+		}
+		keepIDs = append(keepIDs, price.ID)
+	}
+
+	deactivated, err := s.planRepo.DeactivateExcept(ctx, keepIDs, syncedAt)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to deactivate missing plans: %v", ErrInternalServer, err)
+	}
+	s.log.Infow("Plan catalog synced with Stripe. Prices: %d, Deactivated: %d", len(prices), deactivated)
+	return len(prices), nil
+}
+
+// validatePlan проверяет, что план есть в каталоге и не архивирован.
+func (s *PaymentService) validatePlan(ctx context.Context, planID string) (*models.Plan, error) {
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrPlanNotFound, planID)
+		}
+		return nil, fmt.Errorf("%w: failed to get plan: %v", ErrInternalServer, err)
+	}
+	if !plan.Active() {
+		return nil, fmt.Errorf("%w: %s", ErrPlanArchived, planID)
+	}
+	return plan, nil
+}
+
+// handleCatalogWebhookEvent обрабатывает события price.* и product.*. Вместо данных события
+// используется актуальное состояние из Stripe, поэтому порядок доставки событий не важен.
+func (s *PaymentService) handleCatalogWebhookEvent(ctx context.Context, eventType stripego.EventType, data map[string]interface{}) error {
+	id := getStringValue(data, "id")
+	if id == "" {
+		s.log.Errorw("Object ID missing in %s event data", eventType)
+		return nil
+	}
+	syncedAt := time.Now()
+
+	switch eventType {
+	case "price.created", "price.updated", "price.deleted":
+		price, err := s.stripeClient.GetPrice(ctx, id)
+		if errors.Is(err, stripe.ErrResourceMissing) {
+			s.log.Infow("Price deleted in Stripe, archiving plan. PlanID: %s", id)
+			return s.planRepo.Deactivate(ctx, id, syncedAt)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get price %s: %w", id, err)
+		}
+		if price.Interval == "" {
+			s.log.Infow("Price %s is not recurring, skipping.", id)
+			return nil
+		}
+		s.log.Infow("Plan refreshed from Stripe. PlanID: %s, Active: %t", id, price.Active && price.ProductActive)
+		return s.planRepo.Upsert(ctx, mapPriceToPlan(price, syncedAt))
+
+	case "product.created", "product.updated", "product.deleted":
+		prices, err := s.stripeClient.ListPrices(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to list prices of product %s: %w", id, err)
+		}
+		for _, price := range prices {
+			if err := s.planRepo.Upsert(ctx, mapPriceToPlan(price, syncedAt)); err != nil {
+				return err
+			}
+		}
+		s.log.Infow("Plans of product refreshed from Stripe. ProductID: %s, Prices: %d", id, len(prices))
+	}
+	return nil
+}
+
+// mapPriceToPlan преобразует цену Stripe в план каталога.
+func mapPriceToPlan(price *stripe.Price, syncedAt time.Time) *models.Plan {
+	return &models.Plan{
+		PlanID:          price.ID,
+		ProductID:       price.ProductID,
+		Name:            price.ProductName,
+		Description:     price.ProductDescription,
+		Nickname:        price.Nickname,
+		Amount:          price.UnitAmount,
+		Currency:        price.Currency,
+		Interval:        price.Interval,
+		IntervalCount:   price.IntervalCount,
+		TrialPeriodDays: price.TrialPeriodDays,
+		PriceActive:     price.Active,
+		ProductActive:   price.ProductActive,
+		SyncedAt:        syncedAt,
+	}
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	stripego "github.com/stripe/stripe-go/v78"
+)
+
+var (
+	ErrChargeNotFound     = errors.New("charge not found")
+	ErrRefundNotFound     = errors.New("refund not found")
+	ErrCreditNoteNotFound = errors.New("credit note not found")
+	ErrAlreadyRefunded    = errors.New("charge is already fully refunded")
+)
+
+// Причины, которые принимает Stripe
+var (
+	refundReasons     = map[string]bool{"duplicate": true, "fraudulent": true, "requested_by_customer": true}
+	creditNoteReasons = map[string]bool{"duplicate": true, "fraudulent": true, "order_change": true, "product_unsatisfactory": true}
+)
+
+// CreateRefundInput параметры возврата: платеж задается напрямую (ChargeID) или через оплаченный им счет (InvoiceID).
+type CreateRefundInput struct {
+	ChargeID       string
+	InvoiceID      string
+	Amount         int64  // 0 - вся невозвращенная сумма
+	Reason         string // duplicate, fraudulent, requested_by_customer
+	IdempotencyKey string
+}
+
+// CreateCreditNoteInput параметры кредит-ноты по счету.
+type CreateCreditNoteInput struct {
+	InvoiceID      string
+	Amount         int64
+	Refund         bool   // Вернуть сумму на карту (только для оплаченного счета); иначе - на баланс клиента
+	Reason         string // duplicate, fraudulent, order_change, product_unsatisfactory
+	Memo           string
+	IdempotencyKey string
+}
+
+// CreateRefund возвращает платеж полностью или частично и сохраняет возврат локально.
+// Событие refund.created публикуется через outbox.
+func (s *PaymentService) CreateRefund(ctx context.Context, input CreateRefundInput) (*models.Refund, error) {
+	if (input.ChargeID == "") == (input.InvoiceID == "") {
+		return nil, fmt.Errorf("%w: exactly one of charge_id and invoice_id is required", ErrInvalidInput)
+	}
+	if input.Amount < 0 {
+		return nil, fmt.Errorf("%w: amount must not be negative", ErrInvalidInput)
+	}
+	if input.Reason != "" && !refundReasons[input.Reason] {
+		return nil, fmt.Errorf("%w: unknown refund reason %q", ErrInvalidInput, input.Reason)
+	}
+
+	charge, err := s.refundableCharge(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Refunded || charge.AmountRefunded >= charge.Amount {
+		return nil, ErrAlreadyRefunded
+	}
+	if input.Amount > charge.Amount-charge.AmountRefunded {
+		return nil, fmt.Errorf("%w: amount exceeds the refundable %d %s", ErrInvalidInput, charge.Amount-charge.AmountRefunded, charge.Currency)
+	}
+
+	created, err := s.stripeClient.CreateRefund(ctx, stripe.NewRefund{
+		ChargeID:       charge.ID,
+		Amount:         input.Amount,
+		Reason:         input.Reason,
+		IdempotencyKey: input.IdempotencyKey,
+	})
+	if err != nil {
+		return nil, s.refundStripeError(err, "create refund", charge.ID)
+	}
+
+	// Изменение датируется временем создания в Stripe, чтобы вебхук charge.refunded с тем же состоянием не считался устаревшим
+	refund, err := s.recordRefund(ctx, created, charge, created.Created)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("Refund created. RefundID: %s, ChargeID: %s, InvoiceID: %s, Amount: %d, Status: %s",
+		refund.RefundID, refund.ChargeID, refund.InvoiceID, refund.Amount, refund.Status)
+	return refund, nil
+}
+
+// CreateCreditNote выпускает кредит-ноту по финализированному счету и сохраняет ее локально.
+// Событие credit_note.created публикуется через outbox; возврат на карту, созданный кредит-нотой,
+// сохраняется из вебхука charge.refunded.
+func (s *PaymentService) CreateCreditNote(ctx context.Context, input CreateCreditNoteInput) (*models.CreditNote, error) {
+	if input.InvoiceID == "" || input.Amount <= 0 {
+		return nil, fmt.Errorf("%w: invoice_id and a positive amount are required", ErrInvalidInput)
+	}
+	if input.Reason != "" && !creditNoteReasons[input.Reason] {
+		return nil, fmt.Errorf("%w: unknown credit note reason %q", ErrInvalidInput, input.Reason)
+	}
+
+	params := stripe.NewCreditNote{
+		InvoiceID:      input.InvoiceID,
+		Amount:         input.Amount,
+		Reason:         input.Reason,
+		Memo:           input.Memo,
+		IdempotencyKey: input.IdempotencyKey,
+	}
+	if input.Refund {
+		params.RefundAmount = input.Amount
+	}
+	created, err := s.stripeClient.CreateCreditNote(ctx, params)
+	if err != nil {
+		return nil, s.refundStripeError(err, "create credit note", input.InvoiceID)
+	}
+
+	creditNote, err := s.recordCreditNote(ctx, created, created.Created)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Infow("Credit note created. CreditNoteID: %s, InvoiceID: %s, Amount: %d, RefundID: %s",
+		creditNote.CreditNoteID, creditNote.InvoiceID, creditNote.Amount, creditNote.RefundID)
+	return creditNote, nil
+}
+
+// GetRefund возвращает сохраненный возврат.
+func (s *PaymentService) GetRefund(ctx context.Context, refundID string) (*models.Refund, error) {
+	refund, err := s.refundRepo.GetRefund(ctx, refundID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+	return refund, nil
+}
+
+// GetCreditNote возвращает сохраненную кредит-ноту.
+func (s *PaymentService) GetCreditNote(ctx context.Context, creditNoteID string) (*models.CreditNote, error) {
+	creditNote, err := s.refundRepo.GetCreditNote(ctx, creditNoteID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCreditNoteNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+	return creditNote, nil
+}
+
+// ListInvoiceRefunds возвращает возвраты и кредит-ноты по счету.
+func (s *PaymentService) ListInvoiceRefunds(ctx context.Context, invoiceID string) ([]*models.Refund, []*models.CreditNote, error) {
+	if invoiceID == "" {
+		return nil, nil, ErrInvalidInput
+	}
+	refunds, err := s.refundRepo.ListRefundsByInvoiceID(ctx, invoiceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+	creditNotes, err := s.refundRepo.ListCreditNotesByInvoiceID(ctx, invoiceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	}
+	return refunds, creditNotes, nil
+}
+
+// refundableCharge находит возвращаемый платеж по ID платежа или счета.
+func (s *PaymentService) refundableCharge(ctx context.Context, input CreateRefundInput) (*stripe.Charge, error) {
+	if input.InvoiceID != "" {
+		charge, err := s.stripeClient.GetInvoiceCharge(ctx, input.InvoiceID)
+		if err != nil {
+			if errors.Is(err, stripe.ErrResourceMissing) {
+				return nil, ErrInvoiceNotFound
+			}
+			return nil, s.refundStripeError(err, "get invoice charge", input.InvoiceID)
+		}
+		if charge == nil {
+			return nil, fmt.Errorf("%w: invoice %s has no card payment to refund", ErrInvalidInput, input.InvoiceID)
+		}
+		return charge, nil
+	}
+
+	charge, err := s.stripeClient.GetCharge(ctx, input.ChargeID)
+	if err != nil {
+		if errors.Is(err, stripe.ErrResourceMissing) {
+			return nil, ErrChargeNotFound
+		}
+		return nil, s.refundStripeError(err, "get charge", input.ChargeID)
+	}
+	return charge, nil
+}
+
+// refundStripeError преобразует ошибку Stripe при возврате или выпуске кредит-ноты в ошибку сервиса.
+func (s *PaymentService) refundStripeError(err error, operation, objectID string) error {
+	var stripeErr *stripego.Error
+	if errors.As(err, &stripeErr) {
+		switch {
+		case stripeErr.Code == stripego.ErrorCodeChargeAlreadyRefunded:
+			return ErrAlreadyRefunded
+		case stripeErr.Code == stripego.ErrorCodeResourceMissing && stripeErr.Param == "invoice":
+			return ErrInvoiceNotFound
+		case stripeErr.Type == StripeErrorTypeInvalidRequest:
+			// Например, сумма больше остатка счета или счет еще не финализирован
+			return fmt.Errorf("%w: %s", ErrInvalidInput, stripeErr.Msg)
+		}
+	}
+	s.log.Errorw("Failed to %s. ObjectID: %s, Error: %v", operation, objectID, err)
+	return fmt.Errorf("%w: failed to %s: %v", ErrStripeClient, operation, err)
+}
+
+// --- Вебхуки ---
+
+// handleChargeRefunded сохраняет возвраты платежа из события charge.refunded. В объекте платежа
+// нет списка возвратов, поэтому он запрашивается у Stripe (так же учитываются возвраты из панели Stripe).
+func (s *PaymentService) handleChargeRefunded(ctx context.Context, eventCreated time.Time, data map[string]interface{}) error {
+	charge := &stripe.Charge{
+		ID:              getStringValue(data, "id"),
+		CustomerID:      getStringValue(data, "customer"),
+		InvoiceID:       getStringValue(data, "invoice"),
+		PaymentIntentID: getStringValue(data, "payment_intent"),
+	}
+	if charge.ID == "" {
+		s.log.Errorw("Charge ID missing in charge.refunded event data")
+		return nil
+	}
+
+	refunds, err := s.stripeClient.ListRefunds(ctx, charge.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list refunds of charge %s: %w", charge.ID, err)
+	}
+	for _, refund := range refunds {
+		if _, err := s.recordRefund(ctx, refund, charge, eventCreated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleRefundUpdated сохраняет изменение статуса возврата из события charge.refund.updated.
+func (s *PaymentService) handleRefundUpdated(ctx context.Context, eventCreated time.Time, data map[string]interface{}) error {
+	refund := &stripe.Refund{
+		ID:              getStringValue(data, "id"),
+		ChargeID:        getStringValue(data, "charge"),
+		PaymentIntentID: getStringValue(data, "payment_intent"),
+		Amount:          getInt64Value(data, "amount"),
+		Currency:        getStringValue(data, "currency"),
+		Status:          getStringValue(data, "status"),
+		Reason:          getStringValue(data, "reason"),
+		FailureReason:   getStringValue(data, "failure_reason"),
+		Created:         getTimeValueFromUnix(data, "created"),
+	}
+	if refund.ID == "" || refund.ChargeID == "" {
+		s.log.Errorw("Refund or charge ID missing in charge.refund.updated event data")
+		return nil
+	}
+
+	charge, err := s.stripeClient.GetCharge(ctx, refund.ChargeID)
+	if err != nil {
+		if errors.Is(err, stripe.ErrResourceMissing) {
+			s.log.Errorw("Charge of updated refund not found in Stripe. RefundID: %s, ChargeID: %s", refund.ID, refund.ChargeID)
+			return nil
+		}
+		return fmt.Errorf("failed to get charge %s: %w", refund.ChargeID, err)
+	}
+	_, err = s.recordRefund(ctx, refund, charge, eventCreated)
+	return err
+}
+
+// handleCreditNoteEvent сохраняет кредит-ноту из событий credit_note.*.
+func (s *PaymentService) handleCreditNoteEvent(ctx context.Context, eventCreated time.Time, data map[string]interface{}) error {
+	creditNote := &stripe.CreditNote{
+		ID:         getStringValue(data, "id"),
+		InvoiceID:  getStringValue(data, "invoice"),
+		CustomerID: getStringValue(data, "customer"),
+		Number:     getStringValue(data, "number"),
+		Type:       getStringValue(data, "type"),
+		Status:     getStringValue(data, "status"),
+		Reason:     getStringValue(data, "reason"),
+		Memo:       getStringValue(data, "memo"),
+		Amount:     getInt64Value(data, "total"),
+		Currency:   getStringValue(data, "currency"),
+		RefundID:   getStringValue(data, "refund"),
+		Created:    getTimeValueFromUnix(data, "created"),
+	}
+	if voidedAt := getTimeValueFromUnix(data, "voided_at"); !voidedAt.IsZero() {
+		creditNote.VoidedAt = &voidedAt
+	}
+	if creditNote.ID == "" {
+		s.log.Errorw("Credit note ID missing in credit note event data")
+		return nil
+	}
+	_, err := s.recordCreditNote(ctx, creditNote, eventCreated)
+	return err
+}
+
+// --- Сохранение ---
+
+// recordRefund сохраняет состояние возврата. Новый возврат публикует refund.created,
+// смена статуса - refund.updated; устаревшее состояние (changedAt раньше сохраненного) пропускается.
+func (s *PaymentService) recordRefund(ctx context.Context, r *stripe.Refund, charge *stripe.Charge, changedAt time.Time) (*models.Refund, error) {
+	userID, err := s.stripeCustomerOwner(ctx, charge.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	refund := &models.Refund{
+		RefundID:         r.ID,
+		UserID:           userID,
+		StripeCustomerID: charge.CustomerID,
+		ChargeID:         charge.ID,
+		PaymentIntentID:  r.PaymentIntentID,
+		InvoiceID:        charge.InvoiceID,
+		Amount:           r.Amount,
+		Currency:         r.Currency,
+		Status:           r.Status,
+		Reason:           r.Reason,
+		FailureReason:    r.FailureReason,
+		StripeCreatedAt:  r.Created,
+		LastEventAt:      changedAt,
+	}
+	if refund.PaymentIntentID == "" {
+		refund.PaymentIntentID = charge.PaymentIntentID
+	}
+	if refund.StripeCreatedAt.IsZero() {
+		refund.StripeCreatedAt = changedAt
+	}
+
+	var eventType kafka.RefundEventType
+	previous, err := s.refundRepo.GetRefund(ctx, refund.RefundID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		eventType = kafka.EventRefundCreated
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	case previous.Status != refund.Status:
+		eventType = kafka.EventRefundUpdated
+	}
+
+	var event *models.OutboxEvent
+	if eventType != "" {
+		if event, err = newRefundOutboxEvent(refund.RefundID, eventType, kafka.NewRefundEvent(ctx, eventType, refund)); err != nil {
+			return nil, fmt.Errorf("%w: failed to build refund event: %v", ErrInternalServer, err)
+		}
+	}
+	applied, err := s.refundRepo.SaveRefund(ctx, refund, event)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to save refund: %v", ErrInternalServer, err)
+	}
+	if !applied {
+		s.log.Infow("Ignoring stale refund state. RefundID: %s, ChangedAt: %s", refund.RefundID, changedAt)
+	}
+	return refund, nil
+}
+
+// recordCreditNote сохраняет состояние кредит-ноты. Новая кредит-нота публикует credit_note.created
+// (или credit_note.voided, если первой пришла уже аннулированная), аннулирование - credit_note.voided.
+func (s *PaymentService) recordCreditNote(ctx context.Context, cn *stripe.CreditNote, changedAt time.Time) (*models.CreditNote, error) {
+	userID, err := s.stripeCustomerOwner(ctx, cn.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	creditNote := &models.CreditNote{
+		CreditNoteID:     cn.ID,
+		UserID:           userID,
+		StripeCustomerID: cn.CustomerID,
+		InvoiceID:        cn.InvoiceID,
+		Number:           cn.Number,
+		Type:             cn.Type,
+		Status:           cn.Status,
+		Reason:           cn.Reason,
+		Memo:             cn.Memo,
+		Amount:           cn.Amount,
+		Currency:         cn.Currency,
+		RefundID:         cn.RefundID,
+		VoidedAt:         cn.VoidedAt,
+		StripeCreatedAt:  cn.Created,
+		LastEventAt:      changedAt,
+	}
+	if creditNote.StripeCreatedAt.IsZero() {
+		creditNote.StripeCreatedAt = changedAt
+	}
+
+	var eventType kafka.RefundEventType
+	previous, err := s.refundRepo.GetCreditNote(ctx, creditNote.CreditNoteID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound) && creditNote.Status == "void":
+		eventType = kafka.EventCreditNoteVoided
+	case errors.Is(err, repository.ErrNotFound):
+		eventType = kafka.EventCreditNoteCreated
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInternalServer, err)
+	case previous.Status != "void" && creditNote.Status == "void":
+		eventType = kafka.EventCreditNoteVoided
+	}
+
+	var event *models.OutboxEvent
+	if eventType != "" {
+		if event, err = newRefundOutboxEvent(creditNote.CreditNoteID, eventType, kafka.NewCreditNoteEvent(ctx, eventType, creditNote)); err != nil {
+			return nil, fmt.Errorf("%w: failed to build credit note event: %v", ErrInternalServer, err)
+		}
+	}
+	applied, err := s.refundRepo.SaveCreditNote(ctx, creditNote, event)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to save credit note: %v", ErrInternalServer, err)
+	}
+	if !applied {
+		s.log.Infow("Ignoring stale credit note state. CreditNoteID: %s, ChangedAt: %s", creditNote.CreditNoteID, changedAt)
+	}
+	return creditNote, nil
+}
+
+// stripeCustomerOwner возвращает пользователя клиента Stripe (пусто, если клиент не связан с пользователем,
+// например создан вручную в панели Stripe).
+func (s *PaymentService) stripeCustomerOwner(ctx context.Context, stripeCustomerID string) (string, error) {
+	if stripeCustomerID == "" {
+		return "", nil
+	}
+	customer, err := s.customerRepo.GetByStripeID(ctx, stripeCustomerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCustomerNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("%w: failed to get customer: %v", ErrInternalServer, err)
+	}
+	return customer.UserID, nil
+}
+
+// newRefundOutboxEvent готовит событие outbox для возврата или кредит-ноты.
+// Ключ сообщения - ID объекта, чтобы все его изменения попадали в одну партицию Kafka.
+func newRefundOutboxEvent(aggregateID string, eventType kafka.RefundEventType, envelope *kafka.RefundEvent) (*models.OutboxEvent, error) {
+	topic, err := kafka.TopicForRefundEventType(eventType)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal refund event: %w", err)
+	}
+	return &models.OutboxEvent{
+		AggregateID: aggregateID,
+		Topic:       topic,
+		MessageKey:  aggregateID,
+		Payload:     payload,
+	}, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/models"
+)
+
+// maxTrialDays максимальная длительность пробного периода, которую принимает Stripe.
+const maxTrialDays = 730
+
+// subscriptionTrial пробный период новой подписки: задается либо днями, либо точным концом.
+type subscriptionTrial struct {
+	Days int64
+	End  *time.Time
+}
+
+// resolveTrial определяет пробный период новой подписки. Явные TrialDays/TrialEnd важнее
+// пробного периода плана; TrialDays = 0 отключает пробный период плана.
+func resolveTrial(input CreateSubscriptionInput, plan *models.Plan, now time.Time) (subscriptionTrial, error) {
+	switch {
+	case input.TrialDays != nil && input.TrialEnd != nil:
+		return subscriptionTrial{}, fmt.Errorf("%w: trial_days and trial_end are mutually exclusive", ErrInvalidInput)
+	case input.TrialEnd != nil:
+		if !input.TrialEnd.After(now) {
+			return subscriptionTrial{}, fmt.Errorf("%w: trial_end must be in the future", ErrInvalidInput)
+		}
+		if input.TrialEnd.After(now.AddDate(0, 0, maxTrialDays)) {
+			return subscriptionTrial{}, fmt.Errorf("%w: trial_end must be within %d days", ErrInvalidInput, maxTrialDays)
+		}
+		return subscriptionTrial{End: input.TrialEnd}, nil
+	case input.TrialDays != nil:
+		if *input.TrialDays < 0 || *input.TrialDays > maxTrialDays {
+			return subscriptionTrial{}, fmt.Errorf("%w: trial_days must be between 0 and %d", ErrInvalidInput, maxTrialDays)
+		}
+		return subscriptionTrial{Days: *input.TrialDays}, nil
+	case plan != nil:
+		return subscriptionTrial{Days: min(plan.TrialPeriodDays, maxTrialDays)}, nil
+	}
+	return subscriptionTrial{}, nil
+}
+
+// setTrialFromWebhook обновляет пробный период подписки по объекту подписки Stripe; возвращает true, если он изменился.
+func setTrialFromWebhook(sub *models.Subscription, data map[string]interface{}) bool {
+	var trialStart, trialEnd *time.Time
+	if t := getTimeValueFromUnix(data, "trial_start"); !t.IsZero() {
+		trialStart = &t
+	}
+	if t := getTimeValueFromUnix(data, "trial_end"); !t.IsZero() {
+		trialEnd = &t
+	}
+	if sameTime(sub.TrialStart, trialStart) && sameTime(sub.TrialEnd, trialEnd) {
+		return false
+	}
+	sub.TrialStart, sub.TrialEnd = trialStart, trialEnd
+	return true
+}
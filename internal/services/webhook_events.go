@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/kafka"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+
+	stripego "github.com/stripe/stripe-go/v78"
+)
+
+// Ограничения выборки журнала вебхуков
+const (
+	defaultWebhookEventsLimit = 50
+	maxWebhookEventsLimit     = 500
+
+	// Максимум событий, повторяемых одним запросом на повтор за период
+	maxWebhookReplayBatch = 500
+	// Через сколько очередь повторит событие, если ручной повтор завершился ошибкой
+	webhookReplayRetryDelay = 1 * time.Minute
+)
+
+var (
+	ErrWebhookEventNotFound = errors.New("webhook event not found")                    // Событие отсутствует в журнале вебхуков
+	ErrWebhookEventBusy     = errors.New("webhook event is currently being processed") // Событие сейчас обрабатывает воркер очереди
+)
+
+// WebhookReplayResult результат ручного повтора одного события.
+type WebhookReplayResult struct {
+	EventID   string `json:"event_id"`
+	EventType string `json:"event_type"`
+	Status    string `json:"status"`          // Статус события после повтора
+	Error     string `json:"error,omitempty"` // Ошибка обработки, если повтор не удался
+}
+
+// WebhookReplaySummary итог повтора событий за период.
+type WebhookReplaySummary struct {
+	Total     int                   `json:"total"`     // Сколько событий попало в период
+	Processed int                   `json:"processed"` // Обработано успешно
+	Stale     int                   `json:"stale"`     // Проигнорировано как устаревшие
+	Failed    int                   `json:"failed"`    // Завершились ошибкой (вернулись в очередь)
+	Skipped   int                   `json:"skipped"`   // Пропущены, так как обрабатываются воркером
+	Results   []WebhookReplayResult `json:"results"`
+}
+
+// webhookEventStatuses допустимые значения фильтра по статусу.
+var webhookEventStatuses = map[string]bool{
+	models.WebhookEventStatusPending:    true,
+	models.WebhookEventStatusProcessing: true,
+	models.WebhookEventStatusProcessed:  true,
+	models.WebhookEventStatusFailed:     true,
+	models.WebhookEventStatusDead:       true,
+	models.WebhookEventStatusStale:      true,
+}
+
+// EnqueueWebhookEvent сохраняет проверенное событие Stripe в очередь обработки.
+// payload - сырое тело запроса вебхука. Возвращает duplicate=true, если событие уже было получено ранее.
+// Сама обработка выполняется асинхронно воркерами (см. ProcessWebhookEvent).
+func (s *PaymentService) EnqueueWebhookEvent(ctx context.Context, event stripego.Event, payload []byte) (bool, error) {
+	record := &models.WebhookEvent{
+		EventID:   event.ID,
+		EventType: string(event.Type),
+		Payload:   payload,
+	}
+	if event.Created > 0 {
+		created := time.Unix(event.Created, 0)
+		record.StripeCreatedAt = &created
+	}
+	if event.Data != nil {
+		var data map[string]interface{}
+		if err := json.Unmarshal(event.Data.Raw, &data); err == nil {
+			if subID := webhookSubscriptionID(data); subID != "" {
+				record.SubscriptionID = &subID
+			}
+		}
+	}
+
+	inserted, err := s.webhookRepo.Enqueue(ctx, record)
+	if err != nil {
+		s.log.Errorw("Failed to enqueue webhook event. EventID: %s, Type: %s, Error: %v", event.ID, event.Type, err)
+		return false, fmt.Errorf("%w: failed to enqueue webhook event: %v", ErrInternalServer, err)
+	}
+	if !inserted {
+		s.log.Infow("Duplicate webhook delivery, event already queued. EventID: %s, Type: %s", event.ID, event.Type)
+		return true, nil
+	}
+
+	s.log.Debugw("Webhook event queued. EventID: %s, Type: %s", event.ID, event.Type)
+	return false, nil
+}
+
+// ProcessWebhookEvent разбирает сохраненное событие и применяет его через HandleWebhookEvent.
+// Вызывается воркерами очереди вебхуков.
+func (s *PaymentService) ProcessWebhookEvent(ctx context.Context, record *models.WebhookEvent) error {
+	var event stripego.Event
+	if err := json.Unmarshal(record.Payload, &event); err != nil {
+		return fmt.Errorf("failed to parse webhook payload: %w", err)
+	}
+	if event.Data == nil {
+		return fmt.Errorf("webhook event %s has no data object", record.EventID)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(event.Data.Raw, &data); err != nil {
+		return fmt.Errorf("failed to parse webhook event data: %w", err)
+	}
+
+	// ID события Stripe используется как correlation ID для всех порожденных им событий Kafka
+	ctx = kafka.WithCorrelationID(ctx, event.ID)
+
+	return s.HandleWebhookEvent(ctx, event.Type, time.Unix(event.Created, 0), webhookSubscriptionID(data), data)
+}
+
+// ListWebhookEvents возвращает события журнала вебхуков по фильтру (например, все события в dead-letter).
+func (s *PaymentService) ListWebhookEvents(ctx context.Context, filter models.WebhookEventFilter) ([]*models.WebhookEvent, error) {
+	if filter.Status != "" && !webhookEventStatuses[filter.Status] {
+		return nil, fmt.Errorf("%w: unknown webhook event status %q", ErrInvalidInput, filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultWebhookEventsLimit
+	}
+	if filter.Limit > maxWebhookEventsLimit {
+		filter.Limit = maxWebhookEventsLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	events, err := s.webhookRepo.List(ctx, filter)
+	if err != nil {
+		s.log.Errorw("Failed to list webhook events. Error: %v", err)
+		return nil, fmt.Errorf("%w: failed to list webhook events: %v", ErrInternalServer, err)
+	}
+	return events, nil
+}
+
+// GetWebhookEvent возвращает событие журнала вебхуков по ID события Stripe.
+func (s *PaymentService) GetWebhookEvent(ctx context.Context, eventID string) (*models.WebhookEvent, error) {
+	event, err := s.webhookRepo.GetByID(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookEventNotFound
+		}
+		s.log.Errorw("Failed to get webhook event. EventID: %s, Error: %v", eventID, err)
+		return nil, fmt.Errorf("%w: failed to get webhook event: %v", ErrInternalServer, err)
+	}
+	return event, nil
+}
+
+// GetWebhookEventAttempts возвращает историю обработки события.
+func (s *PaymentService) GetWebhookEventAttempts(ctx context.Context, eventID string) ([]*models.WebhookEventAttempt, error) {
+	attempts, err := s.webhookRepo.ListAttempts(ctx, eventID)
+	if err != nil {
+		s.log.Errorw("Failed to get webhook event attempts. EventID: %s, Error: %v", eventID, err)
+		return nil, fmt.Errorf("%w: failed to get webhook event attempts: %v", ErrInternalServer, err)
+	}
+	return attempts, nil
+}
+
+// ReplayWebhookEvent повторно применяет сохраненное событие через HandleWebhookEvent.
+// requestedBy - ID пользователя, запросившего повтор (сохраняется в историю обработки).
+// Ошибка обработки не возвращается как error, а попадает в результат: событие возвращается в очередь.
+func (s *PaymentService) ReplayWebhookEvent(ctx context.Context, eventID, requestedBy string) (*WebhookReplayResult, error) {
+	event, claimed, err := s.webhookRepo.ClaimForReplay(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookEventNotFound
+		}
+		s.log.Errorw("Failed to claim webhook event for replay. EventID: %s, Error: %v", eventID, err)
+		return nil, fmt.Errorf("%w: failed to claim webhook event: %v", ErrInternalServer, err)
+	}
+	if !claimed {
+		return nil, ErrWebhookEventBusy
+	}
+	// Событие уже захвачено: обработка и запись результата доводятся до конца, даже если клиент отключился
+	ctx = context.WithoutCancel(ctx)
+
+	s.log.Infow("Replaying webhook event. EventID: %s, Type: %s, Attempt: %d, RequestedBy: %s", event.EventID, event.EventType, event.Attempts, requestedBy)
+	startedAt := time.Now()
+	processErr := s.ProcessWebhookEvent(ctx, event)
+
+	result := &WebhookReplayResult{EventID: event.EventID, EventType: event.EventType}
+	switch {
+	case processErr == nil:
+		result.Status = models.WebhookEventStatusProcessed
+		err = s.webhookRepo.MarkProcessed(ctx, event.EventID)
+	case errors.Is(processErr, ErrStaleWebhookEvent):
+		result.Status = models.WebhookEventStatusStale
+		result.Error = processErr.Error()
+		err = s.webhookRepo.MarkStale(ctx, event.EventID, processErr.Error())
+	default:
+		result.Status = models.WebhookEventStatusFailed
+		result.Error = processErr.Error()
+		s.log.Warnw("Webhook event replay failed. EventID: %s, RequestedBy: %s, Error: %v", event.EventID, requestedBy, processErr)
+		err = s.webhookRepo.MarkFailed(ctx, event.EventID, processErr.Error(), time.Now().Add(webhookReplayRetryDelay))
+	}
+	if err != nil {
+		s.log.Errorw("Failed to record webhook replay result. EventID: %s, Error: %v", event.EventID, err)
+		return nil, fmt.Errorf("%w: failed to record replay result: %v", ErrInternalServer, err)
+	}
+
+	attempt := &models.WebhookEventAttempt{
+		EventID:     event.EventID,
+		Attempt:     event.Attempts,
+		Trigger:     models.WebhookAttemptTriggerReplay,
+		Outcome:     result.Status,
+		TriggeredBy: &requestedBy,
+		StartedAt:   startedAt,
+		FinishedAt:  time.Now(),
+	}
+	if result.Error != "" {
+		attempt.Error = &result.Error
+	}
+	if err := s.webhookRepo.RecordAttempt(ctx, attempt); err != nil {
+		// История - вспомогательные данные, результат повтора уже сохранен
+		s.log.Errorw("Failed to record webhook replay attempt. EventID: %s, Error: %v", event.EventID, err)
+	}
+
+	return result, nil
+}
+
+// ReplayWebhookEvents повторяет все события, полученные в периоде [filter.From, filter.To),
+// с дополнительными фильтрами по статусу и типу. События применяются в порядке их создания в Stripe.
+func (s *PaymentService) ReplayWebhookEvents(ctx context.Context, filter models.WebhookEventFilter, requestedBy string) (*WebhookReplaySummary, error) {
+	if filter.From == nil || filter.To == nil || !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: replay range requires from < to", ErrInvalidInput)
+	}
+	if filter.Status != "" && !webhookEventStatuses[filter.Status] {
+		return nil, fmt.Errorf("%w: unknown webhook event status %q", ErrInvalidInput, filter.Status)
+	}
+	filter.Offset = 0
+	filter.Limit = maxWebhookReplayBatch + 1 // +1, чтобы понять, что период слишком большой
+
+	events, err := s.webhookRepo.List(ctx, filter)
+	if err != nil {
+		s.log.Errorw("Failed to list webhook events for replay. Error: %v", err)
+		return nil, fmt.Errorf("%w: failed to list webhook events: %v", ErrInternalServer, err)
+	}
+	if len(events) > maxWebhookReplayBatch {
+		return nil, fmt.Errorf("%w: more than %d events in range, narrow the range", ErrInvalidInput, maxWebhookReplayBatch)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OrderTime().Before(events[j].OrderTime())
+	})
+
+	s.log.Infow("Replaying webhook events. From: %s, To: %s, Status: %s, Type: %s, Count: %d, RequestedBy: %s",
+		*filter.From, *filter.To, filter.Status, filter.EventType, len(events), requestedBy)
+
+	summary := &WebhookReplaySummary{Total: len(events), Results: make([]WebhookReplayResult, 0, len(events))}
+	for _, event := range events {
+		result, err := s.ReplayWebhookEvent(ctx, event.EventID, requestedBy)
+		if errors.Is(err, ErrWebhookEventBusy) {
+			summary.Skipped++
+			summary.Results = append(summary.Results, WebhookReplayResult{
+				EventID:   event.EventID,
+				EventType: event.EventType,
+				Status:    models.WebhookEventStatusProcessing,
+				Error:     err.Error(),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch result.Status {
+		case models.WebhookEventStatusProcessed:
+			summary.Processed++
+		case models.WebhookEventStatusStale:
+			summary.Stale++
+		default:
+			summary.Failed++
+		}
+		summary.Results = append(summary.Results, *result)
+	}
+
+	return summary, nil
+}
+
+// webhookSubscriptionID извлекает ID подписки Stripe из объекта события.
+// Для событий invoice.* ID подписки лежит в поле "subscription",
+// для customer.subscription.* сам объект является подпиской.
+func webhookSubscriptionID(data map[string]interface{}) string {
+	if subID := getStringValue(data, "subscription"); subID != "" {
+		return subID
+	}
+	if getStringValue(data, "object") == "subscription" {
+		return getStringValue(data, "id")
+	}
+	return ""
+}
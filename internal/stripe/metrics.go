@@ -0,0 +1,109 @@
+package stripe
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/stripe/stripe-go/v78"
+	"github.com/stripe/stripe-go/v78/form"
+)
+
+const (
+	// outcomeSuccess исход успешного вызова Stripe API
+	outcomeSuccess = "success"
+	// outcomeNetworkError исход вызова, завершившегося без ответа Stripe (сеть, таймаут, отмена контекста)
+	outcomeNetworkError = "network_error"
+)
+
+// instrumentedBackend оборачивает backend Stripe SDK и считает вызовы API по методу, эндпоинту
+// и исходу (success или stripe.ErrorType ошибки).
+type instrumentedBackend struct {
+	stripe.Backend
+	calls   *metrics.CounterVec
+	latency *metrics.HistogramVec
+}
+
+func newInstrumentedBackend(backend stripe.Backend, registry *metrics.Registry) *instrumentedBackend {
+	return &instrumentedBackend{
+		Backend: backend,
+		calls: registry.NewCounterVec("stripe_api_requests_total",
+			"Total number of Stripe API calls by method, endpoint and outcome (success or Stripe error type).",
+			"method", "endpoint", "outcome"),
+		latency: registry.NewHistogramVec("stripe_api_request_duration_seconds",
+			"Stripe API call latency (seconds) including SDK retries.",
+			nil, "method", "endpoint"),
+	}
+}
+
+func (b *instrumentedBackend) Call(method, path, key string, params stripe.ParamsContainer, v stripe.LastResponseSetter) error {
+	start := time.Now()
+	err := b.Backend.Call(method, path, key, params, v)
+	b.observe(method, path, start, err)
+	return err
+}
+
+func (b *instrumentedBackend) CallStreaming(method, path, key string, params stripe.ParamsContainer, v stripe.StreamingLastResponseSetter) error {
+	start := time.Now()
+	err := b.Backend.CallStreaming(method, path, key, params, v)
+	b.observe(method, path, start, err)
+	return err
+}
+
+func (b *instrumentedBackend) CallRaw(method, path, key string, body *form.Values, params *stripe.Params, v stripe.LastResponseSetter) error {
+	start := time.Now()
+	err := b.Backend.CallRaw(method, path, key, body, params, v)
+	b.observe(method, path, start, err)
+	return err
+}
+
+func (b *instrumentedBackend) CallMultipart(method, path, key, boundary string, body *bytes.Buffer, params *stripe.Params, v stripe.LastResponseSetter) error {
+	start := time.Now()
+	err := b.Backend.CallMultipart(method, path, key, boundary, body, params, v)
+	b.observe(method, path, start, err)
+	return err
+}
+
+func (b *instrumentedBackend) observe(method, path string, start time.Time, err error) {
+	endpoint := endpointLabel(path)
+	b.calls.Inc(method, endpoint, callOutcome(err))
+	b.latency.Observe(time.Since(start).Seconds(), method, endpoint)
+}
+
+// callOutcome возвращает исход вызова: success, тип ошибки Stripe или network_error.
+func callOutcome(err error) string {
+	if err == nil {
+		return outcomeSuccess
+	}
+	var stripeErr *stripe.Error
+	if errors.As(err, &stripeErr) && stripeErr.Type != "" {
+		return string(stripeErr.Type)
+	}
+	return outcomeNetworkError
+}
+
+// endpointLabel заменяет ID объектов в пути на {id}: /v1/customers/cus_123/sources -> /v1/customers/{id}/sources.
+func endpointLabel(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if isObjectID(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isObjectID отличает ID объекта Stripe (префикс_ и случайная часть с цифрами или заглавными буквами)
+// от имени ресурса (payment_intents, billing_portal - только строчные буквы).
+func isObjectID(segment string) bool {
+	if !strings.Contains(segment, "_") {
+		return false
+	}
+	return strings.IndexFunc(segment, func(r rune) bool { return unicode.IsDigit(r) || unicode.IsUpper(r) }) >= 0
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
 	"github.com/Dhoini/Payment-microservice/pkg/logger"
 
 	"github.com/stripe/stripe-go/v78"
@@ -14,8 +16,267 @@ import (
 const (
 	// Ключ метаданных для связи Stripe Customer с вашим UserID
 	metadataUserIDKey = "user_id"
+
+	// Режимы перерасчета при смене цены подписки
+	prorationBehaviorCreateProrations = "create_prorations" // Разница за остаток периода попадает в следующий счет
+	prorationBehaviorNone             = "none"
+)
+
+// ErrResourceMissing возвращается, если объект не найден в Stripe.
+var ErrResourceMissing = errors.New("stripe: resource missing")
+
+// Price периодическая цена Stripe вместе с данными продукта (элемент каталога планов).
+type Price struct {
+	ID                 string
+	ProductID          string
+	ProductName        string
+	ProductDescription string
+	ProductActive      bool
+	Nickname           string
+	UnitAmount         int64 // В минимальных единицах валюты
+	Currency           string
+	Interval           string // day, week, month, year
+	IntervalCount      int64
+	TrialPeriodDays    int64 // Пробный период цены по умолчанию (recurring.trial_period_days), 0 - нет
+	Active             bool
+}
+
+// Coupon купон Stripe - скидка в процентах или фиксированной суммой.
+type Coupon struct {
+	ID                string
+	Name              string
+	PercentOff        float64 // Скидка в процентах (0 - скидка суммой)
+	AmountOff         int64   // Скидка суммой в минимальных единицах Currency
+	Currency          string
+	Duration          string   // once, repeating, forever
+	DurationInMonths  int64    // Для repeating
+	AppliesToProducts []string // Продукты, к которым применим купон (пусто - ко всем)
+	Valid             bool     // Купон еще можно применить (не истек redeem_by и не исчерпан лимит)
+}
+
+// PromotionCode промокод - код для клиентов, который применяет купон.
+type PromotionCode struct {
+	ID                    string
+	Code                  string
+	Active                bool // Stripe снимает флаг, когда промокод истек, исчерпан или недействителен купон
+	Coupon                *Coupon
+	CustomerID            string     // Промокод доступен только этому клиенту (пусто - всем)
+	ExpiresAt             *time.Time // nil - бессрочно
+	FirstTimeTransaction  bool       // Только для клиентов без оплаченных счетов
+	MinimumAmount         int64      // Минимальная сумма первого счета (0 - без ограничения)
+	MinimumAmountCurrency string
+}
+
+// Discount скидка, примененная к подписке.
+type Discount struct {
+	Coupon          *Coupon
+	PromotionCodeID string // Промокод, через который применен купон (пусто - купон указан напрямую)
+	Start           time.Time
+	End             *time.Time // Конец скидки для repeating-купона (nil - once или forever)
+}
+
+// NewSubscription параметры создания подписки.
+// Пробный период задается либо TrialPeriodDays, либо TrialEnd; на время пробного периода
+// способ оплаты не нужен: его можно сохранить позже через ClientSecret (SetupIntent).
+// Скидка задается либо CouponID, либо PromotionCodeID.
+type NewSubscription struct {
+	CustomerID      string
+	PriceID         string
+	TrialPeriodDays int64      // Длительность пробного периода в днях (0 - без пробного периода)
+	TrialEnd        *time.Time // Точный конец пробного периода
+	CouponID        string
+	PromotionCodeID string // ID промокода (promo_...), а не сам код
+	IdempotencyKey  string
+}
+
+// CreatedSubscription результат создания подписки.
+type CreatedSubscription struct {
+	ID           string
+	Status       string     // Статус в Stripe (incomplete, trialing, active, ...)
+	ClientSecret string     // Секрет PaymentIntent первого счета или SetupIntent для пробного периода
+	TrialStart   *time.Time // Начало пробного периода (nil - без пробного периода)
+	TrialEnd     *time.Time
+	Discount     *Discount // Примененная скидка (nil - без скидки)
+}
+
+// Subscription текущее состояние подписки в Stripe.
+type Subscription struct {
+	ID               string
+	CustomerID       string
+	PriceID          string
+	Status           string
+	CurrentPeriodEnd time.Time
+	TrialStart       *time.Time
+	TrialEnd         *time.Time
+	Discount         *Discount
+}
+
+// NewCheckoutSession параметры сессии Stripe Checkout для оформления подписки на странице Stripe.
+type NewCheckoutSession struct {
+	CustomerID          string
+	UserID              string // Передается в client_reference_id и метаданные сессии и подписки
+	PriceID             string
+	TrialPeriodDays     int64 // 0 - без пробного периода
+	SuccessURL          string
+	CancelURL           string
+	AllowPromotionCodes bool // Поле для промокода на странице оплаты
+	IdempotencyKey      string
+}
+
+// CheckoutSession созданная сессия Stripe Checkout.
+type CheckoutSession struct {
+	ID        string
+	URL       string // Страница оплаты, на которую перенаправляется пользователь
+	ExpiresAt time.Time
+}
+
+// Charge платеж Stripe.
+type Charge struct {
+	ID              string
+	CustomerID      string
+	InvoiceID       string // Счет, который оплачивал платеж (пусто для разовых платежей)
+	PaymentIntentID string
+	Amount          int64
+	AmountRefunded  int64
+	Currency        string
+	Refunded        bool // Платеж возвращен полностью
+}
+
+// Refund возврат платежа.
+type Refund struct {
+	ID              string
+	ChargeID        string
+	PaymentIntentID string
+	Amount          int64
+	Currency        string
+	Status          string // pending, requires_action, succeeded, failed, canceled
+	Reason          string
+	FailureReason   string
+	Created         time.Time
+}
+
+// NewRefund параметры возврата платежа.
+type NewRefund struct {
+	ChargeID       string
+	Amount         int64  // 0 - вся невозвращенная сумма платежа
+	Reason         string // duplicate, fraudulent, requested_by_customer (пусто - без причины)
+	IdempotencyKey string
+}
+
+// CreditNote кредит-нота по счету.
+type CreditNote struct {
+	ID         string
+	InvoiceID  string
+	CustomerID string
+	Number     string
+	Type       string // pre_payment, post_payment
+	Status     string // issued, void
+	Reason     string
+	Memo       string
+	Amount     int64
+	Currency   string
+	RefundID   string // Возврат на карту, созданный кредит-нотой
+	Created    time.Time
+	VoidedAt   *time.Time
+}
+
+// NewCreditNote параметры кредит-ноты.
+type NewCreditNote struct {
+	InvoiceID      string
+	Amount         int64
+	RefundAmount   int64  // Часть суммы, возвращаемая на карту (только для оплаченного счета); остальное - на баланс клиента
+	Reason         string // duplicate, fraudulent, order_change, product_unsatisfactory
+	Memo           string
+	IdempotencyKey string
+}
+
+// PaymentIntent разовый платеж; ClientSecret передается фронтенду для подтверждения через Stripe.js.
+type PaymentIntent struct {
+	ID               string
+	CustomerID       string
+	InvoiceID        string // Счет, который оплачивает PaymentIntent (пусто для разовых платежей)
+	Amount           int64
+	AmountReceived   int64
+	Currency         string
+	Status           string // requires_payment_method, requires_confirmation, requires_action, processing, succeeded, canceled
+	Description      string
+	Metadata         map[string]string
+	ClientSecret     string
+	LastPaymentError string // Сообщение последней неудачной попытки оплаты
+	Created          time.Time
+}
+
+// NewPaymentIntent параметры разового платежа.
+type NewPaymentIntent struct {
+	CustomerID     string
+	UserID         string // Сохраняется в метаданных PaymentIntent
+	Amount         int64  // В минимальных единицах валюты
+	Currency       string
+	Description    string
+	Metadata       map[string]string
+	IdempotencyKey string
+}
+
+// PlanChange параметры смены цены (плана) подписки.
+type PlanChange struct {
+	SubscriptionID string
+	PriceID        string // Новая цена
+	AtPeriodEnd    bool   // true - новая цена начнет действовать со следующего периода, без перерасчета
+	ProrationDate  int64  // Момент перерасчета (Unix) из предпросмотра, чтобы сумма совпала с показанной; 0 - текущее время
+	IdempotencyKey string
+}
+
+// PlanChangeResult результат смены плана.
+type PlanChangeResult struct {
+	EffectiveAt time.Time // Когда новая цена начинает (или начала) действовать
+	ScheduleID  string    // Расписание подписки, если смена отложена до конца периода
+}
+
+// Поведение счетов на время приостановки списаний (pause_collection.behavior).
+const (
+	PauseBehaviorVoid              = string(stripe.SubscriptionPauseCollectionBehaviorVoid)              // Счета аннулируются
+	PauseBehaviorKeepAsDraft       = string(stripe.SubscriptionPauseCollectionBehaviorKeepAsDraft)       // Счета остаются черновиками
+	PauseBehaviorMarkUncollectible = string(stripe.SubscriptionPauseCollectionBehaviorMarkUncollectible) // Счета помечаются безнадежными
 )
 
+// PauseCollection параметры приостановки списаний по подписке.
+type PauseCollection struct {
+	Behavior  string     // PauseBehaviorVoid, PauseBehaviorKeepAsDraft или PauseBehaviorMarkUncollectible
+	ResumesAt *time.Time // Автоматическое возобновление списаний (nil - только вручную)
+}
+
+// InvoicePreview предпросмотр ближайшего счета подписки (upcoming invoice).
+type InvoicePreview struct {
+	AmountDue       int64 // К оплате, в минимальных единицах валюты
+	Subtotal        int64
+	Total           int64
+	ProrationAmount int64 // Сумма строк перерасчета (отрицательная при переходе на более дешевый план)
+	Currency        string
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	NextPaymentAt   time.Time // Когда будет выставлен счет (нулевое время, если неизвестно)
+	ProrationDate   int64     // Момент перерасчета, который нужно передать в PlanChange
+}
+
+// SetupIntent сохранение карты без платежа; ClientSecret передается фронтенду для подтверждения через Stripe.js.
+type SetupIntent struct {
+	ID           string
+	ClientSecret string
+	Status       string // requires_payment_method, requires_confirmation, requires_action, succeeded, ...
+}
+
+// PaymentMethod сохраненная карта клиента.
+type PaymentMethod struct {
+	ID         string
+	CustomerID string // Клиент, к которому привязана карта (пусто - не привязана)
+	Brand      string // visa, mastercard, ...
+	Last4      string
+	ExpMonth   int64
+	ExpYear    int64
+	IsDefault  bool // Карта по умолчанию для счетов клиента (invoice_settings.default_payment_method)
+	Created    time.Time
+}
+
 // Client определяет методы для взаимодействия со Stripe API.
 type Client interface {
 	// CreateCustomer создает нового клиента в Stripe и возвращает его Stripe ID.
@@ -24,12 +285,92 @@ type Client interface {
 	// GetOrCreateCustomer ищет клиента по userID, если не находит - создает нового.
 	GetOrCreateCustomer(ctx context.Context, userID, email string) (string, error)
 
+	// UpdateCustomerEmail изменяет email клиента в Stripe.
+	UpdateCustomerEmail(ctx context.Context, stripeCustomerID, email string) error
+
+	// ListPrices возвращает периодические цены (активные и архивные); productID ограничивает выборку одним продуктом.
+	ListPrices(ctx context.Context, productID string) ([]*Price, error)
+
+	// GetPrice возвращает цену по ID (ErrResourceMissing, если цена удалена).
+	GetPrice(ctx context.Context, priceID string) (*Price, error)
+
+	// GetCoupon возвращает купон по ID (ErrResourceMissing, если купона нет).
+	GetCoupon(ctx context.Context, couponID string) (*Coupon, error)
+
+	// FindPromotionCode ищет промокод по коду без учета регистра; активный промокод важнее неактивного
+	// с тем же кодом (ErrResourceMissing, если кода нет).
+	FindPromotionCode(ctx context.Context, code string) (*PromotionCode, error)
+
 	// CreateSubscription создает подписку в Stripe для клиента.
-	// Возвращает Stripe Subscription ID и Client Secret для первого платежа (если нужен).
-	CreateSubscription(ctx context.Context, stripeCustomerID, planID, idempotencyKey string) (stripeSubscriptionID, clientSecret string, err error)
+	// Возвращает Stripe Subscription ID, статус и Client Secret для первого платежа или сохранения карты (если нужен).
+	CreateSubscription(ctx context.Context, sub NewSubscription) (*CreatedSubscription, error)
+
+	// CancelSubscription отменяет подписку в Stripe и возвращает момент отмены по часам Stripe
+	// (nil, если подписки в Stripe уже нет).
+	CancelSubscription(ctx context.Context, stripeSubscriptionID string) (*time.Time, error)
+
+	// SetCancelAtPeriodEnd планирует отмену подписки в конце текущего периода (true) или снимает ее (false).
+	// Возвращает момент отмены (cancel_at) или nil, если отмена снята.
+	SetCancelAtPeriodEnd(ctx context.Context, stripeSubscriptionID string, cancelAtPeriodEnd bool, idempotencyKey string) (*time.Time, error)
 
-	// CancelSubscription отменяет подписку в Stripe.
-	CancelSubscription(ctx context.Context, stripeSubscriptionID string) error
+	// PauseSubscription приостанавливает списания по подписке (pause_collection).
+	// Повторный вызов меняет поведение и дату возобновления.
+	PauseSubscription(ctx context.Context, stripeSubscriptionID string, pause PauseCollection, idempotencyKey string) error
+
+	// UnpauseSubscription возобновляет списания и возвращает статус подписки в Stripe.
+	UnpauseSubscription(ctx context.Context, stripeSubscriptionID, idempotencyKey string) (status string, err error)
+
+	// ChangeSubscriptionPlan меняет цену подписки сразу (с перерасчетом) или с начала следующего периода.
+	ChangeSubscriptionPlan(ctx context.Context, change PlanChange) (*PlanChangeResult, error)
+
+	// PreviewPlanChange возвращает ближайший счет подписки с учетом смены цены (change.IdempotencyKey не используется).
+	PreviewPlanChange(ctx context.Context, change PlanChange) (*InvoicePreview, error)
+
+	// CreateSetupIntent создает SetupIntent для сохранения карты клиента для будущих списаний.
+	CreateSetupIntent(ctx context.Context, stripeCustomerID, idempotencyKey string) (*SetupIntent, error)
+
+	// ListPaymentMethods возвращает карты клиента с отметкой карты по умолчанию.
+	ListPaymentMethods(ctx context.Context, stripeCustomerID string) ([]*PaymentMethod, error)
+
+	// GetPaymentMethod возвращает способ оплаты по ID (ErrResourceMissing, если его нет).
+	GetPaymentMethod(ctx context.Context, paymentMethodID string) (*PaymentMethod, error)
+
+	// AttachPaymentMethod привязывает способ оплаты к клиенту.
+	AttachPaymentMethod(ctx context.Context, stripeCustomerID, paymentMethodID, idempotencyKey string) (*PaymentMethod, error)
+
+	// DetachPaymentMethod отвязывает способ оплаты от клиента.
+	DetachPaymentMethod(ctx context.Context, paymentMethodID string) error
+
+	// SetDefaultPaymentMethod делает привязанный способ оплаты картой по умолчанию для счетов клиента.
+	SetDefaultPaymentMethod(ctx context.Context, stripeCustomerID, paymentMethodID string) error
+
+	// GetSubscription возвращает текущее состояние подписки (ErrResourceMissing, если ее нет).
+	GetSubscription(ctx context.Context, stripeSubscriptionID string) (*Subscription, error)
+
+	// CreateCheckoutSession создает сессию Stripe Checkout для оформления подписки.
+	CreateCheckoutSession(ctx context.Context, session NewCheckoutSession) (*CheckoutSession, error)
+
+	// CreateBillingPortalSession создает сессию Billing Portal клиента и возвращает ее адрес.
+	CreateBillingPortalSession(ctx context.Context, stripeCustomerID, returnURL string) (string, error)
+
+	// GetCharge возвращает платеж (ErrResourceMissing, если его нет).
+	GetCharge(ctx context.Context, chargeID string) (*Charge, error)
+
+	// GetInvoiceCharge возвращает платеж, которым оплачен счет (nil, если счет не оплачен картой;
+	// ErrResourceMissing, если счета нет).
+	GetInvoiceCharge(ctx context.Context, invoiceID string) (*Charge, error)
+
+	// CreateRefund возвращает платеж полностью или частично.
+	CreateRefund(ctx context.Context, refund NewRefund) (*Refund, error)
+
+	// ListRefunds возвращает все возвраты платежа.
+	ListRefunds(ctx context.Context, chargeID string) ([]*Refund, error)
+
+	// CreateCreditNote выпускает кредит-ноту по финализированному счету.
+	CreateCreditNote(ctx context.Context, creditNote NewCreditNote) (*CreditNote, error)
+
+	// CreatePaymentIntent создает PaymentIntent разового платежа клиента; способ оплаты выбирается на фронтенде.
+	CreatePaymentIntent(ctx context.Context, pi NewPaymentIntent) (*PaymentIntent, error)
 }
 
 // stripeClient реализует интерфейс Client.
@@ -39,9 +380,21 @@ type stripeClient struct {
 }
 
 // NewStripeClient создает новый экземпляр клиента Stripe.
-func NewStripeClient(apiKey string, log *logger.Logger) Client {
+// apiURL переопределяет адрес Stripe API (например, поддельный сервер stripetest); пустая строка - api.stripe.com.
+// Вызовы API считаются в registry по эндпоинту и исходу (см. instrumentedBackend).
+func NewStripeClient(apiKey, apiURL string, registry *metrics.Registry, log *logger.Logger) Client {
+	apiBackend := stripe.GetBackend(stripe.APIBackend)
+	if apiURL != "" {
+		apiBackend = stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{URL: stripe.String(apiURL)})
+	}
+	backends := &stripe.Backends{
+		API:     newInstrumentedBackend(apiBackend, registry),
+		Connect: stripe.GetBackend(stripe.ConnectBackend),
+		Uploads: stripe.GetBackend(stripe.UploadsBackend),
+	}
+
 	sc := &client.API{}
-	sc.Init(apiKey, nil) // Инициализируем клиент Stripe с API ключом
+	sc.Init(apiKey, backends) // Инициализируем клиент Stripe с API ключом
 	return &stripeClient{
 		client: sc,
 		log:    log,
@@ -57,6 +410,9 @@ func (sc *stripeClient) CreateCustomer(ctx context.Context, userID, email string
 		},
 	}
 	params.Context = ctx
+	// Ключ идемпотентности stripe-go выдает на каждый вызов (он защищает только сетевые повторы этого вызова).
+	// Ключ по userID не подходит: Stripe 24 часа повторяет первый ответ (в том числе ошибку) и отклоняет
+	// тот же ключ с другим email. Одного клиента на пользователя обеспечивает CustomerRepository.CreateIfNotExists.
 
 	cus, err := sc.client.Customers.New(params)
 	if err != nil {
@@ -111,21 +467,239 @@ func (sc *stripeClient) GetOrCreateCustomer(ctx context.Context, userID, email s
 	return sc.CreateCustomer(ctx, userID, email)
 }
 
+// UpdateCustomerEmail изменяет email клиента в Stripe.
+func (sc *stripeClient) UpdateCustomerEmail(ctx context.Context, stripeCustomerID, email string) error {
+	params := &stripe.CustomerParams{
+		Email: stripe.String(email),
+	}
+	params.Context = ctx
+
+	if _, err := sc.client.Customers.Update(stripeCustomerID, params); err != nil {
+		logStripeError(sc.log, "UpdateCustomerEmail", err)
+		return fmt.Errorf("stripe: failed to update customer email: %w", err)
+	}
+
+	sc.log.Infow("Stripe customer email updated. StripeCustomerID: %s", stripeCustomerID)
+	return nil
+}
+
+// ListPrices возвращает периодические цены вместе с продуктами.
+func (sc *stripeClient) ListPrices(ctx context.Context, productID string) ([]*Price, error) {
+	params := &stripe.PriceListParams{
+		Type: stripe.String(string(stripe.PriceTypeRecurring)),
+	}
+	if productID != "" {
+		params.Product = stripe.String(productID)
+	}
+	params.Context = ctx
+	params.Limit = stripe.Int64(100)
+	params.AddExpand("data.product")
+
+	var prices []*Price
+	iter := sc.client.Prices.List(params)
+	for iter.Next() {
+		prices = append(prices, mapPrice(iter.Price()))
+	}
+	if err := iter.Err(); err != nil {
+		logStripeError(sc.log, "ListPrices", err)
+		return nil, fmt.Errorf("stripe: failed to list prices: %w", err)
+	}
+	return prices, nil
+}
+
+// GetPrice возвращает цену вместе с продуктом.
+func (sc *stripeClient) GetPrice(ctx context.Context, priceID string) (*Price, error) {
+	params := &stripe.PriceParams{}
+	params.Context = ctx
+	params.AddExpand("product")
+
+	p, err := sc.client.Prices.Get(priceID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil, fmt.Errorf("%w: price %s", ErrResourceMissing, priceID)
+		}
+		logStripeError(sc.log, "GetPrice", err)
+		return nil, fmt.Errorf("stripe: failed to get price: %w", err)
+	}
+	if p.Deleted {
+		return nil, fmt.Errorf("%w: price %s", ErrResourceMissing, priceID)
+	}
+	return mapPrice(p), nil
+}
+
+// mapPrice преобразует цену SDK в Price.
+func mapPrice(p *stripe.Price) *Price {
+	price := &Price{
+		ID:         p.ID,
+		Nickname:   p.Nickname,
+		UnitAmount: p.UnitAmount,
+		Currency:   string(p.Currency),
+		Active:     p.Active,
+	}
+	if p.Recurring != nil {
+		price.Interval = string(p.Recurring.Interval)
+		price.IntervalCount = p.Recurring.IntervalCount
+		price.TrialPeriodDays = p.Recurring.TrialPeriodDays
+	}
+	if p.Product != nil {
+		price.ProductID = p.Product.ID
+		price.ProductName = p.Product.Name
+		price.ProductDescription = p.Product.Description
+		price.ProductActive = p.Product.Active && !p.Product.Deleted
+	}
+	return price
+}
+
+// GetCoupon возвращает купон вместе с продуктами, к которым он применим.
+func (sc *stripeClient) GetCoupon(ctx context.Context, couponID string) (*Coupon, error) {
+	params := &stripe.CouponParams{}
+	params.Context = ctx
+	params.AddExpand("applies_to")
+
+	c, err := sc.client.Coupons.Get(couponID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil, fmt.Errorf("%w: coupon %s", ErrResourceMissing, couponID)
+		}
+		logStripeError(sc.log, "GetCoupon", err)
+		return nil, fmt.Errorf("stripe: failed to get coupon: %w", err)
+	}
+	if c.Deleted {
+		return nil, fmt.Errorf("%w: coupon %s", ErrResourceMissing, couponID)
+	}
+	return mapCoupon(c), nil
+}
+
+// FindPromotionCode ищет промокод по коду. Неактивные промокоды тоже возвращаются,
+// чтобы отличить истекший код от несуществующего.
+func (sc *stripeClient) FindPromotionCode(ctx context.Context, code string) (*PromotionCode, error) {
+	params := &stripe.PromotionCodeListParams{
+		Code: stripe.String(code),
+	}
+	params.Context = ctx
+	params.Limit = stripe.Int64(10)
+	params.AddExpand("data.coupon.applies_to")
+
+	var found *stripe.PromotionCode
+	iter := sc.client.PromotionCodes.List(params)
+	for iter.Next() {
+		p := iter.PromotionCode()
+		if found == nil || (p.Active && !found.Active) {
+			found = p
+		}
+	}
+	if err := iter.Err(); err != nil {
+		logStripeError(sc.log, "FindPromotionCode", err)
+		return nil, fmt.Errorf("stripe: failed to list promotion codes: %w", err)
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: promotion code %q", ErrResourceMissing, code)
+	}
+
+	promo := &PromotionCode{
+		ID:     found.ID,
+		Code:   found.Code,
+		Active: found.Active,
+	}
+	if found.Coupon != nil {
+		promo.Coupon = mapCoupon(found.Coupon)
+	}
+	if found.Customer != nil {
+		promo.CustomerID = found.Customer.ID
+	}
+	if found.ExpiresAt > 0 {
+		expiresAt := time.Unix(found.ExpiresAt, 0).UTC()
+		promo.ExpiresAt = &expiresAt
+	}
+	if found.Restrictions != nil {
+		promo.FirstTimeTransaction = found.Restrictions.FirstTimeTransaction
+		promo.MinimumAmount = found.Restrictions.MinimumAmount
+		promo.MinimumAmountCurrency = string(found.Restrictions.MinimumAmountCurrency)
+	}
+	return promo, nil
+}
+
+// mapCoupon преобразует купон SDK в Coupon.
+func mapCoupon(c *stripe.Coupon) *Coupon {
+	coupon := &Coupon{
+		ID:               c.ID,
+		Name:             c.Name,
+		PercentOff:       c.PercentOff,
+		AmountOff:        c.AmountOff,
+		Currency:         string(c.Currency),
+		Duration:         string(c.Duration),
+		DurationInMonths: c.DurationInMonths,
+		Valid:            c.Valid,
+	}
+	if c.AppliesTo != nil {
+		coupon.AppliesToProducts = c.AppliesTo.Products
+	}
+	return coupon
+}
+
+// mapDiscount преобразует скидку SDK в Discount (nil, если скидки нет).
+func mapDiscount(d *stripe.Discount) *Discount {
+	if d == nil || d.Deleted || d.Coupon == nil {
+		return nil
+	}
+	discount := &Discount{
+		Coupon: mapCoupon(d.Coupon),
+		Start:  time.Unix(d.Start, 0).UTC(),
+	}
+	if d.PromotionCode != nil {
+		discount.PromotionCodeID = d.PromotionCode.ID
+	}
+	if d.End > 0 {
+		end := time.Unix(d.End, 0).UTC()
+		discount.End = &end
+	}
+	return discount
+}
+
 // CreateSubscription создает подписку в Stripe для указанного клиента и плана.
-func (sc *stripeClient) CreateSubscription(ctx context.Context, stripeCustomerID, planID, idempotencyKey string) (string, string, error) {
+// С пробным периодом первый счет нулевой: подписка сразу получает статус trialing, а карта
+// сохраняется через pending_setup_intent. Если к концу пробного периода способа оплаты нет,
+// Stripe отменяет подписку (trial_settings.end_behavior.missing_payment_method = cancel).
+func (sc *stripeClient) CreateSubscription(ctx context.Context, sub NewSubscription) (*CreatedSubscription, error) {
 	params := &stripe.SubscriptionParams{
-		Customer: stripe.String(stripeCustomerID),
+		Customer: stripe.String(sub.CustomerID),
 		Items: []*stripe.SubscriptionItemsParams{
 			{
-				Price: stripe.String(planID),
+				Price: stripe.String(sub.PriceID),
 			},
 		},
 		PaymentBehavior: stripe.String("default_incomplete"), //SubscriptionPaymentBehaviorDefaultIncomplete
 		Params: stripe.Params{
-			IdempotencyKey: stripe.String(idempotencyKey),
+			IdempotencyKey: stripe.String(sub.IdempotencyKey),
 			Context:        ctx,
 		},
 	}
+	trial := sub.TrialPeriodDays > 0 || sub.TrialEnd != nil
+	switch {
+	case sub.TrialEnd != nil:
+		params.TrialEnd = stripe.Int64(sub.TrialEnd.Unix())
+	case sub.TrialPeriodDays > 0:
+		params.TrialPeriodDays = stripe.Int64(sub.TrialPeriodDays)
+	}
+	if trial {
+		params.TrialSettings = &stripe.SubscriptionTrialSettingsParams{
+			EndBehavior: &stripe.SubscriptionTrialSettingsEndBehaviorParams{
+				MissingPaymentMethod: stripe.String(string(stripe.SubscriptionTrialSettingsEndBehaviorMissingPaymentMethodCancel)),
+			},
+		}
+		params.PaymentSettings = &stripe.SubscriptionPaymentSettingsParams{
+			SaveDefaultPaymentMethod: stripe.String(string(stripe.SubscriptionPaymentSettingsSaveDefaultPaymentMethodOnSubscription)),
+		}
+		params.AddExpand("pending_setup_intent")
+	}
+	switch {
+	case sub.PromotionCodeID != "":
+		params.PromotionCode = stripe.String(sub.PromotionCodeID)
+	case sub.CouponID != "":
+		params.Coupon = stripe.String(sub.CouponID)
+	}
 	// Используем AddExpand для получения PaymentIntent
 	params.AddExpand("latest_invoice.payment_intent")
 
@@ -133,25 +707,39 @@ func (sc *stripeClient) CreateSubscription(ctx context.Context, stripeCustomerID
 	subscription, err := sc.client.Subscriptions.New(params)
 	if err != nil {
 		logStripeError(sc.log, "CreateSubscription", err)
-		return "", "", fmt.Errorf("stripe: failed to create subscription: %w", err)
+		return nil, fmt.Errorf("stripe: failed to create subscription: %w", err)
 	}
 
-	sc.log.Infow("Stripe subscription created", "stripeSubscriptionID", subscription.ID, "status", string(subscription.Status))
+	sc.log.Infow("Stripe subscription created. StripeSubID: %s, Status: %s", subscription.ID, subscription.Status)
+
+	created := &CreatedSubscription{
+		ID:       subscription.ID,
+		Status:   string(subscription.Status),
+		Discount: mapDiscount(subscription.Discount),
+	}
+	if subscription.TrialEnd > 0 {
+		trialStart := time.Unix(subscription.TrialStart, 0).UTC()
+		trialEnd := time.Unix(subscription.TrialEnd, 0).UTC()
+		created.TrialStart, created.TrialEnd = &trialStart, &trialEnd
+	}
 
 	// Извлекаем client_secret
-	clientSecret := ""
-	if subscription.LatestInvoice != nil && subscription.LatestInvoice.PaymentIntent != nil {
-		clientSecret = subscription.LatestInvoice.PaymentIntent.ClientSecret
-		sc.log.Debugw("Retrieved client secret from payment intent", "stripeSubscriptionID", subscription.ID, "paymentIntentID", subscription.LatestInvoice.PaymentIntent.ID)
-	} else {
-		sc.log.Warnw("No payment intent or client secret found in created subscription", "stripeSubscriptionID", subscription.ID, "status", string(subscription.Status))
+	switch {
+	case subscription.LatestInvoice != nil && subscription.LatestInvoice.PaymentIntent != nil:
+		created.ClientSecret = subscription.LatestInvoice.PaymentIntent.ClientSecret
+		sc.log.Debugw("Retrieved client secret from payment intent. StripeSubID: %s, PaymentIntentID: %s", subscription.ID, subscription.LatestInvoice.PaymentIntent.ID)
+	case subscription.PendingSetupIntent != nil:
+		created.ClientSecret = subscription.PendingSetupIntent.ClientSecret
+		sc.log.Debugw("Retrieved client secret from setup intent. StripeSubID: %s, SetupIntentID: %s", subscription.ID, subscription.PendingSetupIntent.ID)
+	default:
+		sc.log.Warnw("No payment or setup intent client secret found in created subscription. StripeSubID: %s, Status: %s", subscription.ID, subscription.Status)
 	}
 
-	return subscription.ID, clientSecret, nil
+	return created, nil
 }
 
-// CancelSubscription отменяет подписку в Stripe немедленно.
-func (sc *stripeClient) CancelSubscription(ctx context.Context, stripeSubscriptionID string) error {
+// CancelSubscription отменяет подписку в Stripe немедленно и возвращает canceled_at из ответа Stripe.
+func (sc *stripeClient) CancelSubscription(ctx context.Context, stripeSubscriptionID string) (*time.Time, error) {
 	params := &stripe.SubscriptionCancelParams{
 		Params: stripe.Params{
 			Context: ctx,
@@ -159,22 +747,721 @@ func (sc *stripeClient) CancelSubscription(ctx context.Context, stripeSubscripti
 	}
 
 	// Отменяем подписку через sc.client.Subscriptions.Cancel
-	_, err := sc.client.Subscriptions.Cancel(stripeSubscriptionID, params)
+	canceled, err := sc.client.Subscriptions.Cancel(stripeSubscriptionID, params)
 	if err != nil {
 		// Обрабатываем случай, если подписка уже удалена
 		stripeErr, ok := err.(*stripe.Error)
 		if ok && stripeErr.Code == stripe.ErrorCodeResourceMissing {
 			sc.log.Warnw("Attempted to cancel already canceled/missing Stripe subscription", "stripeSubscriptionID", stripeSubscriptionID)
-			return nil
+			return nil, nil
 		}
 		logStripeError(sc.log, "CancelSubscription", err)
-		return fmt.Errorf("stripe: failed to cancel subscription: %w", err)
+		return nil, fmt.Errorf("stripe: failed to cancel subscription: %w", err)
 	}
 
 	sc.log.Infow("Stripe subscription canceled", "stripeSubscriptionID", stripeSubscriptionID)
+	if canceled.CanceledAt == 0 {
+		return nil, nil
+	}
+	canceledAt := time.Unix(canceled.CanceledAt, 0)
+	return &canceledAt, nil
+}
+
+// SetCancelAtPeriodEnd планирует отмену подписки в конце текущего периода или снимает ее.
+// Возвращает момент, когда подписка будет отменена (nil, если отмена снята).
+// Stripe не позволяет менять cancel_at_period_end у подписки под управлением расписания,
+// поэтому запланированная смена плана отпускается: после отмены она все равно не наступит.
+func (sc *stripeClient) SetCancelAtPeriodEnd(ctx context.Context, stripeSubscriptionID string, cancelAtPeriodEnd bool, idempotencyKey string) (*time.Time, error) {
+	params := &stripe.SubscriptionParams{}
+	params.Context = ctx
+	params.AddExpand("schedule")
+
+	sub, err := sc.client.Subscriptions.Get(stripeSubscriptionID, params)
+	if err != nil {
+		logStripeError(sc.log, "GetSubscription", err)
+		return nil, fmt.Errorf("stripe: failed to get subscription: %w", err)
+	}
+	if err := sc.releaseSchedule(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	updateParams := &stripe.SubscriptionParams{
+		CancelAtPeriodEnd: stripe.Bool(cancelAtPeriodEnd),
+	}
+	updateParams.Context = ctx
+	if idempotencyKey != "" {
+		updateParams.IdempotencyKey = stripe.String(idempotencyKey)
+	}
+
+	updated, err := sc.client.Subscriptions.Update(sub.ID, updateParams)
+	if err != nil {
+		logStripeError(sc.log, "SetCancelAtPeriodEnd", err)
+		return nil, fmt.Errorf("stripe: failed to update cancel_at_period_end: %w", err)
+	}
+
+	sc.log.Infow("Stripe subscription cancel_at_period_end updated. StripeSubID: %s, CancelAtPeriodEnd: %t", sub.ID, cancelAtPeriodEnd)
+	if !updated.CancelAtPeriodEnd {
+		return nil, nil
+	}
+	cancelAt := updated.CancelAt
+	if cancelAt == 0 {
+		cancelAt = updated.CurrentPeriodEnd
+	}
+	t := time.Unix(cancelAt, 0).UTC()
+	return &t, nil
+}
+
+// PauseSubscription приостанавливает списания по подписке. Статус подписки в Stripe при этом не меняется.
+func (sc *stripeClient) PauseSubscription(ctx context.Context, stripeSubscriptionID string, pause PauseCollection, idempotencyKey string) error {
+	params := &stripe.SubscriptionParams{
+		PauseCollection: &stripe.SubscriptionPauseCollectionParams{
+			Behavior: stripe.String(pause.Behavior),
+		},
+	}
+	if pause.ResumesAt != nil {
+		params.PauseCollection.ResumesAt = stripe.Int64(pause.ResumesAt.Unix())
+	}
+	params.Context = ctx
+	if idempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(idempotencyKey)
+	}
+
+	if _, err := sc.client.Subscriptions.Update(stripeSubscriptionID, params); err != nil {
+		logStripeError(sc.log, "PauseSubscription", err)
+		return fmt.Errorf("stripe: failed to pause subscription collection: %w", err)
+	}
+
+	sc.log.Infow("Stripe subscription collection paused. StripeSubID: %s, Behavior: %s", stripeSubscriptionID, pause.Behavior)
 	return nil
 }
 
+// UnpauseSubscription возобновляет списания по подписке (pause_collection сбрасывается пустым значением).
+func (sc *stripeClient) UnpauseSubscription(ctx context.Context, stripeSubscriptionID, idempotencyKey string) (string, error) {
+	params := &stripe.SubscriptionParams{}
+	params.AddExtra("pause_collection", "")
+	params.Context = ctx
+	if idempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(idempotencyKey)
+	}
+
+	sub, err := sc.client.Subscriptions.Update(stripeSubscriptionID, params)
+	if err != nil {
+		logStripeError(sc.log, "UnpauseSubscription", err)
+		return "", fmt.Errorf("stripe: failed to resume subscription collection: %w", err)
+	}
+
+	sc.log.Infow("Stripe subscription collection resumed. StripeSubID: %s, Status: %s", stripeSubscriptionID, sub.Status)
+	return string(sub.Status), nil
+}
+
+// releaseSchedule отпускает активное расписание подписки (запланированную смену плана), если оно есть.
+// sub должна быть получена с expand schedule.
+func (sc *stripeClient) releaseSchedule(ctx context.Context, sub *stripe.Subscription) error {
+	if sub.Schedule == nil || sub.Schedule.Status == stripe.SubscriptionScheduleStatusReleased {
+		return nil
+	}
+	releaseParams := &stripe.SubscriptionScheduleReleaseParams{}
+	releaseParams.Context = ctx
+	if _, err := sc.client.SubscriptionSchedules.Release(sub.Schedule.ID, releaseParams); err != nil {
+		logStripeError(sc.log, "ReleaseSubscriptionSchedule", err)
+		return fmt.Errorf("stripe: failed to release subscription schedule: %w", err)
+	}
+	sc.log.Infow("Pending plan change released. StripeSubID: %s, ScheduleID: %s", sub.ID, sub.Schedule.ID)
+	return nil
+}
+
+// ChangeSubscriptionPlan меняет цену единственного элемента подписки.
+// Немедленная смена выполняется с перерасчетом (create_prorations): разница за остаток периода
+// попадает в следующий счет. Смена с конца периода оформляется расписанием подписки из двух фаз:
+// текущая цена до конца периода, затем новая; после этого расписание отпускает подписку (release).
+func (sc *stripeClient) ChangeSubscriptionPlan(ctx context.Context, change PlanChange) (*PlanChangeResult, error) {
+	params := &stripe.SubscriptionParams{}
+	params.Context = ctx
+	params.AddExpand("schedule")
+
+	sub, err := sc.client.Subscriptions.Get(change.SubscriptionID, params)
+	if err != nil {
+		logStripeError(sc.log, "GetSubscription", err)
+		return nil, fmt.Errorf("stripe: failed to get subscription: %w", err)
+	}
+	if sub.Items == nil || len(sub.Items.Data) == 0 {
+		return nil, fmt.Errorf("stripe: subscription %s has no items", sub.ID)
+	}
+	item := sub.Items.Data[0]
+
+	if change.AtPeriodEnd {
+		return sc.schedulePlanChange(ctx, sub, item, change)
+	}
+
+	// Немедленная смена отменяет ранее запланированную: расписание нужно отпустить,
+	// иначе при переходе к следующей фазе оно вернет цену из расписания
+	if err := sc.releaseSchedule(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	updateParams := &stripe.SubscriptionParams{
+		Items: []*stripe.SubscriptionItemsParams{
+			{
+				ID:    stripe.String(item.ID),
+				Price: stripe.String(change.PriceID),
+			},
+		},
+		ProrationBehavior: stripe.String(prorationBehaviorCreateProrations),
+	}
+	if change.ProrationDate > 0 {
+		updateParams.ProrationDate = stripe.Int64(change.ProrationDate)
+	}
+	updateParams.Context = ctx
+	if change.IdempotencyKey != "" {
+		updateParams.IdempotencyKey = stripe.String(change.IdempotencyKey)
+	}
+
+	if _, err := sc.client.Subscriptions.Update(sub.ID, updateParams); err != nil {
+		logStripeError(sc.log, "ChangeSubscriptionPlan", err)
+		return nil, fmt.Errorf("stripe: failed to change subscription plan: %w", err)
+	}
+
+	effectiveAt := time.Now()
+	if change.ProrationDate > 0 {
+		effectiveAt = time.Unix(change.ProrationDate, 0)
+	}
+	sc.log.Infow("Stripe subscription plan changed. StripeSubID: %s, PriceID: %s", sub.ID, change.PriceID)
+	return &PlanChangeResult{EffectiveAt: effectiveAt.UTC()}, nil
+}
+
+// schedulePlanChange откладывает смену цены до конца текущего периода через расписание подписки.
+func (sc *stripeClient) schedulePlanChange(ctx context.Context, sub *stripe.Subscription, item *stripe.SubscriptionItem, change PlanChange) (*PlanChangeResult, error) {
+	scheduleID := ""
+	if sub.Schedule != nil && sub.Schedule.Status != stripe.SubscriptionScheduleStatusReleased {
+		scheduleID = sub.Schedule.ID
+	} else {
+		createParams := &stripe.SubscriptionScheduleParams{
+			FromSubscription: stripe.String(sub.ID),
+		}
+		createParams.Context = ctx
+		if change.IdempotencyKey != "" {
+			createParams.IdempotencyKey = stripe.String(change.IdempotencyKey + "-schedule")
+		}
+		schedule, err := sc.client.SubscriptionSchedules.New(createParams)
+		if err != nil {
+			logStripeError(sc.log, "CreateSubscriptionSchedule", err)
+			return nil, fmt.Errorf("stripe: failed to create subscription schedule: %w", err)
+		}
+		scheduleID = schedule.ID
+	}
+
+	quantity := item.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	currentPriceID := ""
+	if item.Price != nil {
+		currentPriceID = item.Price.ID
+	}
+
+	updateParams := &stripe.SubscriptionScheduleParams{
+		EndBehavior: stripe.String(string(stripe.SubscriptionScheduleEndBehaviorRelease)),
+		Phases: []*stripe.SubscriptionSchedulePhaseParams{
+			{
+				Items: []*stripe.SubscriptionSchedulePhaseItemParams{
+					{Price: stripe.String(currentPriceID), Quantity: stripe.Int64(quantity)},
+				},
+				StartDate: stripe.Int64(sub.CurrentPeriodStart),
+				EndDate:   stripe.Int64(sub.CurrentPeriodEnd),
+			},
+			{
+				Items: []*stripe.SubscriptionSchedulePhaseItemParams{
+					{Price: stripe.String(change.PriceID), Quantity: stripe.Int64(quantity)},
+				},
+				Iterations:        stripe.Int64(1),
+				ProrationBehavior: stripe.String(prorationBehaviorNone),
+			},
+		},
+	}
+	updateParams.Context = ctx
+	if change.IdempotencyKey != "" {
+		updateParams.IdempotencyKey = stripe.String(change.IdempotencyKey)
+	}
+
+	if _, err := sc.client.SubscriptionSchedules.Update(scheduleID, updateParams); err != nil {
+		logStripeError(sc.log, "UpdateSubscriptionSchedule", err)
+		return nil, fmt.Errorf("stripe: failed to schedule plan change: %w", err)
+	}
+
+	sc.log.Infow("Stripe subscription plan change scheduled. StripeSubID: %s, PriceID: %s, ScheduleID: %s", sub.ID, change.PriceID, scheduleID)
+	return &PlanChangeResult{
+		EffectiveAt: time.Unix(sub.CurrentPeriodEnd, 0).UTC(),
+		ScheduleID:  scheduleID,
+	}, nil
+}
+
+// PreviewPlanChange запрашивает upcoming invoice с новой ценой. Для немедленной смены Stripe
+// включает в счет строки перерасчета на момент ProrationDate; для смены с конца периода
+// перерасчета нет и счет показывает первый платеж по новой цене.
+func (sc *stripeClient) PreviewPlanChange(ctx context.Context, change PlanChange) (*InvoicePreview, error) {
+	subParams := &stripe.SubscriptionParams{}
+	subParams.Context = ctx
+	sub, err := sc.client.Subscriptions.Get(change.SubscriptionID, subParams)
+	if err != nil {
+		logStripeError(sc.log, "GetSubscription", err)
+		return nil, fmt.Errorf("stripe: failed to get subscription: %w", err)
+	}
+	if sub.Items == nil || len(sub.Items.Data) == 0 {
+		return nil, fmt.Errorf("stripe: subscription %s has no items", sub.ID)
+	}
+
+	prorationDate := change.ProrationDate
+	if prorationDate == 0 {
+		prorationDate = time.Now().Unix()
+	}
+	params := &stripe.InvoiceUpcomingParams{
+		Subscription: stripe.String(sub.ID),
+		SubscriptionItems: []*stripe.SubscriptionItemsParams{
+			{
+				ID:    stripe.String(sub.Items.Data[0].ID),
+				Price: stripe.String(change.PriceID),
+			},
+		},
+	}
+	if change.AtPeriodEnd {
+		params.SubscriptionProrationBehavior = stripe.String(prorationBehaviorNone)
+	} else {
+		params.SubscriptionProrationBehavior = stripe.String(prorationBehaviorCreateProrations)
+		params.SubscriptionProrationDate = stripe.Int64(prorationDate)
+	}
+	params.Context = ctx
+
+	inv, err := sc.client.Invoices.Upcoming(params)
+	if err != nil {
+		logStripeError(sc.log, "PreviewPlanChange", err)
+		return nil, fmt.Errorf("stripe: failed to preview plan change: %w", err)
+	}
+
+	preview := &InvoicePreview{
+		AmountDue:   inv.AmountDue,
+		Subtotal:    inv.Subtotal,
+		Total:       inv.Total,
+		Currency:    string(inv.Currency),
+		PeriodStart: time.Unix(inv.PeriodStart, 0).UTC(),
+		PeriodEnd:   time.Unix(inv.PeriodEnd, 0).UTC(),
+	}
+	if !change.AtPeriodEnd {
+		preview.ProrationDate = prorationDate
+	}
+	if inv.NextPaymentAttempt > 0 {
+		preview.NextPaymentAt = time.Unix(inv.NextPaymentAttempt, 0).UTC()
+	}
+	if inv.Lines != nil {
+		for _, line := range inv.Lines.Data {
+			if line.Proration {
+				preview.ProrationAmount += line.Amount
+			}
+		}
+	}
+	return preview, nil
+}
+
+// CreateSetupIntent создает SetupIntent для карты, которую можно списывать без участия клиента (off_session).
+func (sc *stripeClient) CreateSetupIntent(ctx context.Context, stripeCustomerID, idempotencyKey string) (*SetupIntent, error) {
+	params := &stripe.SetupIntentParams{
+		Customer:           stripe.String(stripeCustomerID),
+		Usage:              stripe.String(string(stripe.SetupIntentUsageOffSession)),
+		PaymentMethodTypes: stripe.StringSlice([]string{string(stripe.PaymentMethodTypeCard)}),
+	}
+	params.Context = ctx
+	if idempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(idempotencyKey)
+	}
+
+	si, err := sc.client.SetupIntents.New(params)
+	if err != nil {
+		logStripeError(sc.log, "CreateSetupIntent", err)
+		return nil, fmt.Errorf("stripe: failed to create setup intent: %w", err)
+	}
+
+	sc.log.Infow("Stripe setup intent created. StripeCustomerID: %s, SetupIntentID: %s", stripeCustomerID, si.ID)
+	return &SetupIntent{ID: si.ID, ClientSecret: si.ClientSecret, Status: string(si.Status)}, nil
+}
+
+// ListPaymentMethods возвращает карты клиента. Карта по умолчанию берется из invoice_settings клиента.
+func (sc *stripeClient) ListPaymentMethods(ctx context.Context, stripeCustomerID string) ([]*PaymentMethod, error) {
+	customerParams := &stripe.CustomerParams{}
+	customerParams.Context = ctx
+	cus, err := sc.client.Customers.Get(stripeCustomerID, customerParams)
+	if err != nil {
+		logStripeError(sc.log, "GetCustomer", err)
+		return nil, fmt.Errorf("stripe: failed to get customer: %w", err)
+	}
+	defaultID := ""
+	if cus.InvoiceSettings != nil && cus.InvoiceSettings.DefaultPaymentMethod != nil {
+		defaultID = cus.InvoiceSettings.DefaultPaymentMethod.ID
+	}
+
+	params := &stripe.PaymentMethodListParams{
+		Customer: stripe.String(stripeCustomerID),
+		Type:     stripe.String(string(stripe.PaymentMethodTypeCard)),
+	}
+	params.Context = ctx
+	params.Limit = stripe.Int64(100)
+
+	var methods []*PaymentMethod
+	iter := sc.client.PaymentMethods.List(params)
+	for iter.Next() {
+		pm := mapPaymentMethod(iter.PaymentMethod())
+		pm.IsDefault = pm.ID == defaultID
+		methods = append(methods, pm)
+	}
+	if err := iter.Err(); err != nil {
+		logStripeError(sc.log, "ListPaymentMethods", err)
+		return nil, fmt.Errorf("stripe: failed to list payment methods: %w", err)
+	}
+	return methods, nil
+}
+
+// GetPaymentMethod возвращает способ оплаты по ID.
+func (sc *stripeClient) GetPaymentMethod(ctx context.Context, paymentMethodID string) (*PaymentMethod, error) {
+	params := &stripe.PaymentMethodParams{}
+	params.Context = ctx
+
+	pm, err := sc.client.PaymentMethods.Get(paymentMethodID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil, fmt.Errorf("%w: payment method %s", ErrResourceMissing, paymentMethodID)
+		}
+		logStripeError(sc.log, "GetPaymentMethod", err)
+		return nil, fmt.Errorf("stripe: failed to get payment method: %w", err)
+	}
+	return mapPaymentMethod(pm), nil
+}
+
+// AttachPaymentMethod привязывает способ оплаты к клиенту. Карта, привязанная к другому клиенту, отклоняется Stripe.
+func (sc *stripeClient) AttachPaymentMethod(ctx context.Context, stripeCustomerID, paymentMethodID, idempotencyKey string) (*PaymentMethod, error) {
+	params := &stripe.PaymentMethodAttachParams{
+		Customer: stripe.String(stripeCustomerID),
+	}
+	params.Context = ctx
+	if idempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(idempotencyKey)
+	}
+
+	pm, err := sc.client.PaymentMethods.Attach(paymentMethodID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil, fmt.Errorf("%w: payment method %s", ErrResourceMissing, paymentMethodID)
+		}
+		logStripeError(sc.log, "AttachPaymentMethod", err)
+		return nil, fmt.Errorf("stripe: failed to attach payment method: %w", err)
+	}
+
+	sc.log.Infow("Stripe payment method attached. StripeCustomerID: %s, PaymentMethodID: %s", stripeCustomerID, pm.ID)
+	return mapPaymentMethod(pm), nil
+}
+
+// DetachPaymentMethod отвязывает способ оплаты. Если это была карта по умолчанию, Stripe сбрасывает ее у клиента.
+func (sc *stripeClient) DetachPaymentMethod(ctx context.Context, paymentMethodID string) error {
+	params := &stripe.PaymentMethodDetachParams{}
+	params.Context = ctx
+
+	if _, err := sc.client.PaymentMethods.Detach(paymentMethodID, params); err != nil {
+		logStripeError(sc.log, "DetachPaymentMethod", err)
+		return fmt.Errorf("stripe: failed to detach payment method: %w", err)
+	}
+
+	sc.log.Infow("Stripe payment method detached. PaymentMethodID: %s", paymentMethodID)
+	return nil
+}
+
+// SetDefaultPaymentMethod меняет invoice_settings.default_payment_method клиента. Подписки без собственной
+// карты по умолчанию используют ее для следующих списаний, в том числе для повторных попыток по неоплаченным счетам.
+func (sc *stripeClient) SetDefaultPaymentMethod(ctx context.Context, stripeCustomerID, paymentMethodID string) error {
+	params := &stripe.CustomerParams{
+		InvoiceSettings: &stripe.CustomerInvoiceSettingsParams{
+			DefaultPaymentMethod: stripe.String(paymentMethodID),
+		},
+	}
+	params.Context = ctx
+
+	if _, err := sc.client.Customers.Update(stripeCustomerID, params); err != nil {
+		logStripeError(sc.log, "SetDefaultPaymentMethod", err)
+		return fmt.Errorf("stripe: failed to set default payment method: %w", err)
+	}
+
+	sc.log.Infow("Stripe default payment method updated. StripeCustomerID: %s, PaymentMethodID: %s", stripeCustomerID, paymentMethodID)
+	return nil
+}
+
+// mapPaymentMethod преобразует способ оплаты SDK в PaymentMethod.
+func mapPaymentMethod(pm *stripe.PaymentMethod) *PaymentMethod {
+	method := &PaymentMethod{
+		ID:      pm.ID,
+		Created: time.Unix(pm.Created, 0).UTC(),
+	}
+	if pm.Customer != nil {
+		method.CustomerID = pm.Customer.ID
+	}
+	if pm.Card != nil {
+		method.Brand = string(pm.Card.Brand)
+		method.Last4 = pm.Card.Last4
+		method.ExpMonth = pm.Card.ExpMonth
+		method.ExpYear = pm.Card.ExpYear
+	}
+	return method
+}
+
+// GetSubscription возвращает подписку вместе с ценой, пробным периодом и скидкой.
+func (sc *stripeClient) GetSubscription(ctx context.Context, stripeSubscriptionID string) (*Subscription, error) {
+	params := &stripe.SubscriptionParams{}
+	params.Context = ctx
+
+	sub, err := sc.client.Subscriptions.Get(stripeSubscriptionID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil, fmt.Errorf("%w: subscription %s", ErrResourceMissing, stripeSubscriptionID)
+		}
+		logStripeError(sc.log, "GetSubscription", err)
+		return nil, fmt.Errorf("stripe: failed to get subscription: %w", err)
+	}
+
+	result := &Subscription{
+		ID:               sub.ID,
+		Status:           string(sub.Status),
+		CurrentPeriodEnd: time.Unix(sub.CurrentPeriodEnd, 0).UTC(),
+		Discount:         mapDiscount(sub.Discount),
+	}
+	if sub.Customer != nil {
+		result.CustomerID = sub.Customer.ID
+	}
+	if sub.Items != nil && len(sub.Items.Data) > 0 && sub.Items.Data[0].Price != nil {
+		result.PriceID = sub.Items.Data[0].Price.ID
+	}
+	if sub.TrialEnd > 0 {
+		trialStart := time.Unix(sub.TrialStart, 0).UTC()
+		trialEnd := time.Unix(sub.TrialEnd, 0).UTC()
+		result.TrialStart, result.TrialEnd = &trialStart, &trialEnd
+	}
+	return result, nil
+}
+
+// CreateCheckoutSession создает сессию Checkout в режиме subscription. Подписку создаст Stripe после оплаты;
+// сервис узнает о ней из вебхука checkout.session.completed.
+func (sc *stripeClient) CreateCheckoutSession(ctx context.Context, session NewCheckoutSession) (*CheckoutSession, error) {
+	params := &stripe.CheckoutSessionParams{
+		Mode:              stripe.String(string(stripe.CheckoutSessionModeSubscription)),
+		Customer:          stripe.String(session.CustomerID),
+		ClientReferenceID: stripe.String(session.UserID),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				Price:    stripe.String(session.PriceID),
+				Quantity: stripe.Int64(1),
+			},
+		},
+		SuccessURL: stripe.String(session.SuccessURL),
+		CancelURL:  stripe.String(session.CancelURL),
+		SubscriptionData: &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: map[string]string{metadataUserIDKey: session.UserID},
+		},
+	}
+	if session.TrialPeriodDays > 0 {
+		params.SubscriptionData.TrialPeriodDays = stripe.Int64(session.TrialPeriodDays)
+	}
+	if session.AllowPromotionCodes {
+		params.AllowPromotionCodes = stripe.Bool(true)
+	}
+	params.AddMetadata(metadataUserIDKey, session.UserID)
+	params.Context = ctx
+	if session.IdempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(session.IdempotencyKey)
+	}
+
+	cs, err := sc.client.CheckoutSessions.New(params)
+	if err != nil {
+		logStripeError(sc.log, "CreateCheckoutSession", err)
+		return nil, fmt.Errorf("stripe: failed to create checkout session: %w", err)
+	}
+
+	sc.log.Infow("Stripe checkout session created. StripeCustomerID: %s, SessionID: %s, PriceID: %s", session.CustomerID, cs.ID, session.PriceID)
+	return &CheckoutSession{
+		ID:        cs.ID,
+		URL:       cs.URL,
+		ExpiresAt: time.Unix(cs.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// CreateBillingPortalSession создает сессию портала, где клиент сам управляет картами, счетами и подписками.
+func (sc *stripeClient) CreateBillingPortalSession(ctx context.Context, stripeCustomerID, returnURL string) (string, error) {
+	params := &stripe.BillingPortalSessionParams{
+		Customer:  stripe.String(stripeCustomerID),
+		ReturnURL: stripe.String(returnURL),
+	}
+	params.Context = ctx
+
+	ps, err := sc.client.BillingPortalSessions.New(params)
+	if err != nil {
+		logStripeError(sc.log, "CreateBillingPortalSession", err)
+		return "", fmt.Errorf("stripe: failed to create billing portal session: %w", err)
+	}
+
+	sc.log.Infow("Stripe billing portal session created. StripeCustomerID: %s, SessionID: %s", stripeCustomerID, ps.ID)
+	return ps.URL, nil
+}
+
+// GetCharge возвращает платеж.
+func (sc *stripeClient) GetCharge(ctx context.Context, chargeID string) (*Charge, error) {
+	params := &stripe.ChargeParams{}
+	params.Context = ctx
+
+	ch, err := sc.client.Charges.Get(chargeID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil, fmt.Errorf("%w: charge %s", ErrResourceMissing, chargeID)
+		}
+		logStripeError(sc.log, "GetCharge", err)
+		return nil, fmt.Errorf("stripe: failed to get charge: %w", err)
+	}
+	return mapCharge(ch), nil
+}
+
+// GetInvoiceCharge возвращает платеж по счету (счет запрашивается с раскрытым charge).
+func (sc *stripeClient) GetInvoiceCharge(ctx context.Context, invoiceID string) (*Charge, error) {
+	params := &stripe.InvoiceParams{}
+	params.Context = ctx
+	params.AddExpand("charge")
+
+	inv, err := sc.client.Invoices.Get(invoiceID, params)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil, fmt.Errorf("%w: invoice %s", ErrResourceMissing, invoiceID)
+		}
+		logStripeError(sc.log, "GetInvoiceCharge", err)
+		return nil, fmt.Errorf("stripe: failed to get invoice: %w", err)
+	}
+	if inv.Charge == nil || inv.Charge.ID == "" {
+		return nil, nil
+	}
+	charge := mapCharge(inv.Charge)
+	if charge.InvoiceID == "" {
+		charge.InvoiceID = inv.ID
+	}
+	return charge, nil
+}
+
+// CreateRefund создает возврат платежа.
+func (sc *stripeClient) CreateRefund(ctx context.Context, refund NewRefund) (*Refund, error) {
+	params := &stripe.RefundParams{
+		Charge: stripe.String(refund.ChargeID),
+	}
+	if refund.Amount > 0 {
+		params.Amount = stripe.Int64(refund.Amount)
+	}
+	if refund.Reason != "" {
+		params.Reason = stripe.String(refund.Reason)
+	}
+	params.Context = ctx
+	if refund.IdempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(refund.IdempotencyKey)
+	}
+
+	re, err := sc.client.Refunds.New(params)
+	if err != nil {
+		logStripeError(sc.log, "CreateRefund", err)
+		return nil, fmt.Errorf("stripe: failed to create refund: %w", err)
+	}
+
+	sc.log.Infow("Stripe refund created. ChargeID: %s, RefundID: %s, Amount: %d, Status: %s", refund.ChargeID, re.ID, re.Amount, re.Status)
+	return mapRefund(re), nil
+}
+
+// ListRefunds возвращает возвраты платежа.
+func (sc *stripeClient) ListRefunds(ctx context.Context, chargeID string) ([]*Refund, error) {
+	params := &stripe.RefundListParams{
+		Charge: stripe.String(chargeID),
+	}
+	params.Context = ctx
+
+	var refunds []*Refund
+	iter := sc.client.Refunds.List(params)
+	for iter.Next() {
+		refunds = append(refunds, mapRefund(iter.Refund()))
+	}
+	if err := iter.Err(); err != nil {
+		logStripeError(sc.log, "ListRefunds", err)
+		return nil, fmt.Errorf("stripe: failed to list refunds: %w", err)
+	}
+	return refunds, nil
+}
+
+// CreateCreditNote выпускает кредит-ноту на сумму Amount.
+// Для оплаченного счета часть RefundAmount возвращается на карту, остальное зачисляется на баланс клиента.
+func (sc *stripeClient) CreateCreditNote(ctx context.Context, creditNote NewCreditNote) (*CreditNote, error) {
+	params := &stripe.CreditNoteParams{
+		Invoice: stripe.String(creditNote.InvoiceID),
+		Amount:  stripe.Int64(creditNote.Amount),
+	}
+	if creditNote.RefundAmount > 0 {
+		params.RefundAmount = stripe.Int64(creditNote.RefundAmount)
+		if rest := creditNote.Amount - creditNote.RefundAmount; rest > 0 {
+			params.CreditAmount = stripe.Int64(rest)
+		}
+	}
+	if creditNote.Reason != "" {
+		params.Reason = stripe.String(creditNote.Reason)
+	}
+	if creditNote.Memo != "" {
+		params.Memo = stripe.String(creditNote.Memo)
+	}
+	params.Context = ctx
+	if creditNote.IdempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(creditNote.IdempotencyKey)
+	}
+
+	cn, err := sc.client.CreditNotes.New(params)
+	if err != nil {
+		logStripeError(sc.log, "CreateCreditNote", err)
+		return nil, fmt.Errorf("stripe: failed to create credit note: %w", err)
+	}
+
+	sc.log.Infow("Stripe credit note created. InvoiceID: %s, CreditNoteID: %s, Amount: %d", creditNote.InvoiceID, cn.ID, cn.Total)
+	return mapCreditNote(cn), nil
+}
+
+// CreatePaymentIntent создает PaymentIntent разового платежа. Способы оплаты подключаются автоматически
+// (из настроек Stripe), подтверждение выполняет фронтенд по ClientSecret.
+func (sc *stripeClient) CreatePaymentIntent(ctx context.Context, pi NewPaymentIntent) (*PaymentIntent, error) {
+	params := &stripe.PaymentIntentParams{
+		Customer: stripe.String(pi.CustomerID),
+		Amount:   stripe.Int64(pi.Amount),
+		Currency: stripe.String(pi.Currency),
+		AutomaticPaymentMethods: &stripe.PaymentIntentAutomaticPaymentMethodsParams{
+			Enabled: stripe.Bool(true),
+		},
+	}
+	if pi.Description != "" {
+		params.Description = stripe.String(pi.Description)
+	}
+	for k, v := range pi.Metadata {
+		params.AddMetadata(k, v)
+	}
+	params.AddMetadata(metadataUserIDKey, pi.UserID)
+	params.Context = ctx
+	if pi.IdempotencyKey != "" {
+		params.IdempotencyKey = stripe.String(pi.IdempotencyKey)
+	}
+
+	intent, err := sc.client.PaymentIntents.New(params)
+	if err != nil {
+		logStripeError(sc.log, "CreatePaymentIntent", err)
+		return nil, fmt.Errorf("stripe: failed to create payment intent: %w", err)
+	}
+
+	sc.log.Infow("Stripe payment intent created. StripeCustomerID: %s, PaymentIntentID: %s, Amount: %d %s", pi.CustomerID, intent.ID, intent.Amount, intent.Currency)
+	return mapPaymentIntent(intent), nil
+}
+
 // logStripeError - вспомогательная функция для логирования деталей ошибки Stripe.
 func logStripeError(log *logger.Logger, operation string, err error) {
 	var stripeErr *stripe.Error
@@ -195,3 +1482,98 @@ func logStripeError(log *logger.Logger, operation string, err error) {
 		)
 	}
 }
+
+// mapCharge преобразует платеж Stripe во внутреннюю модель.
+func mapCharge(ch *stripe.Charge) *Charge {
+	charge := &Charge{
+		ID:             ch.ID,
+		Amount:         ch.Amount,
+		AmountRefunded: ch.AmountRefunded,
+		Currency:       string(ch.Currency),
+		Refunded:       ch.Refunded,
+	}
+	if ch.Customer != nil {
+		charge.CustomerID = ch.Customer.ID
+	}
+	if ch.Invoice != nil {
+		charge.InvoiceID = ch.Invoice.ID
+	}
+	if ch.PaymentIntent != nil {
+		charge.PaymentIntentID = ch.PaymentIntent.ID
+	}
+	return charge
+}
+
+// mapRefund преобразует возврат Stripe во внутреннюю модель.
+func mapRefund(re *stripe.Refund) *Refund {
+	refund := &Refund{
+		ID:            re.ID,
+		Amount:        re.Amount,
+		Currency:      string(re.Currency),
+		Status:        string(re.Status),
+		Reason:        string(re.Reason),
+		FailureReason: string(re.FailureReason),
+		Created:       time.Unix(re.Created, 0).UTC(),
+	}
+	if re.Charge != nil {
+		refund.ChargeID = re.Charge.ID
+	}
+	if re.PaymentIntent != nil {
+		refund.PaymentIntentID = re.PaymentIntent.ID
+	}
+	return refund
+}
+
+// mapCreditNote преобразует кредит-ноту Stripe во внутреннюю модель.
+func mapCreditNote(cn *stripe.CreditNote) *CreditNote {
+	creditNote := &CreditNote{
+		ID:       cn.ID,
+		Number:   cn.Number,
+		Type:     string(cn.Type),
+		Status:   string(cn.Status),
+		Reason:   string(cn.Reason),
+		Memo:     cn.Memo,
+		Amount:   cn.Total,
+		Currency: string(cn.Currency),
+		Created:  time.Unix(cn.Created, 0).UTC(),
+	}
+	if cn.Invoice != nil {
+		creditNote.InvoiceID = cn.Invoice.ID
+	}
+	if cn.Customer != nil {
+		creditNote.CustomerID = cn.Customer.ID
+	}
+	if cn.Refund != nil {
+		creditNote.RefundID = cn.Refund.ID
+	}
+	if cn.VoidedAt > 0 {
+		voidedAt := time.Unix(cn.VoidedAt, 0).UTC()
+		creditNote.VoidedAt = &voidedAt
+	}
+	return creditNote
+}
+
+// mapPaymentIntent преобразует PaymentIntent SDK в PaymentIntent.
+func mapPaymentIntent(pi *stripe.PaymentIntent) *PaymentIntent {
+	intent := &PaymentIntent{
+		ID:             pi.ID,
+		Amount:         pi.Amount,
+		AmountReceived: pi.AmountReceived,
+		Currency:       string(pi.Currency),
+		Status:         string(pi.Status),
+		Description:    pi.Description,
+		Metadata:       pi.Metadata,
+		ClientSecret:   pi.ClientSecret,
+		Created:        time.Unix(pi.Created, 0).UTC(),
+	}
+	if pi.Customer != nil {
+		intent.CustomerID = pi.Customer.ID
+	}
+	if pi.Invoice != nil {
+		intent.InvoiceID = pi.Invoice.ID
+	}
+	if pi.LastPaymentError != nil {
+		intent.LastPaymentError = pi.LastPaymentError.Msg
+	}
+	return intent
+}
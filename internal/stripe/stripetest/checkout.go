@@ -0,0 +1,203 @@
+package stripetest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// checkoutSessionLifetime время жизни сессии Checkout (в Stripe по умолчанию 24 часа).
+const checkoutSessionLifetime = 24 * time.Hour
+
+// CheckoutSession сессия Stripe Checkout в режиме subscription.
+type CheckoutSession struct {
+	ID                   string
+	Customer             string
+	ClientReferenceID    string
+	PriceID              string
+	TrialPeriodDays      int64
+	Metadata             map[string]string
+	SubscriptionMetadata map[string]string // subscription_data[metadata] - переносятся в созданную подписку
+	AllowPromotionCodes  bool
+	SuccessURL           string
+	CancelURL            string
+	Status               string // open, complete, expired
+	PaymentStatus        string // unpaid, paid, no_payment_required
+	Subscription         string // Подписка, созданная при завершении сессии
+	ExpiresAt            int64
+	Created              int64
+}
+
+// CheckoutSession возвращает копию сессии Checkout по ID.
+func (s *Server) CheckoutSession(id string) (*CheckoutSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.checkoutSessions[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *cs
+	return &copied, true
+}
+
+// CompleteCheckoutSession завершает сессию, как будто клиент оплатил подписку на странице Checkout:
+// создается и оплачивается подписка, затем отправляется checkout.session.completed.
+// promotionCodeID - промокод, введенный на странице (только если сессия разрешает промокоды).
+// Возвращает ID созданной подписки.
+func (s *Server) CompleteCheckoutSession(id, promotionCodeID string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.checkoutSessions[id]
+	if !ok || cs.Status != "open" {
+		return "", false
+	}
+	if promotionCodeID != "" && !cs.AllowPromotionCodes {
+		return "", false
+	}
+
+	form := url.Values{}
+	form.Set("customer", cs.Customer)
+	form.Set("items[0][price]", cs.PriceID)
+	if cs.TrialPeriodDays > 0 {
+		form.Set("trial_period_days", strconv.FormatInt(cs.TrialPeriodDays, 10))
+	}
+	if promotionCodeID != "" {
+		form.Set("promotion_code", promotionCodeID)
+	}
+	for k, v := range cs.SubscriptionMetadata {
+		form.Set("metadata["+k+"]", v)
+	}
+	// Платеж на странице Checkout уже подтвержден, поэтому подписка создается без default_incomplete
+	if _, apiErr := s.createSubscription(&http.Request{Form: form}); apiErr != nil {
+		return "", false
+	}
+	ids := s.order["subscription"]
+	sub := s.subscriptions[ids[len(ids)-1]]
+
+	cs.Subscription = sub.ID
+	cs.Status = "complete"
+	cs.PaymentStatus = "paid"
+	if sub.TrialEnd != 0 {
+		cs.PaymentStatus = "no_payment_required"
+	}
+	s.emit("checkout.session.completed", renderCheckoutSession(cs), nil)
+	return sub.ID, true
+}
+
+// ExpireCheckoutSession закрывает открытую сессию без оплаты.
+func (s *Server) ExpireCheckoutSession(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs, ok := s.checkoutSessions[id]
+	if !ok || cs.Status != "open" {
+		return false
+	}
+	cs.Status = "expired"
+	s.emit("checkout.session.expired", renderCheckoutSession(cs), nil)
+	return true
+}
+
+// --- Эндпоинты ---
+
+// createCheckoutSession поддерживает только режим subscription с одной ценой.
+func (s *Server) createCheckoutSession(r *http.Request) (interface{}, *apiError) {
+	if mode := r.Form.Get("mode"); mode != "subscription" {
+		return nil, invalidParam("mode", "Only mode=subscription is supported by stripetest.")
+	}
+	customerID := r.Form.Get("customer")
+	if c, ok := s.customers[customerID]; !ok || c.Deleted {
+		return nil, notFound("customer", customerID, "customer")
+	}
+	priceID := r.Form.Get("line_items[0][price]")
+	if priceID == "" {
+		return nil, invalidParam("line_items", "Missing required param: line_items[0][price].")
+	}
+	if price, ok := s.prices[priceID]; !ok || !price.Active {
+		return nil, notFound("price", priceID, "line_items[0][price]")
+	}
+	successURL := r.Form.Get("success_url")
+	if successURL == "" {
+		return nil, invalidParam("success_url", "Missing required param: success_url.")
+	}
+
+	created := time.Now()
+	cs := &CheckoutSession{
+		ID:                   s.newID("cs"),
+		Customer:             customerID,
+		ClientReferenceID:    r.Form.Get("client_reference_id"),
+		PriceID:              priceID,
+		Metadata:             formMap(r.Form, "metadata"),
+		SubscriptionMetadata: formMap(r.Form, "subscription_data[metadata]"),
+		AllowPromotionCodes:  r.Form.Get("allow_promotion_codes") == "true",
+		SuccessURL:           successURL,
+		CancelURL:            r.Form.Get("cancel_url"),
+		Status:               "open",
+		PaymentStatus:        "unpaid",
+		ExpiresAt:            created.Add(checkoutSessionLifetime).Unix(),
+		Created:              created.Unix(),
+	}
+	cs.TrialPeriodDays, _ = strconv.ParseInt(r.Form.Get("subscription_data[trial_period_days]"), 10, 64)
+	s.checkoutSessions[cs.ID] = cs
+	s.remember("checkout_session", cs.ID)
+	return renderCheckoutSession(cs), nil
+}
+
+func (s *Server) getCheckoutSession(id string) (interface{}, *apiError) {
+	cs, ok := s.checkoutSessions[id]
+	if !ok {
+		return nil, notFound("checkout.session", id, "session")
+	}
+	return renderCheckoutSession(cs), nil
+}
+
+// createBillingPortalSession возвращает сессию портала; сам портал не эмулируется.
+func (s *Server) createBillingPortalSession(r *http.Request) (interface{}, *apiError) {
+	customerID := r.Form.Get("customer")
+	if c, ok := s.customers[customerID]; !ok || c.Deleted {
+		return nil, notFound("customer", customerID, "customer")
+	}
+	id := s.newID("bps")
+	return map[string]interface{}{
+		"id":         id,
+		"object":     "billing_portal.session",
+		"customer":   customerID,
+		"return_url": r.Form.Get("return_url"),
+		"url":        s.URL() + "/billing_portal/" + id,
+		"created":    now(),
+		"livemode":   false,
+	}, nil
+}
+
+// --- Представление объектов в формате Stripe API ---
+
+func renderCheckoutSession(cs *CheckoutSession) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":                    cs.ID,
+		"object":                "checkout.session",
+		"mode":                  "subscription",
+		"customer":              cs.Customer,
+		"client_reference_id":   nil,
+		"metadata":              emptyIfNilMap(cs.Metadata),
+		"allow_promotion_codes": cs.AllowPromotionCodes,
+		"success_url":           cs.SuccessURL,
+		"cancel_url":            cs.CancelURL,
+		"status":                cs.Status,
+		"payment_status":        cs.PaymentStatus,
+		"subscription":          nil,
+		"url":                   nil,
+		"expires_at":            cs.ExpiresAt,
+		"created":               cs.Created,
+		"livemode":              false,
+	}
+	if cs.ClientReferenceID != "" {
+		out["client_reference_id"] = cs.ClientReferenceID
+	}
+	if cs.Subscription != "" {
+		out["subscription"] = cs.Subscription
+	}
+	if cs.Status == "open" {
+		out["url"] = "https://checkout.stripe.com/c/pay/" + cs.ID
+	}
+	return out
+}
@@ -0,0 +1,355 @@
+package stripetest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Coupon купон (скидка) поддельного Stripe.
+type Coupon struct {
+	ID               string
+	Name             string
+	PercentOff       float64  // Скидка в процентах (0 - скидка суммой)
+	AmountOff        int64    // Скидка суммой в минимальных единицах Currency
+	Currency         string   // Валюта AmountOff (по умолчанию usd)
+	Duration         string   // once, repeating, forever (по умолчанию once)
+	DurationInMonths int64    // Для repeating
+	AppliesTo        []string // Продукты, к которым применим купон (пусто - ко всем)
+	MaxRedemptions   int64    // 0 - без ограничения
+	TimesRedeemed    int64
+	RedeemBy         int64 // После этого момента купон нельзя применить (0 - бессрочно)
+	Created          int64
+}
+
+// PromotionCode промокод - код для клиентов, который применяет купон.
+type PromotionCode struct {
+	ID                    string
+	Code                  string
+	Coupon                string
+	Active                bool
+	Customer              string // Промокод доступен только этому клиенту (пусто - всем)
+	ExpiresAt             int64  // 0 - бессрочно
+	MaxRedemptions        int64
+	TimesRedeemed         int64
+	FirstTimeTransaction  bool  // Только для клиентов без оплаченных счетов
+	MinimumAmount         int64 // Минимальная сумма первого счета
+	MinimumAmountCurrency string
+	Created               int64
+}
+
+// AddCoupon добавляет купон (или заменяет существующий).
+func (s *Server) AddCoupon(c Coupon) {
+	if c.Currency == "" {
+		c.Currency = "usd"
+	}
+	if c.Duration == "" {
+		c.Duration = "once"
+	}
+	if c.Created == 0 {
+		c.Created = now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.coupons[c.ID]; !ok {
+		s.remember("coupon", c.ID)
+	}
+	s.coupons[c.ID] = &c
+}
+
+// AddPromotionCode добавляет активный промокод к купону и возвращает его ID.
+func (s *Server) AddPromotionCode(p PromotionCode) string {
+	if p.Created == 0 {
+		p.Created = now()
+	}
+	p.Active = true
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.ID == "" {
+		p.ID = s.newID("promo")
+	}
+	if _, ok := s.promotionCodes[p.ID]; !ok {
+		s.remember("promotion_code", p.ID)
+	}
+	s.promotionCodes[p.ID] = &p
+	return p.ID
+}
+
+// DeactivatePromotionCode выключает промокод (active=false).
+func (s *Server) DeactivatePromotionCode(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.promotionCodes[id]
+	if !ok {
+		return false
+	}
+	p.Active = false
+	return true
+}
+
+// --- Внутренние операции (вызываются под s.mu) ---
+
+// couponValid сообщает, можно ли еще применить купон.
+func couponValid(c *Coupon) bool {
+	return (c.MaxRedemptions == 0 || c.TimesRedeemed < c.MaxRedemptions) && (c.RedeemBy == 0 || now() < c.RedeemBy)
+}
+
+// promotionCodeActive сообщает, можно ли применить промокод: как в Stripe, он неактивен, если истек
+// срок, исчерпан лимит или недействителен купон.
+func (s *Server) promotionCodeActive(p *PromotionCode) bool {
+	c, ok := s.coupons[p.Coupon]
+	return p.Active && ok && couponValid(c) &&
+		(p.ExpiresAt == 0 || now() < p.ExpiresAt) &&
+		(p.MaxRedemptions == 0 || p.TimesRedeemed < p.MaxRedemptions)
+}
+
+// hasPaidInvoices сообщает, платил ли клиент раньше (ограничение first_time_transaction).
+func (s *Server) hasPaidInvoices(customerID string) bool {
+	for _, inv := range s.invoices {
+		if inv.Customer == customerID && inv.Status == "paid" && inv.AmountPaid > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDiscount проверяет coupon или promotion_code запроса создания подписки и привязывает скидку к подписке.
+func (s *Server) applyDiscount(sub *Subscription, price *Price, r *http.Request) *apiError {
+	couponID := r.Form.Get("coupon")
+	promotionCodeID := r.Form.Get("promotion_code")
+	if couponID == "" && promotionCodeID == "" {
+		return nil
+	}
+	if couponID != "" && promotionCodeID != "" {
+		return invalidParam("coupon", "You may only specify one of these parameters: coupon, promotion_code.")
+	}
+
+	var promo *PromotionCode
+	if promotionCodeID != "" {
+		p, ok := s.promotionCodes[promotionCodeID]
+		if !ok {
+			return notFound("promotion_code", promotionCodeID, "promotion_code")
+		}
+		if !s.promotionCodeActive(p) {
+			return invalidParam("promotion_code", "This promotion code cannot be redeemed because it is inactive or expired.")
+		}
+		if p.Customer != "" && p.Customer != sub.Customer {
+			return invalidParam("promotion_code", "This promotion code cannot be redeemed by this customer.")
+		}
+		if p.FirstTimeTransaction && s.hasPaidInvoices(sub.Customer) {
+			return invalidParam("promotion_code", "This promotion code is only valid for first time customers.")
+		}
+		if p.MinimumAmount > 0 && (price.UnitAmount < p.MinimumAmount || !strings.EqualFold(price.Currency, p.MinimumAmountCurrency)) {
+			return invalidParam("promotion_code", "This promotion code requires a minimum order amount.")
+		}
+		promo = p
+		couponID = p.Coupon
+	}
+
+	c, ok := s.coupons[couponID]
+	if !ok {
+		return notFound("coupon", couponID, "coupon")
+	}
+	if !couponValid(c) {
+		return invalidParam("coupon", "Coupon expired: "+couponID)
+	}
+	if len(c.AppliesTo) > 0 && !contains(c.AppliesTo, price.Product) {
+		return invalidParam("coupon", "This coupon cannot be applied to the price "+price.ID+".")
+	}
+	if c.AmountOff > 0 && !strings.EqualFold(c.Currency, price.Currency) {
+		return invalidParam("coupon", "The coupon currency does not match the currency of the price "+price.ID+".")
+	}
+
+	c.TimesRedeemed++
+	sub.Coupon = c.ID
+	sub.DiscountID = s.newID("di")
+	sub.DiscountStart = sub.Created
+	sub.DiscountEnd = 0
+	if c.Duration == "repeating" {
+		sub.DiscountEnd = time.Unix(sub.Created, 0).AddDate(0, int(c.DurationInMonths), 0).Unix()
+	}
+	if promo != nil {
+		promo.TimesRedeemed++
+		sub.PromotionCode = promo.ID
+	}
+	return nil
+}
+
+// discountAmount скидка подписки на счет с суммой amount; once-купон учитывается только в первом ненулевом счете.
+func (s *Server) discountAmount(sub *Subscription, amount int64) int64 {
+	c, ok := s.coupons[sub.Coupon]
+	if !ok || amount <= 0 {
+		return 0
+	}
+	sub.discountUsed = true
+	if c.PercentOff > 0 {
+		return int64(float64(amount) * c.PercentOff / 100)
+	}
+	return min(c.AmountOff, amount)
+}
+
+// discountEnded сообщает, закончилось ли действие скидки подписки к моменту at.
+func (s *Server) discountEnded(sub *Subscription, at int64) bool {
+	c, ok := s.coupons[sub.Coupon]
+	if !ok {
+		return false
+	}
+	switch c.Duration {
+	case "once":
+		return sub.discountUsed
+	case "repeating":
+		return sub.DiscountEnd != 0 && sub.DiscountEnd <= at
+	}
+	return false
+}
+
+// removeDiscount снимает скидку с подписки и отправляет customer.discount.deleted.
+func (s *Server) removeDiscount(sub *Subscription) {
+	if discount := s.renderDiscount(sub); discount != nil {
+		s.emit("customer.discount.deleted", discount, nil)
+	}
+	sub.Coupon, sub.PromotionCode, sub.DiscountID = "", "", ""
+	sub.DiscountStart, sub.DiscountEnd = 0, 0
+	sub.discountUsed = false
+}
+
+// --- Эндпоинты ---
+
+func (s *Server) getCoupon(id string, r *http.Request) (interface{}, *apiError) {
+	c, ok := s.coupons[id]
+	if !ok {
+		return nil, notFound("coupon", id, "id")
+	}
+	return renderCoupon(c, contains(formList(r.Form, "expand"), "applies_to")), nil
+}
+
+// listPromotionCodes поддерживает фильтры code (без учета регистра), active, coupon и customer.
+func (s *Server) listPromotionCodes(r *http.Request) (interface{}, *apiError) {
+	expandAppliesTo := contains(formList(r.Form, "expand"), "data.coupon.applies_to")
+	var data []interface{}
+	for _, id := range s.order["promotion_code"] {
+		p := s.promotionCodes[id]
+		if code := r.Form.Get("code"); code != "" && !strings.EqualFold(p.Code, code) {
+			continue
+		}
+		if active := r.Form.Get("active"); active != "" && strconv.FormatBool(s.promotionCodeActive(p)) != active {
+			continue
+		}
+		if coupon := r.Form.Get("coupon"); coupon != "" && p.Coupon != coupon {
+			continue
+		}
+		if customer := r.Form.Get("customer"); customer != "" && p.Customer != customer {
+			continue
+		}
+		data = append(data, s.renderPromotionCode(p, expandAppliesTo))
+	}
+	return renderList("/v1/promotion_codes", data, r.Form), nil
+}
+
+// --- Представление объектов в формате Stripe API ---
+
+// renderCoupon поддерживает expand applies_to.
+func renderCoupon(c *Coupon, expandAppliesTo bool) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":                 c.ID,
+		"object":             "coupon",
+		"name":               c.Name,
+		"percent_off":        nil,
+		"amount_off":         nil,
+		"currency":           nil,
+		"duration":           c.Duration,
+		"duration_in_months": nil,
+		"max_redemptions":    nil,
+		"times_redeemed":     c.TimesRedeemed,
+		"redeem_by":          nil,
+		"valid":              couponValid(c),
+		"created":            c.Created,
+		"livemode":           false,
+	}
+	if c.PercentOff > 0 {
+		out["percent_off"] = c.PercentOff
+	} else {
+		out["amount_off"] = c.AmountOff
+		out["currency"] = c.Currency
+	}
+	if c.Duration == "repeating" {
+		out["duration_in_months"] = c.DurationInMonths
+	}
+	if c.MaxRedemptions > 0 {
+		out["max_redemptions"] = c.MaxRedemptions
+	}
+	if c.RedeemBy > 0 {
+		out["redeem_by"] = c.RedeemBy
+	}
+	if expandAppliesTo && len(c.AppliesTo) > 0 {
+		out["applies_to"] = map[string]interface{}{"products": c.AppliesTo}
+	}
+	return out
+}
+
+func (s *Server) renderPromotionCode(p *PromotionCode, expandAppliesTo bool) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":              p.ID,
+		"object":          "promotion_code",
+		"code":            p.Code,
+		"active":          s.promotionCodeActive(p),
+		"coupon":          nil,
+		"customer":        nil,
+		"expires_at":      nil,
+		"max_redemptions": nil,
+		"times_redeemed":  p.TimesRedeemed,
+		"restrictions": map[string]interface{}{
+			"first_time_transaction":  p.FirstTimeTransaction,
+			"minimum_amount":          nil,
+			"minimum_amount_currency": nil,
+		},
+		"created":  p.Created,
+		"livemode": false,
+	}
+	if c, ok := s.coupons[p.Coupon]; ok {
+		out["coupon"] = renderCoupon(c, expandAppliesTo)
+	}
+	if p.Customer != "" {
+		out["customer"] = p.Customer
+	}
+	if p.ExpiresAt > 0 {
+		out["expires_at"] = p.ExpiresAt
+	}
+	if p.MaxRedemptions > 0 {
+		out["max_redemptions"] = p.MaxRedemptions
+	}
+	if p.MinimumAmount > 0 {
+		restrictions := out["restrictions"].(map[string]interface{})
+		restrictions["minimum_amount"] = p.MinimumAmount
+		restrictions["minimum_amount_currency"] = p.MinimumAmountCurrency
+	}
+	return out
+}
+
+// renderDiscount возвращает скидку подписки или nil, если скидки нет.
+func (s *Server) renderDiscount(sub *Subscription) map[string]interface{} {
+	c, ok := s.coupons[sub.Coupon]
+	if !ok {
+		return nil
+	}
+	out := map[string]interface{}{
+		"id":             sub.DiscountID,
+		"object":         "discount",
+		"coupon":         renderCoupon(c, false),
+		"customer":       sub.Customer,
+		"subscription":   sub.ID,
+		"promotion_code": nil,
+		"start":          sub.DiscountStart,
+		"end":            nil,
+	}
+	if sub.PromotionCode != "" {
+		out["promotion_code"] = sub.PromotionCode
+	}
+	if sub.DiscountEnd != 0 {
+		out["end"] = sub.DiscountEnd
+	}
+	return out
+}
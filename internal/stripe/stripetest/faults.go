@@ -0,0 +1,163 @@
+package stripetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Типы ошибок Stripe API
+const (
+	errorTypeAPI            = "api_error"
+	errorTypeCard           = "card_error"
+	errorTypeIdempotency    = "idempotency_error"
+	errorTypeInvalidRequest = "invalid_request_error"
+)
+
+// apiError ошибка в формате Stripe ({"error": {...}}).
+type apiError struct {
+	status      int
+	Type        string `json:"type"`
+	Code        string `json:"code,omitempty"`
+	DeclineCode string `json:"decline_code,omitempty"`
+	Message     string `json:"message"`
+	Param       string `json:"param,omitempty"`
+}
+
+func (e *apiError) body() []byte {
+	body, _ := json.Marshal(map[string]*apiError{"error": e})
+	return body
+}
+
+func writeError(w http.ResponseWriter, e *apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body())
+}
+
+// notFound ошибка отсутствующего объекта (resource_missing).
+func notFound(kind, id, param string) *apiError {
+	return &apiError{
+		status:  http.StatusNotFound,
+		Type:    errorTypeInvalidRequest,
+		Code:    "resource_missing",
+		Message: "No such " + kind + ": '" + id + "'",
+		Param:   param,
+	}
+}
+
+// invalidParam ошибка неверного параметра запроса.
+func invalidParam(param, message string) *apiError {
+	return &apiError{
+		status:  http.StatusBadRequest,
+		Type:    errorTypeInvalidRequest,
+		Code:    "parameter_invalid",
+		Message: message,
+		Param:   param,
+	}
+}
+
+// Fault сбой, который сервер вернет вместо обработки подходящих запросов.
+type Fault struct {
+	Method      string // HTTP метод (пусто - любой)
+	Path        string // Префикс пути, например "/v1/subscriptions" (пусто - любой)
+	StatusCode  int
+	Type        string // Тип ошибки Stripe (api_error, card_error, invalid_request_error)
+	Code        string
+	DeclineCode string
+	Message     string
+	Times       int // Сколько запросов затронуть (0 - один)
+}
+
+// RateLimit сбой 429 Too Many Requests. stripe-go не повторяет такие запросы (повторяется только 429 lock_timeout).
+func RateLimit(method, path string, times int) Fault {
+	return Fault{
+		Method:     method,
+		Path:       path,
+		StatusCode: http.StatusTooManyRequests,
+		Type:       errorTypeInvalidRequest,
+		Code:       "rate_limit",
+		Message:    "Too many requests hit the API too quickly.",
+		Times:      times,
+	}
+}
+
+// ServerError сбой 500 на стороне Stripe. stripe-go повторяет такие запросы (по умолчанию до 2 раз).
+func ServerError(method, path string, times int) Fault {
+	return Fault{
+		Method:     method,
+		Path:       path,
+		StatusCode: http.StatusInternalServerError,
+		Type:       errorTypeAPI,
+		Message:    "An unknown error occurred.",
+		Times:      times,
+	}
+}
+
+// CardDeclined ошибка карты 402 (declineCode, например, insufficient_funds; пусто - generic_decline).
+func CardDeclined(method, path, declineCode string) Fault {
+	if declineCode == "" {
+		declineCode = "generic_decline"
+	}
+	return Fault{
+		Method:      method,
+		Path:        path,
+		StatusCode:  http.StatusPaymentRequired,
+		Type:        errorTypeCard,
+		Code:        "card_declined",
+		DeclineCode: declineCode,
+		Message:     "Your card was declined.",
+		Times:       1,
+	}
+}
+
+// InjectFault добавляет сбой. Сбои проверяются в порядке добавления.
+func (s *Server) InjectFault(f Fault) {
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	if f.StatusCode == 0 {
+		f.StatusCode = http.StatusInternalServerError
+	}
+	if f.Type == "" {
+		f.Type = errorTypeAPI
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults удаляет все невыполненные сбои.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault возвращает сбой для запроса и уменьшает его счетчик. Вызывается под s.mu.
+func (s *Server) takeFault(method, path string) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != method {
+			continue
+		}
+		if f.Path != "" && !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		f.Times--
+		if f.Times <= 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+		return f
+	}
+	return nil
+}
+
+func (f *Fault) apiError() *apiError {
+	return &apiError{
+		status:      f.StatusCode,
+		Type:        f.Type,
+		Code:        f.Code,
+		DeclineCode: f.DeclineCode,
+		Message:     f.Message,
+	}
+}
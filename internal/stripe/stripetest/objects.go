@@ -0,0 +1,1214 @@
+package stripetest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Price цена (план) в каталоге поддельного Stripe.
+type Price struct {
+	ID         string
+	Product    string
+	Nickname   string
+	UnitAmount int64  // Сумма в минимальных единицах валюты (центах)
+	Currency   string // По умолчанию usd
+	Interval   string // day, week, month, year (по умолчанию month)
+	TrialDays  int64  // recurring.trial_period_days (0 - без пробного периода)
+	Active     bool
+	Created    int64
+}
+
+// Product продукт Stripe, к которому относятся цены.
+type Product struct {
+	ID          string
+	Name        string
+	Description string
+	Active      bool
+	Created     int64
+}
+
+// Customer клиент Stripe.
+type Customer struct {
+	ID                   string
+	Email                string
+	Name                 string
+	Metadata             map[string]string
+	DefaultPaymentMethod string // invoice_settings.default_payment_method
+	Deleted              bool
+	Created              int64
+}
+
+// Subscription подписка Stripe с одним элементом (ценой).
+type Subscription struct {
+	ID                 string
+	Customer           string
+	PriceID            string
+	ItemID             string
+	Status             string
+	CancelAtPeriodEnd  bool
+	CurrentPeriodStart int64
+	CurrentPeriodEnd   int64
+	CanceledAt         int64
+	EndedAt            int64
+	LatestInvoice      string
+	Schedule           string // Активное расписание подписки (пусто, если нет)
+	PendingProration   int64  // Сумма перерасчетов, которая попадет в следующий счет
+	PauseBehavior      string // pause_collection.behavior (пусто, если списания не приостановлены)
+	PauseResumesAt     int64  // pause_collection.resumes_at (0 - возобновление только вручную)
+	TrialStart         int64
+	TrialEnd           int64  // Конец пробного периода (0 - без пробного периода)
+	PendingSetupIntent string // SetupIntent для сохранения карты, пока первый платеж не нужен
+	Coupon             string // Купон скидки (пусто - без скидки)
+	PromotionCode      string // Промокод, через который применен купон
+	DiscountID         string
+	DiscountStart      int64
+	DiscountEnd        int64 // Конец скидки для repeating-купона (0 - once или forever)
+	Metadata           map[string]string
+	Created            int64
+
+	discountUsed bool // Скидка уже попала в счет (once-купон снимается со следующего периода)
+}
+
+// Invoice счет Stripe.
+type Invoice struct {
+	ID            string
+	Customer      string
+	Subscription  string
+	Number        string
+	Status        string // draft, open, paid, void, uncollectible
+	Subtotal      int64  // Сумма до скидки
+	AmountDue     int64
+	AmountPaid    int64
+	Currency      string
+	PaymentIntent string
+	Charge        string // Платеж, которым оплачен счет
+	AttemptCount  int64
+	BillingReason string // subscription_create, subscription_cycle
+	PeriodStart   int64
+	PeriodEnd     int64
+	PaidAt        int64
+	Created       int64
+}
+
+// PaymentIntent платеж по счету или разовый платеж (без Invoice).
+type PaymentIntent struct {
+	ID               string
+	Customer         string
+	Invoice          string
+	Amount           int64
+	AmountReceived   int64
+	Currency         string
+	Status           string // requires_payment_method, processing, succeeded, canceled
+	Description      string
+	Metadata         map[string]string
+	ClientSecret     string
+	LatestCharge     string
+	LastPaymentError string // decline_code последней неудачной попытки
+	Created          int64
+}
+
+// SetupIntent сохранение способа оплаты без платежа (для пробного периода).
+type SetupIntent struct {
+	ID            string
+	Customer      string
+	PaymentMethod string // Карта, сохраненная при подтверждении
+	Status        string // requires_payment_method, succeeded
+	ClientSecret  string
+	Created       int64
+}
+
+// --- Управление состоянием из тестов ---
+
+// AddPrice добавляет цену в каталог. Подписку можно создать только на известную цену, как в Stripe.
+func (s *Server) AddPrice(p Price) {
+	if p.Currency == "" {
+		p.Currency = "usd"
+	}
+	if p.Interval == "" {
+		p.Interval = "month"
+	}
+	if p.Product == "" {
+		p.Product = "prod_" + p.ID
+	}
+	if p.Created == 0 {
+		p.Created = now()
+	}
+	p.Active = true
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.products[p.Product]; !ok {
+		name := p.Nickname
+		if name == "" {
+			name = p.ID
+		}
+		s.addProduct(&Product{ID: p.Product, Name: name})
+	}
+	eventType := "price.updated"
+	if _, ok := s.prices[p.ID]; !ok {
+		s.remember("price", p.ID)
+		eventType = "price.created"
+	}
+	s.prices[p.ID] = &p
+	s.emit(eventType, s.renderPrice(&p, false), nil)
+}
+
+// AddProduct добавляет продукт (или обновляет существующий). Цена без явного продукта получает продукт prod_<price ID>.
+func (s *Server) AddProduct(p Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.products[p.ID]; ok {
+		existing.Name = p.Name
+		existing.Description = p.Description
+		s.emit("product.updated", renderProduct(existing), nil)
+		return
+	}
+	s.addProduct(&p)
+}
+
+// ArchivePrice архивирует цену (active=false) и отправляет price.updated.
+func (s *Server) ArchivePrice(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.prices[id]
+	if !ok {
+		return false
+	}
+	p.Active = false
+	s.emit("price.updated", s.renderPrice(p, false), map[string]interface{}{"active": true})
+	return true
+}
+
+// ArchiveProduct архивирует продукт (active=false) и отправляет product.updated.
+func (s *Server) ArchiveProduct(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.products[id]
+	if !ok {
+		return false
+	}
+	p.Active = false
+	s.emit("product.updated", renderProduct(p), map[string]interface{}{"active": true})
+	return true
+}
+
+// AddCustomer создает клиента напрямую (например, клиента, существовавшего до появления таблицы customers).
+func (s *Server) AddCustomer(userID, email string) *Customer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.newCustomer(email, "", map[string]string{"user_id": userID})
+	copied := *c
+	return &copied
+}
+
+// Customer возвращает копию клиента по ID.
+func (s *Server) Customer(id string) (*Customer, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.customers[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *c
+	return &copied, true
+}
+
+// Subscription возвращает копию подписки по ID.
+func (s *Server) Subscription(id string) (*Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *sub
+	return &copied, true
+}
+
+// Invoice возвращает копию счета по ID.
+func (s *Server) Invoice(id string) (*Invoice, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *inv
+	return &copied, true
+}
+
+// SetSubscriptionStatus меняет статус подписки и отправляет customer.subscription.updated
+// (или customer.subscription.deleted для canceled).
+func (s *Server) SetSubscriptionStatus(id, status string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return false
+	}
+	if status == "canceled" {
+		s.cancel(sub)
+		return true
+	}
+	previous := sub.Status
+	sub.Status = status
+	s.emit("customer.subscription.updated", s.renderSubscription(sub, nil), map[string]interface{}{"status": previous})
+	return true
+}
+
+// PayInvoice проводит успешную оплату счета (как будто клиент подтвердил платеж на фронтенде).
+func (s *Server) PayInvoice(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[id]
+	if !ok {
+		return false
+	}
+	s.pay(inv)
+	return true
+}
+
+// FailInvoicePayment регистрирует неудачную попытку оплаты счета.
+func (s *Server) FailInvoicePayment(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invoices[id]
+	if !ok {
+		return false
+	}
+	inv.AttemptCount++
+	if pi, ok := s.paymentIntents[inv.PaymentIntent]; ok {
+		pi.Status = "requires_payment_method"
+	}
+	s.emit("invoice.payment_failed", s.renderInvoice(inv, nil), nil)
+
+	if sub, ok := s.subscriptions[inv.Subscription]; ok && sub.Status == "active" {
+		sub.Status = "past_due"
+		s.emit("customer.subscription.updated", s.renderSubscription(sub, nil), map[string]interface{}{"status": "active"})
+	}
+	return true
+}
+
+// --- Внутренние операции (вызываются под s.mu) ---
+
+func (s *Server) addProduct(p *Product) {
+	p.Active = true
+	if p.Created == 0 {
+		p.Created = now()
+	}
+	s.products[p.ID] = p
+	s.remember("product", p.ID)
+	s.emit("product.created", renderProduct(p), nil)
+}
+
+func (s *Server) newCustomer(email, name string, metadata map[string]string) *Customer {
+	c := &Customer{
+		ID:       s.newID("cus"),
+		Email:    email,
+		Name:     name,
+		Metadata: metadata,
+		Created:  now(),
+	}
+	s.customers[c.ID] = c
+	s.remember("customer", c.ID)
+	s.emit("customer.created", renderCustomer(c), nil)
+	return c
+}
+
+// pay отмечает счет оплаченным и активирует неоплаченную подписку.
+func (s *Server) pay(inv *Invoice) {
+	if inv.Status == "paid" {
+		return
+	}
+	inv.AttemptCount++
+	inv.Status = "paid"
+	inv.AmountPaid = inv.AmountDue
+	inv.PaidAt = now()
+	if pi, ok := s.paymentIntents[inv.PaymentIntent]; ok {
+		if inv.AmountPaid > 0 {
+			s.succeed(pi)
+			inv.Charge = pi.LatestCharge
+		} else {
+			// Счет погашен кредит-нотами: списывать с карты нечего
+			pi.Status = "succeeded"
+		}
+	}
+	s.emit("invoice.paid", s.renderInvoice(inv, nil), map[string]interface{}{"status": "open"})
+	s.emit("invoice.payment_succeeded", s.renderInvoice(inv, nil), nil)
+
+	if sub, ok := s.subscriptions[inv.Subscription]; ok && (sub.Status == "incomplete" || sub.Status == "past_due" || sub.Status == "unpaid") {
+		previous := sub.Status
+		sub.Status = "active"
+		s.emit("customer.subscription.updated", s.renderSubscription(sub, nil), map[string]interface{}{"status": previous})
+	}
+}
+
+// cancel немедленно отменяет подписку.
+func (s *Server) cancel(sub *Subscription) {
+	if sub.Status == "canceled" {
+		return
+	}
+	sub.Status = "canceled"
+	sub.CanceledAt = now()
+	sub.EndedAt = sub.CanceledAt
+	if inv, ok := s.invoices[sub.LatestInvoice]; ok && inv.Status == "open" {
+		s.setInvoiceStatus(inv, "void")
+		if pi, ok := s.paymentIntents[inv.PaymentIntent]; ok {
+			pi.Status = "canceled"
+		}
+	}
+	s.emit("customer.subscription.deleted", s.renderSubscription(sub, nil), nil)
+}
+
+// newInvoice выставляет и финализирует счет за период подписки вместе с PaymentIntent.
+// Скидка подписки уменьшает стоимость цены; накопленные перерасчеты включаются в счет
+// (сумма к оплате не бывает отрицательной).
+func (s *Server) newInvoice(sub *Subscription, price *Price, billingReason string) *Invoice {
+	inv := &Invoice{
+		ID:            s.newID("in"),
+		Customer:      sub.Customer,
+		Subscription:  sub.ID,
+		Number:        fmt.Sprintf("STRIPETEST-%04d", len(s.order["invoice"])+1),
+		Status:        "open",
+		Subtotal:      price.UnitAmount + sub.PendingProration,
+		AmountDue:     max(price.UnitAmount-s.discountAmount(sub, price.UnitAmount)+sub.PendingProration, 0),
+		Currency:      price.Currency,
+		BillingReason: billingReason,
+		PeriodStart:   sub.CurrentPeriodStart,
+		PeriodEnd:     sub.CurrentPeriodEnd,
+		Created:       now(),
+	}
+	sub.PendingProration = 0
+	if inv.AmountDue > 0 {
+		pi := &PaymentIntent{
+			ID:       s.newID("pi"),
+			Customer: sub.Customer,
+			Invoice:  inv.ID,
+			Amount:   inv.AmountDue,
+			Currency: inv.Currency,
+			Status:   "requires_payment_method",
+			Created:  inv.Created,
+		}
+		pi.ClientSecret = pi.ID + "_secret_stripetest"
+		s.paymentIntents[pi.ID] = pi
+		inv.PaymentIntent = pi.ID
+	}
+	s.invoices[inv.ID] = inv
+	s.remember("invoice", inv.ID)
+	s.emit("invoice.created", s.renderInvoice(inv, nil), nil)
+	s.emit("invoice.finalized", s.renderInvoice(inv, nil), nil)
+	return inv
+}
+
+// invoiceStatusEvents события Stripe, которыми сопровождается перевод счета в статус.
+var invoiceStatusEvents = map[string]string{
+	"draft":         "invoice.updated",
+	"void":          "invoice.voided",
+	"uncollectible": "invoice.marked_uncollectible",
+}
+
+// setInvoiceStatus переводит счет в статус draft, void или uncollectible и отправляет событие.
+func (s *Server) setInvoiceStatus(inv *Invoice, status string) {
+	previous := inv.Status
+	inv.Status = status
+	s.emit(invoiceStatusEvents[status], s.renderInvoice(inv, nil), map[string]interface{}{"status": previous})
+}
+
+// --- Эндпоинты ---
+
+func (s *Server) createCustomer(r *http.Request) (interface{}, *apiError) {
+	c := s.newCustomer(r.Form.Get("email"), r.Form.Get("name"), formMap(r.Form, "metadata"))
+	return renderCustomer(c), nil
+}
+
+func (s *Server) getCustomer(id string) (interface{}, *apiError) {
+	c, ok := s.customers[id]
+	if !ok {
+		return nil, notFound("customer", id, "id")
+	}
+	return renderCustomer(c), nil
+}
+
+func (s *Server) updateCustomer(id string, r *http.Request) (interface{}, *apiError) {
+	c, ok := s.customers[id]
+	if !ok || c.Deleted {
+		return nil, notFound("customer", id, "id")
+	}
+	previous := map[string]interface{}{}
+	if _, ok := r.Form["email"]; ok {
+		previous["email"] = c.Email
+		c.Email = r.Form.Get("email")
+	}
+	if _, ok := r.Form["name"]; ok {
+		previous["name"] = c.Name
+		c.Name = r.Form.Get("name")
+	}
+	if _, ok := r.Form["invoice_settings[default_payment_method]"]; ok {
+		pmID := r.Form.Get("invoice_settings[default_payment_method]")
+		if pm, ok := s.paymentMethods[pmID]; pmID != "" && (!ok || pm.Customer != c.ID) {
+			return nil, invalidParam("invoice_settings[default_payment_method]",
+				"The customer does not have a payment method with the ID "+pmID+". The payment method must be attached to the customer.")
+		}
+		previous["invoice_settings"] = renderInvoiceSettings(c)
+		c.DefaultPaymentMethod = pmID
+	}
+	if metadata := formMap(r.Form, "metadata"); len(metadata) > 0 {
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]string)
+		}
+		for k, v := range metadata {
+			c.Metadata[k] = v
+		}
+	}
+	s.emit("customer.updated", renderCustomer(c), previous)
+	return renderCustomer(c), nil
+}
+
+func (s *Server) deleteCustomer(id string) (interface{}, *apiError) {
+	c, ok := s.customers[id]
+	if !ok || c.Deleted {
+		return nil, notFound("customer", id, "id")
+	}
+	c.Deleted = true
+	for _, sub := range s.subscriptions {
+		if sub.Customer == id {
+			s.cancel(sub)
+		}
+	}
+	s.emit("customer.deleted", renderCustomer(c), nil)
+	return renderCustomer(c), nil
+}
+
+var (
+	searchMetadataClause = regexp.MustCompile(`^metadata\['([^']+)'\]:'([^']*)'$`)
+	searchFieldClause    = regexp.MustCompile(`^([a-z_]+):'([^']*)'$`)
+)
+
+// searchCustomers поддерживает запросы вида metadata['key']:'value' и email:'value', объединенные через AND.
+func (s *Server) searchCustomers(r *http.Request) (interface{}, *apiError) {
+	query := strings.TrimSpace(r.Form.Get("query"))
+	if query == "" {
+		return nil, invalidParam("query", "Missing required param: query.")
+	}
+
+	type clause struct{ metadataKey, field, value string }
+	var clauses []clause
+	for _, part := range strings.Split(query, " AND ") {
+		part = strings.TrimSpace(part)
+		if m := searchMetadataClause.FindStringSubmatch(part); m != nil {
+			clauses = append(clauses, clause{metadataKey: m[1], value: m[2]})
+		} else if m := searchFieldClause.FindStringSubmatch(part); m != nil && (m[1] == "email" || m[1] == "name") {
+			clauses = append(clauses, clause{field: m[1], value: m[2]})
+		} else {
+			return nil, invalidParam("query", "Unsupported search query: "+part)
+		}
+	}
+
+	var data []interface{}
+	for _, id := range s.order["customer"] {
+		c := s.customers[id]
+		if c.Deleted {
+			continue
+		}
+		matched := true
+		for _, cl := range clauses {
+			switch {
+			case cl.metadataKey != "":
+				matched = matched && c.Metadata[cl.metadataKey] == cl.value
+			case cl.field == "email":
+				matched = matched && c.Email == cl.value
+			case cl.field == "name":
+				matched = matched && c.Name == cl.value
+			}
+		}
+		if matched {
+			data = append(data, renderCustomer(c))
+		}
+	}
+
+	data, hasMore := limitList(data, r.Form)
+	return map[string]interface{}{
+		"object":    "search_result",
+		"url":       "/v1/customers/search",
+		"data":      emptyIfNil(data),
+		"has_more":  hasMore,
+		"next_page": nil,
+	}, nil
+}
+
+func (s *Server) createSubscription(r *http.Request) (interface{}, *apiError) {
+	customerID := r.Form.Get("customer")
+	c, ok := s.customers[customerID]
+	if !ok || c.Deleted {
+		return nil, notFound("customer", customerID, "customer")
+	}
+	priceID := r.Form.Get("items[0][price]")
+	if priceID == "" {
+		return nil, invalidParam("items", "Missing required param: items[0][price].")
+	}
+	price, ok := s.prices[priceID]
+	if !ok || !price.Active {
+		return nil, notFound("price", priceID, "items[0][price]")
+	}
+
+	start := time.Now()
+	sub := &Subscription{
+		ID:                 s.newID("sub"),
+		Customer:           customerID,
+		PriceID:            priceID,
+		ItemID:             s.newID("si"),
+		Status:             "incomplete",
+		CurrentPeriodStart: start.Unix(),
+		CurrentPeriodEnd:   periodEnd(start, price.Interval).Unix(),
+		Metadata:           formMap(r.Form, "metadata"),
+		Created:            start.Unix(),
+	}
+
+	// Пробный период: trial_end или trial_period_days (не более 730 дней, как в Stripe)
+	trialEnd, _ := strconv.ParseInt(r.Form.Get("trial_end"), 10, 64)
+	if days, _ := strconv.ParseInt(r.Form.Get("trial_period_days"), 10, 64); days > 0 && trialEnd == 0 {
+		trialEnd = start.AddDate(0, 0, int(days)).Unix()
+	}
+	if trialEnd != 0 {
+		if trialEnd <= start.Unix() {
+			return nil, invalidParam("trial_end", "Invalid timestamp: must be an integer Unix timestamp in the future.")
+		}
+		if trialEnd > start.AddDate(0, 0, 730).Unix() {
+			return nil, invalidParam("trial_end", "Invalid timestamp: can be no more than 730 days in the future.")
+		}
+		sub.Status = "trialing"
+		sub.TrialStart = start.Unix()
+		sub.TrialEnd = trialEnd
+		sub.CurrentPeriodEnd = trialEnd
+	}
+	if apiErr := s.applyDiscount(sub, price, r); apiErr != nil {
+		return nil, apiErr
+	}
+
+	s.subscriptions[sub.ID] = sub
+	s.remember("subscription", sub.ID)
+
+	invoicePrice := price
+	if sub.TrialEnd != 0 {
+		free := *price // Счет за пробный период нулевой
+		free.UnitAmount = 0
+		invoicePrice = &free
+	}
+	inv := s.newInvoice(sub, invoicePrice, "subscription_create")
+	sub.LatestInvoice = inv.ID
+	if sub.TrialEnd != 0 && r.Form.Get("payment_behavior") == "default_incomplete" {
+		si := &SetupIntent{
+			ID:       s.newID("seti"),
+			Customer: customerID,
+			Status:   "requires_payment_method",
+			Created:  start.Unix(),
+		}
+		si.ClientSecret = si.ID + "_secret_stripetest"
+		s.setupIntents[si.ID] = si
+		sub.PendingSetupIntent = si.ID
+	}
+	s.emit("customer.subscription.created", s.renderSubscription(sub, nil), nil)
+
+	// Бесплатный план активируется сразу. С default_incomplete подписка ждет подтверждения платежа
+	// клиентом (см. PayInvoice), иначе считаем, что платеж по сохраненной карте прошел.
+	if inv.AmountDue == 0 || r.Form.Get("payment_behavior") != "default_incomplete" {
+		s.pay(inv)
+	}
+
+	return s.renderSubscription(sub, formList(r.Form, "expand")), nil
+}
+
+func (s *Server) getSubscription(id string, r *http.Request) (interface{}, *apiError) {
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, notFound("subscription", id, "id")
+	}
+	return s.renderSubscription(sub, formList(r.Form, "expand")), nil
+}
+
+func (s *Server) listSubscriptions(r *http.Request) (interface{}, *apiError) {
+	status := r.Form.Get("status")
+	var data []interface{}
+	for _, id := range s.order["subscription"] {
+		sub := s.subscriptions[id]
+		if customer := r.Form.Get("customer"); customer != "" && sub.Customer != customer {
+			continue
+		}
+		if price := r.Form.Get("price"); price != "" && sub.PriceID != price {
+			continue
+		}
+		switch status {
+		case "", "all":
+			if status == "" && sub.Status == "canceled" {
+				continue // Как в Stripe: без фильтра отмененные подписки не возвращаются
+			}
+		default:
+			if sub.Status != status {
+				continue
+			}
+		}
+		data = append(data, s.renderSubscription(sub, nil))
+	}
+	return renderList("/v1/subscriptions", data, r.Form), nil
+}
+
+func (s *Server) updateSubscription(id string, r *http.Request) (interface{}, *apiError) {
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, notFound("subscription", id, "id")
+	}
+	if sub.Status == "canceled" {
+		return nil, invalidParam("", "A canceled subscription can only update its cancellation_details and metadata.")
+	}
+
+	previous := map[string]interface{}{}
+	if value, ok := r.Form["cancel_at_period_end"]; ok {
+		// Как и Stripe: отмену подписки под управлением расписания меняют через само расписание
+		if sub.Schedule != "" {
+			return nil, invalidParam("cancel_at_period_end", "The subscription is managed by the subscription schedule `"+sub.Schedule+"`, and updating any cancelation behavior directly is not allowed. Please update the schedule instead.")
+		}
+		previous["cancel_at_period_end"] = sub.CancelAtPeriodEnd
+		previous["cancel_at"] = cancelAt(sub)
+		sub.CancelAtPeriodEnd = value[0] == "true"
+	}
+	if _, ok := r.Form["pause_collection"]; ok || r.Form.Get("pause_collection[behavior]") != "" {
+		previous["pause_collection"] = renderPauseCollection(sub)
+		behavior := r.Form.Get("pause_collection[behavior]")
+		switch behavior {
+		case "":
+			sub.PauseBehavior, sub.PauseResumesAt = "", 0 // pause_collection="" возобновляет списания
+		case "void", "keep_as_draft", "mark_uncollectible":
+			resumesAt, _ := strconv.ParseInt(r.Form.Get("pause_collection[resumes_at]"), 10, 64)
+			if resumesAt != 0 && resumesAt <= now() {
+				return nil, invalidParam("pause_collection[resumes_at]", "The resumes_at timestamp must be in the future.")
+			}
+			sub.PauseBehavior, sub.PauseResumesAt = behavior, resumesAt
+		default:
+			return nil, invalidParam("pause_collection[behavior]", "Invalid pause_collection[behavior]: must be one of keep_as_draft, mark_uncollectible, or void")
+		}
+	}
+	if itemID := r.Form.Get("items[0][id]"); itemID != "" && itemID != sub.ItemID {
+		return nil, notFound("subscription_item", itemID, "items[0][id]")
+	}
+	if priceID := r.Form.Get("items[0][price]"); priceID != "" && priceID != sub.PriceID {
+		price, ok := s.prices[priceID]
+		if !ok || !price.Active {
+			return nil, notFound("price", priceID, "items[0][price]")
+		}
+		// По умолчанию Stripe пересчитывает остаток периода (create_prorations)
+		if behavior := r.Form.Get("proration_behavior"); behavior != "none" {
+			at, _ := strconv.ParseInt(r.Form.Get("proration_date"), 10, 64)
+			sub.PendingProration += s.proration(sub, sub.PriceID, priceID, at)
+		}
+		previous["items"] = s.renderItems(sub)
+		previous["plan"] = renderPlan(s.prices[sub.PriceID])
+		sub.PriceID = priceID
+	}
+	if metadata := formMap(r.Form, "metadata"); len(metadata) > 0 {
+		if sub.Metadata == nil {
+			sub.Metadata = make(map[string]string)
+		}
+		for k, v := range metadata {
+			sub.Metadata[k] = v
+		}
+	}
+
+	s.emit("customer.subscription.updated", s.renderSubscription(sub, nil), previous)
+	return s.renderSubscription(sub, formList(r.Form, "expand")), nil
+}
+
+func (s *Server) cancelSubscription(id string, r *http.Request) (interface{}, *apiError) {
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return nil, notFound("subscription", id, "id")
+	}
+	s.cancel(sub)
+	return s.renderSubscription(sub, formList(r.Form, "expand")), nil
+}
+
+func (s *Server) getInvoice(id string, r *http.Request) (interface{}, *apiError) {
+	inv, ok := s.invoices[id]
+	if !ok {
+		return nil, notFound("invoice", id, "id")
+	}
+	return s.renderInvoice(inv, formList(r.Form, "expand")), nil
+}
+
+func (s *Server) listInvoices(r *http.Request) (interface{}, *apiError) {
+	var data []interface{}
+	// Stripe возвращает счета от новых к старым
+	ids := s.order["invoice"]
+	for i := len(ids) - 1; i >= 0; i-- {
+		inv := s.invoices[ids[i]]
+		if customer := r.Form.Get("customer"); customer != "" && inv.Customer != customer {
+			continue
+		}
+		if subscription := r.Form.Get("subscription"); subscription != "" && inv.Subscription != subscription {
+			continue
+		}
+		if status := r.Form.Get("status"); status != "" && inv.Status != status {
+			continue
+		}
+		data = append(data, s.renderInvoice(inv, nil))
+	}
+	return renderList("/v1/invoices", data, r.Form), nil
+}
+
+func (s *Server) payInvoiceRequest(id string, r *http.Request) (interface{}, *apiError) {
+	inv, ok := s.invoices[id]
+	if !ok {
+		return nil, notFound("invoice", id, "id")
+	}
+	if inv.Status != "open" && inv.Status != "paid" {
+		return nil, invalidParam("", "Invoice is "+inv.Status+" and cannot be paid.")
+	}
+	s.pay(inv)
+	return s.renderInvoice(inv, formList(r.Form, "expand")), nil
+}
+
+func (s *Server) getPaymentIntent(id string) (interface{}, *apiError) {
+	pi, ok := s.paymentIntents[id]
+	if !ok {
+		return nil, notFound("payment_intent", id, "intent")
+	}
+	return renderPaymentIntent(pi), nil
+}
+
+func (s *Server) getSetupIntent(id string) (interface{}, *apiError) {
+	si, ok := s.setupIntents[id]
+	if !ok {
+		return nil, notFound("setup_intent", id, "intent")
+	}
+	return renderSetupIntent(si), nil
+}
+
+func (s *Server) getPrice(id string, r *http.Request) (interface{}, *apiError) {
+	p, ok := s.prices[id]
+	if !ok {
+		return nil, notFound("price", id, "price")
+	}
+	return s.renderPrice(p, contains(formList(r.Form, "expand"), "product")), nil
+}
+
+func (s *Server) getProduct(id string) (interface{}, *apiError) {
+	p, ok := s.products[id]
+	if !ok {
+		return nil, notFound("product", id, "id")
+	}
+	return renderProduct(p), nil
+}
+
+func (s *Server) listPrices(r *http.Request) (interface{}, *apiError) {
+	var data []interface{}
+	for _, id := range s.order["price"] {
+		p := s.prices[id]
+		if active := r.Form.Get("active"); active != "" && strconv.FormatBool(p.Active) != active {
+			continue
+		}
+		if product := r.Form.Get("product"); product != "" && p.Product != product {
+			continue
+		}
+		if priceType := r.Form.Get("type"); priceType != "" && priceType != "recurring" {
+			continue // Поддельный сервер хранит только периодические цены
+		}
+		data = append(data, s.renderPrice(p, contains(formList(r.Form, "expand"), "data.product")))
+	}
+	return renderList("/v1/prices", data, r.Form), nil
+}
+
+// --- Представление объектов в формате Stripe API ---
+
+func renderCustomer(c *Customer) map[string]interface{} {
+	if c.Deleted {
+		return map[string]interface{}{"id": c.ID, "object": "customer", "deleted": true}
+	}
+	return map[string]interface{}{
+		"id":               c.ID,
+		"object":           "customer",
+		"email":            c.Email,
+		"name":             c.Name,
+		"metadata":         emptyIfNilMap(c.Metadata),
+		"invoice_settings": renderInvoiceSettings(c),
+		"created":          c.Created,
+		"livemode":         false,
+	}
+}
+
+func renderInvoiceSettings(c *Customer) map[string]interface{} {
+	settings := map[string]interface{}{"default_payment_method": nil}
+	if c.DefaultPaymentMethod != "" {
+		settings["default_payment_method"] = c.DefaultPaymentMethod
+	}
+	return settings
+}
+
+func renderProduct(p *Product) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          p.ID,
+		"object":      "product",
+		"name":        p.Name,
+		"description": p.Description,
+		"active":      p.Active,
+		"created":     p.Created,
+		"livemode":    false,
+	}
+}
+
+// renderPrice поддерживает expand product.
+func (s *Server) renderPrice(p *Price, expandProduct bool) map[string]interface{} {
+	var product interface{} = p.Product
+	var trialDays interface{}
+	if p.TrialDays > 0 {
+		trialDays = p.TrialDays
+	}
+	if expandProduct {
+		if prod, ok := s.products[p.Product]; ok {
+			product = renderProduct(prod)
+		}
+	}
+	return map[string]interface{}{
+		"id":          p.ID,
+		"object":      "price",
+		"active":      p.Active,
+		"currency":    p.Currency,
+		"unit_amount": p.UnitAmount,
+		"product":     product,
+		"nickname":    p.Nickname,
+		"type":        "recurring",
+		"recurring":   map[string]interface{}{"interval": p.Interval, "interval_count": 1, "trial_period_days": trialDays},
+		"created":     p.Created,
+		"livemode":    false,
+	}
+}
+
+func renderPlan(p *Price) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             p.ID,
+		"object":         "plan",
+		"active":         p.Active,
+		"amount":         p.UnitAmount,
+		"currency":       p.Currency,
+		"interval":       p.Interval,
+		"interval_count": 1,
+		"product":        p.Product,
+		"nickname":       p.Nickname,
+	}
+}
+
+func (s *Server) renderItems(sub *Subscription) map[string]interface{} {
+	price := s.prices[sub.PriceID]
+	item := map[string]interface{}{
+		"id":           sub.ItemID,
+		"object":       "subscription_item",
+		"subscription": sub.ID,
+		"quantity":     1,
+		"price":        s.renderPrice(price, false),
+		"plan":         renderPlan(price),
+	}
+	return map[string]interface{}{
+		"object":   "list",
+		"url":      "/v1/subscription_items?subscription=" + sub.ID,
+		"data":     []interface{}{item},
+		"has_more": false,
+	}
+}
+
+// renderSubscription поддерживает expand latest_invoice, latest_invoice.payment_intent, schedule и pending_setup_intent.
+func (s *Server) renderSubscription(sub *Subscription, expand []string) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":                   sub.ID,
+		"object":               "subscription",
+		"customer":             sub.Customer,
+		"status":               sub.Status,
+		"items":                s.renderItems(sub),
+		"plan":                 renderPlan(s.prices[sub.PriceID]),
+		"cancel_at_period_end": sub.CancelAtPeriodEnd,
+		"cancel_at":            cancelAt(sub),
+		"current_period_start": sub.CurrentPeriodStart,
+		"current_period_end":   sub.CurrentPeriodEnd,
+		"latest_invoice":       sub.LatestInvoice,
+		"schedule":             nil,
+		"pause_collection":     renderPauseCollection(sub),
+		"trial_start":          nil,
+		"trial_end":            nil,
+		"pending_setup_intent": nil,
+		"discount":             s.renderDiscount(sub),
+		"metadata":             emptyIfNilMap(sub.Metadata),
+		"created":              sub.Created,
+		"start_date":           sub.Created,
+		"livemode":             false,
+	}
+	if sub.Schedule != "" {
+		out["schedule"] = sub.Schedule
+		if contains(expand, "schedule") {
+			out["schedule"] = s.renderSchedule(s.schedules[sub.Schedule])
+		}
+	}
+	if sub.CanceledAt != 0 {
+		out["canceled_at"] = sub.CanceledAt
+		out["ended_at"] = sub.EndedAt
+	}
+	if sub.TrialEnd != 0 {
+		out["trial_start"] = sub.TrialStart
+		out["trial_end"] = sub.TrialEnd
+	}
+	if si, ok := s.setupIntents[sub.PendingSetupIntent]; ok {
+		out["pending_setup_intent"] = si.ID
+		if contains(expand, "pending_setup_intent") {
+			out["pending_setup_intent"] = renderSetupIntent(si)
+		}
+	}
+
+	var invoiceExpand []string
+	expanded := false
+	for _, e := range expand {
+		if e == "latest_invoice" {
+			expanded = true
+		} else if rest, ok := strings.CutPrefix(e, "latest_invoice."); ok {
+			expanded = true
+			invoiceExpand = append(invoiceExpand, rest)
+		}
+	}
+	if inv, ok := s.invoices[sub.LatestInvoice]; ok && expanded {
+		out["latest_invoice"] = s.renderInvoice(inv, invoiceExpand)
+	}
+	return out
+}
+
+// renderInvoice поддерживает expand payment_intent и charge.
+func (s *Server) renderInvoice(inv *Invoice, expand []string) map[string]interface{} {
+	transitions := map[string]interface{}{"finalized_at": inv.Created, "paid_at": nil}
+	if inv.PaidAt != 0 {
+		transitions["paid_at"] = inv.PaidAt
+	}
+	out := map[string]interface{}{
+		"id":                 inv.ID,
+		"object":             "invoice",
+		"customer":           inv.Customer,
+		"subscription":       inv.Subscription,
+		"number":             inv.Number,
+		"status":             inv.Status,
+		"paid":               inv.Status == "paid",
+		"subtotal":           inv.Subtotal,
+		"total":              inv.AmountDue,
+		"amount_due":         inv.AmountDue,
+		"amount_paid":        inv.AmountPaid,
+		"amount_remaining":   inv.AmountDue - inv.AmountPaid,
+		"currency":           inv.Currency,
+		"attempt_count":      inv.AttemptCount,
+		"billing_reason":     inv.BillingReason,
+		"period_start":       inv.PeriodStart,
+		"period_end":         inv.PeriodEnd,
+		"hosted_invoice_url": "https://invoice.stripe.com/i/" + inv.ID,
+		"invoice_pdf":        "https://pay.stripe.com/invoice/" + inv.ID + "/pdf",
+		"status_transitions": transitions,
+		"created":            inv.Created,
+		"livemode":           false,
+	}
+	if inv.PaymentIntent != "" {
+		out["payment_intent"] = inv.PaymentIntent
+		for _, e := range expand {
+			if e == "payment_intent" {
+				out["payment_intent"] = renderPaymentIntent(s.paymentIntents[inv.PaymentIntent])
+			}
+		}
+	}
+	out["charge"] = nil
+	if inv.Charge != "" {
+		out["charge"] = inv.Charge
+		if contains(expand, "charge") {
+			out["charge"] = renderCharge(s.charges[inv.Charge])
+		}
+	}
+	return out
+}
+
+func renderSetupIntent(si *SetupIntent) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":             si.ID,
+		"object":         "setup_intent",
+		"customer":       si.Customer,
+		"payment_method": nil,
+		"status":         si.Status,
+		"usage":          "off_session",
+		"client_secret":  si.ClientSecret,
+		"created":        si.Created,
+		"livemode":       false,
+	}
+	if si.PaymentMethod != "" {
+		out["payment_method"] = si.PaymentMethod
+	}
+	return out
+}
+
+func renderPaymentIntent(pi *PaymentIntent) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":                 pi.ID,
+		"object":             "payment_intent",
+		"amount":             pi.Amount,
+		"amount_received":    pi.AmountReceived,
+		"currency":           pi.Currency,
+		"customer":           pi.Customer,
+		"invoice":            nil,
+		"status":             pi.Status,
+		"description":        nil,
+		"metadata":           emptyIfNilMap(pi.Metadata),
+		"client_secret":      pi.ClientSecret,
+		"latest_charge":      nil,
+		"last_payment_error": nil,
+		"created":            pi.Created,
+		"livemode":           false,
+	}
+	if pi.Invoice != "" {
+		out["invoice"] = pi.Invoice
+	}
+	if pi.Description != "" {
+		out["description"] = pi.Description
+	}
+	if pi.LatestCharge != "" {
+		out["latest_charge"] = pi.LatestCharge
+	}
+	if pi.LastPaymentError != "" {
+		out["last_payment_error"] = map[string]interface{}{
+			"type":         errorTypeCard,
+			"code":         "card_declined",
+			"decline_code": pi.LastPaymentError,
+			"message":      "Your card was declined.",
+		}
+	}
+	return out
+}
+
+func renderList(path string, data []interface{}, form url.Values) map[string]interface{} {
+	data, hasMore := limitList(data, form)
+	return map[string]interface{}{
+		"object":   "list",
+		"url":      path,
+		"data":     emptyIfNil(data),
+		"has_more": hasMore,
+	}
+}
+
+// limitList применяет параметры пагинации starting_after и limit (по умолчанию 10, максимум 100).
+func limitList(data []interface{}, form url.Values) ([]interface{}, bool) {
+	if after := form.Get("starting_after"); after != "" {
+		for i, item := range data {
+			if item.(map[string]interface{})["id"] == after {
+				data = data[i+1:]
+				break
+			}
+		}
+	}
+	limit := 10
+	if n, err := strconv.Atoi(form.Get("limit")); err == nil && n > 0 {
+		limit = min(n, 100)
+	}
+	if len(data) > limit {
+		return data[:limit], true
+	}
+	return data, false
+}
+
+// --- Разбор параметров в формате Stripe (metadata[key]=value, expand[0]=value) ---
+
+// formMap собирает параметры вида prefix[key]=value.
+func formMap(form url.Values, prefix string) map[string]string {
+	out := make(map[string]string)
+	for key, values := range form {
+		if name, ok := strings.CutPrefix(key, prefix+"["); ok && strings.HasSuffix(name, "]") && !strings.Contains(name, "[") {
+			out[strings.TrimSuffix(name, "]")] = values[0]
+		}
+	}
+	return out
+}
+
+// formList собирает параметры вида prefix[0]=value или prefix[]=value в порядке индексов.
+func formList(form url.Values, prefix string) []string {
+	type indexed struct {
+		index int
+		value string
+	}
+	var items []indexed
+	for key, values := range form {
+		name, ok := strings.CutPrefix(key, prefix+"[")
+		if !ok || !strings.HasSuffix(name, "]") {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSuffix(name, "]"))
+		if err != nil {
+			index = len(items)
+		}
+		for _, v := range values {
+			items = append(items, indexed{index: index, value: v})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].index < items[j].index })
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = item.value
+	}
+	return out
+}
+
+// renderPauseCollection возвращает pause_collection подписки или nil, если списания не приостановлены.
+func renderPauseCollection(sub *Subscription) interface{} {
+	if sub.PauseBehavior == "" {
+		return nil
+	}
+	out := map[string]interface{}{"behavior": sub.PauseBehavior, "resumes_at": nil}
+	if sub.PauseResumesAt != 0 {
+		out["resumes_at"] = sub.PauseResumesAt
+	}
+	return out
+}
+
+// cancelAt возвращает момент запланированной отмены (конец текущего периода) или nil.
+func cancelAt(sub *Subscription) interface{} {
+	if !sub.CancelAtPeriodEnd {
+		return nil
+	}
+	return sub.CurrentPeriodEnd
+}
+
+// periodEnd вычисляет конец расчетного периода.
+func periodEnd(start time.Time, interval string) time.Time {
+	switch interval {
+	case "day":
+		return start.AddDate(0, 0, 1)
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "year":
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func emptyIfNil(data []interface{}) []interface{} {
+	if data == nil {
+		return []interface{}{}
+	}
+	return data
+}
+
+func emptyIfNilMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
@@ -0,0 +1,117 @@
+package stripetest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PaymentIntent возвращает копию PaymentIntent по ID.
+func (s *Server) PaymentIntent(id string) (*PaymentIntent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pi, ok := s.paymentIntents[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *pi
+	return &copied, true
+}
+
+// ConfirmPaymentIntent проводит успешную оплату разового PaymentIntent (как будто клиент
+// подтвердил платеж через Stripe.js) и отправляет charge.succeeded и payment_intent.succeeded.
+func (s *Server) ConfirmPaymentIntent(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pi, ok := s.paymentIntents[id]
+	if !ok || pi.Invoice != "" || !confirmable(pi) {
+		return false
+	}
+	s.succeed(pi)
+	return true
+}
+
+// FailPaymentIntent регистрирует отклоненную попытку оплаты разового PaymentIntent и отправляет
+// payment_intent.payment_failed. PaymentIntent снова ожидает способ оплаты.
+func (s *Server) FailPaymentIntent(id, declineCode string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pi, ok := s.paymentIntents[id]
+	if !ok || pi.Invoice != "" || !confirmable(pi) {
+		return false
+	}
+	if declineCode == "" {
+		declineCode = "generic_decline"
+	}
+	pi.Status = "requires_payment_method"
+	pi.LastPaymentError = declineCode
+	s.emit("payment_intent.payment_failed", renderPaymentIntent(pi), nil)
+	return true
+}
+
+// CancelPaymentIntent отменяет неоплаченный разовый PaymentIntent и отправляет payment_intent.canceled.
+func (s *Server) CancelPaymentIntent(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pi, ok := s.paymentIntents[id]
+	if !ok || pi.Invoice != "" || !confirmable(pi) {
+		return false
+	}
+	previous := pi.Status
+	pi.Status = "canceled"
+	s.emit("payment_intent.canceled", renderPaymentIntent(pi), map[string]interface{}{"status": previous})
+	return true
+}
+
+// --- Внутренние операции (вызываются под s.mu) ---
+
+// confirmable сообщает, можно ли еще оплатить или отменить PaymentIntent.
+func confirmable(pi *PaymentIntent) bool {
+	return pi.Status != "succeeded" && pi.Status != "canceled"
+}
+
+// succeed списывает полную сумму PaymentIntent, создает платеж и отправляет payment_intent.succeeded.
+func (s *Server) succeed(pi *PaymentIntent) {
+	previous := pi.Status
+	pi.Status = "succeeded"
+	pi.AmountReceived = pi.Amount
+	pi.LastPaymentError = ""
+	pi.LatestCharge = s.newCharge(pi).ID
+	s.emit("payment_intent.succeeded", renderPaymentIntent(pi), map[string]interface{}{"status": previous})
+}
+
+// --- Эндпоинты ---
+
+// createPaymentIntent создает разовый PaymentIntent; оплата проводится методами ConfirmPaymentIntent и FailPaymentIntent.
+func (s *Server) createPaymentIntent(r *http.Request) (interface{}, *apiError) {
+	customerID := r.Form.Get("customer")
+	if customerID != "" {
+		if _, ok := s.customers[customerID]; !ok {
+			return nil, notFound("customer", customerID, "customer")
+		}
+	}
+	amount, err := strconv.ParseInt(r.Form.Get("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		return nil, invalidParam("amount", "Invalid positive integer.")
+	}
+	currency := strings.ToLower(r.Form.Get("currency"))
+	if len(currency) != 3 {
+		return nil, invalidParam("currency", "Invalid currency: "+currency+".")
+	}
+
+	pi := &PaymentIntent{
+		ID:          s.newID("pi"),
+		Customer:    customerID,
+		Amount:      amount,
+		Currency:    currency,
+		Status:      "requires_payment_method",
+		Description: r.Form.Get("description"),
+		Metadata:    formMap(r.Form, "metadata"),
+		Created:     now(),
+	}
+	pi.ClientSecret = pi.ID + "_secret_stripetest"
+	s.paymentIntents[pi.ID] = pi
+	s.remember("payment_intent", pi.ID)
+	s.emit("payment_intent.created", renderPaymentIntent(pi), nil)
+	return renderPaymentIntent(pi), nil
+}
@@ -0,0 +1,201 @@
+package stripetest
+
+import (
+	"net/http"
+	"time"
+)
+
+// PaymentMethod карта поддельного Stripe.
+type PaymentMethod struct {
+	ID       string
+	Customer string // Клиент, к которому привязана карта (пусто - не привязана)
+	Brand    string // По умолчанию visa
+	Last4    string // По умолчанию 4242
+	ExpMonth int64  // По умолчанию 12
+	ExpYear  int64  // По умолчанию следующий год
+	Created  int64
+}
+
+// AddPaymentMethod создает непривязанную карту (как после stripe.createPaymentMethod на фронтенде)
+// и возвращает ее ID.
+func (s *Server) AddPaymentMethod(pm PaymentMethod) string {
+	if pm.Brand == "" {
+		pm.Brand = "visa"
+	}
+	if pm.Last4 == "" {
+		pm.Last4 = "4242"
+	}
+	if pm.ExpMonth == 0 {
+		pm.ExpMonth = 12
+	}
+	if pm.ExpYear == 0 {
+		pm.ExpYear = int64(time.Now().Year() + 1)
+	}
+	if pm.Created == 0 {
+		pm.Created = now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pm.ID == "" {
+		pm.ID = s.newID("pm")
+	}
+	if _, ok := s.paymentMethods[pm.ID]; !ok {
+		s.remember("payment_method", pm.ID)
+	}
+	s.paymentMethods[pm.ID] = &pm
+	return pm.ID
+}
+
+// PaymentMethod возвращает копию карты по ID.
+func (s *Server) PaymentMethod(id string) (*PaymentMethod, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pm, ok := s.paymentMethods[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *pm
+	return &copied, true
+}
+
+// ConfirmSetupIntent подтверждает SetupIntent картой (как будто клиент ввел ее в Stripe.js):
+// карта привязывается к клиенту SetupIntent.
+func (s *Server) ConfirmSetupIntent(id, paymentMethodID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	si, ok := s.setupIntents[id]
+	if !ok || si.Status == "succeeded" {
+		return false
+	}
+	pm, ok := s.paymentMethods[paymentMethodID]
+	if !ok || (pm.Customer != "" && pm.Customer != si.Customer) {
+		return false
+	}
+	if pm.Customer == "" {
+		s.attach(pm, si.Customer)
+	}
+	si.PaymentMethod = pm.ID
+	si.Status = "succeeded"
+	s.emit("setup_intent.succeeded", renderSetupIntent(si), nil)
+	return true
+}
+
+// --- Внутренние операции (вызываются под s.mu) ---
+
+func (s *Server) attach(pm *PaymentMethod, customerID string) {
+	pm.Customer = customerID
+	s.emit("payment_method.attached", renderPaymentMethod(pm), nil)
+}
+
+// --- Эндпоинты ---
+
+func (s *Server) createSetupIntent(r *http.Request) (interface{}, *apiError) {
+	customerID := r.Form.Get("customer")
+	if c, ok := s.customers[customerID]; customerID != "" && (!ok || c.Deleted) {
+		return nil, notFound("customer", customerID, "customer")
+	}
+	si := &SetupIntent{
+		ID:       s.newID("seti"),
+		Customer: customerID,
+		Status:   "requires_payment_method",
+		Created:  now(),
+	}
+	si.ClientSecret = si.ID + "_secret_stripetest"
+	s.setupIntents[si.ID] = si
+	s.emit("setup_intent.created", renderSetupIntent(si), nil)
+	return renderSetupIntent(si), nil
+}
+
+// listPaymentMethods поддерживает фильтры customer и type (только card).
+func (s *Server) listPaymentMethods(r *http.Request) (interface{}, *apiError) {
+	customerID := r.Form.Get("customer")
+	if customerID == "" {
+		return nil, invalidParam("customer", "Missing required param: customer.")
+	}
+	if t := r.Form.Get("type"); t != "" && t != "card" {
+		return renderList("/v1/payment_methods", nil, r.Form), nil
+	}
+	var data []interface{}
+	ids := s.order["payment_method"]
+	for i := len(ids) - 1; i >= 0; i-- { // Новые первыми, как в Stripe
+		pm := s.paymentMethods[ids[i]]
+		if pm.Customer == customerID {
+			data = append(data, renderPaymentMethod(pm))
+		}
+	}
+	return renderList("/v1/payment_methods", data, r.Form), nil
+}
+
+func (s *Server) getPaymentMethod(id string) (interface{}, *apiError) {
+	pm, ok := s.paymentMethods[id]
+	if !ok {
+		return nil, notFound("PaymentMethod", id, "payment_method")
+	}
+	return renderPaymentMethod(pm), nil
+}
+
+func (s *Server) attachPaymentMethod(id string, r *http.Request) (interface{}, *apiError) {
+	pm, ok := s.paymentMethods[id]
+	if !ok {
+		return nil, notFound("PaymentMethod", id, "payment_method")
+	}
+	customerID := r.Form.Get("customer")
+	if c, ok := s.customers[customerID]; !ok || c.Deleted {
+		return nil, notFound("customer", customerID, "customer")
+	}
+	switch pm.Customer {
+	case customerID:
+		return renderPaymentMethod(pm), nil
+	case "":
+		s.attach(pm, customerID)
+		return renderPaymentMethod(pm), nil
+	default:
+		return nil, invalidParam("", "The payment method you provided has already been attached to a customer.")
+	}
+}
+
+// detachPaymentMethod отвязывает карту; если она была картой по умолчанию, она сбрасывается у клиента.
+func (s *Server) detachPaymentMethod(id string) (interface{}, *apiError) {
+	pm, ok := s.paymentMethods[id]
+	if !ok {
+		return nil, notFound("PaymentMethod", id, "payment_method")
+	}
+	if pm.Customer == "" {
+		return nil, invalidParam("", "The payment method "+id+" is not attached to a customer so detachment is impossible.")
+	}
+	if c, ok := s.customers[pm.Customer]; ok && c.DefaultPaymentMethod == pm.ID {
+		previous := map[string]interface{}{"invoice_settings": renderInvoiceSettings(c)}
+		c.DefaultPaymentMethod = ""
+		s.emit("customer.updated", renderCustomer(c), previous)
+	}
+	previous := map[string]interface{}{"customer": pm.Customer}
+	pm.Customer = ""
+	s.emit("payment_method.detached", renderPaymentMethod(pm), previous)
+	return renderPaymentMethod(pm), nil
+}
+
+// --- Представление объектов в формате Stripe API ---
+
+func renderPaymentMethod(pm *PaymentMethod) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":       pm.ID,
+		"object":   "payment_method",
+		"type":     "card",
+		"customer": nil,
+		"card": map[string]interface{}{
+			"brand":     pm.Brand,
+			"last4":     pm.Last4,
+			"exp_month": pm.ExpMonth,
+			"exp_year":  pm.ExpYear,
+			"funding":   "credit",
+			"country":   "US",
+		},
+		"created":  pm.Created,
+		"livemode": false,
+	}
+	if pm.Customer != "" {
+		out["customer"] = pm.Customer
+	}
+	return out
+}
@@ -0,0 +1,432 @@
+package stripetest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Charge платеж картой, созданный при оплате счета.
+type Charge struct {
+	ID             string
+	Customer       string
+	Invoice        string
+	PaymentIntent  string
+	Amount         int64
+	AmountRefunded int64
+	Currency       string
+	Created        int64
+}
+
+// Refund возврат платежа.
+type Refund struct {
+	ID            string
+	Charge        string
+	PaymentIntent string
+	Amount        int64
+	Currency      string
+	Status        string // succeeded, failed
+	Reason        string
+	FailureReason string
+	Created       int64
+}
+
+// CreditNote кредит-нота по счету.
+type CreditNote struct {
+	ID       string
+	Invoice  string
+	Customer string
+	Number   string
+	Type     string // pre_payment (счет не оплачен), post_payment
+	Status   string // issued, void
+	Reason   string
+	Memo     string
+	Amount   int64
+	Currency string
+	Refund   string // Возврат на карту, созданный кредит-нотой
+	VoidedAt int64
+	Created  int64
+}
+
+// Причины, которые принимает Stripe
+var (
+	refundReasons     = []string{"duplicate", "fraudulent", "requested_by_customer"}
+	creditNoteReasons = []string{"duplicate", "fraudulent", "order_change", "product_unsatisfactory"}
+)
+
+// Charge возвращает копию платежа по ID.
+func (s *Server) Charge(id string) (*Charge, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch, ok := s.charges[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *ch
+	return &copied, true
+}
+
+// Refund возвращает копию возврата по ID.
+func (s *Server) Refund(id string) (*Refund, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	re, ok := s.refunds[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *re
+	return &copied, true
+}
+
+// CreditNote возвращает копию кредит-ноты по ID.
+func (s *Server) CreditNote(id string) (*CreditNote, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cn, ok := s.creditNotes[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *cn
+	return &copied, true
+}
+
+// FailRefund отмечает успешный возврат неудавшимся (например, карта закрыта) и отправляет
+// charge.refund.updated. Сумма возврата снова становится доступной для возврата.
+func (s *Server) FailRefund(id, failureReason string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	re, ok := s.refunds[id]
+	if !ok || re.Status != "succeeded" {
+		return false
+	}
+	if failureReason == "" {
+		failureReason = "unknown"
+	}
+	re.Status = "failed"
+	re.FailureReason = failureReason
+	if ch, ok := s.charges[re.Charge]; ok {
+		ch.AmountRefunded -= re.Amount
+	}
+	s.emit("charge.refund.updated", renderRefund(re), map[string]interface{}{"status": "succeeded"})
+	return true
+}
+
+// VoidCreditNote аннулирует кредит-ноту и отправляет credit_note.voided. Для неоплаченного счета
+// сумма к оплате восстанавливается; возврат на карту, созданный кредит-нотой, не отменяется.
+func (s *Server) VoidCreditNote(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cn, ok := s.creditNotes[id]
+	if !ok || cn.Status != "issued" {
+		return false
+	}
+	cn.Status = "void"
+	cn.VoidedAt = now()
+	if inv, ok := s.invoices[cn.Invoice]; ok && cn.Type == "pre_payment" && inv.Status == "open" {
+		s.setAmountDue(inv, inv.AmountDue+cn.Amount)
+	}
+	s.emit("credit_note.voided", renderCreditNote(cn), map[string]interface{}{"status": "issued", "voided_at": nil})
+	return true
+}
+
+// --- Внутренние операции (вызываются под s.mu) ---
+
+// newCharge создает платеж по успешному PaymentIntent.
+func (s *Server) newCharge(pi *PaymentIntent) *Charge {
+	ch := &Charge{
+		ID:            s.newID("ch"),
+		Customer:      pi.Customer,
+		Invoice:       pi.Invoice,
+		PaymentIntent: pi.ID,
+		Amount:        pi.Amount,
+		Currency:      pi.Currency,
+		Created:       now(),
+	}
+	s.charges[ch.ID] = ch
+	s.remember("charge", ch.ID)
+	s.emit("charge.succeeded", renderCharge(ch), nil)
+	return ch
+}
+
+// refund возвращает часть платежа и отправляет charge.refunded.
+func (s *Server) refund(ch *Charge, amount int64, reason string) *Refund {
+	re := &Refund{
+		ID:            s.newID("re"),
+		Charge:        ch.ID,
+		PaymentIntent: ch.PaymentIntent,
+		Amount:        amount,
+		Currency:      ch.Currency,
+		Status:        "succeeded",
+		Reason:        reason,
+		Created:       now(),
+	}
+	s.refunds[re.ID] = re
+	s.remember("refund", re.ID)
+
+	previous := ch.AmountRefunded
+	ch.AmountRefunded += amount
+	s.emit("charge.refunded", renderCharge(ch), map[string]interface{}{"amount_refunded": previous, "refunded": previous == ch.Amount})
+	return re
+}
+
+// setAmountDue меняет сумму к оплате открытого счета вместе с суммой его PaymentIntent.
+// Полностью погашенный кредит-нотами счет считается оплаченным.
+func (s *Server) setAmountDue(inv *Invoice, amount int64) {
+	previous := inv.AmountDue
+	inv.AmountDue = amount
+	if pi, ok := s.paymentIntents[inv.PaymentIntent]; ok {
+		pi.Amount = amount
+	}
+	if amount == 0 {
+		s.pay(inv)
+		return
+	}
+	s.emit("invoice.updated", s.renderInvoice(inv, nil), map[string]interface{}{"amount_due": previous})
+}
+
+// --- Эндпоинты ---
+
+func (s *Server) getCharge(id string) (interface{}, *apiError) {
+	ch, ok := s.charges[id]
+	if !ok {
+		return nil, notFound("charge", id, "charge")
+	}
+	return renderCharge(ch), nil
+}
+
+// createRefund возвращает платеж; без amount возвращается вся невозвращенная сумма.
+func (s *Server) createRefund(r *http.Request) (interface{}, *apiError) {
+	chargeID := r.Form.Get("charge")
+	if chargeID == "" {
+		return nil, invalidParam("charge", "Missing required param: charge.")
+	}
+	ch, ok := s.charges[chargeID]
+	if !ok {
+		return nil, notFound("charge", chargeID, "charge")
+	}
+	remaining := ch.Amount - ch.AmountRefunded
+	if remaining == 0 {
+		return nil, &apiError{
+			status:  http.StatusBadRequest,
+			Type:    errorTypeInvalidRequest,
+			Code:    "charge_already_refunded",
+			Message: "Charge " + ch.ID + " has already been refunded.",
+		}
+	}
+	amount := remaining
+	if v := r.Form.Get("amount"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, invalidParam("amount", "Invalid positive integer.")
+		}
+		if parsed > remaining {
+			return nil, invalidParam("amount", fmt.Sprintf("Refund amount (%d) is greater than unrefunded amount on charge (%d).", parsed, remaining))
+		}
+		amount = parsed
+	}
+	reason := r.Form.Get("reason")
+	if reason != "" && !contains(refundReasons, reason) {
+		return nil, invalidParam("reason", "Invalid reason: "+reason+".")
+	}
+	return renderRefund(s.refund(ch, amount, reason)), nil
+}
+
+func (s *Server) listRefunds(r *http.Request) (interface{}, *apiError) {
+	var data []interface{}
+	// Stripe возвращает возвраты от новых к старым
+	ids := s.order["refund"]
+	for i := len(ids) - 1; i >= 0; i-- {
+		re := s.refunds[ids[i]]
+		if charge := r.Form.Get("charge"); charge != "" && re.Charge != charge {
+			continue
+		}
+		data = append(data, renderRefund(re))
+	}
+	return renderList("/v1/refunds", data, r.Form), nil
+}
+
+func (s *Server) getRefund(id string) (interface{}, *apiError) {
+	re, ok := s.refunds[id]
+	if !ok {
+		return nil, notFound("refund", id, "refund")
+	}
+	return renderRefund(re), nil
+}
+
+// createCreditNote выпускает кредит-ноту на сумму amount. Для открытого счета (pre_payment) она
+// уменьшает сумму к оплате; для оплаченного (post_payment) refund_amount возвращается на карту,
+// остальное - на баланс клиента.
+func (s *Server) createCreditNote(r *http.Request) (interface{}, *apiError) {
+	invoiceID := r.Form.Get("invoice")
+	inv, ok := s.invoices[invoiceID]
+	if !ok {
+		return nil, notFound("invoice", invoiceID, "invoice")
+	}
+	if inv.Status != "open" && inv.Status != "paid" {
+		return nil, invalidParam("invoice", "Credit notes can only be issued for open or paid invoices; invoice is "+inv.Status+".")
+	}
+	amounts := make(map[string]int64)
+	for _, param := range []string{"amount", "refund_amount", "credit_amount"} {
+		v := r.Form.Get(param)
+		if v == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			return nil, invalidParam(param, "Invalid non-negative integer.")
+		}
+		amounts[param] = parsed
+	}
+	amount := amounts["amount"]
+	if amount == 0 {
+		return nil, invalidParam("amount", "Missing required param: amount.")
+	}
+	reason := r.Form.Get("reason")
+	if reason != "" && !contains(creditNoteReasons, reason) {
+		return nil, invalidParam("reason", "Invalid reason: "+reason+".")
+	}
+
+	cn := &CreditNote{
+		ID:       s.newID("cn"),
+		Invoice:  inv.ID,
+		Customer: inv.Customer,
+		Number:   fmt.Sprintf("%s-CN-%02d", inv.Number, s.creditNoteCount(inv.ID)+1),
+		Type:     "pre_payment",
+		Status:   "issued",
+		Reason:   reason,
+		Memo:     r.Form.Get("memo"),
+		Amount:   amount,
+		Currency: inv.Currency,
+		Created:  now(),
+	}
+
+	if inv.Status == "open" {
+		if amounts["refund_amount"] > 0 {
+			return nil, invalidParam("refund_amount", "Cannot refund an invoice that has not been paid.")
+		}
+		if amount > inv.AmountDue {
+			return nil, invalidParam("amount", fmt.Sprintf("The credit note amount (%d) exceeds the invoice amount due (%d).", amount, inv.AmountDue))
+		}
+		s.setAmountDue(inv, inv.AmountDue-amount)
+	} else {
+		cn.Type = "post_payment"
+		if amount > inv.AmountPaid-s.creditedAmount(inv.ID) {
+			return nil, invalidParam("amount", fmt.Sprintf("The credit note amount (%d) exceeds the remaining invoice amount (%d).", amount, inv.AmountPaid-s.creditedAmount(inv.ID)))
+		}
+		refundAmount := amounts["refund_amount"]
+		if refundAmount+amounts["credit_amount"] > amount {
+			return nil, invalidParam("amount", "The sum of refund_amount and credit_amount exceeds the credit note amount.")
+		}
+		if refundAmount > 0 {
+			ch, ok := s.charges[inv.Charge]
+			if !ok || refundAmount > ch.Amount-ch.AmountRefunded {
+				return nil, invalidParam("refund_amount", "The refund amount exceeds the unrefunded amount of the invoice payment.")
+			}
+			cn.Refund = s.refund(ch, refundAmount, "").ID
+		}
+	}
+
+	s.creditNotes[cn.ID] = cn
+	s.remember("credit_note", cn.ID)
+	s.emit("credit_note.created", renderCreditNote(cn), nil)
+	return renderCreditNote(cn), nil
+}
+
+// creditNoteCount число кредит-нот счета (для номера новой кредит-ноты).
+func (s *Server) creditNoteCount(invoiceID string) int {
+	n := 0
+	for _, id := range s.order["credit_note"] {
+		if s.creditNotes[id].Invoice == invoiceID {
+			n++
+		}
+	}
+	return n
+}
+
+// creditedAmount сумма действующих post_payment кредит-нот счета.
+func (s *Server) creditedAmount(invoiceID string) int64 {
+	var total int64
+	for _, id := range s.order["credit_note"] {
+		cn := s.creditNotes[id]
+		if cn.Invoice == invoiceID && cn.Type == "post_payment" && cn.Status == "issued" {
+			total += cn.Amount
+		}
+	}
+	return total
+}
+
+// --- Представление объектов в формате Stripe API ---
+
+func renderCharge(ch *Charge) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              ch.ID,
+		"object":          "charge",
+		"amount":          ch.Amount,
+		"amount_captured": ch.Amount,
+		"amount_refunded": ch.AmountRefunded,
+		"currency":        ch.Currency,
+		"customer":        ch.Customer,
+		"invoice":         ch.Invoice,
+		"payment_intent":  ch.PaymentIntent,
+		"paid":            true,
+		"captured":        true,
+		"refunded":        ch.AmountRefunded == ch.Amount,
+		"status":          "succeeded",
+		"created":         ch.Created,
+		"livemode":        false,
+	}
+}
+
+func renderRefund(re *Refund) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":             re.ID,
+		"object":         "refund",
+		"amount":         re.Amount,
+		"charge":         re.Charge,
+		"payment_intent": re.PaymentIntent,
+		"currency":       re.Currency,
+		"status":         re.Status,
+		"reason":         nil,
+		"metadata":       map[string]string{},
+		"created":        re.Created,
+	}
+	if re.Reason != "" {
+		out["reason"] = re.Reason
+	}
+	if re.FailureReason != "" {
+		out["failure_reason"] = re.FailureReason
+	}
+	return out
+}
+
+func renderCreditNote(cn *CreditNote) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":        cn.ID,
+		"object":    "credit_note",
+		"invoice":   cn.Invoice,
+		"customer":  cn.Customer,
+		"number":    cn.Number,
+		"type":      cn.Type,
+		"status":    cn.Status,
+		"reason":    nil,
+		"memo":      cn.Memo,
+		"amount":    cn.Amount,
+		"subtotal":  cn.Amount,
+		"total":     cn.Amount,
+		"currency":  cn.Currency,
+		"refund":    nil,
+		"voided_at": nil,
+		"created":   cn.Created,
+		"livemode":  false,
+	}
+	if cn.Reason != "" {
+		out["reason"] = cn.Reason
+	}
+	if cn.Refund != "" {
+		out["refund"] = cn.Refund
+	}
+	if cn.VoidedAt != 0 {
+		out["voided_at"] = cn.VoidedAt
+	}
+	return out
+}
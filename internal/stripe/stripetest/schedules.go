@@ -0,0 +1,388 @@
+package stripetest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SubscriptionSchedule расписание подписки: последовательность фаз с разными ценами.
+type SubscriptionSchedule struct {
+	ID           string
+	Subscription string
+	Customer     string
+	Status       string // active, released, canceled
+	EndBehavior  string // release, cancel
+	Phases       []SchedulePhase
+	Created      int64
+}
+
+// SchedulePhase фаза расписания.
+type SchedulePhase struct {
+	PriceID   string
+	Quantity  int64
+	StartDate int64
+	EndDate   int64
+}
+
+// Schedule возвращает копию расписания по ID.
+func (s *Server) Schedule(id string) (*SubscriptionSchedule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sched, ok := s.schedules[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *sched
+	copied.Phases = append([]SchedulePhase(nil), sched.Phases...)
+	return &copied, true
+}
+
+// AdvancePeriod переводит подписку в следующий расчетный период, как будто текущий закончился:
+// применяет фазу расписания (или отменяет подписку с cancel_at_period_end), выставляет счет
+// subscription_cycle вместе с накопленными перерасчетами и оплачивает его сохраненной картой.
+// Пока списания приостановлены (pause_collection), счет не оплачивается, а получает статус по behavior.
+func (s *Server) AdvancePeriod(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subscriptions[id]
+	if !ok || sub.Status == "canceled" {
+		return false
+	}
+	if sub.CancelAtPeriodEnd {
+		s.cancel(sub)
+		return true
+	}
+
+	boundary := sub.CurrentPeriodEnd
+	previous := map[string]interface{}{
+		"current_period_start": sub.CurrentPeriodStart,
+		"current_period_end":   sub.CurrentPeriodEnd,
+	}
+	// Пробный период закончился: дальше подписка оплачивается (способ оплаты считается сохраненным)
+	if sub.Status == "trialing" && sub.TrialEnd <= boundary {
+		previous["status"] = sub.Status
+		sub.Status = "active"
+	}
+	if sched, ok := s.schedules[sub.Schedule]; ok && sched.Status == "active" {
+		if phase := sched.phaseAt(boundary); phase != nil && phase.PriceID != sub.PriceID {
+			previous["items"] = s.renderItems(sub)
+			previous["plan"] = renderPlan(s.prices[sub.PriceID])
+			sub.PriceID = phase.PriceID
+		}
+		if last := sched.Phases[len(sched.Phases)-1]; last.EndDate != 0 && boundary >= last.EndDate {
+			s.completeSchedule(sched, sub)
+			if sub.Status == "canceled" {
+				return true
+			}
+		}
+	}
+
+	// Срок приостановки истек: списания возобновляются автоматически
+	if sub.PauseResumesAt != 0 && sub.PauseResumesAt <= boundary {
+		previous["pause_collection"] = renderPauseCollection(sub)
+		sub.PauseBehavior, sub.PauseResumesAt = "", 0
+	}
+	// Скидка закончилась (once-купон уже применен или истек срок repeating-купона)
+	if s.discountEnded(sub, boundary) {
+		previous["discount"] = s.renderDiscount(sub)
+		s.removeDiscount(sub)
+	}
+
+	price := s.prices[sub.PriceID]
+	sub.CurrentPeriodStart = boundary
+	sub.CurrentPeriodEnd = periodEnd(time.Unix(boundary, 0), price.Interval).Unix()
+	s.emit("customer.subscription.updated", s.renderSubscription(sub, nil), previous)
+
+	inv := s.newInvoice(sub, price, "subscription_cycle")
+	sub.LatestInvoice = inv.ID
+	switch sub.PauseBehavior {
+	case "":
+		s.pay(inv)
+	case "keep_as_draft":
+		s.setInvoiceStatus(inv, "draft")
+	case "void":
+		s.setInvoiceStatus(inv, "void")
+	case "mark_uncollectible":
+		s.setInvoiceStatus(inv, "uncollectible")
+	}
+	return true
+}
+
+// --- Внутренние операции (вызываются под s.mu) ---
+
+// phaseAt возвращает фазу, действующую в момент at.
+func (sched *SubscriptionSchedule) phaseAt(at int64) *SchedulePhase {
+	for i := range sched.Phases {
+		phase := &sched.Phases[i]
+		if phase.StartDate <= at && (phase.EndDate == 0 || at < phase.EndDate) {
+			return phase
+		}
+	}
+	return nil
+}
+
+// completeSchedule завершает расписание по end_behavior: release оставляет подписку как есть, cancel отменяет ее.
+func (s *Server) completeSchedule(sched *SubscriptionSchedule, sub *Subscription) {
+	sub.Schedule = ""
+	if sched.EndBehavior == "cancel" {
+		sched.Status = "canceled"
+		s.emit("subscription_schedule.canceled", s.renderSchedule(sched), nil)
+		s.cancel(sub)
+		return
+	}
+	sched.Status = "released"
+	s.emit("subscription_schedule.released", s.renderSchedule(sched), nil)
+}
+
+// proration сумма перерасчета при смене цены fromPriceID на toPriceID в момент at (0 - сейчас):
+// возврат неиспользованной части старой цены плюс оплата остатка периода по новой.
+func (s *Server) proration(sub *Subscription, fromPriceID, toPriceID string, at int64) int64 {
+	return remainingShare(sub, s.prices[toPriceID].UnitAmount, at) - remainingShare(sub, s.prices[fromPriceID].UnitAmount, at)
+}
+
+// remainingShare часть суммы amount, приходящаяся на остаток текущего периода начиная с at (0 - сейчас).
+func remainingShare(sub *Subscription, amount, at int64) int64 {
+	if at == 0 {
+		at = now()
+	}
+	period := sub.CurrentPeriodEnd - sub.CurrentPeriodStart
+	remaining := sub.CurrentPeriodEnd - at
+	if period <= 0 || remaining <= 0 {
+		return 0
+	}
+	return amount * min(remaining, period) / period
+}
+
+// --- Эндпоинты ---
+
+// createSchedule поддерживает только from_subscription: расписание из одной фазы, совпадающей с текущим периодом.
+func (s *Server) createSchedule(r *http.Request) (interface{}, *apiError) {
+	subID := r.Form.Get("from_subscription")
+	if subID == "" {
+		return nil, invalidParam("from_subscription", "Missing required param: from_subscription.")
+	}
+	sub, ok := s.subscriptions[subID]
+	if !ok {
+		return nil, notFound("subscription", subID, "from_subscription")
+	}
+	if sub.Status == "canceled" {
+		return nil, invalidParam("from_subscription", "You cannot migrate a canceled subscription to a schedule.")
+	}
+	if sub.Schedule != "" {
+		return nil, invalidParam("from_subscription", fmt.Sprintf("You cannot migrate a subscription that is already attached to a schedule: `%s`.", sub.Schedule))
+	}
+
+	sched := &SubscriptionSchedule{
+		ID:           s.newID("sub_sched"),
+		Subscription: sub.ID,
+		Customer:     sub.Customer,
+		Status:       "active",
+		EndBehavior:  "release",
+		Phases: []SchedulePhase{{
+			PriceID:   sub.PriceID,
+			Quantity:  1,
+			StartDate: sub.CurrentPeriodStart,
+			EndDate:   sub.CurrentPeriodEnd,
+		}},
+		Created: now(),
+	}
+	s.schedules[sched.ID] = sched
+	s.remember("subscription_schedule", sched.ID)
+	sub.Schedule = sched.ID
+	s.emit("subscription_schedule.created", s.renderSchedule(sched), nil)
+	return s.renderSchedule(sched), nil
+}
+
+func (s *Server) getSchedule(id string) (interface{}, *apiError) {
+	sched, ok := s.schedules[id]
+	if !ok {
+		return nil, notFound("subscription_schedule", id, "schedule")
+	}
+	return s.renderSchedule(sched), nil
+}
+
+// updateSchedule заменяет фазы расписания. Начало фазы без start_date - конец предыдущей,
+// конец фазы без end_date вычисляется по iterations и интервалу цены.
+func (s *Server) updateSchedule(id string, r *http.Request) (interface{}, *apiError) {
+	sched, ok := s.schedules[id]
+	if !ok {
+		return nil, notFound("subscription_schedule", id, "schedule")
+	}
+	if sched.Status != "active" {
+		return nil, invalidParam("", "You cannot update a subscription schedule that is currently in the `"+sched.Status+"` status.")
+	}
+	if value, ok := r.Form["end_behavior"]; ok {
+		sched.EndBehavior = value[0]
+	}
+
+	var phases []SchedulePhase
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("phases[%d]", i)
+		priceID := r.Form.Get(prefix + "[items][0][price]")
+		if priceID == "" {
+			break
+		}
+		price, ok := s.prices[priceID]
+		if !ok {
+			return nil, notFound("price", priceID, prefix+"[items][0][price]")
+		}
+		phase := SchedulePhase{PriceID: priceID, Quantity: 1}
+		if q, err := strconv.ParseInt(r.Form.Get(prefix+"[items][0][quantity]"), 10, 64); err == nil {
+			phase.Quantity = q
+		}
+		phase.StartDate, _ = strconv.ParseInt(r.Form.Get(prefix+"[start_date]"), 10, 64)
+		if phase.StartDate == 0 && len(phases) > 0 {
+			phase.StartDate = phases[len(phases)-1].EndDate
+		}
+		if phase.StartDate == 0 {
+			return nil, invalidParam(prefix+"[start_date]", "The first phase must have a start_date.")
+		}
+		phase.EndDate, _ = strconv.ParseInt(r.Form.Get(prefix+"[end_date]"), 10, 64)
+		if iterations, _ := strconv.Atoi(r.Form.Get(prefix + "[iterations]")); phase.EndDate == 0 && iterations > 0 {
+			end := time.Unix(phase.StartDate, 0)
+			for n := 0; n < iterations; n++ {
+				end = periodEnd(end, price.Interval)
+			}
+			phase.EndDate = end.Unix()
+		}
+		phases = append(phases, phase)
+	}
+	if len(phases) > 0 {
+		sched.Phases = phases
+	}
+
+	s.emit("subscription_schedule.updated", s.renderSchedule(sched), nil)
+	return s.renderSchedule(sched), nil
+}
+
+func (s *Server) releaseSchedule(id string) (interface{}, *apiError) {
+	sched, ok := s.schedules[id]
+	if !ok {
+		return nil, notFound("subscription_schedule", id, "schedule")
+	}
+	if sched.Status != "active" {
+		return nil, invalidParam("", "You cannot release a subscription schedule that is currently in the `"+sched.Status+"` status.")
+	}
+	if sub, ok := s.subscriptions[sched.Subscription]; ok && sub.Schedule == sched.ID {
+		sub.Schedule = ""
+	}
+	sched.Status = "released"
+	s.emit("subscription_schedule.released", s.renderSchedule(sched), nil)
+	return s.renderSchedule(sched), nil
+}
+
+// upcomingInvoice показывает следующий счет подписки. Поддерживает предпросмотр смены цены
+// (subscription_items[0][price], subscription_proration_behavior, subscription_proration_date).
+func (s *Server) upcomingInvoice(r *http.Request) (interface{}, *apiError) {
+	subID := r.Form.Get("subscription")
+	sub, ok := s.subscriptions[subID]
+	if !ok {
+		return nil, notFound("subscription", subID, "subscription")
+	}
+	if sub.Status == "canceled" {
+		return nil, &apiError{status: http.StatusNotFound, Type: errorTypeInvalidRequest, Code: "invoice_upcoming_none", Message: "No upcoming invoices for customer: " + sub.Customer}
+	}
+
+	nextPriceID := sub.PriceID
+	if sched, ok := s.schedules[sub.Schedule]; ok && sched.Status == "active" {
+		if phase := sched.phaseAt(sub.CurrentPeriodEnd); phase != nil {
+			nextPriceID = phase.PriceID
+		}
+	}
+
+	lines := []interface{}{}
+	total := int64(0)
+	addLine := func(priceID string, amount int64, proration bool, start, end int64) {
+		price := s.prices[priceID]
+		lines = append(lines, map[string]interface{}{
+			"id":        s.newID("il_tmp"),
+			"object":    "line_item",
+			"amount":    amount,
+			"currency":  price.Currency,
+			"proration": proration,
+			"period":    map[string]interface{}{"start": start, "end": end},
+			"price":     s.renderPrice(price, false),
+		})
+		total += amount
+	}
+
+	if sub.PendingProration != 0 {
+		addLine(sub.PriceID, sub.PendingProration, true, sub.CurrentPeriodStart, sub.CurrentPeriodEnd)
+	}
+	if priceID := r.Form.Get("subscription_items[0][price]"); priceID != "" {
+		price, ok := s.prices[priceID]
+		if !ok || !price.Active {
+			return nil, notFound("price", priceID, "subscription_items[0][price]")
+		}
+		if priceID != sub.PriceID && r.Form.Get("subscription_proration_behavior") != "none" {
+			at, _ := strconv.ParseInt(r.Form.Get("subscription_proration_date"), 10, 64)
+			if at == 0 {
+				at = now()
+			}
+			// Как в Stripe: отдельные строки за неиспользованное время по старой цене и оставшееся по новой
+			addLine(sub.PriceID, -remainingShare(sub, s.prices[sub.PriceID].UnitAmount, at), true, at, sub.CurrentPeriodEnd)
+			addLine(priceID, remainingShare(sub, price.UnitAmount, at), true, at, sub.CurrentPeriodEnd)
+		}
+		nextPriceID = priceID
+	}
+	next := s.prices[nextPriceID]
+	addLine(nextPriceID, next.UnitAmount, false, sub.CurrentPeriodEnd, periodEnd(time.Unix(sub.CurrentPeriodEnd, 0), next.Interval).Unix())
+
+	return map[string]interface{}{
+		"object":               "invoice",
+		"customer":             sub.Customer,
+		"subscription":         sub.ID,
+		"status":               "draft",
+		"billing_reason":       "upcoming",
+		"amount_due":           max(total, 0),
+		"amount_paid":          0,
+		"amount_remaining":     max(total, 0),
+		"subtotal":             total,
+		"total":                total,
+		"currency":             next.Currency,
+		"period_start":         sub.CurrentPeriodStart,
+		"period_end":           sub.CurrentPeriodEnd,
+		"next_payment_attempt": sub.CurrentPeriodEnd,
+		"lines":                renderList("/v1/invoices/upcoming/lines", lines, nil),
+		"created":              now(),
+		"livemode":             false,
+	}, nil
+}
+
+// --- Представление объектов в формате Stripe API ---
+
+func (s *Server) renderSchedule(sched *SubscriptionSchedule) map[string]interface{} {
+	phases := make([]interface{}, len(sched.Phases))
+	for i, phase := range sched.Phases {
+		phases[i] = map[string]interface{}{
+			"start_date": phase.StartDate,
+			"end_date":   phase.EndDate,
+			"items": []interface{}{
+				map[string]interface{}{"price": phase.PriceID, "quantity": phase.Quantity},
+			},
+		}
+	}
+	out := map[string]interface{}{
+		"id":                    sched.ID,
+		"object":                "subscription_schedule",
+		"customer":              sched.Customer,
+		"subscription":          sched.Subscription,
+		"status":                sched.Status,
+		"end_behavior":          sched.EndBehavior,
+		"phases":                phases,
+		"current_phase":         nil,
+		"released_subscription": nil,
+		"created":               sched.Created,
+		"livemode":              false,
+	}
+	if sched.Status == "released" {
+		out["subscription"] = nil
+		out["released_subscription"] = sched.Subscription
+	}
+	if phase := sched.phaseAt(now()); phase != nil && sched.Status == "active" {
+		out["current_phase"] = map[string]interface{}{"start_date": phase.StartDate, "end_date": phase.EndDate}
+	}
+	return out
+}
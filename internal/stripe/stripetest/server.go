@@ -0,0 +1,363 @@
+// Package stripetest содержит поддельный Stripe API, работающий внутри процесса.
+// Сервер хранит состояние в памяти, поддерживает эндпоинты, которые использует сервис
+// (customers, subscriptions, subscription_schedules, invoices, payment_intents (включая разовые), setup_intents,
+// payment_methods, prices, products, coupons, promotion_codes, checkout/sessions, billing_portal/sessions,
+// charges, refunds, credit_notes), подписывает и рассылает вебхуки и позволяет внедрять сбои (429, 5xx, ошибки карты).
+// Используется для интеграционных тестов и локального запуска сервиса без доступа к сети (см. cmd/stripe-fake).
+package stripetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+// DefaultWebhookSecret секрет подписи вебхуков, если Options.WebhookSecret не задан.
+const DefaultWebhookSecret = "whsec_stripetest"
+
+// Options настройки поддельного сервера.
+type Options struct {
+	WebhookSecret string // Секрет подписи вебхуков (по умолчанию DefaultWebhookSecret)
+	WebhookURL    string // Куда отправлять события (пусто - события только сохраняются, см. Events)
+}
+
+// Request запрос, полученный сервером (для проверки повторов и идемпотентности в тестах).
+type Request struct {
+	Method         string
+	Path           string
+	IdempotencyKey string
+}
+
+// idempotentResponse сохраненный ответ на запрос с Idempotency-Key.
+type idempotentResponse struct {
+	request string // Путь и параметры исходного запроса: Stripe отклоняет тот же ключ с другими параметрами
+	status  int
+	body    []byte
+}
+
+// Server поддельный Stripe API.
+type Server struct {
+	webhookSecret string
+	webhookURL    string
+	httpServer    *httptest.Server
+
+	mu               sync.Mutex
+	seq              int
+	products         map[string]*Product
+	prices           map[string]*Price
+	customers        map[string]*Customer
+	subscriptions    map[string]*Subscription
+	schedules        map[string]*SubscriptionSchedule
+	invoices         map[string]*Invoice
+	paymentIntents   map[string]*PaymentIntent
+	setupIntents     map[string]*SetupIntent
+	paymentMethods   map[string]*PaymentMethod
+	checkoutSessions map[string]*CheckoutSession
+	charges          map[string]*Charge
+	refunds          map[string]*Refund
+	creditNotes      map[string]*CreditNote
+	coupons          map[string]*Coupon
+	promotionCodes   map[string]*PromotionCode
+	order            map[string][]string // Порядок создания объектов по типу (для списков и поиска)
+	idempotent       map[string]idempotentResponse
+	faults           []*Fault
+	requests         []Request
+	events           []Event
+
+	deliveries chan Event
+	closed     bool
+	delivered  sync.WaitGroup
+}
+
+// New создает сервер без запуска HTTP. Server реализует http.Handler, поэтому его можно
+// отдать http.ListenAndServe или запустить на случайном порту через Start.
+func New(opts Options) *Server {
+	if opts.WebhookSecret == "" {
+		opts.WebhookSecret = DefaultWebhookSecret
+	}
+	s := &Server{
+		webhookSecret:    opts.WebhookSecret,
+		webhookURL:       opts.WebhookURL,
+		products:         make(map[string]*Product),
+		prices:           make(map[string]*Price),
+		customers:        make(map[string]*Customer),
+		subscriptions:    make(map[string]*Subscription),
+		schedules:        make(map[string]*SubscriptionSchedule),
+		invoices:         make(map[string]*Invoice),
+		paymentIntents:   make(map[string]*PaymentIntent),
+		setupIntents:     make(map[string]*SetupIntent),
+		paymentMethods:   make(map[string]*PaymentMethod),
+		checkoutSessions: make(map[string]*CheckoutSession),
+		charges:          make(map[string]*Charge),
+		refunds:          make(map[string]*Refund),
+		creditNotes:      make(map[string]*CreditNote),
+		coupons:          make(map[string]*Coupon),
+		promotionCodes:   make(map[string]*PromotionCode),
+		order:            make(map[string][]string),
+		idempotent:       make(map[string]idempotentResponse),
+	}
+	if s.webhookURL != "" {
+		s.deliveries = make(chan Event, 256)
+		s.delivered.Add(1)
+		go s.deliverWebhooks()
+	}
+	return s
+}
+
+// Start запускает сервер на случайном локальном порту и возвращает его адрес.
+func (s *Server) Start() string {
+	s.httpServer = httptest.NewServer(s)
+	return s.httpServer.URL
+}
+
+// URL возвращает адрес запущенного сервера (пусто, если Start не вызывался).
+func (s *Server) URL() string {
+	if s.httpServer == nil {
+		return ""
+	}
+	return s.httpServer.URL
+}
+
+// Client создает stripe.Client сервиса, направленный на этот сервер (метрики клиента - в собственном реестре).
+func (s *Server) Client(log *logger.Logger) stripe.Client {
+	return stripe.NewStripeClient("sk_test_stripetest", s.URL(), metrics.NewRegistry(), log)
+}
+
+// WebhookSecret возвращает секрет, которым подписываются вебхуки.
+func (s *Server) WebhookSecret() string {
+	return s.webhookSecret
+}
+
+// Close останавливает HTTP сервер и дожидается отправки уже созданных вебхуков.
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+	s.mu.Lock()
+	if !s.closed && s.deliveries != nil {
+		close(s.deliveries)
+	}
+	s.closed = true
+	s.mu.Unlock()
+	s.delivered.Wait()
+}
+
+// Requests возвращает все полученные запросы в порядке поступления.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestCount возвращает число запросов с указанным методом и путем (включая повторы клиента).
+func (s *Server) RequestCount(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if r.Method == method && r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+// ServeHTTP маршрутизирует запросы к эндпоинтам Stripe API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, &apiError{status: http.StatusUnauthorized, Type: errorTypeInvalidRequest, Message: "You did not provide an API key."})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, &apiError{status: http.StatusBadRequest, Type: errorTypeInvalidRequest, Message: "Invalid request body."})
+		return
+	}
+
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, IdempotencyKey: idempotencyKey})
+
+	if fault := s.takeFault(r.Method, r.URL.Path); fault != nil {
+		writeError(w, fault.apiError())
+		return
+	}
+
+	// Повтор запроса с тем же ключом идемпотентности получает сохраненный ответ, как в Stripe
+	request := r.URL.Path + "?" + r.PostForm.Encode()
+	if idempotencyKey != "" && r.Method == http.MethodPost {
+		if saved, ok := s.idempotent[idempotencyKey]; ok {
+			if saved.request != request {
+				writeError(w, &apiError{
+					status:  http.StatusBadRequest,
+					Type:    errorTypeIdempotency,
+					Message: "Keys for idempotent requests can only be used with the same parameters they were first used with.",
+				})
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(saved.status)
+			_, _ = w.Write(saved.body)
+			return
+		}
+	}
+
+	result, apiErr := s.route(r)
+	status := http.StatusOK
+	var body []byte
+	if apiErr != nil {
+		status = apiErr.status
+		body = apiErr.body()
+	} else {
+		body, _ = json.Marshal(result)
+	}
+
+	// Ошибки сервера не сохраняются: Stripe разрешает повторить такой запрос с тем же ключом
+	if idempotencyKey != "" && r.Method == http.MethodPost && status < http.StatusInternalServerError {
+		s.idempotent[idempotencyKey] = idempotentResponse{request: request, status: status, body: body}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// route вызывает обработчик эндпоинта. Вызывается под s.mu.
+func (s *Server) route(r *http.Request) (interface{}, *apiError) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1"), "/"), "/")
+	// Ресурсы с пространством имен: checkout/sessions, billing_portal/sessions
+	if (parts[0] == "checkout" || parts[0] == "billing_portal") && len(parts) > 1 {
+		parts = append([]string{parts[0] + "/" + parts[1]}, parts[2:]...)
+	}
+	resource := parts[0]
+	id := ""
+	if len(parts) > 1 {
+		id = parts[1]
+	}
+	action := ""
+	if len(parts) > 2 {
+		action = parts[2]
+	}
+
+	switch {
+	case resource == "customers" && id == "search" && r.Method == http.MethodGet:
+		return s.searchCustomers(r)
+	case resource == "customers" && id == "" && r.Method == http.MethodPost:
+		return s.createCustomer(r)
+	case resource == "customers" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getCustomer(id)
+	case resource == "customers" && id != "" && action == "" && r.Method == http.MethodPost:
+		return s.updateCustomer(id, r)
+	case resource == "customers" && id != "" && action == "" && r.Method == http.MethodDelete:
+		return s.deleteCustomer(id)
+
+	case resource == "subscriptions" && id == "" && r.Method == http.MethodGet:
+		return s.listSubscriptions(r)
+	case resource == "subscriptions" && id == "" && r.Method == http.MethodPost:
+		return s.createSubscription(r)
+	case resource == "subscriptions" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getSubscription(id, r)
+	case resource == "subscriptions" && id != "" && action == "" && r.Method == http.MethodPost:
+		return s.updateSubscription(id, r)
+	case resource == "subscriptions" && id != "" && action == "" && r.Method == http.MethodDelete:
+		return s.cancelSubscription(id, r)
+
+	case resource == "subscription_schedules" && id == "" && r.Method == http.MethodPost:
+		return s.createSchedule(r)
+	case resource == "subscription_schedules" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getSchedule(id)
+	case resource == "subscription_schedules" && id != "" && action == "" && r.Method == http.MethodPost:
+		return s.updateSchedule(id, r)
+	case resource == "subscription_schedules" && id != "" && action == "release" && r.Method == http.MethodPost:
+		return s.releaseSchedule(id)
+
+	case resource == "invoices" && id == "upcoming" && r.Method == http.MethodGet:
+		return s.upcomingInvoice(r)
+	case resource == "invoices" && id == "" && r.Method == http.MethodGet:
+		return s.listInvoices(r)
+	case resource == "invoices" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getInvoice(id, r)
+	case resource == "invoices" && id != "" && action == "pay" && r.Method == http.MethodPost:
+		return s.payInvoiceRequest(id, r)
+
+	case resource == "payment_intents" && id == "" && r.Method == http.MethodPost:
+		return s.createPaymentIntent(r)
+	case resource == "payment_intents" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getPaymentIntent(id)
+	case resource == "charges" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getCharge(id)
+	case resource == "refunds" && id == "" && r.Method == http.MethodGet:
+		return s.listRefunds(r)
+	case resource == "refunds" && id == "" && r.Method == http.MethodPost:
+		return s.createRefund(r)
+	case resource == "refunds" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getRefund(id)
+	case resource == "credit_notes" && id == "" && r.Method == http.MethodPost:
+		return s.createCreditNote(r)
+	case resource == "setup_intents" && id == "" && r.Method == http.MethodPost:
+		return s.createSetupIntent(r)
+	case resource == "setup_intents" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getSetupIntent(id)
+
+	case resource == "payment_methods" && id == "" && r.Method == http.MethodGet:
+		return s.listPaymentMethods(r)
+	case resource == "payment_methods" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getPaymentMethod(id)
+	case resource == "payment_methods" && id != "" && action == "attach" && r.Method == http.MethodPost:
+		return s.attachPaymentMethod(id, r)
+	case resource == "payment_methods" && id != "" && action == "detach" && r.Method == http.MethodPost:
+		return s.detachPaymentMethod(id)
+
+	case resource == "prices" && id == "" && r.Method == http.MethodGet:
+		return s.listPrices(r)
+	case resource == "prices" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getPrice(id, r)
+	case resource == "products" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getProduct(id)
+
+	case resource == "coupons" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getCoupon(id, r)
+	case resource == "promotion_codes" && id == "" && r.Method == http.MethodGet:
+		return s.listPromotionCodes(r)
+
+	case resource == "checkout/sessions" && id == "" && r.Method == http.MethodPost:
+		return s.createCheckoutSession(r)
+	case resource == "checkout/sessions" && id != "" && action == "" && r.Method == http.MethodGet:
+		return s.getCheckoutSession(id)
+	case resource == "billing_portal/sessions" && id == "" && r.Method == http.MethodPost:
+		return s.createBillingPortalSession(r)
+	}
+
+	return nil, &apiError{
+		status:  http.StatusNotFound,
+		Type:    errorTypeInvalidRequest,
+		Message: fmt.Sprintf("Unrecognized request URL (%s: %s).", r.Method, r.URL.Path),
+	}
+}
+
+// newID выдает ID объекта с префиксом Stripe (cus_, sub_, in_, pi_ ...). Вызывается под s.mu.
+func (s *Server) newID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s_test%08d", prefix, s.seq)
+}
+
+// remember запоминает порядок создания объекта. Вызывается под s.mu.
+func (s *Server) remember(kind, id string) {
+	s.order[kind] = append(s.order[kind], id)
+}
+
+// now текущее время в формате Stripe (секунды Unix).
+func now() int64 {
+	return time.Now().Unix()
+}
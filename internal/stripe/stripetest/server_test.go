@@ -0,0 +1,167 @@
+package stripetest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/Dhoini/Payment-microservice/internal/stripe"
+	"github.com/Dhoini/Payment-microservice/internal/stripe/stripetest"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+	stripego "github.com/stripe/stripe-go/v78"
+	"github.com/stripe/stripe-go/v78/webhook"
+)
+
+const testPriceID = "price_basic"
+
+// newTestServer запускает поддельный Stripe с одной ценой и возвращает клиент сервиса, направленный на него.
+func newTestServer(t *testing.T, opts stripetest.Options) (*stripetest.Server, stripe.Client) {
+	t.Helper()
+	srv := stripetest.New(opts)
+	srv.AddPrice(stripetest.Price{ID: testPriceID, UnitAmount: 1000})
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, stripe.NewStripeClient("sk_test_stripetest", srv.URL(), metrics.NewRegistry(), logger.New(logger.FATAL))
+}
+
+func TestGetOrCreateCustomerFindsExistingCustomer(t *testing.T) {
+	srv, client := newTestServer(t, stripetest.Options{})
+	ctx := context.Background()
+
+	created, err := client.CreateCustomer(ctx, "user-1", "user1@example.com")
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	found, err := client.GetOrCreateCustomer(ctx, "user-1", "user1@example.com")
+	if err != nil {
+		t.Fatalf("GetOrCreateCustomer: %v", err)
+	}
+	if found != created {
+		t.Fatalf("GetOrCreateCustomer = %s, want customer found by metadata %s", found, created)
+	}
+	if n := srv.RequestCount(http.MethodPost, "/v1/customers"); n != 1 {
+		t.Fatalf("POST /v1/customers requests = %d, want 1", n)
+	}
+}
+
+func TestCreateCustomerAfterEmailChange(t *testing.T) {
+	_, client := newTestServer(t, stripetest.Options{})
+	ctx := context.Background()
+
+	if _, err := client.CreateCustomer(ctx, "user-1", "old@example.com"); err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	// Повторный вызов с другими параметрами не должен упираться в ключ идемпотентности первого вызова
+	if _, err := client.CreateCustomer(ctx, "user-1", "new@example.com"); err != nil {
+		t.Fatalf("CreateCustomer with changed email: %v", err)
+	}
+}
+
+func TestInjectedFaults(t *testing.T) {
+	srv, client := newTestServer(t, stripetest.Options{})
+	ctx := context.Background()
+
+	customerID, err := client.CreateCustomer(ctx, "user-1", "user1@example.com")
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+
+	// 500 повторяется stripe-go, и вызов проходит со второй попытки
+	srv.InjectFault(stripetest.ServerError(http.MethodPost, "/v1/subscriptions", 1))
+	sub, err := client.CreateSubscription(ctx, stripe.NewSubscription{CustomerID: customerID, PriceID: testPriceID, IdempotencyKey: "sub-1"})
+	if err != nil {
+		t.Fatalf("CreateSubscription after 500: %v", err)
+	}
+	if n := srv.RequestCount(http.MethodPost, "/v1/subscriptions"); n != 2 {
+		t.Fatalf("POST /v1/subscriptions requests = %d, want 2 (one retry)", n)
+	}
+
+	tests := []struct {
+		name       string
+		fault      stripetest.Fault
+		wantType   stripego.ErrorType
+		wantStatus int
+	}{
+		{"rate limit", stripetest.RateLimit(http.MethodGet, "/v1/subscriptions", 1), stripego.ErrorTypeInvalidRequest, http.StatusTooManyRequests},
+		{"card declined", stripetest.CardDeclined(http.MethodGet, "/v1/subscriptions", "insufficient_funds"), stripego.ErrorTypeCard, http.StatusPaymentRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.InjectFault(tt.fault)
+			_, err := client.GetSubscription(ctx, sub.ID)
+			var stripeErr *stripego.Error
+			if !errors.As(err, &stripeErr) {
+				t.Fatalf("GetSubscription error = %v, want *stripe.Error", err)
+			}
+			if stripeErr.Type != tt.wantType || stripeErr.HTTPStatusCode != tt.wantStatus {
+				t.Fatalf("error type/status = %s/%d, want %s/%d", stripeErr.Type, stripeErr.HTTPStatusCode, tt.wantType, tt.wantStatus)
+			}
+
+			// Сбой срабатывает заданное число раз, дальше сервер отвечает как обычно
+			if _, err := client.GetSubscription(ctx, sub.ID); err != nil {
+				t.Fatalf("GetSubscription after fault: %v", err)
+			}
+		})
+	}
+}
+
+func TestSignedWebhookRoundTrip(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		event, err := webhook.ConstructEvent(payload, r.Header.Get("Stripe-Signature"), stripetest.DefaultWebhookSecret)
+		if err != nil {
+			t.Errorf("webhook signature verification failed: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, string(event.Type))
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	srv, client := newTestServer(t, stripetest.Options{WebhookURL: receiver.URL})
+	ctx := context.Background()
+
+	customerID, err := client.CreateCustomer(ctx, "user-1", "user1@example.com")
+	if err != nil {
+		t.Fatalf("CreateCustomer: %v", err)
+	}
+	sub, err := client.CreateSubscription(ctx, stripe.NewSubscription{CustomerID: customerID, PriceID: testPriceID, IdempotencyKey: "sub-1"})
+	if err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	canceledAt, err := client.CancelSubscription(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("CancelSubscription: %v", err)
+	}
+	if canceledAt == nil {
+		t.Fatal("CancelSubscription returned no canceled_at")
+	}
+
+	srv.Close() // Дожидается доставки всех событий
+	mu.Lock()
+	defer mu.Unlock()
+	for _, want := range []string{"customer.subscription.created", "customer.subscription.deleted"} {
+		if !slices.Contains(received, want) {
+			t.Errorf("webhook %s was not delivered; received: %v", want, received)
+		}
+	}
+
+	// Событие, подписанное другим секретом, не проходит проверку
+	payload, _ := srv.NewEvent("customer.subscription.updated", map[string]interface{}{"id": sub.ID, "object": "subscription"}, *canceledAt)
+	forged := stripetest.New(stripetest.Options{WebhookSecret: "whsec_other"}).SignWebhook(payload)
+	if _, err := webhook.ConstructEvent(payload, forged, stripetest.DefaultWebhookSecret); err == nil {
+		t.Fatal("webhook signed with a different secret was accepted")
+	}
+}
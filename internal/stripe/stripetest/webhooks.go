@@ -0,0 +1,94 @@
+package stripetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	stripego "github.com/stripe/stripe-go/v78"
+	"github.com/stripe/stripe-go/v78/webhook"
+)
+
+// Event событие Stripe, созданное при изменении состояния сервера.
+type Event struct {
+	ID      string
+	Type    string
+	Created int64
+	Payload []byte // Тело события в формате Stripe (то, что получает эндпоинт вебхуков)
+}
+
+// Events возвращает все созданные события в порядке появления.
+func (s *Server) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+// SignWebhook возвращает значение заголовка Stripe-Signature для тела вебхука.
+func (s *Server) SignWebhook(payload []byte) string {
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload: payload,
+		Secret:  s.webhookSecret,
+	})
+	return signed.Header
+}
+
+// NewEvent создает подписанное событие произвольного типа, не меняя состояние сервера.
+// Удобно для проверки обработки событий, которые сервер сам не генерирует, или событий не по порядку.
+func (s *Server) NewEvent(eventType string, object map[string]interface{}, created time.Time) (payload []byte, signature string) {
+	s.mu.Lock()
+	event := s.buildEvent(eventType, object, nil, created.Unix())
+	s.mu.Unlock()
+	return event.Payload, s.SignWebhook(event.Payload)
+}
+
+// emit сохраняет событие и ставит его в очередь на отправку. Вызывается под s.mu.
+func (s *Server) emit(eventType string, object, previous map[string]interface{}) {
+	event := s.buildEvent(eventType, object, previous, now())
+	s.events = append(s.events, event)
+	if s.deliveries != nil && !s.closed {
+		s.deliveries <- event
+	}
+}
+
+// buildEvent формирует тело события. Вызывается под s.mu.
+func (s *Server) buildEvent(eventType string, object, previous map[string]interface{}, created int64) Event {
+	id := s.newID("evt")
+	data := map[string]interface{}{"object": object}
+	if len(previous) > 0 {
+		data["previous_attributes"] = previous
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"id":               id,
+		"object":           "event",
+		"api_version":      stripego.APIVersion, // webhook.ConstructEvent отклоняет события другой версии API
+		"created":          created,
+		"type":             eventType,
+		"data":             data,
+		"livemode":         false,
+		"pending_webhooks": 1,
+		"request":          map[string]interface{}{"id": nil, "idempotency_key": nil},
+	})
+	return Event{ID: id, Type: eventType, Created: created, Payload: payload}
+}
+
+// deliverWebhooks отправляет события на WebhookURL по одному, сохраняя порядок.
+// Неудачная доставка не повторяется: для проверки повторов тесты могут отправить Events() сами.
+func (s *Server) deliverWebhooks() {
+	defer s.delivered.Done()
+	client := &http.Client{Timeout: 10 * time.Second}
+	for event := range s.deliveries {
+		req, err := http.NewRequest(http.MethodPost, s.webhookURL, bytes.NewReader(event.Payload))
+		if err != nil {
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Stripe-Signature", s.SignWebhook(event.Payload))
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		_ = resp.Body.Close()
+	}
+}
@@ -0,0 +1,237 @@
+package webhookqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+const (
+	defaultWorkers      = 4
+	defaultPollInterval = 1 * time.Second
+	defaultBatchSize    = 50
+	defaultMaxAttempts  = 10
+
+	// Время на обработку одного события. Обработка не прерывается отменой контекста пула,
+	// чтобы при остановке сервиса уже начатые события дорабатывались до конца.
+	processTimeout = 1 * time.Minute
+
+	// Параметры экспоненциальной задержки между попытками обработки одного события
+	retryBaseDelay = 5 * time.Second
+	retryMaxDelay  = 1 * time.Hour
+)
+
+// Processor применяет сохраненное событие Stripe. Реализуется services.PaymentService.
+type Processor interface {
+	ProcessWebhookEvent(ctx context.Context, event *models.WebhookEvent) error
+}
+
+// Pool разбирает очередь вебхуков в БД фиксированным числом воркеров.
+// События одной подписки применяются в порядке создания в Stripe: ClaimBatch не выдает событие, пока
+// более раннее событие той же подписки ждет обработки или повторной попытки (в любой реплике), а события
+// одной пачки с одинаковым created попадают к одному воркеру.
+// Неудачные попытки повторяются с экспоненциальной задержкой; после maxAttempts событие уходит в dead-letter.
+type Pool struct {
+	repo         repository.WebhookEventRepository
+	processor    Processor
+	workers      int
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	handled      *metrics.CounterVec   // Попытки обработки по типу события и результату (processed/stale/failed/dead)
+	latency      *metrics.HistogramVec // Длительность обработки по типу события
+	log          *logger.Logger
+}
+
+// NewPool создает пул воркеров очереди вебхуков. Нулевые параметры заменяются значениями по умолчанию.
+// Результаты обработки событий считаются в registry.
+func NewPool(repo repository.WebhookEventRepository, processor Processor, workers int, pollInterval time.Duration, batchSize, maxAttempts int, registry *metrics.Registry, log *logger.Logger) *Pool {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	return &Pool{
+		repo:         repo,
+		processor:    processor,
+		workers:      workers,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		maxAttempts:  maxAttempts,
+		handled: registry.NewCounterVec("webhook_events_handled_total",
+			"Total number of Stripe webhook event processing attempts by event type and result.",
+			"event_type", "result"),
+		latency: registry.NewHistogramVec("webhook_event_processing_seconds",
+			"Stripe webhook event processing latency (seconds) by event type.",
+			nil, "event_type"),
+		log: log,
+	}
+}
+
+// Run запускает воркеры и цикл опроса очереди. Блокируется до отмены ctx и завершения начатых событий.
+func (p *Pool) Run(ctx context.Context) {
+	p.log.Infow("Webhook queue started. Workers: %d, PollInterval: %s, BatchSize: %d, MaxAttempts: %d",
+		p.workers, p.pollInterval, p.batchSize, p.maxAttempts)
+
+	queues := make([]chan *models.WebhookEvent, p.workers)
+	var batch, workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan *models.WebhookEvent, p.batchSize)
+		workers.Add(1)
+		go func(queue <-chan *models.WebhookEvent) {
+			defer workers.Done()
+			for event := range queue {
+				p.handle(ctx, event)
+				batch.Done()
+			}
+		}(queues[i])
+	}
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, queue := range queues {
+				close(queue)
+			}
+			workers.Wait()
+			p.log.Infow("Webhook queue stopped")
+			return
+		case <-ticker.C:
+			p.drain(ctx, queues, &batch)
+		}
+	}
+}
+
+// drain захватывает пачки событий и раздает их воркерам, пока очередь не опустеет.
+// Следующая пачка захватывается только после обработки предыдущей, поэтому одновременно в работе не больше batchSize событий.
+func (p *Pool) drain(ctx context.Context, queues []chan *models.WebhookEvent, batch *sync.WaitGroup) {
+	for ctx.Err() == nil {
+		events, err := p.repo.ClaimBatch(ctx, p.batchSize)
+		if err != nil {
+			p.log.Errorw("Webhook queue failed to claim events. Error: %v", err)
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+
+		batch.Add(len(events))
+		for _, event := range events {
+			queues[p.workerFor(event)] <- event
+		}
+		batch.Wait()
+
+		if len(events) < p.batchSize {
+			return
+		}
+	}
+}
+
+// workerFor выбирает воркер по ID подписки (или ID события, если подписки нет).
+func (p *Pool) workerFor(event *models.WebhookEvent) int {
+	key := event.EventID
+	if event.SubscriptionID != nil && *event.SubscriptionID != "" {
+		key = *event.SubscriptionID
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(p.workers))
+}
+
+// handle обрабатывает одно событие и записывает результат и попытку в журнал.
+func (p *Pool) handle(ctx context.Context, event *models.WebhookEvent) {
+	processCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), processTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	err := p.process(processCtx, event)
+	p.latency.Observe(time.Since(startedAt).Seconds(), event.EventType)
+
+	var outcome string
+	var markErr error
+	switch {
+	case err == nil:
+		outcome = models.WebhookEventStatusProcessed
+		markErr = p.repo.MarkProcessed(processCtx, event.EventID)
+		p.log.Infow("Webhook event processed. EventID: %s, Type: %s, Attempt: %d", event.EventID, event.EventType, event.Attempts)
+
+	case errors.Is(err, services.ErrStaleWebhookEvent):
+		outcome = models.WebhookEventStatusStale
+		markErr = p.repo.MarkStale(processCtx, event.EventID, err.Error())
+
+	case event.Attempts >= p.maxAttempts:
+		outcome = models.WebhookEventStatusDead
+		p.log.Errorw("Webhook event moved to dead-letter. EventID: %s, Type: %s, Attempts: %d, Error: %v",
+			event.EventID, event.EventType, event.Attempts, err)
+		markErr = p.repo.MarkDead(processCtx, event.EventID, err.Error())
+
+	default:
+		outcome = models.WebhookEventStatusFailed
+		nextAttemptAt := time.Now().Add(retryDelay(event.Attempts))
+		p.log.Warnw("Webhook event processing failed. EventID: %s, Type: %s, Attempt: %d, NextAttemptAt: %s, Error: %v",
+			event.EventID, event.EventType, event.Attempts, nextAttemptAt, err)
+		markErr = p.repo.MarkFailed(processCtx, event.EventID, err.Error(), nextAttemptAt)
+	}
+	p.handled.Inc(event.EventType, outcome)
+	if markErr != nil {
+		// Событие останется в processing и будет захвачено повторно после таймаута
+		p.log.Errorw("Webhook queue failed to record event result. EventID: %s, Outcome: %s, Error: %v", event.EventID, outcome, markErr)
+	}
+
+	attempt := &models.WebhookEventAttempt{
+		EventID:    event.EventID,
+		Attempt:    event.Attempts,
+		Trigger:    models.WebhookAttemptTriggerQueue,
+		Outcome:    outcome,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
+	if err != nil {
+		errMsg := err.Error()
+		attempt.Error = &errMsg
+	}
+	if err := p.repo.RecordAttempt(processCtx, attempt); err != nil {
+		p.log.Errorw("Webhook queue failed to record attempt history. EventID: %s, Error: %v", event.EventID, err)
+	}
+}
+
+// process вызывает Processor, превращая панику в ошибку, чтобы одно событие не останавливало воркер.
+func (p *Pool) process(ctx context.Context, event *models.WebhookEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while processing webhook event: %v", r)
+		}
+	}()
+	return p.processor.ProcessWebhookEvent(ctx, event)
+}
+
+// retryDelay вычисляет задержку после попытки номер attempt (5, 10, 20, ... секунд, но не больше retryMaxDelay).
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,240 @@
+package webhookqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Dhoini/Payment-microservice/internal/metrics"
+	"github.com/Dhoini/Payment-microservice/internal/models"
+	"github.com/Dhoini/Payment-microservice/internal/repository"
+	"github.com/Dhoini/Payment-microservice/internal/services"
+	"github.com/Dhoini/Payment-microservice/pkg/logger"
+)
+
+// fakeWebhookEventRepo отдает заданные пачки событий и запоминает записанные результаты.
+type fakeWebhookEventRepo struct {
+	repository.WebhookEventRepository
+
+	mu        sync.Mutex
+	batches   [][]*models.WebhookEvent
+	outcomes  map[string]string    // Результат по ID события
+	nextRetry map[string]time.Time // Время следующей попытки для MarkFailed
+	attempts  []*models.WebhookEventAttempt
+}
+
+func newFakeRepo(batches ...[]*models.WebhookEvent) *fakeWebhookEventRepo {
+	return &fakeWebhookEventRepo{batches: batches, outcomes: make(map[string]string), nextRetry: make(map[string]time.Time)}
+}
+
+func (r *fakeWebhookEventRepo) ClaimBatch(_ context.Context, _ int) ([]*models.WebhookEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.batches) == 0 {
+		return nil, nil
+	}
+	batch := r.batches[0]
+	r.batches = r.batches[1:]
+	return batch, nil
+}
+
+func (r *fakeWebhookEventRepo) mark(eventID, outcome string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes[eventID] = outcome
+	return nil
+}
+
+func (r *fakeWebhookEventRepo) MarkProcessed(_ context.Context, eventID string) error {
+	return r.mark(eventID, models.WebhookEventStatusProcessed)
+}
+
+func (r *fakeWebhookEventRepo) MarkStale(_ context.Context, eventID, _ string) error {
+	return r.mark(eventID, models.WebhookEventStatusStale)
+}
+
+func (r *fakeWebhookEventRepo) MarkDead(_ context.Context, eventID, _ string) error {
+	return r.mark(eventID, models.WebhookEventStatusDead)
+}
+
+func (r *fakeWebhookEventRepo) MarkFailed(_ context.Context, eventID, _ string, nextAttemptAt time.Time) error {
+	r.mu.Lock()
+	r.nextRetry[eventID] = nextAttemptAt
+	r.mu.Unlock()
+	return r.mark(eventID, models.WebhookEventStatusFailed)
+}
+
+func (r *fakeWebhookEventRepo) RecordAttempt(_ context.Context, attempt *models.WebhookEventAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+// fakeProcessor возвращает ошибку err (или паникует) и запоминает порядок обработки событий.
+type fakeProcessor struct {
+	mu        sync.Mutex
+	err       error
+	panics    bool
+	processed []string
+}
+
+func (p *fakeProcessor) ProcessWebhookEvent(_ context.Context, event *models.WebhookEvent) error {
+	if p.panics {
+		panic("boom")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed = append(p.processed, event.EventID)
+	return p.err
+}
+
+func newTestPool(repo *fakeWebhookEventRepo, processor Processor, workers, batchSize, maxAttempts int, registry *metrics.Registry) *Pool {
+	return NewPool(repo, processor, workers, 10*time.Millisecond, batchSize, maxAttempts, registry, logger.New(logger.FATAL))
+}
+
+func TestHandleRecordsOutcome(t *testing.T) {
+	tests := []struct {
+		name        string
+		processor   *fakeProcessor
+		attempts    int
+		wantOutcome string
+	}{
+		{"processed", &fakeProcessor{}, 1, models.WebhookEventStatusProcessed},
+		{"stale", &fakeProcessor{err: fmt.Errorf("%w: subscription sub_1", services.ErrStaleWebhookEvent)}, 1, models.WebhookEventStatusStale},
+		{"failed with retry", &fakeProcessor{err: errors.New("stripe unavailable")}, 2, models.WebhookEventStatusFailed},
+		{"dead at max attempts", &fakeProcessor{err: errors.New("stripe unavailable")}, 3, models.WebhookEventStatusDead},
+		{"panic is a failure", &fakeProcessor{panics: true}, 1, models.WebhookEventStatusFailed},
+		// Устаревшее событие не уходит в dead-letter даже на последней попытке
+		{"stale at max attempts", &fakeProcessor{err: services.ErrStaleWebhookEvent}, 3, models.WebhookEventStatusStale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo()
+			registry := metrics.NewRegistry()
+			pool := newTestPool(repo, tt.processor, 1, 10, 3, registry)
+			event := &models.WebhookEvent{EventID: "evt_1", EventType: "invoice.paid", Attempts: tt.attempts}
+
+			startedAt := time.Now()
+			pool.handle(context.Background(), event)
+
+			if got := repo.outcomes["evt_1"]; got != tt.wantOutcome {
+				t.Fatalf("outcome = %q, want %q", got, tt.wantOutcome)
+			}
+			if tt.wantOutcome == models.WebhookEventStatusFailed {
+				if want := startedAt.Add(retryDelay(tt.attempts)); repo.nextRetry["evt_1"].Before(want) {
+					t.Fatalf("next attempt at %s, want not before %s", repo.nextRetry["evt_1"], want)
+				}
+			}
+
+			if len(repo.attempts) != 1 {
+				t.Fatalf("recorded %d attempts, want 1", len(repo.attempts))
+			}
+			attempt := repo.attempts[0]
+			if attempt.Attempt != tt.attempts || attempt.Outcome != tt.wantOutcome || attempt.Trigger != models.WebhookAttemptTriggerQueue {
+				t.Fatalf("attempt = %+v, want attempt %d with outcome %q from the queue", attempt, tt.attempts, tt.wantOutcome)
+			}
+			if (attempt.Error != nil) != (tt.wantOutcome != models.WebhookEventStatusProcessed) {
+				t.Fatalf("attempt error = %v for outcome %q", attempt.Error, tt.wantOutcome)
+			}
+
+			var out strings.Builder
+			if _, err := registry.WriteTo(&out); err != nil {
+				t.Fatalf("WriteTo: %v", err)
+			}
+			want := fmt.Sprintf(`webhook_events_handled_total{event_type="invoice.paid",result="%s"} 1`, tt.wantOutcome)
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("metrics output does not contain %q:\n%s", want, out.String())
+			}
+		})
+	}
+}
+
+func TestDrainKeepsSubscriptionOrder(t *testing.T) {
+	subA, subB := "sub_a", "sub_b"
+	var batch []*models.WebhookEvent
+	for i := 1; i <= 20; i++ {
+		sub := &subA
+		if i%2 == 0 {
+			sub = &subB
+		}
+		batch = append(batch, &models.WebhookEvent{EventID: fmt.Sprintf("evt_%02d", i), EventType: "invoice.paid", SubscriptionID: sub, Attempts: 1})
+	}
+	repo := newFakeRepo(batch)
+	processor := &fakeProcessor{}
+	pool := newTestPool(repo, processor, 4, len(batch)+1, 3, metrics.NewRegistry())
+
+	queues := make([]chan *models.WebhookEvent, pool.workers)
+	var wg, workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan *models.WebhookEvent, pool.batchSize)
+		workers.Add(1)
+		go func(queue <-chan *models.WebhookEvent) {
+			defer workers.Done()
+			for event := range queue {
+				pool.handle(context.Background(), event)
+				wg.Done()
+			}
+		}(queues[i])
+	}
+	pool.drain(context.Background(), queues, &wg)
+	for _, queue := range queues {
+		close(queue)
+	}
+	workers.Wait()
+
+	// События одной подписки попадают к одному воркеру и обрабатываются в порядке пачки
+	var gotA, gotB, wantA, wantB []string
+	for _, id := range processor.processed {
+		if id[len(id)-1]%2 == 1 {
+			gotA = append(gotA, id)
+		} else {
+			gotB = append(gotB, id)
+		}
+	}
+	for _, e := range batch {
+		if *e.SubscriptionID == subA {
+			wantA = append(wantA, e.EventID)
+		} else {
+			wantB = append(wantB, e.EventID)
+		}
+	}
+	if !slices.Equal(gotA, wantA) || !slices.Equal(gotB, wantB) {
+		t.Fatalf("processing order broken:\nsub_a %v, want %v\nsub_b %v, want %v", gotA, wantA, gotB, wantB)
+	}
+}
+
+func TestWorkerForIsStablePerSubscription(t *testing.T) {
+	pool := newTestPool(newFakeRepo(), &fakeProcessor{}, 8, 10, 3, metrics.NewRegistry())
+	sub := "sub_1"
+	first := pool.workerFor(&models.WebhookEvent{EventID: "evt_1", SubscriptionID: &sub})
+	for i := 2; i <= 50; i++ {
+		if got := pool.workerFor(&models.WebhookEvent{EventID: fmt.Sprintf("evt_%d", i), SubscriptionID: &sub}); got != first {
+			t.Fatalf("event %d of the same subscription went to worker %d, want %d", i, got, first)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{10, 2560 * time.Second},
+		{11, retryMaxDelay},
+		{100, retryMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_id VARCHAR(255) NOT NULL,
+    topic VARCHAR(255) NOT NULL,
+    message_key VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ NULL
+    );
+
+-- Частичный индекс: релей читает только неопубликованные события в порядке вставки
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate_id ON outbox(aggregate_id);
+
+COMMENT ON TABLE outbox IS 'Transactional outbox: events written together with subscriptions rows and relayed to Kafka';
+COMMENT ON COLUMN outbox.aggregate_id IS 'Subscription ID the event belongs to; events are relayed in id order per aggregate';
+COMMENT ON COLUMN outbox.topic IS 'Kafka topic the event is published to';
+COMMENT ON COLUMN outbox.message_key IS 'Kafka message key used for partitioning';
+COMMENT ON COLUMN outbox.payload IS 'Serialized event body sent as the Kafka message value';
+COMMENT ON COLUMN outbox.attempts IS 'Number of failed publish attempts so far';
+COMMENT ON COLUMN outbox.next_attempt_at IS 'Earliest time the relay may retry publishing the event';
+COMMENT ON COLUMN outbox.published_at IS 'Timestamp when the event was acknowledged by Kafka (NULL while pending)';
+
+COMMIT;
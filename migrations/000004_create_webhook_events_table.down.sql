@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_webhook_events_received_at;
+DROP INDEX IF EXISTS idx_webhook_events_event_type;
+DROP INDEX IF EXISTS idx_webhook_events_status;
+DROP TABLE IF EXISTS webhook_events;
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhook_events (
+    event_id VARCHAR(255) PRIMARY KEY,
+    event_type VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    error TEXT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_status ON webhook_events(status);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_event_type ON webhook_events(event_type);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_received_at ON webhook_events(received_at);
+
+COMMENT ON TABLE webhook_events IS 'Log of verified Stripe webhook deliveries, used for deduplication by Stripe event ID';
+COMMENT ON COLUMN webhook_events.event_id IS 'Stripe Event ID (evt_...)';
+COMMENT ON COLUMN webhook_events.payload IS 'Raw webhook request body as received from Stripe';
+COMMENT ON COLUMN webhook_events.status IS 'Processing status (processing, processed, failed)';
+COMMENT ON COLUMN webhook_events.error IS 'Error returned by the last failed processing attempt';
+COMMENT ON COLUMN webhook_events.attempts IS 'Number of processing attempts';
+COMMENT ON COLUMN webhook_events.processed_at IS 'Timestamp when the event was processed successfully';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS last_event_at;
+
+COMMENT ON COLUMN webhook_events.status IS 'Processing status (processing, processed, failed)';
+
+COMMIT;
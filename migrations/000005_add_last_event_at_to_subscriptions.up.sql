@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS last_event_at TIMESTAMPTZ NULL;
+
+COMMENT ON COLUMN subscriptions.last_event_at IS 'Stripe "created" timestamp of the event that last changed the subscription; older events are ignored';
+COMMENT ON COLUMN webhook_events.status IS 'Processing status (processing, processed, failed, stale)';
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_webhook_events_subscription_id;
+DROP INDEX IF EXISTS idx_webhook_events_queue;
+
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS next_attempt_at;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS subscription_id;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS stripe_created_at;
+
+COMMENT ON TABLE webhook_events IS 'Log of verified Stripe webhook deliveries, used for deduplication by Stripe event ID';
+COMMENT ON COLUMN webhook_events.status IS 'Processing status (processing, processed, failed, stale)';
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS stripe_created_at TIMESTAMPTZ NULL;
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS subscription_id VARCHAR(255) NULL;
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Индекс для выборки очереди воркерами
+CREATE INDEX IF NOT EXISTS idx_webhook_events_queue ON webhook_events(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_events_subscription_id ON webhook_events(subscription_id);
+
+COMMENT ON TABLE webhook_events IS 'Durable queue and log of verified Stripe webhook deliveries, deduplicated by Stripe event ID';
+COMMENT ON COLUMN webhook_events.status IS 'Processing status (pending, processing, processed, failed, dead, stale)';
+COMMENT ON COLUMN webhook_events.stripe_created_at IS 'Stripe "created" timestamp of the event';
+COMMENT ON COLUMN webhook_events.subscription_id IS 'Stripe Subscription ID the event refers to, if any; events of one subscription are processed in order';
+COMMENT ON COLUMN webhook_events.next_attempt_at IS 'Earliest time of the next processing attempt (exponential backoff after failures)';
+
+COMMIT;
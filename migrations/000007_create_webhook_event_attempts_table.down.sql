@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_webhook_event_attempts_event_id;
+DROP TABLE IF EXISTS webhook_event_attempts;
+
+COMMIT;
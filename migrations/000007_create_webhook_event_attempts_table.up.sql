@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhook_event_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL REFERENCES webhook_events(event_id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    trigger VARCHAR(50) NOT NULL,
+    outcome VARCHAR(50) NOT NULL,
+    error TEXT NULL,
+    triggered_by VARCHAR(255) NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_webhook_event_attempts_event_id ON webhook_event_attempts(event_id);
+
+COMMENT ON TABLE webhook_event_attempts IS 'Processing history of Stripe webhook events (one row per attempt)';
+COMMENT ON COLUMN webhook_event_attempts.attempt IS 'Attempt number of the event at the time of processing';
+COMMENT ON COLUMN webhook_event_attempts.trigger IS 'What started the attempt (queue, replay)';
+COMMENT ON COLUMN webhook_event_attempts.outcome IS 'Resulting event status (processed, failed, dead, stale)';
+COMMENT ON COLUMN webhook_event_attempts.triggered_by IS 'User ID of the operator who requested a replay';
+
+COMMIT;
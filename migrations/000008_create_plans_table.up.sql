@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS plans (
+    plan_id VARCHAR(255) PRIMARY KEY,
+    product_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    nickname VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    billing_interval VARCHAR(20) NOT NULL,
+    interval_count INTEGER NOT NULL DEFAULT 1,
+    price_active BOOLEAN NOT NULL,
+    product_active BOOLEAN NOT NULL,
+    synced_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_plans_product_id ON plans(product_id);
+
+COMMENT ON TABLE plans IS 'Local copy of the Stripe recurring price catalog (one row per price)';
+COMMENT ON COLUMN plans.plan_id IS 'Stripe price ID (price_...), used as plan_id in subscriptions';
+COMMENT ON COLUMN plans.amount IS 'Price in the smallest currency unit';
+COMMENT ON COLUMN plans.price_active IS 'Price is not archived in Stripe';
+COMMENT ON COLUMN plans.product_active IS 'Product of the price is not archived in Stripe';
+COMMENT ON COLUMN plans.synced_at IS 'When the row state was read from Stripe; older reads do not overwrite newer ones';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_at;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS cancel_at_period_end;
+
+COMMIT;
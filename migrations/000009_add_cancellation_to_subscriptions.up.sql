@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS cancel_at TIMESTAMPTZ NULL;
+
+COMMENT ON COLUMN subscriptions.cancel_at_period_end IS 'True if the subscription is scheduled to be canceled at the end of the current period';
+COMMENT ON COLUMN subscriptions.cancel_at IS 'Timestamp when a scheduled cancellation takes effect (NULL if none is scheduled)';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS resumes_at;
+
+COMMENT ON COLUMN subscriptions.status IS 'Current status (e.g., active, incomplete, past_due, canceled)';
+
+COMMIT;
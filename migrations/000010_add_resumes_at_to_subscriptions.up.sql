@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS resumes_at TIMESTAMPTZ NULL;
+
+COMMENT ON COLUMN subscriptions.resumes_at IS 'Timestamp when paused payment collection resumes automatically (NULL if not paused or resumed manually)';
+COMMENT ON COLUMN subscriptions.status IS 'Current status (e.g., active, incomplete, past_due, paused, canceled); paused means Stripe pause_collection is set';
+
+COMMIT;
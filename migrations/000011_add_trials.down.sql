@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE plans DROP COLUMN IF EXISTS trial_period_days;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_end;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS trial_start;
+
+COMMIT;
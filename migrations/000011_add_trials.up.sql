@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_start TIMESTAMPTZ NULL;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS trial_end TIMESTAMPTZ NULL;
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS trial_period_days INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN subscriptions.trial_start IS 'Start of the free trial (NULL if the subscription had no trial)';
+COMMENT ON COLUMN subscriptions.trial_end IS 'End of the free trial; the subscription is in status trialing until then';
+COMMENT ON COLUMN plans.trial_period_days IS 'Default trial length in days for new subscriptions (Stripe recurring.trial_period_days), 0 if none';
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_end;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_duration;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_currency;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_amount_off;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_percent_off;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_promotion_code;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS discount_coupon_id;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS discount_coupon_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS discount_promotion_code VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS discount_percent_off NUMERIC(5, 2) NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS discount_amount_off BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS discount_currency VARCHAR(3) NOT NULL DEFAULT '';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS discount_duration VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS discount_end TIMESTAMPTZ NULL;
+
+COMMENT ON COLUMN subscriptions.discount_coupon_id IS 'Stripe coupon currently applied to the subscription, empty if there is no discount';
+COMMENT ON COLUMN subscriptions.discount_promotion_code IS 'Customer-facing promotion code the coupon was applied with, empty if the coupon was applied directly';
+COMMENT ON COLUMN subscriptions.discount_percent_off IS 'Percent discount of the coupon, 0 for an amount discount';
+COMMENT ON COLUMN subscriptions.discount_amount_off IS 'Amount discount of the coupon in the smallest currency unit, 0 for a percent discount';
+COMMENT ON COLUMN subscriptions.discount_duration IS 'Coupon duration: once, repeating or forever';
+COMMENT ON COLUMN subscriptions.discount_end IS 'When a repeating discount ends (NULL for once and forever coupons)';
+
+COMMIT;
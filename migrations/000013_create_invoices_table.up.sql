@@ -0,0 +1,41 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS invoices (
+    invoice_id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    stripe_customer_id VARCHAR(255) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL DEFAULT '',
+    stripe_subscription_id VARCHAR(255) NOT NULL DEFAULT '',
+    number VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL,
+    billing_reason VARCHAR(50) NOT NULL DEFAULT '',
+    currency VARCHAR(3) NOT NULL,
+    amount_due BIGINT NOT NULL DEFAULT 0,
+    amount_paid BIGINT NOT NULL DEFAULT 0,
+    amount_remaining BIGINT NOT NULL DEFAULT 0,
+    subtotal BIGINT NOT NULL DEFAULT 0,
+    total BIGINT NOT NULL DEFAULT 0,
+    attempt_count INTEGER NOT NULL DEFAULT 0,
+    period_start TIMESTAMPTZ NULL,
+    period_end TIMESTAMPTZ NULL,
+    hosted_invoice_url TEXT NOT NULL DEFAULT '',
+    invoice_pdf TEXT NOT NULL DEFAULT '',
+    paid_at TIMESTAMPTZ NULL,
+    stripe_created_at TIMESTAMPTZ NOT NULL,
+    last_event_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_invoices_user_id_created ON invoices(user_id, stripe_created_at DESC);
+
+COMMENT ON TABLE invoices IS 'Local copy of Stripe invoices, filled from invoice.* webhooks';
+COMMENT ON COLUMN invoices.invoice_id IS 'Stripe invoice ID (in_...)';
+COMMENT ON COLUMN invoices.subscription_id IS 'Local subscription ID; empty if the invoice is not tied to a known subscription';
+COMMENT ON COLUMN invoices.amount_due IS 'Amounts are in the smallest currency unit';
+COMMENT ON COLUMN invoices.hosted_invoice_url IS 'Stripe-hosted page where the customer can view and pay the invoice';
+COMMENT ON COLUMN invoices.invoice_pdf IS 'Link to the invoice PDF';
+COMMENT ON COLUMN invoices.stripe_created_at IS 'When the invoice was created in Stripe; used for history ordering';
+COMMENT ON COLUMN invoices.last_event_at IS 'Creation time of the last applied webhook event; older events do not overwrite newer ones';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS credit_notes;
+DROP TABLE IF EXISTS refunds;
+
+COMMIT;
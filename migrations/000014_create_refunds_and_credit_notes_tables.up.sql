@@ -0,0 +1,54 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS refunds (
+    refund_id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    stripe_customer_id VARCHAR(255) NOT NULL,
+    charge_id VARCHAR(255) NOT NULL,
+    payment_intent_id VARCHAR(255) NOT NULL DEFAULT '',
+    invoice_id VARCHAR(255) NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    failure_reason VARCHAR(100) NOT NULL DEFAULT '',
+    stripe_created_at TIMESTAMPTZ NOT NULL,
+    last_event_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_refunds_invoice_id ON refunds(invoice_id) WHERE invoice_id <> '';
+CREATE INDEX IF NOT EXISTS idx_refunds_charge_id ON refunds(charge_id);
+
+CREATE TABLE IF NOT EXISTS credit_notes (
+    credit_note_id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    stripe_customer_id VARCHAR(255) NOT NULL,
+    invoice_id VARCHAR(255) NOT NULL,
+    number VARCHAR(255) NOT NULL DEFAULT '',
+    type VARCHAR(50) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    memo TEXT NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    refund_id VARCHAR(255) NOT NULL DEFAULT '',
+    voided_at TIMESTAMPTZ NULL,
+    stripe_created_at TIMESTAMPTZ NOT NULL,
+    last_event_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_credit_notes_invoice_id ON credit_notes(invoice_id);
+
+COMMENT ON TABLE refunds IS 'Stripe refunds issued through the admin API or the Stripe dashboard';
+COMMENT ON COLUMN refunds.invoice_id IS 'Invoice paid by the refunded charge; empty for one-off charges';
+COMMENT ON COLUMN refunds.amount IS 'Amount in the smallest currency unit';
+COMMENT ON COLUMN refunds.last_event_at IS 'Time of the last applied Stripe change; older webhook events do not overwrite newer state';
+COMMENT ON TABLE credit_notes IS 'Stripe credit notes that reduce the amount of a finalized invoice';
+COMMENT ON COLUMN credit_notes.type IS 'pre_payment for unpaid invoices, post_payment for paid ones';
+COMMENT ON COLUMN credit_notes.refund_id IS 'Refund created by the credit note; empty if the amount was credited to the customer balance';
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS payments (
+    payment_id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    stripe_customer_id VARCHAR(255) NOT NULL,
+    amount BIGINT NOT NULL,
+    amount_received BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    last_payment_error TEXT NOT NULL DEFAULT '',
+    stripe_created_at TIMESTAMPTZ NOT NULL,
+    last_event_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+    );
+
+CREATE INDEX IF NOT EXISTS idx_payments_user_id_created ON payments(user_id, stripe_created_at DESC);
+
+COMMENT ON TABLE payments IS 'One-off payments (Stripe PaymentIntents) created through the service and updated from payment_intent.* webhooks';
+COMMENT ON COLUMN payments.payment_id IS 'Stripe PaymentIntent ID (pi_...)';
+COMMENT ON COLUMN payments.amount IS 'Amounts are in the smallest currency unit';
+COMMENT ON COLUMN payments.amount_received IS 'Amount actually collected; equals amount once the payment succeeds';
+COMMENT ON COLUMN payments.metadata IS 'PaymentIntent metadata, e.g. what is being purchased';
+COMMENT ON COLUMN payments.last_payment_error IS 'Message of the last failed payment attempt';
+COMMENT ON COLUMN payments.last_event_at IS 'Time of the last applied Stripe change; older webhook events do not overwrite newer ones';
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_outbox_published_at;
+
+COMMENT ON COLUMN outbox.published_at IS 'Timestamp when the event was acknowledged by Kafka (NULL while pending)';
+
+COMMIT;
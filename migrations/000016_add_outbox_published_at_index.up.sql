@@ -0,0 +1,8 @@
+BEGIN;
+
+-- Частичный индекс: релей удаляет опубликованные события старше срока хранения
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox(published_at) WHERE published_at IS NOT NULL;
+
+COMMENT ON COLUMN outbox.published_at IS 'Timestamp when the event was acknowledged by Kafka (NULL while pending); published events are deleted after the retention period';
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS dead_at TIMESTAMPTZ NULL;
+
+-- Релей выбирает самое раннее ожидающее событие каждой подписки
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(aggregate_id, id) WHERE published_at IS NULL AND dead_at IS NULL;
+
+COMMENT ON COLUMN outbox.attempts IS 'Number of failed publish attempts so far; after the limit the event is moved to dead-letter';
+COMMENT ON COLUMN outbox.dead_at IS 'Timestamp when the event was moved to dead-letter after exhausting publish attempts (NULL otherwise); dead events are not published and do not block later events of the aggregate';
+
+COMMIT;